@@ -3,7 +3,7 @@ import { NextRequest, NextResponse } from 'next/server'
 export async function POST(request: NextRequest) {
   try {
     const body = await request.json()
-    const { productName, price } = body
+    const { productId, couponCode } = body
 
     // Get the auth token from the request headers
     const authHeader = request.headers.get('authorization')
@@ -14,24 +14,37 @@ export async function POST(request: NextRequest) {
       )
     }
 
+    const headers: Record<string, string> = {
+      'Content-Type': 'application/json',
+      'Authorization': authHeader,
+    }
+    const idempotencyKey = request.headers.get('idempotency-key')
+    if (idempotencyKey) {
+      headers['Idempotency-Key'] = idempotencyKey
+    }
+
     // Call the backend checkout endpoint
     const backendUrl = process.env.NEXT_PUBLIC_API_URL || 'http://localhost:8080/api'
     const response = await fetch(`${backendUrl}/checkout`, {
       method: 'POST',
-      headers: {
-        'Content-Type': 'application/json',
-        'Authorization': authHeader,
-      },
+      headers,
       body: JSON.stringify({
-        productName,
-        price,
+        productId,
+        couponCode,
       }),
     })
 
     if (!response.ok) {
       const errorData = await response.json()
       return NextResponse.json(
-        { error: { message: errorData.error?.message || 'Failed to create checkout session' } },
+        {
+          error: {
+            message:
+              errorData.error?.details?.couponCode ||
+              errorData.error?.message ||
+              'Failed to create checkout session',
+          },
+        },
         { status: response.status }
       )
     }
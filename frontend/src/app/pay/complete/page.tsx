@@ -0,0 +1,28 @@
+import { Card, CardContent, CardDescription, CardHeader, CardTitle } from '@/components/ui/card'
+
+// Where a brand lands after paying (or abandoning) a creator's payment link. Brands have
+// no account, so this page only confirms what happened.
+export default async function PaymentCompletePage({
+  searchParams,
+}: {
+  searchParams: Promise<{ status?: string }>
+}) {
+  const { status } = await searchParams
+  const paid = status === 'paid'
+
+  return (
+    <div className="min-h-screen bg-gradient-to-br from-slate-900 via-slate-800 to-slate-900 flex items-center justify-center px-4">
+      <Card className="w-full max-w-md bg-slate-800 border-slate-700 text-white">
+        <CardHeader>
+          <CardTitle>{paid ? 'Thank you for your payment' : 'Payment cancelled'}</CardTitle>
+          <CardDescription className="text-slate-400">
+            {paid
+              ? 'Your payment was received and will show on the creator's invoice shortly. You can close this page.'
+              : 'Nothing was charged. You can use the payment link again to pay later.'}
+          </CardDescription>
+        </CardHeader>
+        <CardContent />
+      </Card>
+    </div>
+  )
+}
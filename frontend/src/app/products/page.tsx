@@ -1,8 +1,9 @@
 'use client'
 
-import { useState } from 'react'
+import { useRef, useState } from 'react'
 import { useRouter } from 'next/navigation'
 import { Button } from '@/components/ui/button'
+import { Input } from '@/components/ui/input'
 import { Typography } from '@/components/ui/typography'
 import { Check, ArrowRight, Loader2 } from 'lucide-react'
 
@@ -12,6 +13,7 @@ interface ProductFeature {
 }
 
 interface Product {
+  id: string // the backend catalog's product ID
   name: string
   price: string
   description: string
@@ -21,6 +23,7 @@ interface Product {
 
 const PRODUCTS: Product[] = [
   {
+    id: 'base',
     name: 'Base Product',
     price: '$29',
     description: 'Perfect for getting started with sponsorship management',
@@ -36,6 +39,7 @@ const PRODUCTS: Product[] = [
     ],
   },
   {
+    id: 'pro',
     name: 'Pro Product',
     price: '$99',
     description: 'Advanced features for growing creators',
@@ -57,10 +61,14 @@ export default function ProductsPage() {
   const router = useRouter()
   const [loadingProduct, setLoadingProduct] = useState<string | null>(null)
   const [error, setError] = useState<string | null>(null)
+  const [couponCode, setCouponCode] = useState('')
+  // One idempotency key per product and coupon while the page is open, so repeated
+  // clicks reuse the first checkout session instead of creating another
+  const checkoutKeys = useRef<Record<string, string>>({})
 
-  const handleCheckout = async (productName: string, price: string) => {
+  const handleCheckout = async (productId: string) => {
     try {
-      setLoadingProduct(productName)
+      setLoadingProduct(productId)
       setError(null)
 
       // Check if user is authenticated
@@ -71,16 +79,23 @@ export default function ProductsPage() {
         return
       }
 
+      const code = couponCode.trim()
+      const keyName = `${productId}:${code.toUpperCase()}`
+      if (!checkoutKeys.current[keyName]) {
+        checkoutKeys.current[keyName] = crypto.randomUUID()
+      }
+
       // Call frontend API route which proxies to backend
       const response = await fetch('/api/checkout', {
         method: 'POST',
         headers: {
           'Content-Type': 'application/json',
           'Authorization': `Bearer ${token}`,
+          'Idempotency-Key': checkoutKeys.current[keyName],
         },
         body: JSON.stringify({
-          productName,
-          price,
+          productId,
+          couponCode: code || undefined,
         }),
       })
 
@@ -149,6 +164,15 @@ export default function ProductsPage() {
       {/* Products Grid */}
       <section className="relative py-12 px-4 sm:px-6 lg:px-8">
         <div className="max-w-7xl mx-auto">
+          <div className="flex items-center justify-center gap-3 mb-8 max-w-5xl mx-auto">
+            <Input
+              value={couponCode}
+              onChange={(e) => setCouponCode(e.target.value)}
+              placeholder="Promo code"
+              aria-label="Promo code"
+              className="max-w-xs bg-slate-800 border-slate-600 text-white uppercase"
+            />
+          </div>
           {error && (
             <div className="bg-red-900/20 border border-red-700 rounded p-4 text-red-200 text-sm mb-8 max-w-5xl mx-auto">
               {error}
@@ -199,15 +223,15 @@ export default function ProductsPage() {
 
                   {/* CTA Button */}
                   <Button
-                    onClick={() => handleCheckout(product.name, product.price)}
-                    disabled={loadingProduct === product.name}
+                    onClick={() => handleCheckout(product.id)}
+                    disabled={loadingProduct === product.id}
                     className={`w-full mb-8 font-semibold ${
                       product.highlighted
                         ? 'bg-blue-600 hover:bg-blue-700 text-white'
                         : 'bg-slate-700 hover:bg-slate-600 text-white'
                     }`}
                   >
-                    {loadingProduct === product.name ? (
+                    {loadingProduct === product.id ? (
                       <>
                         <Loader2 className="mr-2 h-4 w-4 animate-spin" />
                         Processing...
@@ -72,6 +72,8 @@ export default function AddDealModal({
   const [loading, setLoading] = useState(false)
   const [error, setError] = useState<string | null>(null)
   const [formData, setFormData] = useState<FormData>(INITIAL_FORM_STATE)
+  // One key per new deal, so a double submit cannot create it twice
+  const [idempotencyKey, setIdempotencyKey] = useState(() => crypto.randomUUID())
 
   // Sync form data when modal opens with a deal to edit
   useEffect(() => {
@@ -141,7 +143,8 @@ export default function AddDealModal({
         result = await sponsorshipApi.updateSponsorship(initialDeal.id, apiInput)
       } else {
         // Create new sponsorship
-        result = await sponsorshipApi.createSponsorship(apiInput)
+        result = await sponsorshipApi.createSponsorship(apiInput, idempotencyKey)
+        setIdempotencyKey(crypto.randomUUID())
       }
 
       // Call the onSubmit callback with the server response
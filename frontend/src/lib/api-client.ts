@@ -92,10 +92,11 @@ class ApiClient {
   async request<T>(
     method: string,
     endpoint: string,
-    data?: unknown
+    data?: unknown,
+    extraHeaders?: Record<string, string>
   ): Promise<ApiResponse<T>> {
     const url = `${API_URL}${endpoint}`
-    const headers = this.getHeaders()
+    const headers = { ...this.getHeaders(), ...extraHeaders }
     const options: RequestInit = {
       method,
       headers,
@@ -131,8 +132,12 @@ class ApiClient {
     return this.request<T>('GET', endpoint)
   }
 
-  async post<T>(endpoint: string, data?: unknown): Promise<ApiResponse<T>> {
-    return this.request<T>('POST', endpoint, data)
+  async post<T>(
+    endpoint: string,
+    data?: unknown,
+    headers?: Record<string, string>
+  ): Promise<ApiResponse<T>> {
+    return this.request<T>('POST', endpoint, data, headers)
   }
 
   async put<T>(endpoint: string, data?: unknown): Promise<ApiResponse<T>> {
@@ -66,7 +66,8 @@ export const sponsorshipApi = {
     return convertSponsorshipDates(response.data)
   },
 
-  async createSponsorship(input: CreateSponsorshipInput): Promise<Sponsorship> {
+  // Pass the same idempotencyKey when retrying so the deal is created only once
+  async createSponsorship(input: CreateSponsorshipInput, idempotencyKey?: string): Promise<Sponsorship> {
     console.log('[sponsorship-api] Creating sponsorship:', {
       brandName: input.brandName,
       dealAmount: input.dealAmount,
@@ -86,7 +87,7 @@ export const sponsorshipApi = {
       startDate: input.startDate ? new Date(input.startDate).toISOString() : null,
       endDate: input.endDate ? new Date(input.endDate).toISOString() : null,
       status: input.status || 'pitch-received',
-    })
+    }, idempotencyKey ? { 'Idempotency-Key': idempotencyKey } : undefined)
     
     if (!response.success || !response.data) {
       console.error('[sponsorship-api] Failed to create sponsorship:', response.error?.message)
@@ -0,0 +1,53 @@
+// Command backfill-brands brings brand records in line with how new deals are matched to
+// brands. It is run once after applying migration 005, and again whenever deals may have
+// been saved without a brand:
+//
+//	go run ./cmd/backfill-brands
+//
+// Brand names normalised by the migration are normalised again like new deals, merging
+// brands that turn out to be the same, and deals without a brand are linked to one.
+package main
+
+import (
+	"fmt"
+
+	"sponsorship-backend/config"
+	"sponsorship-backend/internal/database"
+	"sponsorship-backend/internal/handlers"
+	"sponsorship-backend/internal/repositories"
+	"sponsorship-backend/pkg/logger"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	if err := godotenv.Load(); err != nil {
+		fmt.Println("No .env file found, using environment variables")
+	}
+
+	cfg := config.Load()
+	if err := logger.Init(cfg.LogLevel, cfg.Environment == "development", "logs/app.log"); err != nil {
+		fmt.Printf("Failed to initialize logger: %v\n", err)
+	}
+
+	db, err := database.NewDB(cfg.GetDatabaseURL())
+	if err != nil {
+		logger.Fatal("Failed to initialize database: %v", err)
+	}
+	defer db.Close()
+
+	brandHandler := handlers.NewBrandHandler(repositories.NewBrandRepository(db), repositories.NewSponsorshipRepository(db),
+		repositories.NewSettingsRepository(db), repositories.NewFXRepository(db))
+
+	normalized, err := brandHandler.NormalizeBrands()
+	if err != nil {
+		logger.Fatal("Failed to normalise brand names after %d brand(s): %v", normalized, err)
+	}
+	logger.Info("Normalised %d brand name(s)", normalized)
+
+	linked, err := brandHandler.BackfillBrands()
+	if err != nil {
+		logger.Fatal("Failed to link deals to brands after %d deal(s): %v", linked, err)
+	}
+	logger.Info("Linked %d deal(s) to brands", linked)
+}
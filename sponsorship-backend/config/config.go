@@ -4,14 +4,16 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type Config struct {
 	// Server
-	ServerPort  int
-	Environment string
-	LogLevel    string
+	ServerPort      int
+	Environment     string
+	LogLevel        string
+	ShutdownTimeout time.Duration // how long requests and background workers get to finish on shutdown
 
 	// Database
 	DBHost     string
@@ -27,16 +29,74 @@ type Config struct {
 
 	// CORS
 	CORSAllowedOrigins []string
+
+	// Mail
+	SMTPHost     string
+	SMTPPort     int
+	SMTPUsername string
+	SMTPPassword string
+	MailFrom     string
+
+	// Public pitch form
+	PublicPitchRequiredFields []string
+	PublicPitchRateLimit      int
+	PublicPitchRateWindow     time.Duration
+
+	// File storage
+	StorageBackend      string // local or s3
+	StorageLocalDir     string
+	S3Endpoint          string
+	S3Region            string
+	S3Bucket            string
+	S3AccessKeyID       string
+	S3SecretAccessKey   string
+	S3ForcePathStyle    bool
+	MaxUploadBytes      int64
+	CreatorStorageQuota int64
+
+	// Exchange rates
+	FXRatesFile string // CSV of daily rates loaded at startup; empty to skip
+
+	// Payments
+	PaymentProvider     string // stripe, or fake for offline development
+	StripeSecretKey     string
+	StripeWebhookSecret string // signing secret of the webhook endpoint (whsec_...)
+	FrontendURL         string // base of the URLs checkout returns the customer to
+
+	// Plans
+	FreePlanMaxActiveDeals int // active deals allowed without a subscription; 0 for unlimited
+
+	// Idempotency keys
+	IdempotencyKeyTTL time.Duration // how long responses are replayed for a retried key
+
+	// Admins
+	AdminEmails []string // users allowed on admin routes, such as refunds
+
+	// Outbound webhooks
+	WebhookTimeout      time.Duration // how long an endpoint has to answer a delivery
+	WebhookMaxAttempts  int           // attempts before a delivery is marked failed
+	WebhookPollInterval time.Duration // how often due deliveries are sent
+	WebhookAllowHTTP    bool          // accept http endpoint URLs as well as https, for development
+
+	// Outbox
+	OutboxPollInterval time.Duration // how often stored sponsorship and payment events are handed to subscribers
 }
 
 func Load() *Config {
 	jwtHours, _ := strconv.Atoi(getEnv("JWT_EXPIRATION_HOURS", "24"))
+	pitchWindowMinutes := getEnvInt("PUBLIC_PITCH_RATE_WINDOW_MINUTES", 60)
+	idempotencyHours := getEnvInt("IDEMPOTENCY_KEY_TTL_HOURS", 24)
+	webhookTimeoutSeconds := getEnvInt("WEBHOOK_TIMEOUT_SECONDS", 10)
+	webhookPollSeconds := getEnvInt("WEBHOOK_POLL_INTERVAL_SECONDS", 5)
+	outboxPollSeconds := getEnvInt("OUTBOX_POLL_INTERVAL_SECONDS", 2)
+	shutdownSeconds := getEnvInt("SHUTDOWN_TIMEOUT_SECONDS", 30)
 
 	return &Config{
 		// Server
-		ServerPort:  getEnvInt("SERVER_PORT", 8080),
-		Environment: getEnv("ENVIRONMENT", "development"),
-		LogLevel:    getEnv("LOG_LEVEL", "info"),
+		ServerPort:      getEnvInt("SERVER_PORT", 8080),
+		Environment:     getEnv("ENVIRONMENT", "development"),
+		LogLevel:        getEnv("LOG_LEVEL", "info"),
+		ShutdownTimeout: time.Duration(shutdownSeconds) * time.Second,
 
 		// Database
 		DBHost:     getEnv("DB_HOST", "localhost"),
@@ -56,6 +116,57 @@ func Load() *Config {
 			"http://localhost:3001",
 			"https://yourdomain.com",
 		},
+
+		// Mail
+		SMTPHost:     getEnv("SMTP_HOST", ""),
+		SMTPPort:     getEnvInt("SMTP_PORT", 587),
+		SMTPUsername: getEnv("SMTP_USERNAME", ""),
+		SMTPPassword: getEnv("SMTP_PASSWORD", ""),
+		MailFrom:     getEnv("MAIL_FROM", "no-reply@localhost"),
+
+		// Public pitch form
+		PublicPitchRequiredFields: getEnvList("PUBLIC_PITCH_REQUIRED_FIELDS", "brandName,contactName,contactEmail,description"),
+		PublicPitchRateLimit:      getEnvInt("PUBLIC_PITCH_RATE_LIMIT", 5),
+		PublicPitchRateWindow:     time.Duration(pitchWindowMinutes) * time.Minute,
+
+		// File storage
+		StorageBackend:      getEnv("STORAGE_BACKEND", "local"),
+		StorageLocalDir:     getEnv("STORAGE_LOCAL_DIR", "uploads"),
+		S3Endpoint:          getEnv("S3_ENDPOINT", "https://s3.amazonaws.com"),
+		S3Region:            getEnv("S3_REGION", "us-east-1"),
+		S3Bucket:            getEnv("S3_BUCKET", ""),
+		S3AccessKeyID:       getEnv("S3_ACCESS_KEY_ID", ""),
+		S3SecretAccessKey:   getEnv("S3_SECRET_ACCESS_KEY", ""),
+		S3ForcePathStyle:    getEnv("S3_FORCE_PATH_STYLE", "false") == "true",
+		MaxUploadBytes:      getEnvInt64("MAX_UPLOAD_BYTES", 25<<20),
+		CreatorStorageQuota: getEnvInt64("CREATOR_STORAGE_QUOTA_BYTES", 1<<30),
+
+		// Exchange rates
+		FXRatesFile: getEnv("FX_RATES_FILE", ""),
+
+		// Payments
+		PaymentProvider:     getEnv("PAYMENT_PROVIDER", "stripe"),
+		StripeSecretKey:     getEnv("STRIPE_SECRET_KEY", ""),
+		StripeWebhookSecret: getEnv("STRIPE_WEBHOOK_SECRET", ""),
+		FrontendURL:         getEnv("FRONTEND_URL", "http://localhost:3000"),
+
+		// Plans
+		FreePlanMaxActiveDeals: getEnvInt("FREE_PLAN_MAX_ACTIVE_DEALS", 3),
+
+		// Idempotency keys
+		IdempotencyKeyTTL: time.Duration(idempotencyHours) * time.Hour,
+
+		// Admins
+		AdminEmails: getEnvList("ADMIN_EMAILS", ""),
+
+		// Outbound webhooks
+		WebhookTimeout:      time.Duration(webhookTimeoutSeconds) * time.Second,
+		WebhookMaxAttempts:  getEnvInt("WEBHOOK_MAX_ATTEMPTS", 10),
+		WebhookPollInterval: time.Duration(webhookPollSeconds) * time.Second,
+		WebhookAllowHTTP:    getEnv("WEBHOOK_ALLOW_HTTP", "false") == "true",
+
+		// Outbox
+		OutboxPollInterval: time.Duration(outboxPollSeconds) * time.Second,
 	}
 }
 
@@ -71,6 +182,16 @@ func getEnv(key, defaultVal string) string {
 	return defaultVal
 }
 
+func getEnvList(key, defaultVal string) []string {
+	var values []string
+	for _, v := range strings.Split(getEnv(key, defaultVal), ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
 func getEnvInt(key string, defaultVal int) int {
 	valStr := getEnv(key, "")
 	if val, err := strconv.Atoi(valStr); err == nil {
@@ -78,3 +199,11 @@ func getEnvInt(key string, defaultVal int) int {
 	}
 	return defaultVal
 }
+
+func getEnvInt64(key string, defaultVal int64) int64 {
+	valStr := getEnv(key, "")
+	if val, err := strconv.ParseInt(valStr, 10, 64); err == nil {
+		return val
+	}
+	return defaultVal
+}
@@ -15,4 +15,4 @@ require (
 	github.com/joho/godotenv v1.5.1
 )
 
-require github.com/stripe/stripe-go/v76 v76.25.0 // indirect
+require github.com/stripe/stripe-go/v76 v76.25.0
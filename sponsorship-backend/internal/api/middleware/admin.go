@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+)
+
+// AdminMiddleware restricts routes to the users whose email is on the configured admin list
+type AdminMiddleware struct {
+	emails map[string]bool
+}
+
+func NewAdminMiddleware(emails []string) *AdminMiddleware {
+	am := &AdminMiddleware{emails: make(map[string]bool)}
+	for _, email := range emails {
+		am.emails[strings.ToLower(email)] = true
+	}
+	return am
+}
+
+// RequireAdmin must run after authentication. Other users get 403 Forbidden.
+func (am *AdminMiddleware) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		email := r.Header.Get("X-Email")
+		if email == "" || !am.emails[strings.ToLower(email)] {
+			logger.Warn("Admin route %s %s refused for %s", r.Method, r.URL.Path, email)
+			api.WriteError(w, errors.ErrForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,100 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+	"sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+)
+
+// Features a plan can include
+const (
+	FeatureAPIAccess = "apiAccess"
+)
+
+// EntitlementMiddleware enforces the limits of the user's plan. Users without an entitled
+// subscription get the free plan.
+type EntitlementMiddleware struct {
+	subscriptionRepo *repositories.SubscriptionRepository
+	sponsorshipRepo  *repositories.SponsorshipRepository
+	freePlan         models.Entitlements
+}
+
+func NewEntitlementMiddleware(subscriptionRepo *repositories.SubscriptionRepository,
+	sponsorshipRepo *repositories.SponsorshipRepository, freePlan models.Entitlements) *EntitlementMiddleware {
+	return &EntitlementMiddleware{
+		subscriptionRepo: subscriptionRepo,
+		sponsorshipRepo:  sponsorshipRepo,
+		freePlan:         freePlan,
+	}
+}
+
+func (em *EntitlementMiddleware) entitlements(userID string) (*models.Entitlements, error) {
+	e, err := em.subscriptionRepo.GetEntitlements(userID)
+	if err == errors.ErrNotFound {
+		free := em.freePlan
+		return &free, nil
+	}
+	return e, err
+}
+
+// RequireFeature rejects requests from users whose plan lacks a feature with 403
+// FEATURE_NOT_IN_PLAN
+func (em *EntitlementMiddleware) RequireFeature(feature string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			userID := r.Header.Get("X-User-ID")
+			e, err := em.entitlements(userID)
+			if err != nil {
+				logger.Error("Failed to get entitlements for user %s: %v", userID, err)
+				api.WriteError(w, errors.ErrInternalError)
+				return
+			}
+
+			included := false
+			switch feature {
+			case FeatureAPIAccess:
+				included = e.APIAccess
+			}
+			if !included {
+				logger.Debug("User %s on plan %s denied %s on %s", userID, e.Plan, feature, r.URL.Path)
+				api.WriteError(w, errors.ErrFeatureNotInPlan.WithDetails(map[string]string{
+					"plan":    e.Plan,
+					"feature": feature,
+				}))
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// CheckActiveDeals returns ErrPlanLimitReached if the creator already has as many active
+// deals as the user's plan allows, so no other deal can be added or reopened
+func (em *EntitlementMiddleware) CheckActiveDeals(userID, creatorID string) error {
+	e, err := em.entitlements(userID)
+	if err != nil {
+		return fmt.Errorf("failed to get entitlements: %w", err)
+	}
+	if e.MaxActiveDeals == nil {
+		return nil
+	}
+
+	active, err := em.sponsorshipRepo.CountActiveSponsorships(creatorID)
+	if err != nil {
+		return err
+	}
+	if active >= *e.MaxActiveDeals {
+		logger.Debug("Creator %s on plan %s is at its limit of %d active deals", creatorID, e.Plan, active)
+		return errors.ErrPlanLimitReached.WithDetails(map[string]interface{}{
+			"plan":           e.Plan,
+			"maxActiveDeals": *e.MaxActiveDeals,
+		})
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/repositories"
+	"sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+)
+
+const (
+	// maxIdempotencyKeyLength matches the idempotency_keys column
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentBodyBytes is the largest request body read to fingerprint a request
+	maxIdempotentBodyBytes = 1 << 20
+)
+
+// IdempotencyMiddleware replays the response to a request sent with an Idempotency-Key
+// header when the same user retries with the same key, instead of running it again.
+// Keys are kept for a TTL. Only successful responses are stored: a failed request frees
+// its key, so the client can fix the request and retry with the same key.
+type IdempotencyMiddleware struct {
+	repo      *repositories.IdempotencyRepository
+	ttl       time.Duration
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewIdempotencyMiddleware(repo *repositories.IdempotencyRepository, ttl time.Duration) *IdempotencyMiddleware {
+	return &IdempotencyMiddleware{repo: repo, ttl: ttl, lastSweep: time.Now()}
+}
+
+// recordingWriter passes a response through while keeping a copy of its status and body
+type recordingWriter struct {
+	http.ResponseWriter
+	statusCode int
+	body       bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(code int) {
+	if rw.statusCode == 0 {
+		rw.statusCode = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	if rw.statusCode == 0 {
+		rw.statusCode = http.StatusOK
+	}
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// Middleware must run after authentication; requests without a key or user pass through.
+// A key reused with a different request fails with 409 IDEMPOTENCY_KEY_REUSED, and a
+// retry while the first request is still running with 409 IDEMPOTENCY_KEY_IN_USE.
+func (im *IdempotencyMiddleware) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get("Idempotency-Key")
+		userID := r.Header.Get("X-User-ID")
+		if key == "" || userID == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			api.WriteError(w, errors.ErrValidationError.WithDetails(map[string]string{
+				"Idempotency-Key": "must be at most 255 characters",
+			}))
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBodyBytes))
+		if err != nil {
+			logger.Warn("Failed to read body of idempotent request: %v", err)
+			api.WriteError(w, errors.ErrInvalidRequest)
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		io.WriteString(hash, r.Method+" "+r.URL.Path+"\n")
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		im.sweep(time.Now())
+
+		existing, reserved, err := im.repo.Reserve(userID, key, requestHash, im.ttl)
+		if err != nil {
+			logger.Error("Failed to reserve idempotency key for user %s: %v", userID, err)
+			api.WriteError(w, errors.ErrInternalError)
+			return
+		}
+		if !reserved {
+			switch {
+			case existing.RequestHash != requestHash:
+				logger.Warn("Idempotency key reused by user %s for a different request to %s", userID, r.URL.Path)
+				api.WriteError(w, errors.ErrIdempotencyKeyReused)
+			case existing.StatusCode == nil:
+				api.WriteError(w, errors.ErrIdempotencyKeyInUse)
+			default:
+				logger.Info("Replaying response for idempotency key of user %s on %s", userID, r.URL.Path)
+				if existing.ContentType != "" {
+					w.Header().Set("Content-Type", existing.ContentType)
+				}
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(*existing.StatusCode)
+				w.Write(existing.ResponseBody)
+			}
+			return
+		}
+
+		// The key is freed unless the response is stored, including when the handler panics
+		recorder := &recordingWriter{ResponseWriter: w}
+		completed := false
+		defer func() {
+			if completed {
+				return
+			}
+			if err := im.repo.Release(userID, key); err != nil {
+				logger.Error("Failed to release idempotency key for user %s: %v", userID, err)
+			}
+		}()
+
+		next.ServeHTTP(recorder, r)
+
+		if recorder.statusCode < 200 || recorder.statusCode >= 300 {
+			return
+		}
+		err = im.repo.Complete(userID, key, recorder.statusCode, recorder.Header().Get("Content-Type"),
+			recorder.body.Bytes())
+		if err != nil {
+			logger.Error("Failed to store response for idempotency key of user %s: %v", userID, err)
+			return
+		}
+		completed = true
+	})
+}
+
+// sweep deletes expired keys at most once per TTL, so the table does not grow with every
+// key ever sent
+func (im *IdempotencyMiddleware) sweep(now time.Time) {
+	im.mu.Lock()
+	if now.Sub(im.lastSweep) <= im.ttl {
+		im.mu.Unlock()
+		return
+	}
+	im.lastSweep = now
+	im.mu.Unlock()
+
+	deleted, err := im.repo.DeleteExpired(now)
+	if err != nil {
+		logger.Error("Failed to delete expired idempotency keys: %v", err)
+		return
+	}
+	logger.Debug("Deleted %d expired idempotency keys", deleted)
+}
@@ -0,0 +1,88 @@
+package middleware
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+)
+
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// RateLimiter allows a fixed number of requests per client IP in each time window
+type RateLimiter struct {
+	mu        sync.Mutex
+	limit     int
+	window    time.Duration
+	clients   map[string]*rateWindow
+	lastSweep time.Time
+}
+
+func NewRateLimiter(limit int, window time.Duration) *RateLimiter {
+	return &RateLimiter{
+		limit:     limit,
+		window:    window,
+		clients:   make(map[string]*rateWindow),
+		lastSweep: time.Now(),
+	}
+}
+
+// Middleware rejects requests over the limit with 429 Too Many Requests
+func (rl *RateLimiter) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip := clientIP(r)
+		allowed, retryAfter := rl.allow(ip, time.Now())
+		if !allowed {
+			logger.Warn("Rate limit exceeded for %s on %s %s", ip, r.Method, r.URL.Path)
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			api.WriteError(w, errors.ErrTooManyRequests)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (rl *RateLimiter) allow(key string, now time.Time) (bool, time.Duration) {
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+
+	// Drop expired windows so the map does not grow with every client ever seen
+	if now.Sub(rl.lastSweep) > rl.window {
+		for k, win := range rl.clients {
+			if now.Sub(win.start) >= rl.window {
+				delete(rl.clients, k)
+			}
+		}
+		rl.lastSweep = now
+	}
+
+	win, ok := rl.clients[key]
+	if !ok || now.Sub(win.start) >= rl.window {
+		rl.clients[key] = &rateWindow{start: now, count: 1}
+		return true, 0
+	}
+
+	if win.count >= rl.limit {
+		return false, rl.window - now.Sub(win.start)
+	}
+
+	win.count++
+	return true, 0
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,45 @@
+-- 005_create_brands_table.sql
+CREATE TABLE IF NOT EXISTS brands (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    creator_id UUID NOT NULL REFERENCES creators(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    normalized_name VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (creator_id, normalized_name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_brands_creator_id ON brands(creator_id);
+
+ALTER TABLE sponsorships ADD COLUMN IF NOT EXISTS brand_id UUID REFERENCES brands(id);
+CREATE INDEX IF NOT EXISTS idx_sponsorships_brand_id ON sponsorships(brand_id);
+
+-- Backfill brands from existing deals. The expression mirrors normalizeBrandName for
+-- the common cases: lowercase, drop a trailing legal suffix, strip non-alphanumerics.
+INSERT INTO brands (creator_id, name, normalized_name)
+SELECT DISTINCT ON (creator_id, normalized_name) creator_id, brand_name, normalized_name
+FROM (
+    SELECT creator_id, brand_name, created_at,
+           regexp_replace(
+               regexp_replace(lower(brand_name), '[^a-z0-9]+(inc|incorporated|llc|ltd|limited|co|corp|corporation|company|gmbh|ag|sa|plc|bv|pty)[^a-z0-9]*$', ''),
+               '[^a-z0-9]', '', 'g'
+           ) AS normalized_name
+    FROM sponsorships
+) s
+ORDER BY creator_id, normalized_name, created_at DESC
+ON CONFLICT (creator_id, normalized_name) DO NOTHING;
+
+UPDATE sponsorships s
+SET brand_id = b.id
+FROM brands b
+WHERE s.brand_id IS NULL
+  AND b.creator_id = s.creator_id
+  AND b.normalized_name = regexp_replace(
+          regexp_replace(lower(s.brand_name), '[^a-z0-9]+(inc|incorporated|llc|ltd|limited|co|corp|corporation|company|gmbh|ag|sa|plc|bv|pty)[^a-z0-9]*$', ''),
+          '[^a-z0-9]', '', 'g'
+      );
+
+-- Deals the brand or creator walked away from count as losses in brand summaries
+ALTER TABLE sponsorships DROP CONSTRAINT IF EXISTS sponsorships_status_check;
+ALTER TABLE sponsorships ADD CONSTRAINT sponsorships_status_check
+    CHECK (status IN ('pitch-received', 'under-review', 'negotiating', 'approved', 'contracted', 'content-creation', 'awaiting-review', 'published', 'completed', 'declined'));
@@ -0,0 +1,4 @@
+-- 006_add_username_to_users.sql
+-- The username doubles as the creator's public handle for the inbound pitch form
+ALTER TABLE users ADD COLUMN IF NOT EXISTS username VARCHAR(255);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_username ON users(username);
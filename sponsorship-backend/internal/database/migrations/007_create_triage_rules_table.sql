@@ -0,0 +1,17 @@
+-- 007_create_triage_rules_table.sql
+ALTER TABLE sponsorships ADD COLUMN IF NOT EXISTS category VARCHAR(100);
+CREATE INDEX IF NOT EXISTS idx_sponsorships_category ON sponsorships(category);
+
+CREATE TABLE IF NOT EXISTS triage_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    creator_id UUID NOT NULL REFERENCES creators(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    position INTEGER NOT NULL,
+    enabled BOOLEAN NOT NULL DEFAULT TRUE,
+    conditions JSONB NOT NULL DEFAULT '[]'::JSONB,
+    action JSONB NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_triage_rules_creator_position ON triage_rules(creator_id, position);
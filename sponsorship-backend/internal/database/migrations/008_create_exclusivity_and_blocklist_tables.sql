@@ -0,0 +1,26 @@
+-- 008_create_exclusivity_and_blocklist_tables.sql
+CREATE TABLE IF NOT EXISTS exclusivity_clauses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sponsorship_id UUID NOT NULL REFERENCES sponsorships(id) ON DELETE CASCADE,
+    creator_id UUID NOT NULL REFERENCES creators(id) ON DELETE CASCADE,
+    category VARCHAR(100) NOT NULL,
+    starts_on DATE NOT NULL,
+    ends_on DATE NOT NULL,
+    notes TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (ends_on >= starts_on)
+);
+
+CREATE INDEX IF NOT EXISTS idx_exclusivity_clauses_sponsorship_id ON exclusivity_clauses(sponsorship_id);
+CREATE INDEX IF NOT EXISTS idx_exclusivity_clauses_creator_category ON exclusivity_clauses(creator_id, lower(category));
+
+CREATE TABLE IF NOT EXISTS blocklist_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    creator_id UUID NOT NULL REFERENCES creators(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL CHECK (kind IN ('brand', 'category')),
+    value VARCHAR(255) NOT NULL,
+    normalized_value VARCHAR(255) NOT NULL,
+    reason VARCHAR(500),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (creator_id, kind, normalized_value)
+);
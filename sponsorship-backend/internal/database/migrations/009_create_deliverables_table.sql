@@ -0,0 +1,27 @@
+-- 009_create_deliverables_table.sql
+CREATE TABLE IF NOT EXISTS deliverables (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sponsorship_id UUID NOT NULL REFERENCES sponsorships(id) ON DELETE CASCADE,
+    creator_id UUID NOT NULL REFERENCES creators(id) ON DELETE CASCADE,
+    type VARCHAR(30) NOT NULL CHECK (type IN ('dedicated-video', 'integration', 'short', 'story')),
+    platform VARCHAR(50),
+    title VARCHAR(255) NOT NULL,
+    due_date DATE,
+    publish_date DATE,
+    published_url VARCHAR(1000),
+    status VARCHAR(30) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'in-progress', 'awaiting-review', 'approved', 'published', 'completed')),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_deliverables_sponsorship_id ON deliverables(sponsorship_id);
+CREATE INDEX IF NOT EXISTS idx_deliverables_due_date ON deliverables(due_date);
+
+-- Turn the free-text deliverables of existing deals into tracked deliverables.
+-- sponsorships.deliverables is kept as a plain list of titles for older clients.
+INSERT INTO deliverables (sponsorship_id, creator_id, type, title, status)
+SELECT s.id, s.creator_id, 'integration', d.title,
+       CASE s.status WHEN 'completed' THEN 'completed' WHEN 'published' THEN 'published' ELSE 'pending' END
+FROM sponsorships s
+CROSS JOIN LATERAL unnest(s.deliverables) AS d(title)
+WHERE NOT EXISTS (SELECT 1 FROM deliverables x WHERE x.sponsorship_id = s.id);
@@ -0,0 +1,26 @@
+-- 010_create_deliverable_drafts_table.sql
+-- Contractual number of change rounds a brand may request per deliverable; NULL means unlimited
+ALTER TABLE sponsorships ADD COLUMN IF NOT EXISTS revision_limit INTEGER CHECK (revision_limit >= 0);
+
+-- Change rounds the brand has requested so far
+ALTER TABLE deliverables ADD COLUMN IF NOT EXISTS revision_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS deliverable_drafts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    deliverable_id UUID NOT NULL REFERENCES deliverables(id) ON DELETE CASCADE,
+    sponsorship_id UUID NOT NULL REFERENCES sponsorships(id) ON DELETE CASCADE,
+    creator_id UUID NOT NULL REFERENCES creators(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    link_url VARCHAR(1000),
+    file_name VARCHAR(255),
+    notes TEXT,
+    submitted_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    review_token VARCHAR(64) NOT NULL UNIQUE,
+    status VARCHAR(30) NOT NULL DEFAULT 'pending-review' CHECK (status IN ('pending-review', 'approved', 'changes-requested', 'superseded')),
+    reviewer_name VARCHAR(255),
+    review_comments TEXT,
+    reviewed_at TIMESTAMP,
+    UNIQUE (deliverable_id, version)
+);
+
+CREATE INDEX IF NOT EXISTS idx_deliverable_drafts_deliverable_id ON deliverable_drafts(deliverable_id);
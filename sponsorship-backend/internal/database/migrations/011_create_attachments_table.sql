@@ -0,0 +1,18 @@
+-- 011_create_attachments_table.sql
+CREATE TABLE IF NOT EXISTS attachments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sponsorship_id UUID NOT NULL REFERENCES sponsorships(id) ON DELETE CASCADE,
+    creator_id UUID NOT NULL REFERENCES creators(id) ON DELETE CASCADE,
+    kind VARCHAR(20) NOT NULL DEFAULT 'other' CHECK (kind IN ('contract', 'brief', 'invoice', 'draft', 'other')),
+    file_name VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes BIGINT NOT NULL CHECK (size_bytes >= 0),
+    storage_key VARCHAR(500) NOT NULL UNIQUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_attachments_sponsorship_id ON attachments(sponsorship_id);
+CREATE INDEX IF NOT EXISTS idx_attachments_creator_id ON attachments(creator_id);
+
+-- Drafts can point at an uploaded file instead of an external link
+ALTER TABLE deliverable_drafts ADD COLUMN IF NOT EXISTS attachment_id UUID REFERENCES attachments(id) ON DELETE SET NULL;
@@ -0,0 +1,17 @@
+-- 012_create_payment_milestones_table.sql
+CREATE TABLE IF NOT EXISTS payment_milestones (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sponsorship_id UUID NOT NULL REFERENCES sponsorships(id) ON DELETE CASCADE,
+    creator_id UUID NOT NULL REFERENCES creators(id) ON DELETE CASCADE,
+    label VARCHAR(255) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    due_date DATE NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'unpaid' CHECK (status IN ('unpaid', 'paid')),
+    paid_on DATE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((status = 'paid') = (paid_on IS NOT NULL))
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_milestones_sponsorship_id ON payment_milestones(sponsorship_id);
+CREATE INDEX IF NOT EXISTS idx_payment_milestones_unpaid ON payment_milestones(creator_id, due_date) WHERE status = 'unpaid';
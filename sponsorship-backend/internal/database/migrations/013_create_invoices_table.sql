@@ -0,0 +1,71 @@
+-- 013_create_invoices_table.sql
+-- The creator's own details as printed on invoices
+CREATE TABLE IF NOT EXISTS billing_details (
+    creator_id UUID PRIMARY KEY REFERENCES creators(id) ON DELETE CASCADE,
+    legal_name VARCHAR(255) NOT NULL,
+    email VARCHAR(255),
+    address_line1 VARCHAR(255),
+    address_line2 VARCHAR(255),
+    city VARCHAR(100),
+    postal_code VARCHAR(20),
+    region VARCHAR(100),
+    country VARCHAR(2),
+    tax_id VARCHAR(50),
+    payment_instructions TEXT,
+    invoice_prefix VARCHAR(20) NOT NULL DEFAULT 'INV-',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Next invoice number per creator. Numbers are taken in the same transaction that issues
+-- the invoice, so a failed issue never leaves a gap.
+CREATE TABLE IF NOT EXISTS invoice_sequences (
+    creator_id UUID PRIMARY KEY REFERENCES creators(id) ON DELETE CASCADE,
+    next_number INTEGER NOT NULL DEFAULT 1
+);
+
+CREATE TABLE IF NOT EXISTS invoices (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    creator_id UUID NOT NULL REFERENCES creators(id) ON DELETE CASCADE,
+    sponsorship_id UUID NOT NULL REFERENCES sponsorships(id) ON DELETE RESTRICT,
+    milestone_id UUID REFERENCES payment_milestones(id) ON DELETE SET NULL,
+    sequence_number INTEGER,
+    invoice_number VARCHAR(50),
+    status VARCHAR(20) NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'sent', 'paid', 'void')),
+    issue_date DATE,
+    due_date DATE,
+    bill_to_name VARCHAR(255) NOT NULL,
+    bill_to_contact VARCHAR(255),
+    bill_to_email VARCHAR(255),
+    bill_to_address TEXT,
+    bill_to_tax_id VARCHAR(50),
+    seller_details JSONB,
+    subtotal DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    tax_label VARCHAR(50),
+    tax_rate DECIMAL(5, 2) NOT NULL DEFAULT 0 CHECK (tax_rate >= 0),
+    tax_amount DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    total DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    notes TEXT,
+    sent_at TIMESTAMP,
+    paid_at TIMESTAMP,
+    voided_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (creator_id, sequence_number),
+    CHECK (status = 'draft' OR sequence_number IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoices_creator_id ON invoices(creator_id);
+CREATE INDEX IF NOT EXISTS idx_invoices_sponsorship_id ON invoices(sponsorship_id);
+
+CREATE TABLE IF NOT EXISTS invoice_line_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    invoice_id UUID NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+    deliverable_id UUID REFERENCES deliverables(id) ON DELETE SET NULL,
+    position INTEGER NOT NULL,
+    description VARCHAR(500) NOT NULL,
+    quantity DECIMAL(10, 2) NOT NULL DEFAULT 1 CHECK (quantity > 0),
+    unit_price DECIMAL(10, 2) NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoice_line_items_invoice_id ON invoice_line_items(invoice_id);
@@ -0,0 +1,8 @@
+-- 014_add_currency_columns.sql
+-- ISO 4217 code of every stored amount. Existing rows were all recorded in US dollars.
+ALTER TABLE sponsorships ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+
+-- Milestones and invoices are always in their deal's currency; invoices keep their own
+-- copy because an issued invoice must not change.
+ALTER TABLE payment_milestones ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE invoices ADD COLUMN IF NOT EXISTS currency CHAR(3) NOT NULL DEFAULT 'USD';
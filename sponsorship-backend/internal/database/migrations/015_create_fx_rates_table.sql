@@ -0,0 +1,17 @@
+-- 015_create_fx_rates_table.sql
+-- Daily exchange rates: one unit of base_currency buys rate units of quote_currency.
+-- Loaded from a CSV file; see FX_RATES_FILE.
+CREATE TABLE IF NOT EXISTS fx_rates (
+    rate_date DATE NOT NULL,
+    base_currency CHAR(3) NOT NULL,
+    quote_currency CHAR(3) NOT NULL,
+    rate NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    PRIMARY KEY (base_currency, quote_currency, rate_date)
+);
+
+-- Per-creator preferences; a creator without a row uses the defaults
+CREATE TABLE IF NOT EXISTS creator_settings (
+    creator_id UUID PRIMARY KEY REFERENCES creators(id) ON DELETE CASCADE,
+    base_currency CHAR(3) NOT NULL DEFAULT 'USD',
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,25 @@
+-- 016_create_expenses_table.sql
+-- Production costs of a deal. An expense may be in a different currency from its deal,
+-- e.g. travel abroad.
+CREATE TABLE IF NOT EXISTS expenses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    sponsorship_id UUID NOT NULL REFERENCES sponsorships(id) ON DELETE CASCADE,
+    creator_id UUID NOT NULL REFERENCES creators(id) ON DELETE CASCADE,
+    category VARCHAR(20) NOT NULL DEFAULT 'other'
+        CHECK (category IN ('props', 'equipment', 'editing', 'travel', 'talent', 'software', 'advertising', 'other')),
+    description TEXT,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    expense_date DATE NOT NULL,
+    receipt_attachment_id UUID REFERENCES attachments(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_expenses_sponsorship_id ON expenses(sponsorship_id);
+CREATE INDEX IF NOT EXISTS idx_expenses_creator_date ON expenses(creator_id, expense_date);
+
+-- Receipts are uploaded as deal attachments
+ALTER TABLE attachments DROP CONSTRAINT IF EXISTS attachments_kind_check;
+ALTER TABLE attachments ADD CONSTRAINT attachments_kind_check
+    CHECK (kind IN ('contract', 'brief', 'invoice', 'draft', 'receipt', 'other'));
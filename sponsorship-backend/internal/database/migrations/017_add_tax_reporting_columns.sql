@@ -0,0 +1,7 @@
+-- 017_add_tax_reporting_columns.sql
+-- Country of the paying brand, for income by payer country
+ALTER TABLE brands ADD COLUMN IF NOT EXISTS country VARCHAR(2);
+
+-- Percentage of a deal paid to an agent or manager
+ALTER TABLE sponsorships ADD COLUMN IF NOT EXISTS commission_rate DECIMAL(5, 2)
+    CHECK (commission_rate >= 0 AND commission_rate <= 100);
@@ -0,0 +1,30 @@
+-- 018_create_payments_table.sql
+-- What users paid us through Stripe, recorded from webhook events.
+CREATE TABLE IF NOT EXISTS payments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    -- the Checkout Session (cs_...) or Stripe invoice (in_...) that was paid
+    provider_reference VARCHAR(255) NOT NULL UNIQUE,
+    payment_intent_id VARCHAR(255),
+    customer_id VARCHAR(255),
+    description TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending'
+        CHECK (status IN ('pending', 'paid', 'failed', 'refunded', 'partially_refunded')),
+    amount DECIMAL(10, 2) NOT NULL,
+    amount_refunded DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    currency CHAR(3) NOT NULL,
+    paid_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_payments_user_id ON payments(user_id);
+CREATE INDEX IF NOT EXISTS idx_payments_payment_intent_id ON payments(payment_intent_id);
+CREATE INDEX IF NOT EXISTS idx_payments_customer_id ON payments(customer_id);
+
+-- Stripe delivers events at least once; an event ID is handled only the first time
+CREATE TABLE IF NOT EXISTS stripe_events (
+    id VARCHAR(255) PRIMARY KEY,
+    type VARCHAR(100) NOT NULL,
+    processed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,20 @@
+-- 019_create_products_table.sql
+-- What we sell. Checkout charges the price stored here, never one sent by the client.
+CREATE TABLE IF NOT EXISTS products (
+    id VARCHAR(50) PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL DEFAULT 'USD',
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO products (id, name, description, amount, currency) VALUES
+    ('base', 'Base Product', 'Perfect for getting started with sponsorship management', 29.00, 'USD'),
+    ('pro', 'Pro Product', 'Advanced features for growing creators', 99.00, 'USD')
+ON CONFLICT (id) DO NOTHING;
+
+-- The product a payment bought, from the checkout session's metadata
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS product_id VARCHAR(50) REFERENCES products(id) ON DELETE SET NULL;
@@ -0,0 +1,32 @@
+-- 020_create_subscriptions_table.sql
+-- Products with a billing interval are subscription plans, and carry the limits of the plan.
+-- A NULL max_active_deals is unlimited.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS billing_interval VARCHAR(10)
+    CHECK (billing_interval IN ('month', 'year'));
+ALTER TABLE products ADD COLUMN IF NOT EXISTS max_active_deals INTEGER CHECK (max_active_deals > 0);
+ALTER TABLE products ADD COLUMN IF NOT EXISTS api_access BOOLEAN NOT NULL DEFAULT FALSE;
+
+UPDATE products SET billing_interval = 'month', max_active_deals = 10
+WHERE id = 'base';
+UPDATE products SET billing_interval = 'month', max_active_deals = NULL, api_access = TRUE
+WHERE id = 'pro';
+
+-- Users' Stripe subscriptions, kept in sync from webhook events
+CREATE TABLE IF NOT EXISTS subscriptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID REFERENCES users(id) ON DELETE CASCADE,
+    product_id VARCHAR(50) REFERENCES products(id) ON DELETE SET NULL,
+    provider_subscription_id VARCHAR(255) NOT NULL UNIQUE,
+    customer_id VARCHAR(255),
+    -- Stripe's subscription status: trialing, active, past_due, canceled, unpaid, incomplete, ...
+    status VARCHAR(30) NOT NULL,
+    current_period_end TIMESTAMP,
+    cancel_at_period_end BOOLEAN NOT NULL DEFAULT FALSE,
+    -- creation time of the newest event applied, so late deliveries of older events are ignored
+    last_event_at TIMESTAMP NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_subscriptions_user_id ON subscriptions(user_id);
+CREATE INDEX IF NOT EXISTS idx_subscriptions_customer_id ON subscriptions(customer_id);
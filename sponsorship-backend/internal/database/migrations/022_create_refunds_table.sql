@@ -0,0 +1,17 @@
+-- 022_create_refunds_table.sql
+-- Refunds issued through the API. The payment's amount_refunded is kept in step with
+-- them, and with charge.refunded events for refunds made elsewhere.
+CREATE TABLE IF NOT EXISTS refunds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    provider_refund_id VARCHAR(255) NOT NULL UNIQUE,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL,
+    reason VARCHAR(30) CHECK (reason IN ('duplicate', 'fraudulent', 'requested_by_customer')),
+    -- as reported by the provider when the refund was issued
+    status VARCHAR(20) NOT NULL,
+    issued_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_refunds_payment_id ON refunds(payment_id);
@@ -0,0 +1,23 @@
+-- 023_create_payment_links_table.sql
+-- Hosted checkouts a creator sends a brand to pay an invoice or milestone by card. The
+-- Stripe webhook marks what the link bills paid once the brand has paid.
+CREATE TABLE IF NOT EXISTS payment_links (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    creator_id UUID NOT NULL REFERENCES creators(id) ON DELETE CASCADE,
+    invoice_id UUID REFERENCES invoices(id) ON DELETE CASCADE,
+    milestone_id UUID REFERENCES payment_milestones(id) ON DELETE CASCADE,
+    -- the Checkout Session (cs_...) the link opens
+    provider_session_id VARCHAR(255) NOT NULL UNIQUE,
+    url TEXT NOT NULL,
+    amount DECIMAL(10, 2) NOT NULL CHECK (amount > 0),
+    currency CHAR(3) NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'open' CHECK (status IN ('open', 'paid', 'failed', 'expired')),
+    expires_at TIMESTAMP NOT NULL,
+    paid_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (invoice_id IS NOT NULL OR milestone_id IS NOT NULL)
+);
+
+CREATE INDEX IF NOT EXISTS idx_payment_links_invoice_id ON payment_links(invoice_id);
+CREATE INDEX IF NOT EXISTS idx_payment_links_milestone_id ON payment_links(milestone_id);
@@ -0,0 +1,46 @@
+-- 024_create_coupons_table.sql
+-- Discount codes entered at checkout. A coupon takes either a percentage or a fixed amount
+-- in one currency off the price.
+CREATE TABLE IF NOT EXISTS coupons (
+    code VARCHAR(50) PRIMARY KEY CHECK (code = UPPER(code)),
+    description TEXT,
+    percent_off DECIMAL(5, 2) CHECK (percent_off > 0 AND percent_off < 100),
+    amount_off DECIMAL(10, 2) CHECK (amount_off > 0),
+    currency CHAR(3),
+    -- once discounts the first payment of a plan, forever every renewal
+    duration VARCHAR(10) NOT NULL DEFAULT 'once' CHECK (duration IN ('once', 'forever')),
+    expires_at TIMESTAMP,
+    max_redemptions INTEGER CHECK (max_redemptions > 0),
+    times_redeemed INTEGER NOT NULL DEFAULT 0,
+    active BOOLEAN NOT NULL DEFAULT TRUE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK ((percent_off IS NULL) <> (amount_off IS NULL)),
+    CHECK ((amount_off IS NULL) = (currency IS NULL))
+);
+
+-- The products a coupon applies to; a coupon without rows applies to every product
+CREATE TABLE IF NOT EXISTS coupon_products (
+    coupon_code VARCHAR(50) NOT NULL REFERENCES coupons(code) ON DELETE CASCADE,
+    product_id VARCHAR(50) NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    PRIMARY KEY (coupon_code, product_id)
+);
+
+-- A coupon used in a checkout. It is held while the checkout is open so that open
+-- checkouts count towards max_redemptions, and becomes redeemed when the checkout
+-- completes or is released when it expires.
+CREATE TABLE IF NOT EXISTS coupon_redemptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    coupon_code VARCHAR(50) NOT NULL REFERENCES coupons(code) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    product_id VARCHAR(50) REFERENCES products(id) ON DELETE SET NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'held' CHECK (status IN ('held', 'redeemed', 'released')),
+    provider_reference VARCHAR(255),
+    discount_amount DECIMAL(10, 2),
+    currency CHAR(3),
+    held_until TIMESTAMP NOT NULL,
+    redeemed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_coupon_redemptions_coupon_code ON coupon_redemptions(coupon_code);
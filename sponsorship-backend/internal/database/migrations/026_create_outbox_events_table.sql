@@ -0,0 +1,24 @@
+-- 026_create_outbox_events_table.sql
+-- Events about sponsorship changes, written in the same transaction as the change and
+-- handed to in-process subscribers (such as webhooks) afterwards. A change is thus never
+-- saved without its event, nor an event published for a change that was rolled back.
+CREATE TABLE IF NOT EXISTS outbox_events (
+    id UUID PRIMARY KEY,
+    creator_id UUID NOT NULL,
+    aggregate_id UUID NOT NULL, -- the sponsorship the event is about
+    event_type VARCHAR(50) NOT NULL,
+    payload JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'published', 'failed')),
+    attempts INTEGER NOT NULL DEFAULT 0,
+    -- when the event is next handed to subscribers; pushed back while one instance has it
+    next_attempt_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    last_error TEXT,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    published_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_outbox_events_due ON outbox_events(next_attempt_at) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS idx_outbox_events_aggregate_id ON outbox_events(aggregate_id, created_at);
+
+-- Events handed to the webhooks subscriber again are queued once per endpoint
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_event_id ON webhook_deliveries(endpoint_id, event_id);
@@ -0,0 +1,16 @@
+-- 027_add_currency_to_triage_amount_conditions.sql
+-- dealAmount conditions name the currency of their value, and pitches in other currencies
+-- are converted into it. Conditions saved before were validated as US dollar amounts.
+UPDATE triage_rules r
+SET conditions = (
+    SELECT jsonb_agg(
+        CASE WHEN c->>'field' = 'dealAmount' AND NOT c ? 'currency'
+             THEN c || '{"currency": "USD"}'::JSONB
+             ELSE c
+        END ORDER BY n)
+    FROM jsonb_array_elements(r.conditions) WITH ORDINALITY AS e(c, n)
+)
+WHERE EXISTS (
+    SELECT 1 FROM jsonb_array_elements(r.conditions) c
+    WHERE c->>'field' = 'dealAmount' AND NOT c ? 'currency'
+);
@@ -0,0 +1,7 @@
+-- 028_unpublish_imported_deliverables_without_url.sql
+-- Deliverables imported from published deals in 009 were marked published without the URL
+-- a published deliverable needs, so every later edit of them failed validation. They go
+-- back to approved until the creator publishes them with their URL.
+UPDATE deliverables
+SET status = 'approved', updated_at = NOW()
+WHERE status = 'published' AND published_url IS NULL;
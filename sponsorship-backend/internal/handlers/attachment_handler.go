@@ -0,0 +1,321 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/storage"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// multipartMemoryBytes is how much of an upload is kept in memory before spilling to disk
+const multipartMemoryBytes = 8 << 20
+
+// allowedAttachmentTypes are the sniffed content types accepted for upload. Office
+// documents sniff as application/zip.
+var allowedAttachmentTypes = []string{
+	"application/pdf",
+	"application/zip",
+	"image/png",
+	"image/jpeg",
+	"image/gif",
+	"image/webp",
+	"video/mp4",
+	"video/webm",
+	"audio/mpeg",
+	"audio/wave",
+	"text/plain; charset=utf-8",
+}
+
+// attachmentRepository is the part of AttachmentRepository the attachment handler uses
+type attachmentRepository interface {
+	CreateAttachment(a *models.Attachment, quotaBytes int64) error
+	GetAttachment(id, sponsorshipID, creatorID string) (*models.Attachment, error)
+	ListAttachments(sponsorshipID, creatorID string) ([]*models.Attachment, error)
+	DeleteAttachment(id, sponsorshipID, creatorID string) error
+	GetStorageUsed(creatorID string) (int64, error)
+}
+
+// sponsorshipFinder looks up one of a creator's deals, as SponsorshipRepository does
+type sponsorshipFinder interface {
+	GetSponsorshipByID(id, creatorID string) (*models.Sponsorship, error)
+}
+
+type AttachmentHandler struct {
+	repo            attachmentRepository
+	sponsorshipRepo sponsorshipFinder
+	store           storage.Storage
+	maxUploadBytes  int64
+	quotaBytes      int64
+}
+
+func NewAttachmentHandler(repo attachmentRepository, sponsorshipRepo sponsorshipFinder,
+	store storage.Storage, maxUploadBytes, quotaBytes int64) *AttachmentHandler {
+	return &AttachmentHandler{
+		repo:            repo,
+		sponsorshipRepo: sponsorshipRepo,
+		store:           store,
+		maxUploadBytes:  maxUploadBytes,
+		quotaBytes:      quotaBytes,
+	}
+}
+
+// ListAttachments lists the files attached to a sponsorship
+func (h *AttachmentHandler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	attachments, err := h.repo.ListAttachments(id, creatorID)
+	if err != nil {
+		logger.Error("Failed to list attachments for sponsorship %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if attachments == nil {
+		attachments = []*models.Attachment{}
+	}
+
+	api.WriteSuccess(w, http.StatusOK, attachments)
+}
+
+// UploadAttachment stores a file sent as the "file" field of a multipart form, with an
+// optional "kind" field
+func (h *AttachmentHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	// Leave room for the multipart framing around the file itself
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxUploadBytes+1<<20)
+	if err := r.ParseMultipartForm(multipartMemoryBytes); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			logger.Warn("Upload for sponsorship %s exceeds %d bytes", id, h.maxUploadBytes)
+			api.WriteError(w, apierrors.ErrPayloadTooLarge.WithDetails(map[string]int64{"maxBytes": h.maxUploadBytes}))
+			return
+		}
+		logger.Warn("Failed to parse upload for sponsorship %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"file": "file is required",
+		}))
+		return
+	}
+	defer file.Close()
+
+	kind := r.FormValue("kind")
+	if kind == "" {
+		kind = "other"
+	}
+	if !contains(models.ValidAttachmentKinds, kind) {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"kind": "kind must be one of " + strings.Join(models.ValidAttachmentKinds, ", "),
+		}))
+		return
+	}
+
+	if header.Size > h.maxUploadBytes {
+		logger.Warn("Upload for sponsorship %s is %d bytes, limit %d", id, header.Size, h.maxUploadBytes)
+		api.WriteError(w, apierrors.ErrPayloadTooLarge.WithDetails(map[string]int64{"maxBytes": h.maxUploadBytes}))
+		return
+	}
+
+	if _, err := h.sponsorshipRepo.GetSponsorshipByID(id, creatorID); err != nil {
+		logger.Warn("Sponsorship not found for upload: ID=%s, Creator=%s", id, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	contentType, err := sniffContentType(file)
+	if err != nil {
+		logger.Error("Failed to read upload for sponsorship %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+	if !contains(allowedAttachmentTypes, contentType) {
+		logger.Warn("Rejected upload of type %s for sponsorship %s", contentType, id)
+		api.WriteError(w, apierrors.ErrUnsupportedMediaType.WithDetails(map[string]string{"contentType": contentType}))
+		return
+	}
+
+	// Checked here to avoid storing a file that cannot fit, and again as the row is
+	// recorded, since other uploads may have finished in the meantime
+	used, err := h.repo.GetStorageUsed(creatorID)
+	if err != nil {
+		logger.Error("Failed to get storage used by creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+	if used+header.Size > h.quotaBytes {
+		logger.Warn("Creator %s storage quota exceeded: %d + %d > %d", creatorID, used, header.Size, h.quotaBytes)
+		api.WriteError(w, apierrors.ErrStorageQuotaExceeded.WithDetails(map[string]int64{
+			"quotaBytes": h.quotaBytes,
+			"usedBytes":  used,
+		}))
+		return
+	}
+
+	attachment := &models.Attachment{
+		ID:            uuid.New().String(),
+		SponsorshipID: id,
+		CreatorID:     creatorID,
+		Kind:          kind,
+		FileName:      sanitizeFileName(header.Filename),
+		ContentType:   contentType,
+		SizeBytes:     header.Size,
+	}
+	attachment.StorageKey = creatorID + "/" + id + "/" + attachment.ID
+
+	if err := h.store.Put(attachment.StorageKey, file, header.Size, contentType); err != nil {
+		logger.Error("Failed to store upload for sponsorship %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if err := h.repo.CreateAttachment(attachment, h.quotaBytes); err != nil {
+		if err := h.store.Delete(attachment.StorageKey); err != nil {
+			logger.Error("Failed to remove orphaned object %s: %v", attachment.StorageKey, err)
+		}
+		var appErr *apierrors.AppError
+		if errors.As(err, &appErr) && errors.Is(appErr, apierrors.ErrStorageQuotaExceeded) {
+			// The error carries the quota and the usage it was checked against
+			logger.Warn("Creator %s storage quota exceeded by a concurrent upload: %d more bytes", creatorID,
+				header.Size)
+			api.WriteError(w, appErr)
+			return
+		}
+		logger.Error("Failed to record attachment for sponsorship %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Attachment uploaded: ID=%s, Sponsorship=%s, Kind=%s, Type=%s, Size=%d",
+		attachment.ID, id, kind, contentType, attachment.SizeBytes)
+	api.WriteSuccess(w, http.StatusCreated, attachment)
+}
+
+// DownloadAttachment streams an attached file
+func (h *AttachmentHandler) DownloadAttachment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	attachmentID := chi.URLParam(r, "attachmentId")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	attachment, err := h.repo.GetAttachment(attachmentID, id, creatorID)
+	if err != nil {
+		logger.Warn("Attachment not found: ID=%s, Sponsorship=%s, Creator=%s", attachmentID, id, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	serveAttachment(w, h.store, attachment)
+}
+
+// DeleteAttachment removes an attached file
+func (h *AttachmentHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	attachmentID := chi.URLParam(r, "attachmentId")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	attachment, err := h.repo.GetAttachment(attachmentID, id, creatorID)
+	if err != nil {
+		logger.Warn("Attachment not found for delete: ID=%s, Sponsorship=%s", attachmentID, id)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	if err := h.repo.DeleteAttachment(attachmentID, id, creatorID); err != nil {
+		logger.Warn("Failed to delete attachment: ID=%s, Sponsorship=%s, Error: %v", attachmentID, id, err)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	// The record is gone, so a leftover object only costs space; log it and move on
+	if err := h.store.Delete(attachment.StorageKey); err != nil {
+		logger.Error("Failed to delete stored object %s: %v", attachment.StorageKey, err)
+	}
+
+	logger.Info("Attachment deleted: ID=%s, Sponsorship=%s", attachmentID, id)
+	api.WriteSuccess(w, http.StatusOK, map[string]bool{"deleted": true})
+}
+
+// serveAttachment writes a stored file as a download. The sniffed content type is sent
+// with nosniff so browsers do not reinterpret it.
+func serveAttachment(w http.ResponseWriter, store storage.Storage, attachment *models.Attachment) {
+	body, err := store.Get(attachment.StorageKey)
+	if err != nil {
+		if err == storage.ErrNotExist {
+			logger.Error("Stored object missing for attachment %s", attachment.ID)
+			api.WriteError(w, apierrors.ErrNotFound)
+			return
+		}
+		logger.Error("Failed to open attachment %s: %v", attachment.ID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+	defer body.Close()
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(attachment.SizeBytes, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": attachment.FileName}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+
+	if _, err := io.Copy(w, body); err != nil {
+		logger.Warn("Failed to stream attachment %s: %v", attachment.ID, err)
+	}
+}
+
+// sniffContentType detects the content type from the first bytes of the file and
+// rewinds it
+func sniffContentType(file multipart.File) (string, error) {
+	buf := make([]byte, 512)
+	n, err := io.ReadFull(file, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+	return http.DetectContentType(buf[:n]), nil
+}
+
+// sanitizeFileName keeps the base name of an uploaded file without control characters
+func sanitizeFileName(name string) string {
+	name = path.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == "/" {
+		name = "attachment"
+	}
+	for len(name) > 255 {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"sponsorship-backend/internal/models"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+const (
+	testCreatorID     = "11111111-1111-1111-1111-111111111111"
+	testSponsorshipID = "22222222-2222-2222-2222-222222222222"
+)
+
+type fakeAttachmentRepo struct {
+	attachments []*models.Attachment
+	used        int64
+	// raced is recorded by another upload after GetStorageUsed, before CreateAttachment
+	raced int64
+}
+
+func (f *fakeAttachmentRepo) CreateAttachment(a *models.Attachment, quotaBytes int64) error {
+	f.used += f.raced
+	f.raced = 0
+	if f.used+a.SizeBytes > quotaBytes {
+		return apierrors.ErrStorageQuotaExceeded.WithDetails(map[string]int64{"quotaBytes": quotaBytes, "usedBytes": f.used})
+	}
+	f.attachments = append(f.attachments, a)
+	f.used += a.SizeBytes
+	return nil
+}
+
+func (f *fakeAttachmentRepo) GetAttachment(id, sponsorshipID, creatorID string) (*models.Attachment, error) {
+	for _, a := range f.attachments {
+		if a.ID == id && a.SponsorshipID == sponsorshipID && a.CreatorID == creatorID {
+			return a, nil
+		}
+	}
+	return nil, apierrors.ErrNotFound
+}
+
+func (f *fakeAttachmentRepo) ListAttachments(sponsorshipID, creatorID string) ([]*models.Attachment, error) {
+	var list []*models.Attachment
+	for _, a := range f.attachments {
+		if a.SponsorshipID == sponsorshipID && a.CreatorID == creatorID {
+			list = append(list, a)
+		}
+	}
+	return list, nil
+}
+
+func (f *fakeAttachmentRepo) DeleteAttachment(id, sponsorshipID, creatorID string) error {
+	for i, a := range f.attachments {
+		if a.ID == id && a.SponsorshipID == sponsorshipID && a.CreatorID == creatorID {
+			f.attachments = append(f.attachments[:i], f.attachments[i+1:]...)
+			f.used -= a.SizeBytes
+			return nil
+		}
+	}
+	return apierrors.ErrNotFound
+}
+
+func (f *fakeAttachmentRepo) GetStorageUsed(creatorID string) (int64, error) {
+	return f.used, nil
+}
+
+// fakeSponsorships finds the deals it holds, keyed by ID
+type fakeSponsorships map[string]*models.Sponsorship
+
+func (f fakeSponsorships) GetSponsorshipByID(id, creatorID string) (*models.Sponsorship, error) {
+	if s, ok := f[id]; ok && s.CreatorID == creatorID {
+		return s, nil
+	}
+	return nil, apierrors.ErrNotFound
+}
+
+func newTestAttachmentHandler(t *testing.T, maxUploadBytes, quotaBytes int64) (*AttachmentHandler, *fakeAttachmentRepo) {
+	store, err := storage.NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	repo := &fakeAttachmentRepo{}
+	sponsorships := fakeSponsorships{
+		testSponsorshipID: {ID: testSponsorshipID, CreatorID: testCreatorID},
+	}
+	return NewAttachmentHandler(repo, sponsorships, store, maxUploadBytes, quotaBytes), repo
+}
+
+// uploadRequest builds a multipart upload of content to the test deal
+func uploadRequest(t *testing.T, fileName string, content []byte) *http.Request {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	part.Write(content)
+	form.WriteField("kind", "contract")
+	form.Close()
+
+	r := httptest.NewRequest(http.MethodPost, "/api/sponsorships/"+testSponsorshipID+"/attachments", &body)
+	r.Header.Set("Content-Type", form.FormDataContentType())
+	r.Header.Set("X-Creator-ID", testCreatorID)
+
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", testSponsorshipID)
+	return r.WithContext(context.WithValue(r.Context(), chi.RouteCtxKey, rctx))
+}
+
+// errorCode returns the code of an error response written by api.WriteError
+func errorCode(t *testing.T, w *httptest.ResponseRecorder) string {
+	var resp struct {
+		Error struct {
+			Code string `json:"code"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response %q: %v", w.Body.String(), err)
+	}
+	return resp.Error.Code
+}
+
+func TestUploadAttachmentStoresFile(t *testing.T) {
+	h, repo := newTestAttachmentHandler(t, 1<<10, 1<<20)
+
+	w := httptest.NewRecorder()
+	h.UploadAttachment(w, uploadRequest(t, "contract.txt", []byte("signed by both parties")))
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body.String())
+	}
+	if len(repo.attachments) != 1 {
+		t.Fatalf("recorded %d attachments, want 1", len(repo.attachments))
+	}
+	a := repo.attachments[0]
+	if a.Kind != "contract" || a.FileName != "contract.txt" || a.SizeBytes != 22 {
+		t.Errorf("recorded %+v", a)
+	}
+	if !strings.HasPrefix(a.StorageKey, testCreatorID+"/"+testSponsorshipID+"/") {
+		t.Errorf("storage key %q is not under the creator and deal", a.StorageKey)
+	}
+}
+
+func TestUploadAttachmentRejectsFileOverSizeLimit(t *testing.T) {
+	tests := []struct {
+		name string
+		size int
+	}{
+		// Caught once the form is parsed, from the file's own size
+		{"over the limit", 11},
+		// Cut off while the form is read, before it is parsed
+		{"over the limit and framing allowance", 2 << 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h, repo := newTestAttachmentHandler(t, 10, 1<<30)
+
+			w := httptest.NewRecorder()
+			h.UploadAttachment(w, uploadRequest(t, "big.txt", bytes.Repeat([]byte("a"), tt.size)))
+
+			if w.Code != http.StatusRequestEntityTooLarge {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+			}
+			if code := errorCode(t, w); code != apierrors.ErrPayloadTooLarge.Code {
+				t.Errorf("error code = %s, want %s", code, apierrors.ErrPayloadTooLarge.Code)
+			}
+			if len(repo.attachments) != 0 {
+				t.Error("an oversized upload was recorded")
+			}
+		})
+	}
+}
+
+func TestUploadAttachmentRejectsUploadOverQuota(t *testing.T) {
+	h, repo := newTestAttachmentHandler(t, 1<<10, 100)
+	repo.used = 90
+
+	w := httptest.NewRecorder()
+	h.UploadAttachment(w, uploadRequest(t, "notes.txt", bytes.Repeat([]byte("a"), 11)))
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	if code := errorCode(t, w); code != apierrors.ErrStorageQuotaExceeded.Code {
+		t.Errorf("error code = %s, want %s", code, apierrors.ErrStorageQuotaExceeded.Code)
+	}
+	if len(repo.attachments) != 0 {
+		t.Error("an upload over quota was recorded")
+	}
+
+	// Exactly filling the quota is allowed
+	w = httptest.NewRecorder()
+	h.UploadAttachment(w, uploadRequest(t, "notes.txt", bytes.Repeat([]byte("a"), 10)))
+	if w.Code != http.StatusCreated {
+		t.Errorf("status = %d, want %d for an upload that fills the quota", w.Code, http.StatusCreated)
+	}
+}
+
+// recordingStore notes the keys written to and deleted from the store it wraps
+type recordingStore struct {
+	storage.Storage
+	put, deleted []string
+}
+
+func (s *recordingStore) Put(key string, body io.Reader, size int64, contentType string) error {
+	s.put = append(s.put, key)
+	return s.Storage.Put(key, body, size, contentType)
+}
+
+func (s *recordingStore) Delete(key string) error {
+	s.deleted = append(s.deleted, key)
+	return s.Storage.Delete(key)
+}
+
+func TestUploadAttachmentRejectsUploadFilledByConcurrentUpload(t *testing.T) {
+	h, repo := newTestAttachmentHandler(t, 1<<10, 100)
+	store := &recordingStore{Storage: h.store}
+	h.store = store
+	repo.raced = 95
+
+	w := httptest.NewRecorder()
+	h.UploadAttachment(w, uploadRequest(t, "notes.txt", bytes.Repeat([]byte("a"), 10)))
+
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusRequestEntityTooLarge)
+	}
+	if code := errorCode(t, w); code != apierrors.ErrStorageQuotaExceeded.Code {
+		t.Errorf("error code = %s, want %s", code, apierrors.ErrStorageQuotaExceeded.Code)
+	}
+	if len(repo.attachments) != 0 {
+		t.Error("an upload over quota was recorded")
+	}
+	if len(store.put) != 1 || len(store.deleted) != 1 || store.deleted[0] != store.put[0] {
+		t.Errorf("stored %q and deleted %q, want the stored object deleted", store.put, store.deleted)
+	}
+}
@@ -0,0 +1,253 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/payments"
+)
+
+// refundReasons are the reasons a refund can be given, as the provider knows them
+var refundReasons = map[string]bool{
+	"duplicate":             true,
+	"fraudulent":            true,
+	"requested_by_customer": true,
+}
+
+type BillingHandler struct {
+	provider         payments.Provider
+	subscriptionRepo *repositories.SubscriptionRepository
+	sponsorshipRepo  *repositories.SponsorshipRepository
+	paymentRepo      *repositories.PaymentRepository
+	freePlan         models.Entitlements
+	frontendURL      string
+}
+
+// EntitlementsResponse is the user's plan with how much of it is in use
+type EntitlementsResponse struct {
+	*models.Entitlements
+	ActiveDeals int `json:"activeDeals"`
+}
+
+// BillingResponse is the user's plan and what they have paid
+type BillingResponse struct {
+	Plan              string            `json:"plan"`
+	Status            string            `json:"status"`
+	RenewalDate       *time.Time        `json:"renewalDate"` // nil on the free plan or once cancelled
+	CurrentPeriodEnd  *time.Time        `json:"currentPeriodEnd"`
+	CancelAtPeriodEnd bool              `json:"cancelAtPeriodEnd"`
+	Invoices          []*models.Payment `json:"invoices"`
+}
+
+type PortalResponse struct {
+	PortalURL string `json:"portalUrl"`
+}
+
+type RefundRequest struct {
+	Amount json.Number `json:"amount"` // defaults to what is left of the payment
+	Reason string      `json:"reason"`
+}
+
+type RefundResponse struct {
+	Refund  *models.Refund  `json:"refund"`
+	Payment *models.Payment `json:"payment"`
+}
+
+func NewBillingHandler(provider payments.Provider, subscriptionRepo *repositories.SubscriptionRepository,
+	sponsorshipRepo *repositories.SponsorshipRepository, paymentRepo *repositories.PaymentRepository,
+	freePlan models.Entitlements, frontendURL string) *BillingHandler {
+	return &BillingHandler{
+		provider:         provider,
+		subscriptionRepo: subscriptionRepo,
+		sponsorshipRepo:  sponsorshipRepo,
+		paymentRepo:      paymentRepo,
+		freePlan:         freePlan,
+		frontendURL:      frontendURL,
+	}
+}
+
+func (h *BillingHandler) entitlements(userID string) (*models.Entitlements, error) {
+	e, err := h.subscriptionRepo.GetEntitlements(userID)
+	if err == apierrors.ErrNotFound {
+		free := h.freePlan
+		return &free, nil
+	}
+	return e, err
+}
+
+// GetEntitlements returns what the user's plan allows and the creator's active deal count
+func (h *BillingHandler) GetEntitlements(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	entitlements, err := h.entitlements(userID)
+	if err != nil {
+		logger.Error("Failed to get entitlements for user %s: %v", userID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	active, err := h.sponsorshipRepo.CountActiveSponsorships(creatorID)
+	if err != nil {
+		logger.Error("Failed to count active deals for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, EntitlementsResponse{Entitlements: entitlements, ActiveDeals: active})
+}
+
+// GetBilling returns the user's plan, when it renews and their payments, newest first
+func (h *BillingHandler) GetBilling(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+
+	entitlements, err := h.entitlements(userID)
+	if err != nil {
+		logger.Error("Failed to get entitlements for user %s: %v", userID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	invoices, err := h.paymentRepo.ListUserPayments(userID)
+	if err != nil {
+		logger.Error("Failed to list payments for user %s: %v", userID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+	if invoices == nil {
+		invoices = []*models.Payment{}
+	}
+
+	response := BillingResponse{
+		Plan:              entitlements.Plan,
+		Status:            entitlements.Status,
+		CurrentPeriodEnd:  entitlements.CurrentPeriodEnd,
+		CancelAtPeriodEnd: entitlements.CancelAtPeriodEnd,
+		Invoices:          invoices,
+	}
+	if !entitlements.CancelAtPeriodEnd {
+		response.RenewalDate = entitlements.CurrentPeriodEnd
+	}
+
+	api.WriteSuccess(w, http.StatusOK, response)
+}
+
+// CreatePortalSession returns the URL of the provider's customer portal, where the user
+// updates payment methods, downloads receipts and cancels their subscription
+func (h *BillingHandler) CreatePortalSession(w http.ResponseWriter, r *http.Request) {
+	userID := r.Header.Get("X-User-ID")
+
+	customerID, err := h.paymentRepo.GetCustomerID(userID)
+	if err == apierrors.ErrNotFound {
+		logger.Warn("Billing portal requested by user %s, who has never paid", userID)
+		api.WriteError(w, apierrors.ErrNotFound.WithDetails("no billing account; buy a plan first"))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to get customer ID for user %s: %v", userID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	url, err := h.provider.CreatePortalSession(customerID, h.frontendURL+"/dashboard")
+	if err != nil {
+		logger.Error("Failed to create billing portal session for user %s: %v", userID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Billing portal session created for user %s", userID)
+	api.WriteSuccess(w, http.StatusOK, PortalResponse{PortalURL: url})
+}
+
+// IssueRefund refunds a payment, in whole or in part, with the provider and records the
+// refund against the payment. Admin only.
+func (h *BillingHandler) IssueRefund(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	adminID := r.Header.Get("X-User-ID")
+
+	var req RefundRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode refund request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	payment, err := h.paymentRepo.GetPayment(id)
+	if err == apierrors.ErrNotFound {
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to get payment %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if (payment.Status != "paid" && payment.Status != "partially_refunded") || payment.PaymentIntentID == "" {
+		api.WriteError(w, apierrors.ErrInvalidStateTransition.WithDetails(map[string]string{
+			"status": "only paid payments can be refunded",
+		}))
+		return
+	}
+
+	remaining := payment.Amount.Sub(payment.AmountRefunded)
+	amount := remaining
+	details := map[string]string{}
+	if req.Amount != "" {
+		var msg string
+		if amount, msg = parseAmount(req.Amount, payment.Currency); msg != "" {
+			details["amount"] = msg
+		} else if !amount.IsPositive() || amount.Cmp(remaining) > 0 {
+			details["amount"] = "must be more than zero and at most " + remaining.Format() + " " + payment.Currency
+		}
+	}
+	if req.Reason != "" && !refundReasons[req.Reason] {
+		details["reason"] = "reason must be duplicate, fraudulent or requested_by_customer"
+	}
+	if len(details) > 0 {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(details))
+		return
+	}
+
+	issued, err := h.provider.Refund(&payments.RefundParams{
+		PaymentIntentID: payment.PaymentIntentID,
+		Amount:          amount,
+		Reason:          req.Reason,
+	})
+	if err != nil {
+		logger.Error("Failed to refund payment %s: %v", payment.ID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	refund := &models.Refund{
+		PaymentID:        payment.ID,
+		ProviderRefundID: issued.ID,
+		Amount:           amount,
+		Currency:         payment.Currency,
+		Reason:           req.Reason,
+		Status:           issued.Status,
+		IssuedBy:         adminID,
+	}
+	// The refund was made; the provider's charge.refunded event still brings the payment
+	// up to date if it cannot be recorded here
+	payment, err = h.paymentRepo.RecordIssuedRefund(refund)
+	if err != nil {
+		logger.Error("Refund %s of payment %s was issued but not recorded: %v", issued.ID, id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Refund issued: Payment=%s, Refund=%s, Amount=%s, Admin=%s", payment.ID, issued.ID, amount, adminID)
+	api.WriteSuccess(w, http.StatusCreated, RefundResponse{Refund: refund, Payment: payment})
+}
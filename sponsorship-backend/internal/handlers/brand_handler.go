@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/money"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type BrandHandler struct {
+	repo            *repositories.BrandRepository
+	sponsorshipRepo *repositories.SponsorshipRepository
+	settingsRepo    *repositories.SettingsRepository
+	fxRepo          *repositories.FXRepository
+}
+
+type UpdateBrandRequest struct {
+	Country *string `json:"country"` // "" clears it
+}
+
+func NewBrandHandler(repo *repositories.BrandRepository, sponsorshipRepo *repositories.SponsorshipRepository,
+	settingsRepo *repositories.SettingsRepository, fxRepo *repositories.FXRepository) *BrandHandler {
+	return &BrandHandler{repo: repo, sponsorshipRepo: sponsorshipRepo, settingsRepo: settingsRepo, fxRepo: fxRepo}
+}
+
+// NormalizeBrands renormalises every brand's name the way new deals are normalised, merging
+// brands that then share a name. Migration 005 created brands for existing deals with a
+// SQL approximation that keeps stacked legal suffixes and drops non-ASCII letters, so
+// later deals with the same brand did not always find them. It returns how many brands
+// were changed.
+func (h *BrandHandler) NormalizeBrands() (int, error) {
+	brands, err := h.repo.ListAllBrands()
+	if err != nil {
+		return 0, err
+	}
+
+	changed := 0
+	for _, brand := range brands {
+		normalized := normalizeBrandName(brand.Name)
+		if normalized == "" || normalized == brand.NormalizedName {
+			continue
+		}
+		if err := h.repo.RenormalizeBrand(brand, normalized); err != nil {
+			return changed, err
+		}
+		changed++
+	}
+
+	return changed, nil
+}
+
+// BackfillBrands links deals without a brand, such as those whose brand could not be saved
+// when they were created, to a brand, creating brands as needed and named after the newest
+// deal with them. It normalises names like new deals do, so later deals with the same
+// brand find these brands. It returns how many deals were linked.
+func (h *BrandHandler) BackfillBrands() (int, error) {
+	sponsorships, err := h.repo.ListUnbrandedSponsorships()
+	if err != nil {
+		return 0, err
+	}
+
+	linked := 0
+	for _, s := range sponsorships {
+		normalized := normalizeBrandName(s.BrandName)
+		if normalized == "" {
+			continue
+		}
+
+		brand, err := h.repo.FindOrCreateBrand(s.CreatorID, s.BrandName, normalized)
+		if err != nil {
+			return linked, err
+		}
+		if err := h.repo.SetSponsorshipBrand(s.ID, brand.ID); err != nil {
+			return linked, err
+		}
+		linked++
+	}
+
+	return linked, nil
+}
+
+// ListBrands lists the brands the creator has dealt with
+func (h *BrandHandler) ListBrands(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	logger.Debug("Fetching brands for creator %s", creatorID)
+
+	brands, err := h.repo.ListBrands(creatorID)
+	if err != nil {
+		logger.Error("Failed to list brands for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if brands == nil {
+		brands = []*models.Brand{}
+	}
+
+	api.WriteSuccess(w, http.StatusOK, brands)
+}
+
+// UpdateBrand updates the details the creator keeps about a brand
+func (h *BrandHandler) UpdateBrand(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req UpdateBrandRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode update brand request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	brand, err := h.repo.GetBrandByID(id, creatorID)
+	if err != nil {
+		logger.Warn("Brand not found: ID=%s, Creator=%s", id, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	if req.Country != nil {
+		brand.Country = strings.ToUpper(strings.TrimSpace(*req.Country))
+	}
+	if brand.Country != "" && len(brand.Country) != 2 {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"country": "country must be a two-letter ISO code",
+		}))
+		return
+	}
+
+	if err := h.repo.UpdateBrand(brand); err != nil {
+		logger.Error("Failed to update brand %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Brand updated: ID=%s, Country=%s, Creator=%s", id, brand.Country, creatorID)
+	api.WriteSuccess(w, http.StatusOK, brand)
+}
+
+// GetBrandSummary returns relationship history and lifetime value for a brand. Amounts are
+// in the creator's base currency, converted at each deal's contract date.
+func (h *BrandHandler) GetBrandSummary(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	logger.Debug("Fetching brand summary: ID=%s, Creator=%s", id, creatorID)
+
+	brand, err := h.repo.GetBrandByID(id, creatorID)
+	if err != nil {
+		logger.Warn("Brand not found: ID=%s, Creator=%s", id, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	summary, err := h.repo.GetBrandSummary(brand)
+	if err != nil {
+		logger.Error("Failed to summarise brand %s for creator %s: %v", id, creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if err := h.totalDealAmounts(summary, creatorID); err != nil {
+		logger.Error("Failed to total deal amounts of brand %s for creator %s: %v", id, creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, summary)
+}
+
+// totalDealAmounts fills in the lifetime and average deal amount of a brand summary
+func (h *BrandHandler) totalDealAmounts(summary *models.BrandSummary, creatorID string) error {
+	deals, err := h.sponsorshipRepo.ListDealValues(creatorID, summary.BrandID)
+	if err != nil {
+		return err
+	}
+
+	converter, err := newCreatorConverter(h.settingsRepo, h.fxRepo, creatorID)
+	if err != nil {
+		return err
+	}
+
+	summary.Currency = converter.base
+	summary.TotalDealAmount = money.Zero(converter.base)
+	converted := 0
+	for _, d := range deals {
+		amount, ok, err := converter.convert(d.Amount, d.ContractedAt)
+		if err != nil {
+			return err
+		}
+		if ok {
+			summary.TotalDealAmount = summary.TotalDealAmount.Add(amount)
+			converted++
+		}
+	}
+	summary.AverageDealAmount = summary.TotalDealAmount.Div(int64(converted))
+	summary.MissingRates = converter.missingRates()
+
+	return nil
+}
@@ -3,34 +3,83 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
-	"os"
-	"strconv"
-	"strings"
-
-	"github.com/stripe/stripe-go/v76"
-	"github.com/stripe/stripe-go/v76/checkout/session"
+	"time"
 
 	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
 	apierrors "sponsorship-backend/pkg/errors"
 	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/money"
+	"sponsorship-backend/pkg/payments"
 )
 
-type CheckoutHandler struct{}
+// couponHoldDuration is how long a coupon redemption is held for an unpaid checkout. It
+// matches the lifetime of a Stripe checkout session.
+const couponHoldDuration = 24 * time.Hour
+
+// productCatalog is the part of ProductRepository checkout uses
+type productCatalog interface {
+	GetProduct(id string) (*models.Product, error)
+	ListActiveProducts() ([]*models.Product, error)
+}
+
+// entitlementsFinder looks up what a user's current plan allows, as SubscriptionRepository does
+type entitlementsFinder interface {
+	GetEntitlements(userID string) (*models.Entitlements, error)
+}
+
+// couponRedemptions holds coupon redemptions for checkouts and settles them once the
+// checkout is paid or expires, as CouponRepository does
+type couponRedemptions interface {
+	GetCoupon(code string) (*models.Coupon, error)
+	HoldRedemption(redemption *models.CouponRedemption) error
+	RedeemRedemption(id, providerReference string, discount money.Money, redeemedAt time.Time) (bool, error)
+	ReleaseRedemption(id string) (bool, error)
+}
+
+type CheckoutHandler struct {
+	provider         payments.Provider
+	frontendURL      string
+	productRepo      productCatalog
+	subscriptionRepo entitlementsFinder
+	couponRepo       couponRedemptions
+}
 
 type CheckoutRequest struct {
-	ProductName string `json:"productName"`
-	Price       string `json:"price"`
+	ProductID  string `json:"productId"`
+	CouponCode string `json:"couponCode"`
 }
 
 type CheckoutResponse struct {
-	CheckoutURL string `json:"checkoutUrl"`
+	CheckoutURL string       `json:"checkoutUrl"`
+	Discount    *money.Money `json:"discount,omitempty"` // taken off the price by the coupon
 }
 
-func NewCheckoutHandler() *CheckoutHandler {
-	return &CheckoutHandler{}
+func NewCheckoutHandler(provider payments.Provider, productRepo productCatalog, subscriptionRepo entitlementsFinder,
+	couponRepo couponRedemptions, frontendURL string) *CheckoutHandler {
+	return &CheckoutHandler{provider: provider, frontendURL: frontendURL, productRepo: productRepo,
+		subscriptionRepo: subscriptionRepo, couponRepo: couponRepo}
 }
 
-// CreateCheckoutSession creates a Stripe checkout session and returns the URL
+// ListProducts returns the products on sale with their prices
+func (h *CheckoutHandler) ListProducts(w http.ResponseWriter, r *http.Request) {
+	products, err := h.productRepo.ListActiveProducts()
+	if err != nil {
+		logger.Error("Failed to list products: %v", err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+	if products == nil {
+		products = []*models.Product{}
+	}
+
+	api.WriteSuccess(w, http.StatusOK, products)
+}
+
+// CreateCheckoutSession creates a checkout session with the payment provider for a catalog
+// product and returns the URL. The price charged is the catalog's, less the discount of a
+// coupon if one is given. Plans are sold as subscriptions, and a user with a current
+// subscription cannot start a second one.
 func (h *CheckoutHandler) CreateCheckoutSession(w http.ResponseWriter, r *http.Request) {
 	var req CheckoutRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -46,45 +95,66 @@ func (h *CheckoutHandler) CreateCheckoutSession(w http.ResponseWriter, r *http.R
 		return
 	}
 
-	logger.Debug("Creating checkout session for user %s, product: %s", userID, req.ProductName)
-
-	// Initialize Stripe API key
-	stripeSecretKey := os.Getenv("STRIPE_SECRET_KEY")
-	if stripeSecretKey == "" {
-		logger.Error("STRIPE_SECRET_KEY not configured")
+	product, err := h.productRepo.GetProduct(req.ProductID)
+	if err == apierrors.ErrNotFound || (err == nil && !product.Active) {
+		logger.Warn("Checkout failed: unknown product %q", req.ProductID)
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"productId": "unknown product",
+		}))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to get product %s: %v", req.ProductID, err)
 		api.WriteError(w, apierrors.ErrInternalError)
 		return
 	}
-	stripe.Key = stripeSecretKey
-
-	// Parse price from string (e.g., "$29" -> 2900 cents)
-	priceAmount := parsePriceToStripeAmount(req.Price)
-
-	// Create checkout session parameters
-	params := &stripe.CheckoutSessionParams{
-		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
-		LineItems: []*stripe.CheckoutSessionLineItemParams{
-			{
-				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
-					Currency: stripe.String("usd"),
-					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
-						Name: stripe.String(req.ProductName),
-					},
-					UnitAmount: stripe.Int64(priceAmount),
-				},
-				Quantity: stripe.Int64(1),
-			},
-		},
-		Mode:              stripe.String(string(stripe.CheckoutSessionModePayment)),
-		SuccessURL:        stripe.String(os.Getenv("FRONTEND_URL") + "/dashboard?payment=success"),
-		CancelURL:         stripe.String(os.Getenv("FRONTEND_URL") + "/products?payment=cancelled"),
-		ClientReferenceID: stripe.String(userID),
-	}
-
-	// Create the session
-	sess, err := session.New(params)
+
+	if product.BillingInterval != "" {
+		current, err := h.subscriptionRepo.GetEntitlements(userID)
+		if err != nil && err != apierrors.ErrNotFound {
+			logger.Error("Failed to get entitlements for user %s: %v", userID, err)
+			api.WriteError(w, apierrors.ErrInternalError)
+			return
+		}
+		if current != nil {
+			logger.Warn("Checkout failed: user %s already subscribes to %s", userID, current.Plan)
+			api.WriteError(w, apierrors.ErrConflict.WithDetails("already subscribed to the "+current.Plan+" plan"))
+			return
+		}
+	}
+
+	params := &payments.CheckoutParams{
+		ProductName:       product.Name,
+		Amount:            product.Amount,
+		BillingInterval:   product.BillingInterval,
+		ClientReferenceID: userID,
+		Metadata:          map[string]string{"user_id": userID, "product_id": product.ID, "product_name": product.Name},
+		SuccessURL:        h.frontendURL + "/dashboard?payment=success",
+		CancelURL:         h.frontendURL + "/products?payment=cancelled",
+	}
+
+	// The redemption is held until the checkout is paid or expires, when the Stripe
+	// webhook redeems or releases it
+	var redemption *models.CouponRedemption
+	if req.CouponCode != "" {
+		var ok bool
+		if redemption, params.Discount, ok = h.holdCoupon(w, req.CouponCode, userID, product); !ok {
+			return
+		}
+		params.Metadata["coupon_code"] = redemption.CouponCode
+		params.Metadata["coupon_redemption_id"] = redemption.ID
+	}
+
+	logger.Debug("Creating checkout session for user %s, product: %s", userID, product.ID)
+
+	sess, err := h.provider.CreateCheckoutSession(params)
 	if err != nil {
-		logger.Error("Failed to create Stripe checkout session: %v", err)
+		logger.Error("Failed to create checkout session: %v", err)
+		if redemption != nil {
+			if _, err := h.couponRepo.ReleaseRedemption(redemption.ID); err != nil {
+				logger.Error("Failed to release coupon redemption %s: %v", redemption.ID, err)
+			}
+		}
 		api.WriteError(w, apierrors.ErrInternalError)
 		return
 	}
@@ -92,24 +162,58 @@ func (h *CheckoutHandler) CreateCheckoutSession(w http.ResponseWriter, r *http.R
 	response := CheckoutResponse{
 		CheckoutURL: sess.URL,
 	}
+	if params.Discount != nil {
+		response.Discount = &params.Discount.AmountOff
+	}
 
-	logger.Info("Checkout session created: ProductName=%s, User=%s, SessionID=%s",
-		req.ProductName, userID, sess.ID)
+	logger.Info("Checkout session created: Product=%s, User=%s, SessionID=%s",
+		product.ID, userID, sess.ID)
 	api.WriteSuccess(w, http.StatusOK, response)
 }
 
-// parsePriceToStripeAmount converts price string like "$29" to Stripe amount in cents (2900)
-func parsePriceToStripeAmount(price string) int64 {
-	// Remove $ and spaces
-	cleanPrice := strings.TrimSpace(strings.TrimPrefix(price, "$"))
+// holdCoupon checks that a coupon can be used for a product and holds one of its
+// redemptions for the user's checkout. It writes the error response and returns false if
+// the coupon cannot be used.
+func (h *CheckoutHandler) holdCoupon(w http.ResponseWriter, code, userID string,
+	product *models.Product) (*models.CouponRedemption, *payments.Discount, bool) {
+	code = normalizeCouponCode(code)
+	invalid := func(msg string) (*models.CouponRedemption, *payments.Discount, bool) {
+		logger.Warn("Checkout failed: coupon %q rejected for user %s: %s", code, userID, msg)
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{"couponCode": msg}))
+		return nil, nil, false
+	}
+
+	coupon, err := h.couponRepo.GetCoupon(code)
+	if err == apierrors.ErrNotFound {
+		return invalid("unknown coupon code")
+	}
+	if err != nil {
+		logger.Error("Failed to get coupon %s: %v", code, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return nil, nil, false
+	}
+
+	now := time.Now()
+	discount, msg := couponDiscount(coupon, product, now)
+	if msg != "" {
+		return invalid(msg)
+	}
 
-	// Parse as float
-	amount, err := strconv.ParseFloat(cleanPrice, 64)
+	redemption := &models.CouponRedemption{
+		CouponCode: coupon.Code,
+		UserID:     userID,
+		ProductID:  product.ID,
+		HeldUntil:  now.Add(couponHoldDuration),
+	}
+	err = h.couponRepo.HoldRedemption(redemption)
+	if err == apierrors.ErrConflict {
+		return invalid("coupon has been fully redeemed")
+	}
 	if err != nil {
-		logger.Warn("Failed to parse price: %s, defaulting to 0", price)
-		return 0
+		logger.Error("Failed to hold redemption of coupon %s: %v", code, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return nil, nil, false
 	}
 
-	// Convert to cents
-	return int64(amount * 100)
+	return redemption, &payments.Discount{Code: coupon.Code, AmountOff: discount, Duration: coupon.Duration}, true
 }
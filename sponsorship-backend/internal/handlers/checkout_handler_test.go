@@ -0,0 +1,392 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"sponsorship-backend/internal/models"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/money"
+	"sponsorship-backend/pkg/payments"
+)
+
+const (
+	testUserID        = "33333333-3333-3333-3333-333333333333"
+	testWebhookSecret = "whsec_test"
+)
+
+// fakeBillingStore stands in for the product, subscription, coupon, payment and payment
+// link repositories. Like them it applies each Stripe event ID at most once.
+type fakeBillingStore struct {
+	products      map[string]*models.Product
+	entitlements  map[string]*models.Entitlements // by user
+	coupons       map[string]*models.Coupon
+	redemptions   map[string]*models.CouponRedemption
+	payments      []*models.Payment
+	subscriptions []*models.Subscription
+	handled       map[string]bool // Stripe event IDs already applied
+	seq           int
+}
+
+func newFakeBillingStore() *fakeBillingStore {
+	return &fakeBillingStore{
+		products: map[string]*models.Product{
+			"base": {ID: "base", Name: "Base Package", Amount: money.New(2900, "USD"), Currency: "USD",
+				BillingInterval: "month", Active: true},
+			"report": {ID: "report", Name: "Audience Report", Amount: money.New(4900, "USD"), Currency: "USD",
+				Active: true},
+			"retired": {ID: "retired", Name: "Retired", Amount: money.New(100, "USD"), Currency: "USD"},
+		},
+		entitlements: map[string]*models.Entitlements{},
+		coupons:      map[string]*models.Coupon{},
+		redemptions:  map[string]*models.CouponRedemption{},
+		handled:      map[string]bool{},
+	}
+}
+
+func (f *fakeBillingStore) claim(eventID string) bool {
+	if f.handled[eventID] {
+		return false
+	}
+	f.handled[eventID] = true
+	return true
+}
+
+func (f *fakeBillingStore) GetProduct(id string) (*models.Product, error) {
+	if p, ok := f.products[id]; ok {
+		return p, nil
+	}
+	return nil, apierrors.ErrNotFound
+}
+
+func (f *fakeBillingStore) ListActiveProducts() ([]*models.Product, error) {
+	var products []*models.Product
+	for _, p := range f.products {
+		if p.Active {
+			products = append(products, p)
+		}
+	}
+	return products, nil
+}
+
+func (f *fakeBillingStore) GetEntitlements(userID string) (*models.Entitlements, error) {
+	if e, ok := f.entitlements[userID]; ok {
+		return e, nil
+	}
+	return nil, apierrors.ErrNotFound
+}
+
+func (f *fakeBillingStore) GetCoupon(code string) (*models.Coupon, error) {
+	if c, ok := f.coupons[code]; ok {
+		return c, nil
+	}
+	return nil, apierrors.ErrNotFound
+}
+
+func (f *fakeBillingStore) HoldRedemption(redemption *models.CouponRedemption) error {
+	coupon, ok := f.coupons[redemption.CouponCode]
+	if !ok {
+		return apierrors.ErrNotFound
+	}
+	if coupon.MaxRedemptions != nil {
+		used := 0
+		for _, r := range f.redemptions {
+			if r.CouponCode == coupon.Code && (r.Status == "redeemed" || r.Status == "held") {
+				used++
+			}
+		}
+		if used >= *coupon.MaxRedemptions {
+			return apierrors.ErrConflict
+		}
+	}
+	f.seq++
+	redemption.ID = fmt.Sprintf("00000000-0000-0000-0000-%012d", f.seq)
+	redemption.Status = "held"
+	held := *redemption
+	f.redemptions[redemption.ID] = &held
+	return nil
+}
+
+func (f *fakeBillingStore) RedeemRedemption(id, providerReference string, discount money.Money,
+	redeemedAt time.Time) (bool, error) {
+	r, ok := f.redemptions[id]
+	if !ok || r.Status == "redeemed" {
+		return false, nil
+	}
+	r.Status = "redeemed"
+	r.ProviderReference = providerReference
+	r.DiscountAmount = &discount
+	r.RedeemedAt = &redeemedAt
+	f.coupons[r.CouponCode].TimesRedeemed++
+	return true, nil
+}
+
+func (f *fakeBillingStore) ReleaseRedemption(id string) (bool, error) {
+	r, ok := f.redemptions[id]
+	if !ok || r.Status != "held" {
+		return false, nil
+	}
+	r.Status = "released"
+	return true, nil
+}
+
+func (f *fakeBillingStore) RecordPayment(eventID, eventType string, p *models.Payment) (bool, error) {
+	if !f.claim(eventID) {
+		return false, nil
+	}
+	for _, existing := range f.payments {
+		if existing.ProviderReference == p.ProviderReference {
+			if existing.Status == "pending" {
+				existing.Status = p.Status
+			}
+			return true, nil
+		}
+	}
+	stored := *p
+	f.payments = append(f.payments, &stored)
+	return true, nil
+}
+
+func (f *fakeBillingStore) RecordRefund(eventID, eventType, paymentIntentID string, refunded money.Money) (bool, error) {
+	if !f.claim(eventID) {
+		return false, nil
+	}
+	for _, p := range f.payments {
+		if p.PaymentIntentID == paymentIntentID && p.Currency == refunded.Currency() {
+			p.AmountRefunded = refunded
+			switch {
+			case refunded.Cmp(p.Amount) >= 0:
+				p.Status = "refunded"
+			case refunded.IsPositive():
+				p.Status = "partially_refunded"
+			}
+			return true, nil
+		}
+	}
+	return true, apierrors.ErrNotFound
+}
+
+func (f *fakeBillingStore) LinkSubscription(eventID, eventType string, sub *models.Subscription) (bool, error) {
+	return f.SyncSubscription(eventID, eventType, sub)
+}
+
+func (f *fakeBillingStore) SyncSubscription(eventID, eventType string, sub *models.Subscription) (bool, error) {
+	if !f.claim(eventID) {
+		return false, nil
+	}
+	for _, existing := range f.subscriptions {
+		if existing.ProviderSubscriptionID == sub.ProviderSubscriptionID {
+			existing.Status = sub.Status
+			return true, nil
+		}
+	}
+	stored := *sub
+	f.subscriptions = append(f.subscriptions, &stored)
+	return true, nil
+}
+
+func (f *fakeBillingStore) MarkLinkPaid(eventID, eventType, sessionID string, paidAt time.Time) (bool, error) {
+	if !f.claim(eventID) {
+		return false, nil
+	}
+	return false, apierrors.ErrNotFound
+}
+
+func (f *fakeBillingStore) CloseLink(eventID, eventType, sessionID, status string) (bool, error) {
+	return false, nil
+}
+
+// failingProvider is a payment provider that cannot create checkout sessions
+type failingProvider struct {
+	payments.Provider
+}
+
+func (failingProvider) CreateCheckoutSession(params *payments.CheckoutParams) (*payments.CheckoutSession, error) {
+	return nil, fmt.Errorf("provider unavailable")
+}
+
+func checkoutRequest(t *testing.T, body interface{}) *http.Request {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/api/checkout", bytes.NewReader(payload))
+	r.Header.Set("X-User-ID", testUserID)
+	return r
+}
+
+// checkoutSessionID returns the ID of the fake session a checkout response points to
+func checkoutSessionID(t *testing.T, w *httptest.ResponseRecorder) string {
+	var resp struct {
+		Data struct {
+			CheckoutURL string `json:"checkoutUrl"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	i := strings.LastIndex(resp.Data.CheckoutURL, "/")
+	if i < 0 {
+		t.Fatalf("unexpected checkout URL %q", resp.Data.CheckoutURL)
+	}
+	return resp.Data.CheckoutURL[i+1:]
+}
+
+func TestCreateCheckoutSessionRejectsUnknownProduct(t *testing.T) {
+	for _, productID := range []string{"missing", "retired", ""} {
+		t.Run(productID, func(t *testing.T) {
+			store := newFakeBillingStore()
+			provider := payments.NewFakeProvider(testWebhookSecret)
+			h := NewCheckoutHandler(provider, store, store, store, "https://app.example.com")
+
+			w := httptest.NewRecorder()
+			h.CreateCheckoutSession(w, checkoutRequest(t, map[string]string{"productId": productID}))
+
+			if w.Code != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+			}
+			if code := errorCode(t, w); code != apierrors.ErrValidationError.Code {
+				t.Errorf("error code = %s, want %s", code, apierrors.ErrValidationError.Code)
+			}
+			if _, ok := provider.Session("cs_fake_1"); ok {
+				t.Error("a checkout session was created for an unknown product")
+			}
+		})
+	}
+}
+
+func TestCreateCheckoutSessionChargesCatalogPrice(t *testing.T) {
+	store := newFakeBillingStore()
+	provider := payments.NewFakeProvider(testWebhookSecret)
+	h := NewCheckoutHandler(provider, store, store, store, "https://app.example.com")
+
+	// Whatever price the client sends is ignored
+	w := httptest.NewRecorder()
+	h.CreateCheckoutSession(w, checkoutRequest(t, map[string]interface{}{
+		"productId": "report", "amount": 1, "currency": "JPY",
+	}))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	sess, ok := provider.Session(checkoutSessionID(t, w))
+	if !ok {
+		t.Fatal("no checkout session was created")
+	}
+	if sess.Params.Amount != money.New(4900, "USD") {
+		t.Errorf("session charges %s %s, want 49.00 USD", sess.Params.Amount, sess.Params.Amount.Currency())
+	}
+	if sess.Params.BillingInterval != "" || sess.Params.Discount != nil {
+		t.Errorf("one-off product sold with interval %q and discount %v", sess.Params.BillingInterval,
+			sess.Params.Discount)
+	}
+	if sess.Params.ClientReferenceID != testUserID || sess.Params.Metadata["product_id"] != "report" {
+		t.Errorf("session references user %q and product %q", sess.Params.ClientReferenceID,
+			sess.Params.Metadata["product_id"])
+	}
+}
+
+func TestCreateCheckoutSessionRejectsSecondSubscription(t *testing.T) {
+	store := newFakeBillingStore()
+	store.entitlements[testUserID] = &models.Entitlements{Plan: "base", Status: "active"}
+	provider := payments.NewFakeProvider(testWebhookSecret)
+	h := NewCheckoutHandler(provider, store, store, store, "https://app.example.com")
+
+	w := httptest.NewRecorder()
+	h.CreateCheckoutSession(w, checkoutRequest(t, map[string]string{"productId": "base"}))
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusConflict)
+	}
+}
+
+func TestCreateCheckoutSessionHoldsCoupon(t *testing.T) {
+	store := newFakeBillingStore()
+	percentOff := 20.0
+	store.coupons["LAUNCH20"] = &models.Coupon{Code: "LAUNCH20", PercentOff: &percentOff, Duration: "once",
+		Active: true}
+	provider := payments.NewFakeProvider(testWebhookSecret)
+	h := NewCheckoutHandler(provider, store, store, store, "https://app.example.com")
+
+	w := httptest.NewRecorder()
+	h.CreateCheckoutSession(w, checkoutRequest(t, map[string]string{"productId": "report", "couponCode": "launch20"}))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusOK, w.Body.String())
+	}
+	sess, ok := provider.Session(checkoutSessionID(t, w))
+	if !ok {
+		t.Fatal("no checkout session was created")
+	}
+	if sess.Params.Discount == nil || sess.Params.Discount.AmountOff != money.New(980, "USD") {
+		t.Fatalf("session discount = %+v, want 9.80 USD", sess.Params.Discount)
+	}
+	redemption := store.redemptions[sess.Params.Metadata["coupon_redemption_id"]]
+	if redemption == nil || redemption.Status != "held" || redemption.UserID != testUserID {
+		t.Fatalf("redemption = %+v, want one held for the user", redemption)
+	}
+}
+
+func TestCreateCheckoutSessionReleasesCouponWhenProviderFails(t *testing.T) {
+	store := newFakeBillingStore()
+	percentOff := 20.0
+	maxRedemptions := 1
+	store.coupons["LAUNCH20"] = &models.Coupon{Code: "LAUNCH20", PercentOff: &percentOff, Duration: "once",
+		MaxRedemptions: &maxRedemptions, Active: true}
+	h := NewCheckoutHandler(failingProvider{}, store, store, store, "https://app.example.com")
+
+	w := httptest.NewRecorder()
+	h.CreateCheckoutSession(w, checkoutRequest(t, map[string]string{"productId": "report", "couponCode": "LAUNCH20"}))
+
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusInternalServerError)
+	}
+	if len(store.redemptions) != 1 {
+		t.Fatalf("%d redemptions, want 1", len(store.redemptions))
+	}
+	for _, r := range store.redemptions {
+		if r.Status != "released" {
+			t.Errorf("redemption status = %s, want released", r.Status)
+		}
+	}
+
+	// The released redemption is available to the next checkout
+	h = NewCheckoutHandler(payments.NewFakeProvider(testWebhookSecret), store, store, store, "https://app.example.com")
+	w = httptest.NewRecorder()
+	h.CreateCheckoutSession(w, checkoutRequest(t, map[string]string{"productId": "report", "couponCode": "LAUNCH20"}))
+	if w.Code != http.StatusOK {
+		t.Errorf("status = %d, want %d for a coupon whose hold was released", w.Code, http.StatusOK)
+	}
+}
+
+func TestCreateCheckoutSessionRejectsFullyRedeemedCoupon(t *testing.T) {
+	store := newFakeBillingStore()
+	amountOff := money.New(500, "USD")
+	maxRedemptions := 1
+	store.coupons["ONCE"] = &models.Coupon{Code: "ONCE", AmountOff: &amountOff, Currency: "USD", Duration: "once",
+		MaxRedemptions: &maxRedemptions, Active: true}
+	provider := payments.NewFakeProvider(testWebhookSecret)
+	h := NewCheckoutHandler(provider, store, store, store, "https://app.example.com")
+
+	w := httptest.NewRecorder()
+	h.CreateCheckoutSession(w, checkoutRequest(t, map[string]string{"productId": "report", "couponCode": "ONCE"}))
+	if w.Code != http.StatusOK {
+		t.Fatalf("first checkout status = %d, want %d", w.Code, http.StatusOK)
+	}
+
+	w = httptest.NewRecorder()
+	h.CreateCheckoutSession(w, checkoutRequest(t, map[string]string{"productId": "report", "couponCode": "ONCE"}))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("second checkout status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if code := errorCode(t, w); code != apierrors.ErrValidationError.Code {
+		t.Errorf("error code = %s, want %s", code, apierrors.ErrValidationError.Code)
+	}
+}
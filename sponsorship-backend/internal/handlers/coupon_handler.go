@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/money"
+)
+
+// couponCodePattern is what a coupon code may look like once upper-cased
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9_-]{3,50}$`)
+
+// CouponHandler lets admins define the coupons users can enter at checkout
+type CouponHandler struct {
+	repo        *repositories.CouponRepository
+	productRepo *repositories.ProductRepository
+}
+
+// CouponRequest defines a coupon. Exactly one of PercentOff and AmountOff is given;
+// AmountOff is in Currency.
+type CouponRequest struct {
+	Code           string      `json:"code"`
+	Description    string      `json:"description"`
+	PercentOff     json.Number `json:"percentOff"`
+	AmountOff      json.Number `json:"amountOff"`
+	Currency       string      `json:"currency"`
+	Duration       string      `json:"duration"` // once (default) or forever
+	ExpiresAt      *time.Time  `json:"expiresAt"`
+	MaxRedemptions *int        `json:"maxRedemptions"`
+	ProductIDs     []string    `json:"productIds"` // empty for every product
+}
+
+func NewCouponHandler(repo *repositories.CouponRepository, productRepo *repositories.ProductRepository) *CouponHandler {
+	return &CouponHandler{repo: repo, productRepo: productRepo}
+}
+
+// normalizeCouponCode returns a coupon code as stored, so codes match whatever their case
+func normalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// ListCoupons returns every coupon, including inactive and expired ones. Admin only.
+func (h *CouponHandler) ListCoupons(w http.ResponseWriter, r *http.Request) {
+	coupons, err := h.repo.ListCoupons()
+	if err != nil {
+		logger.Error("Failed to list coupons: %v", err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+	if coupons == nil {
+		coupons = []*models.Coupon{}
+	}
+
+	api.WriteSuccess(w, http.StatusOK, coupons)
+}
+
+// CreateCoupon defines a new coupon. Admin only.
+func (h *CouponHandler) CreateCoupon(w http.ResponseWriter, r *http.Request) {
+	var req CouponRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode create coupon request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	coupon := &models.Coupon{
+		Code:           normalizeCouponCode(req.Code),
+		Description:    strings.TrimSpace(req.Description),
+		Duration:       req.Duration,
+		ExpiresAt:      req.ExpiresAt,
+		MaxRedemptions: req.MaxRedemptions,
+		ProductIDs:     req.ProductIDs,
+	}
+	if coupon.Duration == "" {
+		coupon.Duration = "once"
+	}
+	if coupon.ProductIDs == nil {
+		coupon.ProductIDs = []string{}
+	}
+
+	details := map[string]string{}
+	if !couponCodePattern.MatchString(coupon.Code) {
+		details["code"] = "code must be 3 to 50 letters, digits, dashes or underscores"
+	}
+	switch {
+	case (req.PercentOff == "") == (req.AmountOff == ""):
+		details["percentOff"] = "give either percentOff or amountOff"
+	case req.PercentOff != "":
+		pct, err := strconv.ParseFloat(req.PercentOff.String(), 64)
+		if err != nil || pct <= 0 || pct >= 100 {
+			details["percentOff"] = "must be more than 0 and less than 100"
+		} else {
+			coupon.PercentOff = &pct
+		}
+	default:
+		coupon.Currency = strings.ToUpper(req.Currency)
+		amountOff, msg := parseAmount(req.AmountOff, coupon.Currency)
+		if msg == "" && !amountOff.IsPositive() {
+			msg = "must be more than zero"
+		}
+		if msg != "" {
+			details["amountOff"] = msg
+		} else {
+			coupon.AmountOff = &amountOff
+		}
+	}
+	if coupon.Duration != "once" && coupon.Duration != "forever" {
+		details["duration"] = "duration must be once or forever"
+	}
+	if coupon.ExpiresAt != nil && !coupon.ExpiresAt.After(time.Now()) {
+		details["expiresAt"] = "must be in the future"
+	}
+	if coupon.MaxRedemptions != nil && *coupon.MaxRedemptions <= 0 {
+		details["maxRedemptions"] = "must be at least 1"
+	}
+	for _, productID := range coupon.ProductIDs {
+		if _, err := h.productRepo.GetProduct(productID); err == apierrors.ErrNotFound {
+			details["productIds"] = "unknown product " + strconv.Quote(productID)
+			break
+		} else if err != nil {
+			logger.Error("Failed to get product %s: %v", productID, err)
+			api.WriteError(w, apierrors.ErrInternalError)
+			return
+		}
+	}
+	if len(details) > 0 {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(details))
+		return
+	}
+
+	if err := h.repo.CreateCoupon(coupon); err != nil {
+		if appErr, ok := err.(*apierrors.AppError); ok {
+			api.WriteError(w, appErr)
+			return
+		}
+		logger.Error("Failed to create coupon %s: %v", coupon.Code, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Coupon created: Code=%s, Admin=%s", coupon.Code, r.Header.Get("X-User-ID"))
+	api.WriteSuccess(w, http.StatusCreated, coupon)
+}
+
+// DeactivateCoupon stops a coupon being accepted at checkout. It is kept, with its
+// redemptions, for reporting. Admin only.
+func (h *CouponHandler) DeactivateCoupon(w http.ResponseWriter, r *http.Request) {
+	code := normalizeCouponCode(chi.URLParam(r, "code"))
+
+	err := h.repo.DeactivateCoupon(code)
+	if err == apierrors.ErrNotFound {
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to deactivate coupon %s: %v", code, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Coupon deactivated: Code=%s, Admin=%s", code, r.Header.Get("X-User-ID"))
+	api.WriteSuccess(w, http.StatusOK, map[string]string{"message": "Coupon deactivated"})
+}
+
+// couponDiscount returns what a coupon takes off a product's price. On failure the second
+// result says why the coupon cannot be used, for the couponCode validation error.
+func couponDiscount(coupon *models.Coupon, product *models.Product, now time.Time) (money.Money, string) {
+	if !coupon.Active || (coupon.ExpiresAt != nil && !coupon.ExpiresAt.After(now)) {
+		return money.Money{}, "coupon has expired"
+	}
+	if len(coupon.ProductIDs) > 0 && !contains(coupon.ProductIDs, product.ID) {
+		return money.Money{}, "coupon does not apply to " + product.Name
+	}
+
+	var discount money.Money
+	switch {
+	case coupon.PercentOff != nil:
+		discount = product.Amount.Percent(*coupon.PercentOff)
+	case coupon.AmountOff != nil && coupon.Currency == product.Currency:
+		discount = *coupon.AmountOff
+	default:
+		return money.Money{}, "coupon is only valid for prices in " + coupon.Currency
+	}
+
+	if !discount.IsPositive() || discount.Cmp(product.Amount) >= 0 {
+		return money.Money{}, "coupon cannot be used for " + product.Name
+	}
+	return discount, ""
+}
@@ -0,0 +1,343 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// defaultDeliverableType is used for deliverables given only as a title
+const defaultDeliverableType = "integration"
+
+type DeliverableHandler struct {
+	repo            *repositories.DeliverableRepository
+	sponsorshipRepo *repositories.SponsorshipRepository
+}
+
+type DeliverableRequest struct {
+	Type         string     `json:"type"`
+	Platform     string     `json:"platform"`
+	Title        string     `json:"title"`
+	DueDate      *time.Time `json:"dueDate"`
+	PublishDate  *time.Time `json:"publishDate"`
+	PublishedURL string     `json:"publishedUrl"`
+	Status       string     `json:"status"`
+}
+
+type PublishDeliverableRequest struct {
+	PublishedURL string     `json:"publishedUrl"`
+	PublishDate  *time.Time `json:"publishDate"`
+}
+
+func NewDeliverableHandler(repo *repositories.DeliverableRepository, sponsorshipRepo *repositories.SponsorshipRepository) *DeliverableHandler {
+	return &DeliverableHandler{repo: repo, sponsorshipRepo: sponsorshipRepo}
+}
+
+// ListDeliverables lists the deliverables of a sponsorship
+func (h *DeliverableHandler) ListDeliverables(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	deliverables, err := h.repo.ListDeliverables(id, creatorID)
+	if err != nil {
+		logger.Error("Failed to list deliverables for sponsorship %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if deliverables == nil {
+		deliverables = []*models.Deliverable{}
+	}
+
+	api.WriteSuccess(w, http.StatusOK, deliverables)
+}
+
+// CreateDeliverable adds a deliverable to a sponsorship
+func (h *DeliverableHandler) CreateDeliverable(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req DeliverableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode create deliverable request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	if _, err := h.sponsorshipRepo.GetSponsorshipByID(id, creatorID); err != nil {
+		logger.Warn("Sponsorship not found for deliverable: ID=%s, Creator=%s", id, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	deliverable := &models.Deliverable{
+		SponsorshipID: id,
+		CreatorID:     creatorID,
+		Type:          req.Type,
+		Platform:      strings.ToLower(strings.TrimSpace(req.Platform)),
+		Title:         strings.TrimSpace(req.Title),
+		DueDate:       req.DueDate,
+		PublishDate:   req.PublishDate,
+		PublishedURL:  req.PublishedURL,
+		Status:        req.Status,
+	}
+	if deliverable.Status == "" {
+		deliverable.Status = "pending"
+	}
+
+	if details := validateDeliverable(deliverable); len(details) > 0 {
+		logger.Warn("Create deliverable validation failed for sponsorship %s: %v", id, details)
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(details))
+		return
+	}
+
+	if err := h.repo.CreateDeliverable(deliverable); err != nil {
+		logger.Error("Failed to create deliverable for sponsorship %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Deliverable created: ID=%s, Sponsorship=%s, Type=%s", deliverable.ID, id, deliverable.Type)
+	api.WriteSuccess(w, http.StatusCreated, deliverable)
+}
+
+// UpdateDeliverable updates the non-empty fields of a deliverable
+func (h *DeliverableHandler) UpdateDeliverable(w http.ResponseWriter, r *http.Request) {
+	var req DeliverableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode update deliverable request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	deliverable, ok := h.loadDeliverable(w, r)
+	if !ok {
+		return
+	}
+
+	if req.Type != "" {
+		deliverable.Type = req.Type
+	}
+	if req.Platform != "" {
+		deliverable.Platform = strings.ToLower(strings.TrimSpace(req.Platform))
+	}
+	if req.Title != "" {
+		deliverable.Title = strings.TrimSpace(req.Title)
+	}
+	if req.DueDate != nil {
+		deliverable.DueDate = req.DueDate
+	}
+	if req.PublishDate != nil {
+		deliverable.PublishDate = req.PublishDate
+	}
+	if req.PublishedURL != "" {
+		deliverable.PublishedURL = req.PublishedURL
+	}
+	if req.Status != "" {
+		deliverable.Status = req.Status
+	}
+
+	h.save(w, r, deliverable)
+}
+
+// PublishDeliverable records where and when a deliverable went live
+func (h *DeliverableHandler) PublishDeliverable(w http.ResponseWriter, r *http.Request) {
+	var req PublishDeliverableRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode publish deliverable request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	if strings.TrimSpace(req.PublishedURL) == "" {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"publishedUrl": "publishedUrl is required",
+		}))
+		return
+	}
+
+	deliverable, ok := h.loadDeliverable(w, r)
+	if !ok {
+		return
+	}
+
+	publishDate := time.Now().Truncate(24 * time.Hour)
+	if req.PublishDate != nil {
+		publishDate = *req.PublishDate
+	}
+
+	deliverable.PublishedURL = strings.TrimSpace(req.PublishedURL)
+	deliverable.PublishDate = &publishDate
+	if deliverable.Status != "completed" {
+		deliverable.Status = "published"
+	}
+
+	h.save(w, r, deliverable)
+}
+
+// CompleteDeliverable marks a deliverable as done
+func (h *DeliverableHandler) CompleteDeliverable(w http.ResponseWriter, r *http.Request) {
+	deliverable, ok := h.loadDeliverable(w, r)
+	if !ok {
+		return
+	}
+
+	deliverable.Status = "completed"
+	if deliverable.PublishDate == nil {
+		today := time.Now().Truncate(24 * time.Hour)
+		deliverable.PublishDate = &today
+	}
+
+	h.save(w, r, deliverable)
+}
+
+// DeleteDeliverable removes a deliverable from a sponsorship
+func (h *DeliverableHandler) DeleteDeliverable(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	deliverableID := chi.URLParam(r, "deliverableId")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	if err := h.repo.DeleteDeliverable(deliverableID, id, creatorID); err != nil {
+		logger.Warn("Failed to delete deliverable: ID=%s, Sponsorship=%s, Error: %v", deliverableID, id, err)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	// Removing the last outstanding deliverable can finish the deal
+	h.rollUp(id, creatorID, r.Header.Get("X-User-ID"))
+
+	logger.Info("Deliverable deleted: ID=%s, Sponsorship=%s", deliverableID, id)
+	api.WriteSuccess(w, http.StatusOK, map[string]bool{"deleted": true})
+}
+
+func (h *DeliverableHandler) loadDeliverable(w http.ResponseWriter, r *http.Request) (*models.Deliverable, bool) {
+	id := chi.URLParam(r, "id")
+	deliverableID := chi.URLParam(r, "deliverableId")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	deliverable, err := h.repo.GetDeliverable(deliverableID, id, creatorID)
+	if err != nil {
+		logger.Warn("Deliverable not found: ID=%s, Sponsorship=%s, Creator=%s", deliverableID, id, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return nil, false
+	}
+
+	return deliverable, true
+}
+
+func (h *DeliverableHandler) save(w http.ResponseWriter, r *http.Request, deliverable *models.Deliverable) {
+	if details := validateDeliverable(deliverable); len(details) > 0 {
+		logger.Warn("Deliverable validation failed: ID=%s, %v", deliverable.ID, details)
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(details))
+		return
+	}
+
+	if err := h.repo.UpdateDeliverable(deliverable); err != nil {
+		logger.Error("Failed to update deliverable %s: %v", deliverable.ID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	h.rollUp(deliverable.SponsorshipID, deliverable.CreatorID, r.Header.Get("X-User-ID"))
+
+	logger.Info("Deliverable updated: ID=%s, Sponsorship=%s, Status=%s",
+		deliverable.ID, deliverable.SponsorshipID, deliverable.Status)
+	api.WriteSuccess(w, http.StatusOK, deliverable)
+}
+
+// rollUp advances a signed deal to published once every deliverable is published, and to
+// completed once every deliverable is completed. It never moves a deal backwards.
+func (h *DeliverableHandler) rollUp(sponsorshipID, creatorID, changedBy string) {
+	deliverables, err := h.repo.ListDeliverables(sponsorshipID, creatorID)
+	if err != nil {
+		logger.Error("Failed to load deliverables for rollup of sponsorship %s: %v", sponsorshipID, err)
+		return
+	}
+
+	target := deliverableRollupStatus(deliverables)
+	if target == "" {
+		return
+	}
+
+	sponsorship, err := h.sponsorshipRepo.GetSponsorshipByID(sponsorshipID, creatorID)
+	if err != nil {
+		logger.Error("Failed to load sponsorship %s for rollup: %v", sponsorshipID, err)
+		return
+	}
+
+	// Deals that were never signed, or already further along, are left alone
+	if !contains(models.WonStatuses, sponsorship.Status) || statusIndex(sponsorship.Status) >= statusIndex(target) {
+		return
+	}
+
+	oldStatus := sponsorship.Status
+	sponsorship.Status = target
+	if err := h.sponsorshipRepo.UpdateSponsorship(sponsorship, oldStatus, changedBy, "All deliverables "+target); err != nil {
+		logger.Error("Failed to roll sponsorship %s up to %s: %v", sponsorshipID, target, err)
+		return
+	}
+
+	logger.Info("Sponsorship %s rolled up from %s to %s", sponsorshipID, oldStatus, target)
+}
+
+// deliverableRollupStatus returns the deal status implied by its deliverables, or "" if none
+func deliverableRollupStatus(deliverables []*models.Deliverable) string {
+	if len(deliverables) == 0 {
+		return ""
+	}
+
+	allCompleted, allPublished := true, true
+	for _, d := range deliverables {
+		if d.Status != "completed" {
+			allCompleted = false
+		}
+		if d.Status != "completed" && d.Status != "published" {
+			allPublished = false
+		}
+	}
+
+	switch {
+	case allCompleted:
+		return "completed"
+	case allPublished:
+		return "published"
+	}
+	return ""
+}
+
+// statusIndex is a deal status's position in the pipeline
+func statusIndex(status string) int {
+	for i, s := range models.ValidStatuses {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
+
+func validateDeliverable(d *models.Deliverable) map[string]string {
+	details := map[string]string{}
+	if !contains(models.ValidDeliverableTypes, d.Type) {
+		details["type"] = "type must be one of " + strings.Join(models.ValidDeliverableTypes, ", ")
+	}
+	if d.Title == "" {
+		details["title"] = "title is required"
+	}
+	if !contains(models.ValidDeliverableStatuses, d.Status) {
+		details["status"] = "status must be one of " + strings.Join(models.ValidDeliverableStatuses, ", ")
+	}
+	if d.Status == "published" && d.PublishedURL == "" {
+		details["publishedUrl"] = "publishedUrl is required for published deliverables"
+	}
+	return details
+}
@@ -0,0 +1,36 @@
+package handlers
+
+import (
+	"testing"
+
+	"sponsorship-backend/internal/models"
+)
+
+func TestDeliverableRollupStatus(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []string
+		want     string
+	}{
+		{"no deliverables", nil, ""},
+		{"one pending", []string{"pending"}, ""},
+		{"one approved", []string{"approved"}, ""},
+		{"one published", []string{"published"}, "published"},
+		{"one completed", []string{"completed"}, "completed"},
+		{"all published", []string{"published", "published"}, "published"},
+		{"published and completed", []string{"published", "completed"}, "published"},
+		{"all completed", []string{"completed", "completed", "completed"}, "completed"},
+		{"one still in review", []string{"published", "awaiting-review"}, ""},
+		{"completed and in progress", []string{"completed", "in-progress"}, ""},
+	}
+
+	for _, tt := range tests {
+		var deliverables []*models.Deliverable
+		for _, status := range tt.statuses {
+			deliverables = append(deliverables, &models.Deliverable{Status: status})
+		}
+		if got := deliverableRollupStatus(deliverables); got != tt.want {
+			t.Errorf("%s: deliverableRollupStatus(%v) = %q, want %q", tt.name, tt.statuses, got, tt.want)
+		}
+	}
+}
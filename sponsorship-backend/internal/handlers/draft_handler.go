@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// maxReviewBodyBytes caps the size of an unauthenticated review submission
+const maxReviewBodyBytes = 64 << 10
+
+type DraftHandler struct {
+	repo            *repositories.DraftRepository
+	deliverableRepo *repositories.DeliverableRepository
+	sponsorshipRepo *repositories.SponsorshipRepository
+	attachmentRepo  *repositories.AttachmentRepository
+	store           storage.Storage
+}
+
+// SubmitDraftRequest points at the draft either as a link or as an uploaded attachment
+type SubmitDraftRequest struct {
+	LinkURL      string `json:"linkUrl"`
+	AttachmentID string `json:"attachmentId"`
+	FileName     string `json:"fileName"`
+	Notes        string `json:"notes"`
+}
+
+type ReviewDraftRequest struct {
+	ReviewerName string `json:"reviewerName"`
+	Comments     string `json:"comments"`
+}
+
+// DraftReview is what the brand sees when opening a review link
+type DraftReview struct {
+	Draft            *models.DeliverableDraft `json:"draft"`
+	BrandName        string                   `json:"brandName"`
+	DeliverableTitle string                   `json:"deliverableTitle"`
+	DeliverableType  string                   `json:"deliverableType"`
+	Platform         string                   `json:"platform"`
+	DueDate          *time.Time               `json:"dueDate"`
+	RevisionLimit    *int                     `json:"revisionLimit"`
+	RevisionsUsed    int                      `json:"revisionsUsed"`
+}
+
+func NewDraftHandler(repo *repositories.DraftRepository, deliverableRepo *repositories.DeliverableRepository,
+	sponsorshipRepo *repositories.SponsorshipRepository, attachmentRepo *repositories.AttachmentRepository,
+	store storage.Storage) *DraftHandler {
+	return &DraftHandler{
+		repo:            repo,
+		deliverableRepo: deliverableRepo,
+		sponsorshipRepo: sponsorshipRepo,
+		attachmentRepo:  attachmentRepo,
+		store:           store,
+	}
+}
+
+// ListDrafts lists every version submitted for a deliverable
+func (h *DraftHandler) ListDrafts(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	deliverableID := chi.URLParam(r, "deliverableId")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	drafts, err := h.repo.ListDrafts(deliverableID, id, creatorID)
+	if err != nil {
+		logger.Error("Failed to list drafts for deliverable %s: %v", deliverableID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if drafts == nil {
+		drafts = []*models.DeliverableDraft{}
+	}
+
+	api.WriteSuccess(w, http.StatusOK, drafts)
+}
+
+// SubmitDraft submits a new version of a deliverable for brand review
+func (h *DraftHandler) SubmitDraft(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	deliverableID := chi.URLParam(r, "deliverableId")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req SubmitDraftRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode submit draft request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	req.LinkURL = strings.TrimSpace(req.LinkURL)
+	if req.LinkURL == "" && req.AttachmentID == "" {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"linkUrl": "linkUrl or attachmentId is required",
+		}))
+		return
+	}
+	if u, err := url.Parse(req.LinkURL); req.LinkURL != "" && (err != nil || (u.Scheme != "http" && u.Scheme != "https")) {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"linkUrl": "linkUrl must be an http(s) link to the draft",
+		}))
+		return
+	}
+
+	deliverable, err := h.deliverableRepo.GetDeliverable(deliverableID, id, creatorID)
+	if err != nil {
+		logger.Warn("Deliverable not found for draft: ID=%s, Sponsorship=%s, Creator=%s", deliverableID, id, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+	if deliverable.Status == "published" || deliverable.Status == "completed" {
+		api.WriteError(w, apierrors.ErrConflict.WithDetails("deliverable is already "+deliverable.Status))
+		return
+	}
+
+	draft := &models.DeliverableDraft{
+		DeliverableID: deliverableID,
+		SponsorshipID: id,
+		CreatorID:     creatorID,
+		LinkURL:       req.LinkURL,
+		AttachmentID:  req.AttachmentID,
+		FileName:      strings.TrimSpace(req.FileName),
+		Notes:         req.Notes,
+	}
+	if draft.AttachmentID != "" {
+		attachment, err := h.attachmentRepo.GetAttachment(draft.AttachmentID, id, creatorID)
+		if err != nil {
+			api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+				"attachmentId": "attachment not found on this sponsorship",
+			}))
+			return
+		}
+		if draft.FileName == "" {
+			draft.FileName = attachment.FileName
+		}
+	}
+	if err := h.repo.SubmitDraft(draft); err != nil {
+		logger.Error("Failed to submit draft for deliverable %s: %v", deliverableID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	h.syncDealReviewStatus(id, creatorID, r.Header.Get("X-User-ID"))
+
+	logger.Info("Draft submitted: Deliverable=%s, Version=%d, Sponsorship=%s", deliverableID, draft.Version, id)
+	api.WriteSuccess(w, http.StatusCreated, draft)
+}
+
+// GetReview shows the brand the draft behind a review link
+func (h *DraftHandler) GetReview(w http.ResponseWriter, r *http.Request) {
+	review, ok := h.loadReview(w, r)
+	if !ok {
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, review)
+}
+
+// DownloadReviewFile lets the brand download the uploaded file behind a review link
+func (h *DraftHandler) DownloadReviewFile(w http.ResponseWriter, r *http.Request) {
+	review, ok := h.loadReview(w, r)
+	if !ok {
+		return
+	}
+
+	draft := review.Draft
+	if draft.AttachmentID == "" {
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	attachment, err := h.attachmentRepo.GetAttachment(draft.AttachmentID, draft.SponsorshipID, draft.CreatorID)
+	if err != nil {
+		logger.Warn("Attachment %s of draft %s not found", draft.AttachmentID, draft.ID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	serveAttachment(w, h.store, attachment)
+}
+
+// ApproveDraft records the brand's approval of a draft
+func (h *DraftHandler) ApproveDraft(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, "approved")
+}
+
+// RequestChanges records the brand's change request for a draft, using up one revision
+func (h *DraftHandler) RequestChanges(w http.ResponseWriter, r *http.Request) {
+	h.review(w, r, "changes-requested")
+}
+
+func (h *DraftHandler) review(w http.ResponseWriter, r *http.Request, decision string) {
+	var req ReviewDraftRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxReviewBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("Failed to decode draft review: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	req.Comments = strings.TrimSpace(req.Comments)
+	if decision == "changes-requested" && req.Comments == "" {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"comments": "comments are required when requesting changes",
+		}))
+		return
+	}
+
+	review, ok := h.loadReview(w, r)
+	if !ok {
+		return
+	}
+
+	draft := review.Draft
+	if draft.Status != "pending-review" {
+		api.WriteError(w, apierrors.ErrConflict.WithDetails("draft is "+draft.Status))
+		return
+	}
+
+	if decision == "changes-requested" && review.RevisionLimit != nil && review.RevisionsUsed >= *review.RevisionLimit {
+		logger.Warn("Revision limit reached for deliverable %s: %d of %d used",
+			draft.DeliverableID, review.RevisionsUsed, *review.RevisionLimit)
+		api.WriteError(w, apierrors.ErrRevisionLimitReached.WithDetails(map[string]int{
+			"revisionLimit": *review.RevisionLimit,
+			"revisionsUsed": review.RevisionsUsed,
+		}))
+		return
+	}
+
+	draft.Status = decision
+	draft.ReviewerName = strings.TrimSpace(req.ReviewerName)
+	draft.ReviewComments = req.Comments
+	if err := h.repo.ReviewDraft(draft); err != nil {
+		if err == apierrors.ErrConflict {
+			api.WriteError(w, apierrors.ErrConflict.WithDetails("draft has already been reviewed"))
+			return
+		}
+		logger.Error("Failed to review draft %s: %v", draft.ID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+	if decision == "changes-requested" {
+		review.RevisionsUsed++
+	}
+
+	h.syncDealReviewStatus(draft.SponsorshipID, draft.CreatorID, "")
+
+	logger.Info("Draft %s of deliverable %s reviewed: %s", draft.ID, draft.DeliverableID, decision)
+	api.WriteSuccess(w, http.StatusOK, review)
+}
+
+func (h *DraftHandler) loadReview(w http.ResponseWriter, r *http.Request) (*DraftReview, bool) {
+	token := chi.URLParam(r, "token")
+
+	draft, err := h.repo.GetDraftByToken(token)
+	if err != nil {
+		logger.Warn("Unknown draft review token from %s", r.RemoteAddr)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return nil, false
+	}
+
+	deliverable, err := h.deliverableRepo.GetDeliverable(draft.DeliverableID, draft.SponsorshipID, draft.CreatorID)
+	if err != nil {
+		logger.Warn("Deliverable %s for draft %s not found: %v", draft.DeliverableID, draft.ID, err)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return nil, false
+	}
+
+	sponsorship, err := h.sponsorshipRepo.GetSponsorshipByID(draft.SponsorshipID, draft.CreatorID)
+	if err != nil {
+		logger.Warn("Sponsorship %s for draft %s not found", draft.SponsorshipID, draft.ID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return nil, false
+	}
+
+	return &DraftReview{
+		Draft:            draft,
+		BrandName:        sponsorship.BrandName,
+		DeliverableTitle: deliverable.Title,
+		DeliverableType:  deliverable.Type,
+		Platform:         deliverable.Platform,
+		DueDate:          deliverable.DueDate,
+		RevisionLimit:    sponsorship.RevisionLimit,
+		RevisionsUsed:    deliverable.RevisionCount,
+	}, true
+}
+
+// syncDealReviewStatus moves a deal into awaiting-review while any of its deliverables
+// waits on the brand, and back to content-creation once the brand has answered and
+// work remains
+func (h *DraftHandler) syncDealReviewStatus(sponsorshipID, creatorID, changedBy string) {
+	sponsorship, err := h.sponsorshipRepo.GetSponsorshipByID(sponsorshipID, creatorID)
+	if err != nil {
+		logger.Error("Failed to load sponsorship %s for review status: %v", sponsorshipID, err)
+		return
+	}
+
+	deliverables, err := h.deliverableRepo.ListDeliverables(sponsorshipID, creatorID)
+	if err != nil {
+		logger.Error("Failed to load deliverables of sponsorship %s for review status: %v", sponsorshipID, err)
+		return
+	}
+
+	awaiting, inProgress := false, false
+	for _, d := range deliverables {
+		switch d.Status {
+		case "awaiting-review":
+			awaiting = true
+		case "pending", "in-progress":
+			inProgress = true
+		}
+	}
+
+	target := ""
+	switch {
+	case awaiting && (sponsorship.Status == "contracted" || sponsorship.Status == "content-creation"):
+		target = "awaiting-review"
+	case !awaiting && inProgress && sponsorship.Status == "awaiting-review":
+		target = "content-creation"
+	}
+	if target == "" {
+		return
+	}
+
+	oldStatus := sponsorship.Status
+	sponsorship.Status = target
+
+	reason := "Draft submitted for brand review"
+	if target == "content-creation" {
+		reason = "Brand review answered"
+	}
+	if err := h.sponsorshipRepo.UpdateSponsorship(sponsorship, oldStatus, changedBy, reason); err != nil {
+		logger.Error("Failed to move sponsorship %s to %s: %v", sponsorshipID, target, err)
+		return
+	}
+}
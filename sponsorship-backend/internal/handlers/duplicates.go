@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"sponsorship-backend/internal/models"
+)
+
+// SponsorshipWarning is a non-fatal notice attached to a create response
+type SponsorshipWarning struct {
+	Type          string   `json:"type"`
+	Message       string   `json:"message"`
+	SponsorshipID string   `json:"sponsorshipId,omitempty"`
+	Link          string   `json:"link,omitempty"`
+	Reasons       []string `json:"reasons,omitempty"`
+}
+
+const (
+	warningPossibleDuplicate   = "possible_duplicate"
+	warningExclusivityConflict = "exclusivity_conflict"
+)
+
+// brandSuffixes are legal-entity suffixes dropped when comparing brand names
+var brandSuffixes = map[string]bool{
+	"inc": true, "incorporated": true, "llc": true, "ltd": true, "limited": true,
+	"co": true, "corp": true, "corporation": true, "company": true, "gmbh": true,
+	"ag": true, "sa": true, "plc": true, "bv": true, "pty": true,
+}
+
+// freeMailDomains are shared mailbox providers that say nothing about the sender's company
+var freeMailDomains = map[string]bool{
+	"gmail.com": true, "googlemail.com": true, "yahoo.com": true, "hotmail.com": true,
+	"outlook.com": true, "live.com": true, "icloud.com": true, "me.com": true,
+	"aol.com": true, "proton.me": true, "protonmail.com": true, "gmx.com": true,
+}
+
+// normalizeBrandName lowercases a brand name, strips punctuation and legal suffixes
+// so that "Nike, Inc." and "NIKE" compare equal
+func normalizeBrandName(name string) string {
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	for len(words) > 1 && brandSuffixes[words[len(words)-1]] {
+		words = words[:len(words)-1]
+	}
+
+	return strings.Join(words, "")
+}
+
+// emailDomain returns the lowercased domain of an email address, or "" if there is none
+func emailDomain(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at < 0 || at == len(email)-1 {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(email[at+1:]))
+}
+
+// companyEmailDomain is like emailDomain but ignores free mail providers
+func companyEmailDomain(email string) string {
+	domain := emailDomain(email)
+	if freeMailDomains[domain] {
+		return ""
+	}
+	return domain
+}
+
+// findDuplicateWarnings compares a new deal against existing deals whose dates overlap
+// and flags those that share the normalised brand name or the brand contact's email domain
+func findDuplicateWarnings(sponsorship *models.Sponsorship, candidates []*models.Sponsorship) []SponsorshipWarning {
+	brand := normalizeBrandName(sponsorship.BrandName)
+	domain := companyEmailDomain(sponsorship.ContactEmail)
+
+	var warnings []SponsorshipWarning
+	for _, c := range candidates {
+		if c.ID == sponsorship.ID {
+			continue
+		}
+
+		var reasons []string
+		if brand != "" && normalizeBrandName(c.BrandName) == brand {
+			reasons = append(reasons, "same_brand")
+		}
+		if domain != "" && companyEmailDomain(c.ContactEmail) == domain {
+			reasons = append(reasons, "same_contact_domain")
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+		reasons = append(reasons, "overlapping_dates")
+
+		warnings = append(warnings, SponsorshipWarning{
+			Type:          warningPossibleDuplicate,
+			Message:       fmt.Sprintf("Possible duplicate of %s deal created %s", c.BrandName, c.CreatedAt.Format("2006-01-02")),
+			SponsorshipID: c.ID,
+			Link:          "/api/sponsorships/" + c.ID,
+			Reasons:       reasons,
+		})
+	}
+
+	return warnings
+}
+
+// mergeSponsorships folds duplicate into target: target values win, empty target
+// fields are filled from the duplicate, deliverables are unioned and notes concatenated
+func mergeSponsorships(target, duplicate *models.Sponsorship) {
+	if target.ProductService == "" {
+		target.ProductService = duplicate.ProductService
+	}
+	if !target.DealAmount.IsPositive() {
+		target.DealAmount = duplicate.DealAmount
+		target.Currency = duplicate.Currency
+	}
+	if target.Priority == "" {
+		target.Priority = duplicate.Priority
+	}
+	if target.ContactName == "" {
+		target.ContactName = duplicate.ContactName
+	}
+	if target.ContactEmail == "" {
+		target.ContactEmail = duplicate.ContactEmail
+	}
+	if target.ContactPhone == "" {
+		target.ContactPhone = duplicate.ContactPhone
+	}
+	if target.Description == "" {
+		target.Description = duplicate.Description
+	}
+	if target.TargetAudience == "" {
+		target.TargetAudience = duplicate.TargetAudience
+	}
+	if target.Category == "" {
+		target.Category = duplicate.Category
+	}
+	if target.CommissionRate == nil {
+		target.CommissionRate = duplicate.CommissionRate
+	}
+	if target.StartDate.IsZero() {
+		target.StartDate = duplicate.StartDate
+	}
+	if target.EndDate.IsZero() {
+		target.EndDate = duplicate.EndDate
+	}
+
+	seen := make(map[string]bool, len(target.Deliverables))
+	for _, d := range target.Deliverables {
+		seen[strings.ToLower(strings.TrimSpace(d))] = true
+	}
+	for _, d := range duplicate.Deliverables {
+		key := strings.ToLower(strings.TrimSpace(d))
+		if !seen[key] {
+			seen[key] = true
+			target.Deliverables = append(target.Deliverables, d)
+		}
+	}
+
+	mergedNote := fmt.Sprintf("Merged duplicate deal %s (%s, contact %s <%s>)",
+		duplicate.ID, duplicate.BrandName, duplicate.ContactName, duplicate.ContactEmail)
+	notes := []string{}
+	for _, n := range []string{target.Notes, duplicate.Notes, mergedNote} {
+		if strings.TrimSpace(n) != "" {
+			notes = append(notes, n)
+		}
+	}
+	target.Notes = strings.Join(notes, "\n\n")
+}
@@ -0,0 +1,26 @@
+package handlers
+
+import "testing"
+
+func TestNormalizeBrandName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"Nike", "nike"},
+		{"Nike, Inc.", "nike"},
+		{"NIKE", "nike"},
+		{"Acme Co Inc", "acme"},
+		{"Acme Company", "acme"},
+		{"Müller GmbH", "müller"},
+		{"Co", "co"},
+		{"Red Bull Media House", "redbullmediahouse"},
+		{"  ", ""},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeBrandName(tt.name); got != tt.want {
+			t.Errorf("normalizeBrandName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
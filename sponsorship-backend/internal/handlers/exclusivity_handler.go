@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ExclusivityHandler struct {
+	sponsorshipRepo *repositories.SponsorshipRepository
+	exclusivityRepo *repositories.ExclusivityRepository
+	blocklistRepo   *repositories.BlocklistRepository
+}
+
+type CreateExclusivityClauseRequest struct {
+	Category   string    `json:"category"`
+	StartsOn   time.Time `json:"startsOn"`
+	EndsOn     time.Time `json:"endsOn"`
+	WindowDays int       `json:"windowDays"`
+	Notes      string    `json:"notes"`
+}
+
+type CreateBlocklistEntryRequest struct {
+	Kind   string `json:"kind"`
+	Value  string `json:"value"`
+	Reason string `json:"reason"`
+}
+
+func NewExclusivityHandler(sponsorshipRepo *repositories.SponsorshipRepository, exclusivityRepo *repositories.ExclusivityRepository,
+	blocklistRepo *repositories.BlocklistRepository) *ExclusivityHandler {
+	return &ExclusivityHandler{
+		sponsorshipRepo: sponsorshipRepo,
+		exclusivityRepo: exclusivityRepo,
+		blocklistRepo:   blocklistRepo,
+	}
+}
+
+// normalizeCategory makes category comparisons case and whitespace insensitive
+func normalizeCategory(category string) string {
+	return strings.ToLower(strings.TrimSpace(category))
+}
+
+// ListClauses lists the exclusivity clauses of a deal
+func (h *ExclusivityHandler) ListClauses(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	clauses, err := h.exclusivityRepo.ListClauses(id, creatorID)
+	if err != nil {
+		logger.Error("Failed to list exclusivity clauses for sponsorship %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if clauses == nil {
+		clauses = []*models.ExclusivityClause{}
+	}
+
+	api.WriteSuccess(w, http.StatusOK, clauses)
+}
+
+// CreateClause adds an exclusivity clause to a deal. The window is either given as
+// explicit dates or as windowDays after the deal's end date, starting with the deal.
+func (h *ExclusivityHandler) CreateClause(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req CreateExclusivityClauseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode create exclusivity clause request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	sponsorship, err := h.sponsorshipRepo.GetSponsorshipByID(id, creatorID)
+	if err != nil {
+		logger.Warn("Sponsorship not found for exclusivity clause: ID=%s, Creator=%s", id, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	clause := &models.ExclusivityClause{
+		SponsorshipID: sponsorship.ID,
+		CreatorID:     creatorID,
+		Category:      strings.TrimSpace(req.Category),
+		StartsOn:      req.StartsOn,
+		EndsOn:        req.EndsOn,
+		Notes:         req.Notes,
+	}
+	if clause.Category == "" {
+		clause.Category = sponsorship.Category
+	}
+	if clause.StartsOn.IsZero() {
+		clause.StartsOn = sponsorship.StartDate
+	}
+	if clause.EndsOn.IsZero() && req.WindowDays > 0 {
+		clause.EndsOn = sponsorship.EndDate.AddDate(0, 0, req.WindowDays)
+	}
+
+	details := map[string]string{}
+	if clause.Category == "" {
+		details["category"] = "category is required when the deal has none"
+	}
+	if clause.EndsOn.IsZero() {
+		details["endsOn"] = "endsOn or a positive windowDays is required"
+	} else if clause.EndsOn.Before(clause.StartsOn) {
+		details["endsOn"] = "endsOn must not be before startsOn"
+	}
+	if len(details) > 0 {
+		logger.Warn("Create exclusivity clause validation failed for sponsorship %s: %v", id, details)
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(details))
+		return
+	}
+
+	if err := h.exclusivityRepo.CreateClause(clause); err != nil {
+		logger.Error("Failed to create exclusivity clause for sponsorship %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Exclusivity clause created: ID=%s, Sponsorship=%s, Category=%s, %s..%s",
+		clause.ID, id, clause.Category, clause.StartsOn.Format("2006-01-02"), clause.EndsOn.Format("2006-01-02"))
+	api.WriteSuccess(w, http.StatusCreated, clause)
+}
+
+// DeleteClause removes an exclusivity clause from a deal
+func (h *ExclusivityHandler) DeleteClause(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	clauseID := chi.URLParam(r, "clauseId")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	if err := h.exclusivityRepo.DeleteClause(clauseID, id, creatorID); err != nil {
+		logger.Warn("Failed to delete exclusivity clause: ID=%s, Sponsorship=%s, Error: %v", clauseID, id, err)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	logger.Info("Exclusivity clause deleted: ID=%s, Sponsorship=%s", clauseID, id)
+	api.WriteSuccess(w, http.StatusOK, map[string]bool{"deleted": true})
+}
+
+// ListBlocklist lists the creator's blocked brands and categories
+func (h *ExclusivityHandler) ListBlocklist(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	entries, err := h.blocklistRepo.ListEntries(creatorID)
+	if err != nil {
+		logger.Error("Failed to list blocklist for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if entries == nil {
+		entries = []*models.BlocklistEntry{}
+	}
+
+	api.WriteSuccess(w, http.StatusOK, entries)
+}
+
+// CreateBlocklistEntry blocks a brand or category
+func (h *ExclusivityHandler) CreateBlocklistEntry(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req CreateBlocklistEntryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode create blocklist entry request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	entry := &models.BlocklistEntry{
+		CreatorID: creatorID,
+		Kind:      req.Kind,
+		Value:     strings.TrimSpace(req.Value),
+		Reason:    req.Reason,
+	}
+	switch req.Kind {
+	case "brand":
+		entry.NormalizedValue = normalizeBrandName(entry.Value)
+	case "category":
+		entry.NormalizedValue = normalizeCategory(entry.Value)
+	default:
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"kind": "kind must be brand or category",
+		}))
+		return
+	}
+	if entry.NormalizedValue == "" {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"value": "value is required",
+		}))
+		return
+	}
+
+	if err := h.blocklistRepo.CreateEntry(entry); err != nil {
+		if errors.Is(err, apierrors.ErrConflict) {
+			api.WriteError(w, apierrors.ErrConflict)
+			return
+		}
+		logger.Error("Failed to create blocklist entry for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Blocklist entry created: Kind=%s, Value=%s, Creator=%s", entry.Kind, entry.Value, creatorID)
+	api.WriteSuccess(w, http.StatusCreated, entry)
+}
+
+// DeleteBlocklistEntry unblocks a brand or category
+func (h *ExclusivityHandler) DeleteBlocklistEntry(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	if err := h.blocklistRepo.DeleteEntry(id, creatorID); err != nil {
+		logger.Warn("Failed to delete blocklist entry: ID=%s, Creator=%s, Error: %v", id, creatorID, err)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	logger.Info("Blocklist entry deleted: ID=%s, Creator=%s", id, creatorID)
+	api.WriteSuccess(w, http.StatusOK, map[string]bool{"deleted": true})
+}
@@ -0,0 +1,205 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/money"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ExpenseHandler struct {
+	repo            *repositories.ExpenseRepository
+	sponsorshipRepo *repositories.SponsorshipRepository
+	attachmentRepo  *repositories.AttachmentRepository
+}
+
+// ExpenseRequest records a cost. The currency defaults to the deal's; the receipt is an
+// attachment of the same deal, typically uploaded with kind "receipt".
+type ExpenseRequest struct {
+	Category            string      `json:"category"`
+	Description         string      `json:"description"`
+	Amount              json.Number `json:"amount"`
+	Currency            string      `json:"currency"`
+	ExpenseDate         time.Time   `json:"expenseDate"`
+	ReceiptAttachmentID *string     `json:"receiptAttachmentId"` // "" removes the receipt
+}
+
+func NewExpenseHandler(repo *repositories.ExpenseRepository, sponsorshipRepo *repositories.SponsorshipRepository,
+	attachmentRepo *repositories.AttachmentRepository) *ExpenseHandler {
+	return &ExpenseHandler{repo: repo, sponsorshipRepo: sponsorshipRepo, attachmentRepo: attachmentRepo}
+}
+
+// ListExpenses lists a sponsorship's expenses
+func (h *ExpenseHandler) ListExpenses(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	expenses, err := h.repo.ListExpenses(id, creatorID)
+	if err != nil {
+		logger.Error("Failed to list expenses for sponsorship %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if expenses == nil {
+		expenses = []*models.Expense{}
+	}
+
+	api.WriteSuccess(w, http.StatusOK, expenses)
+}
+
+// CreateExpense records a production cost against a sponsorship
+func (h *ExpenseHandler) CreateExpense(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req ExpenseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode create expense request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	sponsorship, err := h.sponsorshipRepo.GetSponsorshipByID(id, creatorID)
+	if err != nil {
+		logger.Warn("Sponsorship not found for expense: ID=%s, Creator=%s", id, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	expense := &models.Expense{
+		SponsorshipID: id,
+		CreatorID:     creatorID,
+		Category:      "other",
+		Currency:      sponsorship.Currency,
+	}
+	details := h.apply(expense, &req)
+	if len(details) > 0 {
+		logger.Warn("Create expense validation failed for sponsorship %s: %v", id, details)
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(details))
+		return
+	}
+
+	if err := h.repo.CreateExpense(expense); err != nil {
+		logger.Error("Failed to create expense for sponsorship %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Expense created: ID=%s, Sponsorship=%s, Category=%s, Amount=%s %s",
+		expense.ID, id, expense.Category, expense.Amount, expense.Currency)
+	api.WriteSuccess(w, http.StatusCreated, expense)
+}
+
+// UpdateExpense updates the non-empty fields of an expense
+func (h *ExpenseHandler) UpdateExpense(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	expenseID := chi.URLParam(r, "expenseId")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req ExpenseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode update expense request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	expense, err := h.repo.GetExpense(expenseID, id, creatorID)
+	if err != nil {
+		logger.Warn("Expense not found: ID=%s, Sponsorship=%s", expenseID, id)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	// A new currency without a new amount keeps the amount figure
+	if req.Currency != "" && req.Amount == "" {
+		req.Amount = json.Number(expense.Amount.String())
+	}
+	if details := h.apply(expense, &req); len(details) > 0 {
+		logger.Warn("Update expense validation failed for %s: %v", expenseID, details)
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(details))
+		return
+	}
+
+	if err := h.repo.UpdateExpense(expense); err != nil {
+		logger.Error("Failed to update expense %s: %v", expenseID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Expense updated: ID=%s, Sponsorship=%s", expenseID, id)
+	api.WriteSuccess(w, http.StatusOK, expense)
+}
+
+// DeleteExpense removes an expense
+func (h *ExpenseHandler) DeleteExpense(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	expenseID := chi.URLParam(r, "expenseId")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	if err := h.repo.DeleteExpense(expenseID, id, creatorID); err != nil {
+		logger.Warn("Failed to delete expense: ID=%s, Sponsorship=%s, Error: %v", expenseID, id, err)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	logger.Info("Expense deleted: ID=%s, Sponsorship=%s", expenseID, id)
+	api.WriteSuccess(w, http.StatusOK, map[string]bool{"deleted": true})
+}
+
+// apply copies the non-empty fields of a request onto an expense and validates the result
+func (h *ExpenseHandler) apply(expense *models.Expense, req *ExpenseRequest) map[string]string {
+	details := map[string]string{}
+
+	if req.Category != "" {
+		expense.Category = strings.ToLower(req.Category)
+	}
+	if req.Description != "" {
+		expense.Description = strings.TrimSpace(req.Description)
+	}
+	if req.Currency != "" {
+		expense.Currency = strings.ToUpper(req.Currency)
+	}
+	if req.Amount != "" {
+		amount, amountErr := parseAmount(req.Amount, expense.Currency)
+		if amountErr != "" {
+			details["amount"] = amountErr
+		}
+		expense.Amount = amount
+	}
+	if !req.ExpenseDate.IsZero() {
+		expense.ExpenseDate = req.ExpenseDate
+	}
+	if req.ReceiptAttachmentID != nil {
+		expense.ReceiptAttachmentID = *req.ReceiptAttachmentID
+	}
+
+	if !contains(models.ValidExpenseCategories, expense.Category) {
+		details["category"] = "category must be one of " + strings.Join(models.ValidExpenseCategories, ", ")
+	}
+	if !money.ValidCurrency(expense.Currency) {
+		details["currency"] = "currency must be a supported ISO 4217 currency code"
+	} else if details["amount"] == "" && !expense.Amount.IsPositive() {
+		details["amount"] = "amount must be greater than 0"
+	}
+	if expense.ExpenseDate.IsZero() {
+		details["expenseDate"] = "expenseDate is required"
+	}
+	if expense.ReceiptAttachmentID != "" {
+		if _, err := h.attachmentRepo.GetAttachment(expense.ReceiptAttachmentID, expense.SponsorshipID, expense.CreatorID); err != nil {
+			details["receiptAttachmentId"] = "receiptAttachmentId must be an attachment of this deal"
+		}
+	}
+
+	return details
+}
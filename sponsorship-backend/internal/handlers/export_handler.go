@@ -0,0 +1,334 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+	"sponsorship-backend/pkg/ledger"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/money"
+)
+
+// The accounts journal entries post to. Codes are those of Xero's default chart of
+// accounts; names are what QuickBooks matches on.
+var (
+	accountBank          = ledger.Account{Code: "090", Name: "Business Bank Account"}
+	accountReceivable    = ledger.Account{Code: "610", Name: "Accounts Receivable"}
+	accountIncome        = ledger.Account{Code: "200", Name: "Sponsorship Income"}
+	accountSalesTax      = ledger.Account{Code: "820", Name: "Sales Tax Payable"}
+	accountCurrencyGains = ledger.Account{Code: "499", Name: "Realised Currency Gains"}
+
+	expenseAccounts = map[string]ledger.Account{
+		"props":       {Code: "429", Name: "Production Props"},
+		"equipment":   {Code: "453", Name: "Equipment"},
+		"editing":     {Code: "412", Name: "Editing"},
+		"travel":      {Code: "493", Name: "Travel"},
+		"talent":      {Code: "477", Name: "Talent"},
+		"software":    {Code: "485", Name: "Software Subscriptions"},
+		"advertising": {Code: "400", Name: "Advertising"},
+		"other":       {Code: "429", Name: "General Expenses"},
+	}
+)
+
+// defaultXeroTaxRate is the tax rate name written on Xero journal lines; sales tax is
+// posted as its own line so every line is untaxed
+const defaultXeroTaxRate = "Tax Exempt"
+
+type ExportHandler struct {
+	invoiceRepo     *repositories.InvoiceRepository
+	incomeRepo      *repositories.IncomeRepository
+	expenseRepo     *repositories.ExpenseRepository
+	sponsorshipRepo *repositories.SponsorshipRepository
+	settingsRepo    *repositories.SettingsRepository
+	fxRepo          *repositories.FXRepository
+}
+
+func NewExportHandler(invoiceRepo *repositories.InvoiceRepository, incomeRepo *repositories.IncomeRepository,
+	expenseRepo *repositories.ExpenseRepository, sponsorshipRepo *repositories.SponsorshipRepository,
+	settingsRepo *repositories.SettingsRepository, fxRepo *repositories.FXRepository) *ExportHandler {
+	return &ExportHandler{
+		invoiceRepo:     invoiceRepo,
+		incomeRepo:      incomeRepo,
+		expenseRepo:     expenseRepo,
+		sponsorshipRepo: sponsorshipRepo,
+		settingsRepo:    settingsRepo,
+		fxRepo:          fxRepo,
+	}
+}
+
+// ExportAccounting downloads the creator's invoices, payments and expenses between two
+// dates (inclusive) as a double-entry journal in the base currency. format is journal
+// (CSV, the default), iif (QuickBooks) or xero (manual journal CSV).
+func (h *ExportHandler) ExportAccounting(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+	query := r.URL.Query()
+
+	details := map[string]string{}
+	from, err := time.Parse("2006-01-02", query.Get("from"))
+	if err != nil {
+		details["from"] = "from must be a date in YYYY-MM-DD format"
+	}
+	to, err := time.Parse("2006-01-02", query.Get("to"))
+	if err != nil {
+		details["to"] = "to must be a date in YYYY-MM-DD format"
+	} else if to.Before(from) {
+		details["to"] = "to must not be before from"
+	}
+	format := query.Get("format")
+	if format == "" {
+		format = "journal"
+	}
+	if format != "journal" && format != "iif" && format != "xero" {
+		details["format"] = "format must be journal, iif or xero"
+	}
+	if len(details) > 0 {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(details))
+		return
+	}
+	end := to.AddDate(0, 0, 1)
+
+	invoices, err := h.invoiceRepo.ListInvoiceActivity(creatorID, from, end)
+	if err != nil {
+		logger.Error("Failed to load invoices for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	income, err := h.incomeRepo.ListIncome(creatorID, from, end)
+	if err != nil {
+		logger.Error("Failed to load income for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	expenses, err := h.expenseRepo.ListCreatorExpenses(creatorID, &from, &end)
+	if err != nil {
+		logger.Error("Failed to load expenses for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	deals, err := h.sponsorshipRepo.ListDealValues(creatorID, "")
+	if err != nil {
+		logger.Error("Failed to load deals for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	converter, err := newCreatorConverter(h.settingsRepo, h.fxRepo, creatorID)
+	if err != nil {
+		logger.Error("Failed to get base currency for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	entries, err := buildJournal(from, end, invoices, income, expenses, deals, converter)
+	if err != nil {
+		logger.Error("Failed to convert journal for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+	// A journal with entries left out would not reconcile, so refuse rather than guess
+	if missing := converter.missingRates(); len(missing) > 0 {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"missingRates": "no exchange rate for " + strings.Join(missing, ", "),
+		}))
+		return
+	}
+
+	var buf bytes.Buffer
+	fileName := fmt.Sprintf("journal-%s-%s", from.Format("2006-01-02"), to.Format("2006-01-02"))
+	contentType := "text/csv; charset=utf-8"
+	switch format {
+	case "iif":
+		err = ledger.WriteIIF(&buf, entries)
+		fileName += ".iif"
+		contentType = "text/plain; charset=utf-8"
+	case "xero":
+		taxRate := query.Get("taxRate")
+		if taxRate == "" {
+			taxRate = defaultXeroTaxRate
+		}
+		err = ledger.WriteXeroCSV(&buf, entries, taxRate)
+		fileName += "-xero.csv"
+	default:
+		err = ledger.WriteJournalCSV(&buf, entries)
+		fileName += ".csv"
+	}
+	if err != nil {
+		logger.Error("Failed to write %s journal for creator %s: %v", format, creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	w.WriteHeader(http.StatusOK)
+	w.Write(buf.Bytes())
+}
+
+// posting is a journal line before conversion, with the day whose rate converts it
+type posting struct {
+	account  ledger.Account
+	amount   money.Money
+	rateDate time.Time
+}
+
+// buildJournal turns the activity within [from, to) into journal entries, oldest first.
+// Each entry's ID is derived from the record and event it comes from, so exporting the
+// same period twice yields the same IDs:
+//
+//   - invoice-<id>-issued: receivable against income and sales tax, on the issue date
+//   - invoice-<id>-paid: bank against receivable, on the day it was paid
+//   - invoice-<id>-void: the reverse of the issue entry, on the day it was voided
+//   - milestone-<id>-paid: bank against income, for milestones no sent or paid invoice bills
+//   - expense-<id>: the expense category's account against bank
+//
+// Entries that cannot be converted are left out; the caller checks missingRates.
+func buildJournal(from, to time.Time, invoices []*models.Invoice, income []*models.IncomeEntry,
+	expenses []*models.Expense, deals []*models.DealValue, converter *currencyConverter) ([]ledger.Entry, error) {
+	within := func(t *time.Time) bool {
+		return t != nil && !t.Before(from) && t.Before(to)
+	}
+
+	var entries []ledger.Entry
+	add := func(e ledger.Entry, postings []posting) error {
+		converted, ok, err := convertEntry(e, postings, converter)
+		if err != nil || !ok {
+			return err
+		}
+		entries = append(entries, converted)
+		return nil
+	}
+
+	for _, inv := range invoices {
+		if inv.IssueDate == nil {
+			continue
+		}
+		issued := func(sign int64) []posting {
+			lines := []posting{
+				{accountReceivable, inv.Total.MulRat(sign, 1), *inv.IssueDate},
+				{accountIncome, inv.Subtotal.MulRat(-sign, 1), *inv.IssueDate},
+			}
+			if !inv.TaxAmount.IsZero() {
+				lines = append(lines, posting{accountSalesTax, inv.TaxAmount.MulRat(-sign, 1), *inv.IssueDate})
+			}
+			return lines
+		}
+		entry := ledger.Entry{Reference: inv.InvoiceNumber, Name: inv.BillToName}
+
+		if within(inv.IssueDate) {
+			entry.ID, entry.Date, entry.Memo = "invoice-"+inv.ID+"-issued", *inv.IssueDate, "Invoice "+inv.InvoiceNumber
+			if err := add(entry, issued(1)); err != nil {
+				return nil, err
+			}
+		}
+		if within(inv.PaidAt) {
+			entry.ID, entry.Date, entry.Memo = "invoice-"+inv.ID+"-paid", *inv.PaidAt, "Payment of invoice "+inv.InvoiceNumber
+			if err := add(entry, []posting{
+				{accountBank, inv.Total, *inv.PaidAt},
+				{accountReceivable, inv.Total.Neg(), *inv.IssueDate},
+			}); err != nil {
+				return nil, err
+			}
+		}
+		if within(inv.VoidedAt) {
+			entry.ID, entry.Date, entry.Memo = "invoice-"+inv.ID+"-void", *inv.VoidedAt, "Void of invoice "+inv.InvoiceNumber
+			if err := add(entry, issued(-1)); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	for _, e := range income {
+		if e.Source != "milestone" {
+			continue
+		}
+		entry := ledger.Entry{
+			ID:   "milestone-" + e.SourceID + "-paid",
+			Date: e.ReceivedOn,
+			Name: e.BrandName,
+			Memo: "Milestone payment: " + e.Reference,
+		}
+		if err := add(entry, []posting{
+			{accountBank, e.Amount, e.ReceivedOn},
+			{accountIncome, e.Amount.Neg(), e.ReceivedOn},
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	brands := make(map[string]string, len(deals))
+	for _, d := range deals {
+		brands[d.SponsorshipID] = d.BrandName
+	}
+	for _, e := range expenses {
+		account, ok := expenseAccounts[e.Category]
+		if !ok {
+			account = expenseAccounts["other"]
+		}
+		memo := e.Category
+		if e.Description != "" {
+			memo += ": " + e.Description
+		}
+		entry := ledger.Entry{ID: "expense-" + e.ID, Date: e.ExpenseDate, Name: brands[e.SponsorshipID], Memo: memo}
+		if err := add(entry, []posting{
+			{account, e.Amount, e.ExpenseDate},
+			{accountBank, e.Amount.Neg(), e.ExpenseDate},
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if !entries[i].Date.Equal(entries[j].Date) {
+			return entries[i].Date.Before(entries[j].Date)
+		}
+		return entries[i].ID < entries[j].ID
+	})
+	return entries, nil
+}
+
+// convertEntry converts an entry's postings to the base currency. When they are converted
+// at one day's rate, the rounding difference is taken up by the second line; when the
+// days differ, as for a payment of a foreign-currency invoice, the difference is a
+// realised currency gain or loss. The second result is false if a rate was missing.
+func convertEntry(e ledger.Entry, postings []posting, converter *currencyConverter) (ledger.Entry, bool, error) {
+	sum := money.Zero(converter.base)
+	sameDay := true
+	for i, p := range postings {
+		rateDate := p.rateDate
+		amount, ok, err := converter.convert(p.amount, &rateDate)
+		if err != nil || !ok {
+			return ledger.Entry{}, false, err
+		}
+		e.Lines = append(e.Lines, ledger.Line{Account: p.account, Amount: amount})
+		sum = sum.Add(amount)
+		if i > 0 && !sameCalendarDay(p.rateDate, postings[0].rateDate) {
+			sameDay = false
+		}
+	}
+
+	switch {
+	case sum.IsZero():
+	case sameDay && len(e.Lines) > 1:
+		e.Lines[1].Amount = e.Lines[1].Amount.Sub(sum)
+	default:
+		e.Lines = append(e.Lines, ledger.Line{Account: accountCurrencyGains, Amount: sum.Neg()})
+	}
+	return e, true, nil
+}
+
+func sameCalendarDay(a, b time.Time) bool {
+	a, b = a.UTC(), b.UTC()
+	return a.Year() == b.Year() && a.YearDay() == b.YearDay()
+}
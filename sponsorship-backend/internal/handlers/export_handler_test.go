@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/fx"
+	"sponsorship-backend/pkg/money"
+)
+
+// fakeRateSource serves the rates stored for each day
+type fakeRateSource map[string][]*models.FXRate
+
+func (f fakeRateSource) LatestRates(asOf time.Time) ([]*models.FXRate, error) {
+	return f[asOf.Format("2006-01-02")], nil
+}
+
+func TestBuildJournal(t *testing.T) {
+	day := func(s string) time.Time {
+		d, _ := time.Parse("2006-01-02", s)
+		return d
+	}
+	eur := func(minor int64) money.Money { return money.New(minor, "EUR") }
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+	issued, paid, voided := day("2024-03-01"), day("2024-04-01"), day("2024-06-01")
+
+	converter := &currencyConverter{
+		repo: fakeRateSource{
+			"2024-03-01": {{Date: issued, Base: "EUR", Quote: "USD", Rate: "1.5"}},
+			"2024-04-01": {{Date: paid, Base: "EUR", Quote: "USD", Rate: "1.6"}},
+		},
+		base:    "USD",
+		tables:  map[time.Time]*fx.Table{},
+		missing: map[string]bool{},
+	}
+
+	invoices := []*models.Invoice{
+		{ID: "i1", InvoiceNumber: "INV-0001", BillToName: "Acme GmbH", IssueDate: &issued, PaidAt: &paid,
+			VoidedAt: &voided, Subtotal: eur(5), TaxAmount: eur(5), Total: eur(10)},
+		{ID: "i2", InvoiceNumber: "INV-0002", Subtotal: eur(100), TaxAmount: eur(0), Total: eur(100)},
+	}
+	income := []*models.IncomeEntry{
+		{Source: "milestone", SourceID: "m1", BrandName: "Acme", Reference: "Kickoff", Amount: usd(50000),
+			ReceivedOn: day("2024-03-15")},
+		{Source: "invoice", SourceID: "i1", Amount: eur(5), ReceivedOn: paid},
+	}
+	expenses := []*models.Expense{
+		{ID: "e1", SponsorshipID: "s1", Category: "props", Amount: money.New(2000, "GBP"),
+			ExpenseDate: day("2024-03-10")},
+		{ID: "e2", SponsorshipID: "s1", Category: "travel", Description: "Flights", Amount: usd(2000),
+			ExpenseDate: day("2024-03-20")},
+		{ID: "e3", SponsorshipID: "s2", Category: "catering", Amount: usd(500), ExpenseDate: day("2024-03-21")},
+	}
+	deals := []*models.DealValue{{SponsorshipID: "s1", BrandName: "Acme"}}
+
+	entries, err := buildJournal(issued, day("2024-05-01"), invoices, income, expenses, deals, converter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type line struct {
+		code  string
+		minor int64
+	}
+	want := []struct {
+		id, name, memo string
+		lines          []line
+	}{
+		// 0.10 EUR at 1.5 is 0.15 USD; 0.05 EUR converts to 0.08 twice, so income takes
+		// up the cent of rounding
+		{"invoice-i1-issued", "Acme GmbH", "Invoice INV-0001", []line{{"610", 15}, {"200", -7}, {"820", -8}}},
+		{"milestone-m1-paid", "Acme", "Milestone payment: Kickoff", []line{{"090", 50000}, {"200", -50000}}},
+		{"expense-e2", "Acme", "travel: Flights", []line{{"493", 2000}, {"090", -2000}}},
+		{"expense-e3", "", "catering", []line{{"429", 500}, {"090", -500}}},
+		// paid at 1.6 against a receivable booked at 1.5
+		{"invoice-i1-paid", "Acme GmbH", "Payment of invoice INV-0001", []line{{"090", 16}, {"610", -15}, {"499", -1}}},
+	}
+
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(entries), len(want), entries)
+	}
+	for i, w := range want {
+		e := entries[i]
+		var got []line
+		for _, l := range e.Lines {
+			if l.Amount.Currency() != "USD" {
+				t.Errorf("%s: line in %s, want USD", e.ID, l.Amount.Currency())
+			}
+			got = append(got, line{l.Account.Code, l.Amount.Minor()})
+		}
+		if e.ID != w.id || e.Name != w.name || e.Memo != w.memo || !reflect.DeepEqual(got, w.lines) {
+			t.Errorf("entry %d = %s %q %q %v, want %s %q %q %v", i, e.ID, e.Name, e.Memo, got, w.id, w.name, w.memo,
+				w.lines)
+		}
+		if !e.Balanced() {
+			t.Errorf("%s is not balanced", e.ID)
+		}
+	}
+
+	if missing := converter.missingRates(); !reflect.DeepEqual(missing, []string{"GBP/USD 2024-03-10"}) {
+		t.Errorf("missingRates() = %q", missing)
+	}
+}
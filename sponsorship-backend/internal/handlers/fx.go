@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+	"sponsorship-backend/pkg/fx"
+	"sponsorship-backend/pkg/money"
+)
+
+// rateSource gives the latest stored exchange rates as of a day
+type rateSource interface {
+	LatestRates(asOf time.Time) ([]*models.FXRate, error)
+}
+
+// currencyConverter converts deal amounts into a creator's base currency for reports.
+// A deal is converted at the rate of the day it was contracted, or today's rate if it
+// has not been contracted yet. Rate tables are loaded once per day per report.
+type currencyConverter struct {
+	repo    rateSource
+	base    string
+	today   time.Time
+	tables  map[time.Time]*fx.Table
+	missing map[string]bool
+}
+
+// newCreatorConverter builds a converter into the creator's base currency
+func newCreatorConverter(settingsRepo *repositories.SettingsRepository, fxRepo rateSource,
+	creatorID string) (*currencyConverter, error) {
+	settings, err := settingsRepo.GetSettings(creatorID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	return &currencyConverter{
+		repo:    fxRepo,
+		base:    settings.BaseCurrency,
+		today:   time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC),
+		tables:  map[time.Time]*fx.Table{},
+		missing: map[string]bool{},
+	}, nil
+}
+
+// convert returns amount in the base currency at the rate for contractedAt. The second
+// result is false if no rate was available, in which case the pair is remembered for
+// missingRates and the amount should be left out of totals.
+func (c *currencyConverter) convert(amount money.Money, contractedAt *time.Time) (money.Money, bool, error) {
+	if amount.Currency() == c.base {
+		return amount, true, nil
+	}
+
+	day := c.today
+	if contractedAt != nil {
+		t := contractedAt.UTC()
+		day = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+
+	table, ok := c.tables[day]
+	if !ok {
+		var err error
+		if table, err = loadRateTable(c.repo, day); err != nil {
+			return money.Money{}, false, err
+		}
+		c.tables[day] = table
+	}
+
+	rate, ok := table.Lookup(amount.Currency(), c.base)
+	if !ok {
+		c.missing[fmt.Sprintf("%s/%s %s", amount.Currency(), c.base, day.Format("2006-01-02"))] = true
+		return money.Money{}, false, nil
+	}
+	return amount.Convert(c.base, rate), true, nil
+}
+
+// missingRates lists the currency pairs and days with no rate, e.g. "EUR/USD 2024-03-01"
+func (c *currencyConverter) missingRates() []string {
+	if len(c.missing) == 0 {
+		return nil
+	}
+	missing := make([]string, 0, len(c.missing))
+	for m := range c.missing {
+		missing = append(missing, m)
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// loadRateTable builds a table of the latest rates stored as of day
+func loadRateTable(repo rateSource, day time.Time) (*fx.Table, error) {
+	stored, err := repo.LatestRates(day)
+	if err != nil {
+		return nil, err
+	}
+	rates := make([]fx.Rate, 0, len(stored))
+	for _, s := range stored {
+		rate, err := money.ParseRate(s.Rate)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stored fx rate %s/%s %q: %w", s.Base, s.Quote, s.Rate, err)
+		}
+		rates = append(rates, fx.Rate{Date: s.Date, Base: s.Base, Quote: s.Quote, Rate: rate})
+	}
+	return fx.NewTable(rates), nil
+}
@@ -0,0 +1,622 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/money"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// defaultInvoicePrefix is used until the creator sets their own
+const defaultInvoicePrefix = "INV-"
+
+type InvoiceHandler struct {
+	repo            *repositories.InvoiceRepository
+	sponsorshipRepo *repositories.SponsorshipRepository
+	milestoneRepo   *repositories.MilestoneRepository
+	deliverableRepo *repositories.DeliverableRepository
+}
+
+type BillingDetailsRequest struct {
+	LegalName           string `json:"legalName"`
+	Email               string `json:"email"`
+	AddressLine1        string `json:"addressLine1"`
+	AddressLine2        string `json:"addressLine2"`
+	City                string `json:"city"`
+	PostalCode          string `json:"postalCode"`
+	Region              string `json:"region"`
+	Country             string `json:"country"`
+	TaxID               string `json:"taxId"`
+	PaymentInstructions string `json:"paymentInstructions"`
+	InvoicePrefix       string `json:"invoicePrefix"`
+}
+
+type InvoiceLineItemRequest struct {
+	DeliverableID string      `json:"deliverableId"`
+	Description   string      `json:"description"`
+	Quantity      float64     `json:"quantity"`
+	UnitPrice     json.Number `json:"unitPrice"`
+}
+
+// InvoiceRequest creates or updates a draft invoice. Line items default to the
+// milestone, or else the deal's deliverables; bill-to fields default to the deal's brand.
+type InvoiceRequest struct {
+	SponsorshipID string                   `json:"sponsorshipId"`
+	MilestoneID   string                   `json:"milestoneId"`
+	DueDate       *time.Time               `json:"dueDate"`
+	BillToName    string                   `json:"billToName"`
+	BillToContact string                   `json:"billToContact"`
+	BillToEmail   string                   `json:"billToEmail"`
+	BillToAddress string                   `json:"billToAddress"`
+	BillToTaxID   string                   `json:"billToTaxId"`
+	TaxLabel      string                   `json:"taxLabel"`
+	TaxRate       *float64                 `json:"taxRate"`
+	Notes         string                   `json:"notes"`
+	LineItems     []InvoiceLineItemRequest `json:"lineItems"`
+}
+
+type MarkInvoicePaidRequest struct {
+	PaidAt *time.Time `json:"paidAt"`
+}
+
+func NewInvoiceHandler(repo *repositories.InvoiceRepository, sponsorshipRepo *repositories.SponsorshipRepository,
+	milestoneRepo *repositories.MilestoneRepository, deliverableRepo *repositories.DeliverableRepository) *InvoiceHandler {
+	return &InvoiceHandler{
+		repo:            repo,
+		sponsorshipRepo: sponsorshipRepo,
+		milestoneRepo:   milestoneRepo,
+		deliverableRepo: deliverableRepo,
+	}
+}
+
+// GetBillingDetails returns the creator's billing details
+func (h *InvoiceHandler) GetBillingDetails(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	details, err := h.repo.GetBillingDetails(creatorID)
+	if err == apierrors.ErrNotFound {
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to get billing details for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, details)
+}
+
+// SaveBillingDetails sets the creator's billing details
+func (h *InvoiceHandler) SaveBillingDetails(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req BillingDetailsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode billing details request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	details := &models.BillingDetails{
+		CreatorID:           creatorID,
+		LegalName:           strings.TrimSpace(req.LegalName),
+		Email:               strings.TrimSpace(req.Email),
+		AddressLine1:        req.AddressLine1,
+		AddressLine2:        req.AddressLine2,
+		City:                req.City,
+		PostalCode:          req.PostalCode,
+		Region:              req.Region,
+		Country:             strings.ToUpper(strings.TrimSpace(req.Country)),
+		TaxID:               strings.TrimSpace(req.TaxID),
+		PaymentInstructions: req.PaymentInstructions,
+		InvoicePrefix:       req.InvoicePrefix,
+	}
+	if details.InvoicePrefix == "" {
+		details.InvoicePrefix = defaultInvoicePrefix
+	}
+
+	validation := map[string]string{}
+	if details.LegalName == "" {
+		validation["legalName"] = "legalName is required"
+	}
+	if details.Country != "" && len(details.Country) != 2 {
+		validation["country"] = "country must be a two-letter ISO code"
+	}
+	if len(details.InvoicePrefix) > 20 {
+		validation["invoicePrefix"] = "invoicePrefix must be at most 20 characters"
+	}
+	if len(validation) > 0 {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(validation))
+		return
+	}
+
+	if err := h.repo.SaveBillingDetails(details); err != nil {
+		logger.Error("Failed to save billing details for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Billing details saved for creator %s", creatorID)
+	api.WriteSuccess(w, http.StatusOK, details)
+}
+
+// ListInvoices lists the creator's invoices, optionally filtered with ?status=
+func (h *InvoiceHandler) ListInvoices(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	invoices, err := h.repo.ListInvoices(creatorID, r.URL.Query().Get("status"))
+	if err != nil {
+		logger.Error("Failed to list invoices for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if invoices == nil {
+		invoices = []*models.Invoice{}
+	}
+
+	api.WriteSuccess(w, http.StatusOK, invoices)
+}
+
+// GetInvoice returns an invoice with its line items
+func (h *InvoiceHandler) GetInvoice(w http.ResponseWriter, r *http.Request) {
+	invoice, ok := h.loadInvoice(w, r)
+	if !ok {
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, invoice)
+}
+
+// CreateInvoice creates a draft invoice for a signed deal or one of its milestones
+func (h *InvoiceHandler) CreateInvoice(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req InvoiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode create invoice request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	sponsorship, err := h.sponsorshipRepo.GetSponsorshipByID(req.SponsorshipID, creatorID)
+	if err != nil {
+		logger.Warn("Sponsorship not found for invoice: ID=%s, Creator=%s", req.SponsorshipID, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+	if !contains(models.WonStatuses, sponsorship.Status) {
+		api.WriteError(w, apierrors.ErrInvalidStateTransition.WithDetails(map[string]string{
+			"sponsorshipId": "deal must be contracted before it can be invoiced",
+		}))
+		return
+	}
+
+	invoice := &models.Invoice{
+		CreatorID:     creatorID,
+		SponsorshipID: sponsorship.ID,
+		Currency:      sponsorship.Currency,
+		BillToName:    sponsorship.BrandName,
+		BillToContact: sponsorship.ContactName,
+		BillToEmail:   sponsorship.ContactEmail,
+	}
+
+	var milestone *models.PaymentMilestone
+	if req.MilestoneID != "" {
+		milestone, err = h.milestoneRepo.GetMilestone(req.MilestoneID, sponsorship.ID, creatorID)
+		if err != nil {
+			api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+				"milestoneId": "milestone not found on this sponsorship",
+			}))
+			return
+		}
+		invoice.MilestoneID = milestone.ID
+		due := milestone.DueDate
+		invoice.DueDate = &due
+	}
+
+	details := applyInvoiceRequest(invoice, &req)
+	if len(req.LineItems) == 0 {
+		invoice.LineItems, err = h.defaultLineItems(sponsorship, milestone)
+		if err != nil {
+			logger.Error("Failed to build line items for sponsorship %s: %v", sponsorship.ID, err)
+			api.WriteError(w, apierrors.ErrInternalError)
+			return
+		}
+	}
+	computeInvoiceTotals(invoice)
+
+	if validateInvoice(invoice, details); len(details) > 0 {
+		logger.Warn("Create invoice validation failed for sponsorship %s: %v", sponsorship.ID, details)
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(details))
+		return
+	}
+
+	if err := h.repo.CreateInvoice(invoice); err != nil {
+		logger.Error("Failed to create invoice for sponsorship %s: %v", sponsorship.ID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Draft invoice created: ID=%s, Sponsorship=%s, Total=%s %s", invoice.ID, sponsorship.ID, invoice.Total, invoice.Currency)
+	api.WriteSuccess(w, http.StatusCreated, invoice)
+}
+
+// UpdateInvoice edits a draft invoice. Sent invoices are immutable.
+func (h *InvoiceHandler) UpdateInvoice(w http.ResponseWriter, r *http.Request) {
+	var req InvoiceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode update invoice request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	invoice, ok := h.loadInvoice(w, r)
+	if !ok {
+		return
+	}
+	if invoice.Status != "draft" {
+		api.WriteError(w, apierrors.ErrInvalidStateTransition.WithDetails("only draft invoices can be edited"))
+		return
+	}
+
+	details := applyInvoiceRequest(invoice, &req)
+	computeInvoiceTotals(invoice)
+
+	if validateInvoice(invoice, details); len(details) > 0 {
+		logger.Warn("Update invoice validation failed for %s: %v", invoice.ID, details)
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(details))
+		return
+	}
+
+	if err := h.repo.UpdateDraftInvoice(invoice); err != nil {
+		if err == apierrors.ErrInvalidStateTransition {
+			api.WriteError(w, apierrors.ErrInvalidStateTransition.WithDetails("only draft invoices can be edited"))
+			return
+		}
+		logger.Error("Failed to update invoice %s: %v", invoice.ID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Draft invoice updated: ID=%s, Total=%s %s", invoice.ID, invoice.Total, invoice.Currency)
+	api.WriteSuccess(w, http.StatusOK, invoice)
+}
+
+// DeleteInvoice removes a draft invoice
+func (h *InvoiceHandler) DeleteInvoice(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	if err := h.repo.DeleteDraftInvoice(id, creatorID); err != nil {
+		if err == apierrors.ErrInvalidStateTransition {
+			// Either missing or no longer a draft; say which
+			if _, getErr := h.repo.GetInvoice(id, creatorID); getErr == nil {
+				api.WriteError(w, apierrors.ErrInvalidStateTransition.WithDetails("sent invoices can only be voided"))
+				return
+			}
+			api.WriteError(w, apierrors.ErrNotFound)
+			return
+		}
+		logger.Error("Failed to delete invoice %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Draft invoice deleted: ID=%s, Creator=%s", id, creatorID)
+	api.WriteSuccess(w, http.StatusOK, map[string]bool{"deleted": true})
+}
+
+// SendInvoice numbers a draft invoice and marks it sent
+func (h *InvoiceHandler) SendInvoice(w http.ResponseWriter, r *http.Request) {
+	invoice, ok := h.loadInvoice(w, r)
+	if !ok {
+		return
+	}
+
+	seller, err := h.repo.GetBillingDetails(invoice.CreatorID)
+	if err == apierrors.ErrNotFound {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"billingDetails": "set your billing details before sending invoices",
+		}))
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to get billing details for creator %s: %v", invoice.CreatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if err := h.repo.IssueInvoice(invoice, seller, time.Now().Truncate(24*time.Hour)); err != nil {
+		if err == apierrors.ErrInvalidStateTransition {
+			api.WriteError(w, apierrors.ErrInvalidStateTransition.WithDetails("invoice has already been sent"))
+			return
+		}
+		logger.Error("Failed to issue invoice %s: %v", invoice.ID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Invoice sent: ID=%s, Number=%s, Total=%s %s", invoice.ID, invoice.InvoiceNumber, invoice.Total, invoice.Currency)
+	api.WriteSuccess(w, http.StatusOK, invoice)
+}
+
+// MarkInvoicePaid records payment of a sent invoice
+func (h *InvoiceHandler) MarkInvoicePaid(w http.ResponseWriter, r *http.Request) {
+	var req MarkInvoicePaidRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode mark invoice paid request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	invoice, ok := h.loadInvoice(w, r)
+	if !ok {
+		return
+	}
+
+	paidAt := time.Now()
+	if req.PaidAt != nil {
+		paidAt = *req.PaidAt
+	}
+
+	if err := h.repo.MarkInvoicePaid(invoice, paidAt); err != nil {
+		if err == apierrors.ErrInvalidStateTransition {
+			api.WriteError(w, apierrors.ErrInvalidStateTransition.WithDetails("only sent invoices can be marked paid"))
+			return
+		}
+		logger.Error("Failed to mark invoice %s paid: %v", invoice.ID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Invoice paid: ID=%s, Number=%s", invoice.ID, invoice.InvoiceNumber)
+	api.WriteSuccess(w, http.StatusOK, invoice)
+}
+
+// VoidInvoice cancels a sent invoice
+func (h *InvoiceHandler) VoidInvoice(w http.ResponseWriter, r *http.Request) {
+	invoice, ok := h.loadInvoice(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.repo.VoidInvoice(invoice); err != nil {
+		if err == apierrors.ErrInvalidStateTransition {
+			api.WriteError(w, apierrors.ErrInvalidStateTransition.WithDetails("only sent invoices can be voided"))
+			return
+		}
+		logger.Error("Failed to void invoice %s: %v", invoice.ID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Invoice voided: ID=%s, Number=%s", invoice.ID, invoice.InvoiceNumber)
+	api.WriteSuccess(w, http.StatusOK, invoice)
+}
+
+// GetInvoicePDF renders an invoice as PDF
+func (h *InvoiceHandler) GetInvoicePDF(w http.ResponseWriter, r *http.Request) {
+	invoice, ok := h.loadRenderable(w, r)
+	if !ok {
+		return
+	}
+
+	body := renderInvoicePDF(invoice)
+	w.Header().Set("Content-Type", "application/pdf")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", invoiceFileName(invoice)+".pdf"))
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// GetInvoiceHTML renders an invoice as an HTML page
+func (h *InvoiceHandler) GetInvoiceHTML(w http.ResponseWriter, r *http.Request) {
+	invoice, ok := h.loadRenderable(w, r)
+	if !ok {
+		return
+	}
+
+	body, err := renderInvoiceHTML(invoice)
+	if err != nil {
+		logger.Error("Failed to render invoice %s: %v", invoice.ID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+func (h *InvoiceHandler) loadInvoice(w http.ResponseWriter, r *http.Request) (*models.Invoice, bool) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	invoice, err := h.repo.GetInvoice(id, creatorID)
+	if err == apierrors.ErrNotFound {
+		logger.Warn("Invoice not found: ID=%s, Creator=%s", id, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return nil, false
+	}
+	if err != nil {
+		logger.Error("Failed to get invoice %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return nil, false
+	}
+
+	return invoice, true
+}
+
+// loadRenderable loads an invoice for rendering; drafts show the current billing details
+func (h *InvoiceHandler) loadRenderable(w http.ResponseWriter, r *http.Request) (*models.Invoice, bool) {
+	invoice, ok := h.loadInvoice(w, r)
+	if !ok {
+		return nil, false
+	}
+
+	if invoice.Seller == nil {
+		seller, err := h.repo.GetBillingDetails(invoice.CreatorID)
+		if err != nil && err != apierrors.ErrNotFound {
+			logger.Error("Failed to get billing details for creator %s: %v", invoice.CreatorID, err)
+			api.WriteError(w, apierrors.ErrInternalError)
+			return nil, false
+		}
+		invoice.Seller = seller
+	}
+
+	return invoice, true
+}
+
+// defaultLineItems bills a milestone as one line, or else splits the deal amount evenly
+// across the deal's deliverables, putting any leftover cents on the first lines
+func (h *InvoiceHandler) defaultLineItems(sponsorship *models.Sponsorship, milestone *models.PaymentMilestone) ([]*models.InvoiceLineItem, error) {
+	if milestone != nil {
+		return []*models.InvoiceLineItem{{
+			Description: fmt.Sprintf("%s - %s", sponsorship.ProductService, milestone.Label),
+			Quantity:    1,
+			UnitPrice:   milestone.Amount,
+		}}, nil
+	}
+
+	deliverables, err := h.deliverableRepo.ListDeliverables(sponsorship.ID, sponsorship.CreatorID)
+	if err != nil {
+		return nil, err
+	}
+	if len(deliverables) == 0 {
+		return []*models.InvoiceLineItem{{
+			Description: "Sponsorship: " + sponsorship.ProductService,
+			Quantity:    1,
+			UnitPrice:   sponsorship.DealAmount,
+		}}, nil
+	}
+
+	shares := sponsorship.DealAmount.Allocate(len(deliverables))
+	items := make([]*models.InvoiceLineItem, len(deliverables))
+	for i, d := range deliverables {
+		description := d.Title
+		if d.Platform != "" {
+			description = fmt.Sprintf("%s (%s, %s)", d.Title, d.Type, d.Platform)
+		}
+		items[i] = &models.InvoiceLineItem{
+			DeliverableID: d.ID,
+			Description:   description,
+			Quantity:      1,
+			UnitPrice:     shares[i],
+		}
+	}
+	return items, nil
+}
+
+// applyInvoiceRequest copies the non-empty request fields onto an invoice, returning
+// errors for line items whose price cannot be read
+func applyInvoiceRequest(invoice *models.Invoice, req *InvoiceRequest) map[string]string {
+	details := map[string]string{}
+	if req.DueDate != nil {
+		invoice.DueDate = req.DueDate
+	}
+	if req.BillToName != "" {
+		invoice.BillToName = strings.TrimSpace(req.BillToName)
+	}
+	if req.BillToContact != "" {
+		invoice.BillToContact = req.BillToContact
+	}
+	if req.BillToEmail != "" {
+		invoice.BillToEmail = strings.TrimSpace(req.BillToEmail)
+	}
+	if req.BillToAddress != "" {
+		invoice.BillToAddress = req.BillToAddress
+	}
+	if req.BillToTaxID != "" {
+		invoice.BillToTaxID = strings.TrimSpace(req.BillToTaxID)
+	}
+	if req.TaxLabel != "" {
+		invoice.TaxLabel = req.TaxLabel
+	}
+	if req.TaxRate != nil {
+		invoice.TaxRate = *req.TaxRate
+	}
+	if req.Notes != "" {
+		invoice.Notes = req.Notes
+	}
+	if len(req.LineItems) > 0 {
+		invoice.LineItems = make([]*models.InvoiceLineItem, len(req.LineItems))
+		for i, item := range req.LineItems {
+			quantity := item.Quantity
+			if quantity == 0 {
+				quantity = 1
+			}
+			unitPrice, priceErr := parseAmount(item.UnitPrice, invoice.Currency)
+			if priceErr != "" {
+				details[fmt.Sprintf("lineItems[%d].unitPrice", i)] = priceErr
+			}
+			invoice.LineItems[i] = &models.InvoiceLineItem{
+				DeliverableID: item.DeliverableID,
+				Description:   strings.TrimSpace(item.Description),
+				Quantity:      quantity,
+				UnitPrice:     unitPrice,
+			}
+		}
+	}
+	return details
+}
+
+// computeInvoiceTotals rounds each line and the tax to the minor unit
+func computeInvoiceTotals(invoice *models.Invoice) {
+	subtotal := money.Zero(invoice.Currency)
+	for _, item := range invoice.LineItems {
+		item.Amount = item.UnitPrice.Mul(item.Quantity)
+		subtotal = subtotal.Add(item.Amount)
+	}
+	invoice.Subtotal = subtotal
+	invoice.TaxAmount = subtotal.Percent(invoice.TaxRate)
+	invoice.Total = subtotal.Add(invoice.TaxAmount)
+}
+
+// validateInvoice adds the invoice's field errors to details
+func validateInvoice(invoice *models.Invoice, details map[string]string) {
+	if invoice.BillToName == "" {
+		details["billToName"] = "billToName is required"
+	}
+	if invoice.TaxRate < 0 || invoice.TaxRate > 100 {
+		details["taxRate"] = "taxRate must be between 0 and 100"
+	}
+	if len(invoice.LineItems) == 0 {
+		details["lineItems"] = "at least one line item is required"
+	}
+	for i, item := range invoice.LineItems {
+		if item.Description == "" {
+			details[fmt.Sprintf("lineItems[%d].description", i)] = "description is required"
+		}
+		if item.Quantity <= 0 {
+			details[fmt.Sprintf("lineItems[%d].quantity", i)] = "quantity must be greater than 0"
+		}
+		if !item.Amount.Fits(amountPrecision, amountScale) {
+			details[fmt.Sprintf("lineItems[%d].amount", i)] = "amount must be less than " + maxAmount(invoice.Currency)
+		}
+	}
+	if !invoice.Total.IsPositive() {
+		details["total"] = "invoice total must be greater than 0"
+	} else if !invoice.Total.Fits(amountPrecision, amountScale) {
+		details["total"] = "invoice total must be less than " + maxAmount(invoice.Currency)
+	}
+}
+
+// invoiceFileName names a rendered invoice after its number, or its ID while a draft
+func invoiceFileName(invoice *models.Invoice) string {
+	if invoice.InvoiceNumber != "" {
+		return invoice.InvoiceNumber
+	}
+	return "draft-" + invoice.ID
+}
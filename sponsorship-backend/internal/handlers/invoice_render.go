@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"strings"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/pdf"
+)
+
+var invoiceTemplate = template.Must(template.New("invoice").Funcs(template.FuncMap{
+	"date": formatInvoiceDate,
+	"qty":  formatQuantity,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: Helvetica, Arial, sans-serif; color: #222; max-width: 800px; margin: 40px auto; }
+table { width: 100%; border-collapse: collapse; }
+th, td { padding: 6px 4px; text-align: left; vertical-align: top; }
+.items th { border-bottom: 1px solid #222; }
+.items td { border-bottom: 1px solid #ddd; }
+.num { text-align: right; }
+.totals td { border: none; }
+.status { text-transform: uppercase; color: #888; }
+.pre { white-space: pre-line; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+{{if ne .Invoice.Status "sent"}}<p class="status">{{.Invoice.Status}}</p>{{end}}
+<table>
+<tr>
+<td>
+{{with .Invoice.Seller}}<strong>{{.LegalName}}</strong><br>
+<span class="pre">{{.Address}}</span>
+{{if .Email}}<br>{{.Email}}{{end}}
+{{if .TaxID}}<br>Tax ID: {{.TaxID}}{{end}}{{end}}
+</td>
+<td class="num">
+{{if .Invoice.IssueDate}}Issued: {{date .Invoice.IssueDate}}<br>{{end}}
+{{if .Invoice.DueDate}}Due: {{date .Invoice.DueDate}}{{end}}
+</td>
+</tr>
+</table>
+<h3>Bill to</h3>
+<p><strong>{{.Invoice.BillToName}}</strong>
+{{if .Invoice.BillToContact}}<br>Attn: {{.Invoice.BillToContact}}{{end}}
+{{if .Invoice.BillToAddress}}<br><span class="pre">{{.Invoice.BillToAddress}}</span>{{end}}
+{{if .Invoice.BillToEmail}}<br>{{.Invoice.BillToEmail}}{{end}}
+{{if .Invoice.BillToTaxID}}<br>Tax ID: {{.Invoice.BillToTaxID}}{{end}}</p>
+<table class="items">
+<tr><th>Description</th><th class="num">Qty</th><th class="num">Unit price</th><th class="num">Amount</th></tr>
+{{range .Invoice.LineItems}}<tr><td>{{.Description}}</td><td class="num">{{qty .Quantity}}</td><td class="num">{{.UnitPrice.Format}}</td><td class="num">{{.Amount.Format}}</td></tr>
+{{end}}</table>
+<table class="totals">
+<tr><td class="num">Subtotal</td><td class="num">{{.Invoice.Subtotal.Format}}</td></tr>
+{{if .Invoice.TaxRate}}<tr><td class="num">{{.TaxLabel}}</td><td class="num">{{.Invoice.TaxAmount.Format}}</td></tr>{{end}}
+<tr><td class="num"><strong>Total</strong></td><td class="num"><strong>{{.Invoice.Total.Format}} {{.Invoice.Currency}}</strong></td></tr>
+</table>
+{{with .Invoice.Seller}}{{if .PaymentInstructions}}<h3>Payment</h3>
+<p class="pre">{{.PaymentInstructions}}</p>{{end}}{{end}}
+{{if .Invoice.Notes}}<p class="pre">{{.Invoice.Notes}}</p>{{end}}
+</body>
+</html>
+`))
+
+// invoiceView adds the display-only fields the templates need
+type invoiceView struct {
+	Invoice  *models.Invoice
+	Title    string
+	TaxLabel string
+}
+
+func newInvoiceView(invoice *models.Invoice) invoiceView {
+	view := invoiceView{Invoice: invoice, Title: "Invoice " + invoice.InvoiceNumber}
+	if invoice.InvoiceNumber == "" {
+		view.Title = "Draft invoice"
+	}
+	label := invoice.TaxLabel
+	if label == "" {
+		label = "Tax"
+	}
+	view.TaxLabel = fmt.Sprintf("%s (%g%%)", label, invoice.TaxRate)
+	return view
+}
+
+// renderInvoiceHTML renders an invoice as a standalone HTML page
+func renderInvoiceHTML(invoice *models.Invoice) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := invoiceTemplate.Execute(&buf, newInvoiceView(invoice)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// renderInvoicePDF lays out an invoice on A4 pages
+func renderInvoicePDF(invoice *models.Invoice) []byte {
+	const (
+		margin     = 50.0
+		lineHeight = 14.0
+		bodySize   = 10.0
+		descWidth  = 270.0
+	)
+	right := pdf.PageWidth - margin
+	colQty, colUnit := 360.0, 450.0
+	view := newInvoiceView(invoice)
+
+	doc := pdf.New()
+	page := doc.AddPage()
+	y := margin + 20
+
+	newPageIfNeeded := func(needed float64) {
+		if y+needed > pdf.PageHeight-margin {
+			page = doc.AddPage()
+			y = margin + 20
+		}
+	}
+
+	page.Text(margin, y, 20, true, view.Title)
+	if invoice.Status != "sent" {
+		page.TextRight(right, y, 12, true, strings.ToUpper(invoice.Status))
+	}
+	y += 30
+
+	// Seller on the left, dates on the right
+	top := y
+	if seller := invoice.Seller; seller != nil {
+		page.Text(margin, y, bodySize, true, seller.LegalName)
+		y += lineHeight
+		for _, line := range append(strings.Split(seller.Address(), "\n"), seller.Email) {
+			if line != "" {
+				page.Text(margin, y, bodySize, false, line)
+				y += lineHeight
+			}
+		}
+		if seller.TaxID != "" {
+			page.Text(margin, y, bodySize, false, "Tax ID: "+seller.TaxID)
+			y += lineHeight
+		}
+	}
+	dateY := top
+	if invoice.IssueDate != nil {
+		page.TextRight(right, dateY, bodySize, false, "Issued: "+formatInvoiceDate(invoice.IssueDate))
+		dateY += lineHeight
+	}
+	if invoice.DueDate != nil {
+		page.TextRight(right, dateY, bodySize, false, "Due: "+formatInvoiceDate(invoice.DueDate))
+	}
+	y += lineHeight
+
+	page.Text(margin, y, 12, true, "Bill to")
+	y += lineHeight + 2
+	page.Text(margin, y, bodySize, true, invoice.BillToName)
+	y += lineHeight
+	var billTo []string
+	if invoice.BillToContact != "" {
+		billTo = append(billTo, "Attn: "+invoice.BillToContact)
+	}
+	billTo = append(billTo, strings.Split(invoice.BillToAddress, "\n")...)
+	billTo = append(billTo, invoice.BillToEmail)
+	if invoice.BillToTaxID != "" {
+		billTo = append(billTo, "Tax ID: "+invoice.BillToTaxID)
+	}
+	for _, line := range billTo {
+		if line != "" {
+			page.Text(margin, y, bodySize, false, line)
+			y += lineHeight
+		}
+	}
+	y += lineHeight
+
+	header := func() {
+		page.Text(margin, y, bodySize, true, "Description")
+		page.TextRight(colQty, y, bodySize, true, "Qty")
+		page.TextRight(colUnit, y, bodySize, true, "Unit price")
+		page.TextRight(right, y, bodySize, true, "Amount")
+		y += 5
+		page.Line(margin, y, right, y, 0.8)
+		y += lineHeight
+	}
+	header()
+
+	for _, item := range invoice.LineItems {
+		lines := pdf.Wrap(item.Description, bodySize, false, descWidth)
+		if y+float64(len(lines))*lineHeight > pdf.PageHeight-margin {
+			page = doc.AddPage()
+			y = margin + 20
+			header()
+		}
+		page.TextRight(colQty, y, bodySize, false, formatQuantity(item.Quantity))
+		page.TextRight(colUnit, y, bodySize, false, item.UnitPrice.Format())
+		page.TextRight(right, y, bodySize, false, item.Amount.Format())
+		for _, line := range lines {
+			page.Text(margin, y, bodySize, false, line)
+			y += lineHeight
+		}
+		y += 2
+	}
+
+	newPageIfNeeded(4 * lineHeight)
+	page.Line(colQty, y-lineHeight+4, right, y-lineHeight+4, 0.5)
+	y += 4
+	page.TextRight(colUnit, y, bodySize, false, "Subtotal")
+	page.TextRight(right, y, bodySize, false, invoice.Subtotal.Format())
+	y += lineHeight
+	if invoice.TaxRate != 0 {
+		page.TextRight(colUnit, y, bodySize, false, view.TaxLabel)
+		page.TextRight(right, y, bodySize, false, invoice.TaxAmount.Format())
+		y += lineHeight
+	}
+	page.TextRight(colUnit, y, bodySize+1, true, "Total")
+	page.TextRight(right, y, bodySize+1, true, invoice.Total.Format()+" "+invoice.Currency)
+	y += 2 * lineHeight
+
+	paragraph := func(title, text string) {
+		if strings.TrimSpace(text) == "" {
+			return
+		}
+		lines := pdf.Wrap(text, bodySize, false, right-margin)
+		newPageIfNeeded(float64(len(lines)+2) * lineHeight)
+		if title != "" {
+			page.Text(margin, y, 12, true, title)
+			y += lineHeight + 2
+		}
+		for _, line := range lines {
+			page.Text(margin, y, bodySize, false, line)
+			y += lineHeight
+		}
+		y += lineHeight
+	}
+	if invoice.Seller != nil {
+		paragraph("Payment", invoice.Seller.PaymentInstructions)
+	}
+	paragraph("", invoice.Notes)
+
+	return doc.Bytes()
+}
+
+func formatQuantity(q float64) string {
+	return fmt.Sprintf("%g", q)
+}
+
+func formatInvoiceDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("2 Jan 2006")
+}
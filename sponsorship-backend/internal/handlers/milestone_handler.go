@@ -0,0 +1,290 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type MilestoneHandler struct {
+	repo            *repositories.MilestoneRepository
+	sponsorshipRepo *repositories.SponsorshipRepository
+}
+
+// MilestoneRequest gives the amount either directly or as a percentage of the deal amount
+type MilestoneRequest struct {
+	Label   string      `json:"label"`
+	Amount  json.Number `json:"amount"`
+	Percent float64     `json:"percent"`
+	DueDate time.Time   `json:"dueDate"`
+	Status  string      `json:"status"`
+}
+
+type MarkMilestonePaidRequest struct {
+	ReceivedDate *time.Time `json:"receivedDate"`
+}
+
+func NewMilestoneHandler(repo *repositories.MilestoneRepository, sponsorshipRepo *repositories.SponsorshipRepository) *MilestoneHandler {
+	return &MilestoneHandler{repo: repo, sponsorshipRepo: sponsorshipRepo}
+}
+
+// ListMilestones lists a sponsorship's payment schedule
+func (h *MilestoneHandler) ListMilestones(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	milestones, err := h.repo.ListMilestones(id, creatorID)
+	if err != nil {
+		logger.Error("Failed to list payment milestones for sponsorship %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if milestones == nil {
+		milestones = []*models.PaymentMilestone{}
+	}
+
+	api.WriteSuccess(w, http.StatusOK, milestones)
+}
+
+// CreateMilestone adds a line to a sponsorship's payment schedule
+func (h *MilestoneHandler) CreateMilestone(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req MilestoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode create payment milestone request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	sponsorship, err := h.sponsorshipRepo.GetSponsorshipByID(id, creatorID)
+	if err != nil {
+		logger.Warn("Sponsorship not found for payment milestone: ID=%s, Creator=%s", id, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	amount, amountErr := parseAmount(req.Amount, sponsorship.Currency)
+	milestone := &models.PaymentMilestone{
+		SponsorshipID: id,
+		CreatorID:     creatorID,
+		Label:         strings.TrimSpace(req.Label),
+		Amount:        amount,
+		Currency:      sponsorship.Currency,
+		DueDate:       req.DueDate,
+		Status:        "unpaid",
+	}
+	if req.Percent > 0 && amount.IsZero() && amountErr == "" {
+		milestone.Amount = sponsorship.DealAmount.Percent(req.Percent)
+		if milestone.Label == "" {
+			milestone.Label = fmt.Sprintf("%g%% of deal", req.Percent)
+		}
+	}
+
+	details := h.validate(milestone, sponsorship)
+	if amountErr != "" {
+		details["amount"] = amountErr
+	}
+	if len(details) > 0 {
+		logger.Warn("Create payment milestone validation failed for sponsorship %s: %v", id, details)
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(details))
+		return
+	}
+
+	if err := h.repo.CreateMilestone(milestone); err != nil {
+		logger.Error("Failed to create payment milestone for sponsorship %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Payment milestone created: ID=%s, Sponsorship=%s, Amount=%s %s, Due=%s",
+		milestone.ID, id, milestone.Amount, milestone.Currency, milestone.DueDate.Format("2006-01-02"))
+	api.WriteSuccess(w, http.StatusCreated, milestone)
+}
+
+// UpdateMilestone updates the non-empty fields of a payment milestone. Setting the
+// status back to unpaid clears the received date.
+func (h *MilestoneHandler) UpdateMilestone(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	milestoneID := chi.URLParam(r, "milestoneId")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req MilestoneRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode update payment milestone request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	milestone, err := h.repo.GetMilestone(milestoneID, id, creatorID)
+	if err != nil {
+		logger.Warn("Payment milestone not found: ID=%s, Sponsorship=%s", milestoneID, id)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	sponsorship, err := h.sponsorshipRepo.GetSponsorshipByID(id, creatorID)
+	if err != nil {
+		logger.Warn("Sponsorship not found for payment milestone: ID=%s, Creator=%s", id, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	if req.Label != "" {
+		milestone.Label = strings.TrimSpace(req.Label)
+	}
+	if req.Amount != "" {
+		amount, amountErr := parseAmount(req.Amount, milestone.Currency)
+		if amountErr != "" {
+			api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{"amount": amountErr}))
+			return
+		}
+		milestone.Amount = amount
+	} else if req.Percent > 0 {
+		milestone.Amount = sponsorship.DealAmount.Percent(req.Percent)
+	}
+	if !req.DueDate.IsZero() {
+		milestone.DueDate = req.DueDate
+	}
+	switch req.Status {
+	case "":
+	case "unpaid":
+		milestone.Status = "unpaid"
+		milestone.PaidOn = nil
+	default:
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"status": "use the paid endpoint to mark a milestone as paid",
+		}))
+		return
+	}
+
+	if details := h.validate(milestone, sponsorship); len(details) > 0 {
+		logger.Warn("Update payment milestone validation failed for %s: %v", milestoneID, details)
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(details))
+		return
+	}
+
+	h.save(w, milestone)
+}
+
+// MarkMilestonePaid records that a milestone's payment was received
+func (h *MilestoneHandler) MarkMilestonePaid(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	milestoneID := chi.URLParam(r, "milestoneId")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req MarkMilestonePaidRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode mark milestone paid request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	milestone, err := h.repo.GetMilestone(milestoneID, id, creatorID)
+	if err != nil {
+		logger.Warn("Payment milestone not found: ID=%s, Sponsorship=%s", milestoneID, id)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	received := time.Now().Truncate(24 * time.Hour)
+	if req.ReceivedDate != nil {
+		received = *req.ReceivedDate
+	}
+	if received.After(time.Now()) {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"receivedDate": "receivedDate cannot be in the future",
+		}))
+		return
+	}
+
+	if err := h.repo.MarkMilestonePaid(milestone, received); err != nil {
+		logger.Error("Failed to mark payment milestone %s paid: %v", milestone.ID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Payment milestone paid: ID=%s, Sponsorship=%s, PaidOn=%s",
+		milestone.ID, milestone.SponsorshipID, received.Format("2006-01-02"))
+	api.WriteSuccess(w, http.StatusOK, milestone)
+}
+
+// DeleteMilestone removes a line from a sponsorship's payment schedule
+func (h *MilestoneHandler) DeleteMilestone(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	milestoneID := chi.URLParam(r, "milestoneId")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	if err := h.repo.DeleteMilestone(milestoneID, id, creatorID); err != nil {
+		logger.Warn("Failed to delete payment milestone: ID=%s, Sponsorship=%s, Error: %v", milestoneID, id, err)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	logger.Info("Payment milestone deleted: ID=%s, Sponsorship=%s", milestoneID, id)
+	api.WriteSuccess(w, http.StatusOK, map[string]bool{"deleted": true})
+}
+
+// save stores a milestone and writes it as the response. It returns false if it could
+// not be stored.
+func (h *MilestoneHandler) save(w http.ResponseWriter, milestone *models.PaymentMilestone) bool {
+	if err := h.repo.UpdateMilestone(milestone); err != nil {
+		logger.Error("Failed to update payment milestone %s: %v", milestone.ID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return false
+	}
+
+	logger.Info("Payment milestone updated: ID=%s, Sponsorship=%s, Status=%s",
+		milestone.ID, milestone.SponsorshipID, milestone.Status)
+	api.WriteSuccess(w, http.StatusOK, milestone)
+	return true
+}
+
+// validate checks a milestone and that the schedule does not exceed the deal amount
+func (h *MilestoneHandler) validate(milestone *models.PaymentMilestone, sponsorship *models.Sponsorship) map[string]string {
+	details := map[string]string{}
+	if milestone.Label == "" {
+		details["label"] = "label is required"
+	}
+	if !milestone.Amount.IsPositive() {
+		details["amount"] = "amount or percent must be greater than 0"
+	}
+	if milestone.DueDate.IsZero() {
+		details["dueDate"] = "dueDate is required"
+	}
+	if len(details) > 0 {
+		return details
+	}
+
+	existing, err := h.repo.ListMilestones(sponsorship.ID, sponsorship.CreatorID)
+	if err != nil {
+		logger.Error("Failed to load payment schedule of sponsorship %s: %v", sponsorship.ID, err)
+		return details
+	}
+
+	scheduled := milestone.Amount
+	for _, m := range existing {
+		if m.ID != milestone.ID {
+			scheduled = scheduled.Add(m.Amount)
+		}
+	}
+	if scheduled.Cmp(sponsorship.DealAmount) > 0 {
+		details["amount"] = fmt.Sprintf("payment schedule would total %s, more than the deal amount of %s %s",
+			scheduled.Format(), sponsorship.DealAmount.Format(), sponsorship.Currency)
+	}
+
+	return details
+}
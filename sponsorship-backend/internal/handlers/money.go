@@ -0,0 +1,46 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"sponsorship-backend/pkg/money"
+)
+
+// Amounts are stored in DECIMAL(10, 2) columns
+const (
+	amountPrecision = 10
+	amountScale     = 2
+)
+
+// parseAmount reads an amount from a request. Amounts are decoded as json.Number so they
+// are parsed from the exact text the client sent. An empty number is zero. On failure the
+// second result is a message for the field's validation error.
+func parseAmount(n json.Number, currency string) (money.Money, string) {
+	if n == "" {
+		return money.Zero(currency), ""
+	}
+
+	m, err := money.Parse(n.String(), currency)
+	switch err {
+	case nil:
+	case money.ErrUnknownCurrency:
+		return money.Money{}, fmt.Sprintf("unknown currency %q", currency)
+	case money.ErrTooPrecise:
+		return money.Money{}, fmt.Sprintf("%s amounts have at most %d decimal places", currency, money.Decimals(currency))
+	default:
+		return money.Money{}, "must be a decimal number"
+	}
+
+	if !m.Fits(amountPrecision, amountScale) {
+		return money.Money{}, fmt.Sprintf("must be less than %s", maxAmount(currency))
+	}
+	return m, ""
+}
+
+// maxAmount formats the first amount too large for an amount column
+func maxAmount(currency string) string {
+	limit := money.MustParse("1"+strings.Repeat("0", amountPrecision-amountScale), currency)
+	return limit.Format() + " " + currency
+}
@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/money"
+	"sponsorship-backend/pkg/payments"
+)
+
+// PaymentLinkHandler creates hosted checkouts where a brand pays a creator's invoice or
+// milestone by card. The brand is charged, not the logged-in creator.
+type PaymentLinkHandler struct {
+	provider        payments.Provider
+	repo            *repositories.PaymentLinkRepository
+	invoiceRepo     *repositories.InvoiceRepository
+	milestoneRepo   *repositories.MilestoneRepository
+	sponsorshipRepo *repositories.SponsorshipRepository
+	frontendURL     string
+}
+
+func NewPaymentLinkHandler(provider payments.Provider, repo *repositories.PaymentLinkRepository,
+	invoiceRepo *repositories.InvoiceRepository, milestoneRepo *repositories.MilestoneRepository,
+	sponsorshipRepo *repositories.SponsorshipRepository, frontendURL string) *PaymentLinkHandler {
+	return &PaymentLinkHandler{
+		provider:        provider,
+		repo:            repo,
+		invoiceRepo:     invoiceRepo,
+		milestoneRepo:   milestoneRepo,
+		sponsorshipRepo: sponsorshipRepo,
+		frontendURL:     frontendURL,
+	}
+}
+
+// CreateInvoicePaymentLink returns a link where the brand pays a sent invoice's total
+func (h *PaymentLinkHandler) CreateInvoicePaymentLink(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	invoice, err := h.invoiceRepo.GetInvoice(id, creatorID)
+	if err == apierrors.ErrNotFound {
+		logger.Warn("Invoice not found: ID=%s, Creator=%s", id, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to get invoice %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if invoice.Status != "sent" {
+		api.WriteError(w, apierrors.ErrInvalidStateTransition.WithDetails("only sent invoices can be paid online"))
+		return
+	}
+	if !invoice.Total.IsPositive() {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"total": "only invoices with a total above zero can be paid online",
+		}))
+		return
+	}
+
+	link := &models.PaymentLink{
+		CreatorID: creatorID,
+		InvoiceID: invoice.ID,
+		Amount:    invoice.Total,
+		Currency:  invoice.Currency,
+	}
+	h.issue(w, link, invoiceProductName(invoice), invoice.BillToEmail)
+}
+
+// CreateMilestonePaymentLink returns a link where the brand pays an unpaid milestone
+func (h *PaymentLinkHandler) CreateMilestonePaymentLink(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	milestoneID := chi.URLParam(r, "milestoneId")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	milestone, err := h.milestoneRepo.GetMilestone(milestoneID, id, creatorID)
+	if err == apierrors.ErrNotFound {
+		logger.Warn("Payment milestone not found: ID=%s, Sponsorship=%s", milestoneID, id)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to get milestone %s: %v", milestoneID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if milestone.Status != "unpaid" {
+		api.WriteError(w, apierrors.ErrInvalidStateTransition.WithDetails("milestone is already paid"))
+		return
+	}
+
+	sponsorship, err := h.sponsorshipRepo.GetSponsorshipByID(id, creatorID)
+	if err != nil {
+		logger.Error("Failed to get sponsorship %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	link := &models.PaymentLink{
+		CreatorID:   creatorID,
+		MilestoneID: milestone.ID,
+		Amount:      milestone.Amount,
+		Currency:    milestone.Currency,
+	}
+	h.issue(w, link, sponsorship.BrandName+" sponsorship: "+milestone.Label, sponsorship.ContactEmail)
+}
+
+// issue answers with the open link for the same invoice or milestone and amount if there
+// is one, so a link can be fetched again, or else starts a checkout and stores a new link
+func (h *PaymentLinkHandler) issue(w http.ResponseWriter, link *models.PaymentLink, name, email string) {
+	existing, err := h.repo.GetOpenLink(link.CreatorID, link.InvoiceID, link.MilestoneID)
+	if err != nil && err != apierrors.ErrNotFound {
+		logger.Error("Failed to get open payment link: %v", err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+	if existing != nil && sameAmount(existing.Amount, link.Amount) {
+		api.WriteSuccess(w, http.StatusOK, existing)
+		return
+	}
+
+	link.ID = uuid.New().String()
+	metadata := map[string]string{"payment_link_id": link.ID, "creator_id": link.CreatorID}
+	if link.InvoiceID != "" {
+		metadata["invoice_id"] = link.InvoiceID
+	}
+	if link.MilestoneID != "" {
+		metadata["milestone_id"] = link.MilestoneID
+	}
+
+	sess, err := h.provider.CreateCheckoutSession(&payments.CheckoutParams{
+		ProductName:   name,
+		Amount:        link.Amount,
+		CustomerEmail: email,
+		Metadata:      metadata,
+		SuccessURL:    h.frontendURL + "/pay/complete?status=paid",
+		CancelURL:     h.frontendURL + "/pay/complete?status=cancelled",
+	})
+	if err != nil {
+		logger.Error("Failed to create checkout session for payment link: %v", err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	link.ProviderSessionID = sess.ID
+	link.URL = sess.URL
+	link.ExpiresAt = sess.ExpiresAt
+	if err := h.repo.CreateLink(link); err != nil {
+		logger.Error("Failed to store payment link for session %s: %v", sess.ID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Payment link created: ID=%s, Invoice=%s, Milestone=%s, Amount=%s %s",
+		link.ID, link.InvoiceID, link.MilestoneID, link.Amount, link.Currency)
+	api.WriteSuccess(w, http.StatusCreated, link)
+}
+
+func sameAmount(a, b money.Money) bool {
+	return a.Currency() == b.Currency() && a.Cmp(b) == 0
+}
+
+// invoiceProductName is what the brand sees they are paying for an invoice
+func invoiceProductName(invoice *models.Invoice) string {
+	name := "Invoice " + invoice.InvoiceNumber
+	if invoice.Seller != nil && invoice.Seller.LegalName != "" {
+		name += " from " + invoice.Seller.LegalName
+	}
+	return name
+}
@@ -0,0 +1,277 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/mailer"
+	"sponsorship-backend/pkg/money"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// maxPitchBodyBytes caps the size of an unauthenticated pitch submission
+const maxPitchBodyBytes = 64 << 10
+
+// defaultPitchDuration is used when a brand does not propose campaign dates
+const defaultPitchDuration = 30 * 24 * time.Hour
+
+type PublicPitchHandler struct {
+	userRepo       *repositories.UserRepository
+	sponsorships   *SponsorshipHandler
+	mailer         mailer.Mailer
+	requiredFields []string
+}
+
+type PublicPitchRequest struct {
+	BrandName      string      `json:"brandName"`
+	ProductService string      `json:"productService"`
+	Budget         json.Number `json:"budget"`
+	Currency       string      `json:"currency"` // defaults to the creator's base currency
+	ContactName    string      `json:"contactName"`
+	ContactEmail   string      `json:"contactEmail"`
+	ContactPhone   string      `json:"contactPhone"`
+	Description    string      `json:"description"`
+	Deliverables   []string    `json:"deliverables"`
+	TargetAudience string      `json:"targetAudience"`
+	Category       string      `json:"category"`
+	StartDate      time.Time   `json:"startDate"`
+	EndDate        time.Time   `json:"endDate"`
+
+	// Website is a honeypot: the form hides it from people, so only bots fill it in
+	Website string `json:"website"`
+}
+
+type PublicPitchResponse struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+func NewPublicPitchHandler(userRepo *repositories.UserRepository, sponsorships *SponsorshipHandler, m mailer.Mailer, requiredFields []string) *PublicPitchHandler {
+	return &PublicPitchHandler{
+		userRepo:       userRepo,
+		sponsorships:   sponsorships,
+		mailer:         m,
+		requiredFields: requiredFields,
+	}
+}
+
+// SubmitPitch accepts a sponsorship pitch from a brand for the creator with the given handle
+func (h *PublicPitchHandler) SubmitPitch(w http.ResponseWriter, r *http.Request) {
+	handle := chi.URLParam(r, "handle")
+
+	var req PublicPitchRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxPitchBodyBytes)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Warn("Failed to decode public pitch for %s: %v", handle, err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	if req.Website != "" {
+		// Answer exactly as for a real pitch so bots get no signal
+		logger.Warn("Public pitch honeypot triggered for %s from %s", handle, r.RemoteAddr)
+		api.WriteSuccess(w, http.StatusAccepted, PublicPitchResponse{ID: uuid.New().String(), Status: "received"})
+		return
+	}
+
+	if details := h.validate(&req); len(details) > 0 {
+		logger.Warn("Public pitch validation failed for %s: %v", handle, details)
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(details))
+		return
+	}
+
+	creator, err := h.userRepo.GetUserByUsername(handle)
+	if err != nil {
+		logger.Warn("Public pitch for unknown creator handle %s", handle)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	// validate has checked the currency; the budget is checked again in the currency it ends up in
+	currency := strings.ToUpper(req.Currency)
+	if currency == "" {
+		if currency, err = h.sponsorships.baseCurrency(creator.ID); err != nil {
+			logger.Error("Failed to get base currency for creator %s: %v", creator.ID, err)
+			api.WriteError(w, apierrors.ErrInternalError)
+			return
+		}
+	}
+	budget, budgetErr := parseAmount(req.Budget, currency)
+	if budgetErr != "" {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{"budget": "budget " + budgetErr}))
+		return
+	}
+
+	startDate := req.StartDate
+	if startDate.IsZero() {
+		startDate = time.Now().Truncate(24 * time.Hour)
+	}
+	endDate := req.EndDate
+	if endDate.IsZero() {
+		endDate = startDate.Add(defaultPitchDuration)
+	}
+
+	// Only the address itself is kept: a display name would be echoed into every email to it
+	var contactEmail string
+	if req.ContactEmail != "" {
+		addr, err := mail.ParseAddress(req.ContactEmail)
+		if err != nil {
+			api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+				"contactEmail": "contactEmail must be a valid email address",
+			}))
+			return
+		}
+		contactEmail = addr.Address
+	}
+
+	sponsorship := &models.Sponsorship{
+		ID:             uuid.New().String(),
+		CreatorID:      creator.ID,
+		BrandName:      strings.TrimSpace(req.BrandName),
+		ProductService: req.ProductService,
+		DealAmount:     budget,
+		Currency:       currency,
+		Priority:       "medium",
+		ContactName:    req.ContactName,
+		ContactEmail:   contactEmail,
+		ContactPhone:   req.ContactPhone,
+		Description:    req.Description,
+		Deliverables:   req.Deliverables,
+		TargetAudience: req.TargetAudience,
+		Category:       req.Category,
+		StartDate:      startDate,
+		EndDate:        endDate,
+		Status:         "pitch-received",
+		CreatedAt:      time.Now(),
+		UpdatedAt:      time.Now(),
+	}
+	if sponsorship.Deliverables == nil {
+		sponsorship.Deliverables = []string{}
+	}
+
+	logger.Debug("Creating public pitch: Brand=%s, Creator=%s", sponsorship.BrandName, creator.ID)
+
+	_, err = h.sponsorships.createPitch(sponsorship, "", "Submitted via public pitch form")
+	if errors.Is(err, apierrors.ErrBlocklisted) {
+		// Blocked brands get the same answer as everyone else
+		logger.Info("Public pitch from blocklisted brand %s discarded for creator %s", sponsorship.BrandName, creator.ID)
+		api.WriteSuccess(w, http.StatusAccepted, PublicPitchResponse{ID: sponsorship.ID, Status: "received"})
+		return
+	}
+	if errors.Is(err, apierrors.ErrPlanLimitReached) {
+		// The sender is told no more than that the creator is not taking pitches
+		logger.Warn("Public pitch from %s rejected: creator %s is at the plan's active deal limit",
+			sponsorship.BrandName, creator.ID)
+		api.WriteError(w, apierrors.ErrNotAcceptingPitches)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to create public pitch for creator %s: %v", creator.ID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	// Pitches declined by a triage rule get the rule's reply instead of an acknowledgement
+	if sponsorship.ContactEmail != "" && sponsorship.Status != "declined" {
+		go h.sendAcknowledgement(creator, sponsorship)
+	}
+
+	logger.Info("Public pitch received: ID=%s, Brand=%s, Creator=%s", sponsorship.ID, sponsorship.BrandName, creator.ID)
+	api.WriteSuccess(w, http.StatusAccepted, PublicPitchResponse{ID: sponsorship.ID, Status: "received"})
+}
+
+// validate checks the configured required fields and the format of the values supplied
+func (h *PublicPitchHandler) validate(req *PublicPitchRequest) map[string]string {
+	values := map[string]string{
+		"brandName":      req.BrandName,
+		"productService": req.ProductService,
+		"contactName":    req.ContactName,
+		"contactEmail":   req.ContactEmail,
+		"contactPhone":   req.ContactPhone,
+		"description":    req.Description,
+		"targetAudience": req.TargetAudience,
+		"category":       req.Category,
+	}
+	currency := strings.ToUpper(req.Currency)
+	if currency == "" {
+		currency = money.DefaultCurrency
+	}
+	budget, budgetErr := parseAmount(req.Budget, currency)
+	if budget.IsPositive() {
+		values["budget"] = budget.String()
+	}
+	if len(req.Deliverables) > 0 {
+		values["deliverables"] = strings.Join(req.Deliverables, ",")
+	}
+	if !req.StartDate.IsZero() {
+		values["startDate"] = req.StartDate.String()
+	}
+	if !req.EndDate.IsZero() {
+		values["endDate"] = req.EndDate.String()
+	}
+
+	details := map[string]string{}
+	for _, field := range h.requiredFields {
+		if strings.TrimSpace(values[field]) == "" {
+			details[field] = fmt.Sprintf("%s is required", field)
+		}
+	}
+
+	// The deal needs a brand name regardless of form configuration
+	if strings.TrimSpace(req.BrandName) == "" {
+		details["brandName"] = "brandName is required"
+	}
+	if req.ContactEmail != "" {
+		if _, err := mail.ParseAddress(req.ContactEmail); err != nil {
+			details["contactEmail"] = "contactEmail must be a valid email address"
+		}
+	}
+	if !money.ValidCurrency(currency) {
+		details["currency"] = "currency must be a supported ISO 4217 currency code"
+	} else if budgetErr != "" {
+		details["budget"] = "budget " + budgetErr
+	} else if budget.IsNegative() {
+		details["budget"] = "budget cannot be negative"
+	}
+	if !req.StartDate.IsZero() && !req.EndDate.IsZero() && req.EndDate.Before(req.StartDate) {
+		details["endDate"] = "endDate must not be before startDate"
+	}
+
+	return details
+}
+
+// sendAcknowledgement emails the brand contact to confirm the pitch arrived. Anyone can
+// submit the form with any contact address, and register any username to submit it to, so
+// the email is a fixed text that repeats nothing either of them chose; otherwise the form
+// would relay their message to whoever they name.
+func (h *PublicPitchHandler) sendAcknowledgement(creator *models.User, sponsorship *models.Sponsorship) {
+	msg := &mailer.Message{
+		To:      []string{sponsorship.ContactEmail},
+		ReplyTo: creator.Email,
+		Subject: "We received your sponsorship pitch",
+		Body: "Hi,\n\n" +
+			"Thanks for reaching out. Your sponsorship pitch has been received and will be reviewed " +
+			"shortly. We'll get back to you at this address.\n\n" +
+			"Reference: " + sponsorship.ID + "\n",
+	}
+
+	if err := h.mailer.Send(msg); err != nil {
+		logger.Error("Failed to send pitch acknowledgement for sponsorship %s: %v", sponsorship.ID, err)
+		return
+	}
+
+	logger.Debug("Pitch acknowledgement sent for sponsorship %s", sponsorship.ID)
+}
@@ -0,0 +1,556 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/money"
+)
+
+// agingBuckets are the days-overdue ranges of the receivables aging report
+var agingBuckets = []struct {
+	label   string
+	minDays int
+	maxDays int // 0 for open-ended
+}{
+	{"1-30", 1, 30},
+	{"31-60", 31, 60},
+	{"61-90", 61, 90},
+	{"90+", 91, 0},
+}
+
+type ReportHandler struct {
+	milestoneRepo   *repositories.MilestoneRepository
+	incomeRepo      *repositories.IncomeRepository
+	sponsorshipRepo *repositories.SponsorshipRepository
+	expenseRepo     *repositories.ExpenseRepository
+	settingsRepo    *repositories.SettingsRepository
+	fxRepo          *repositories.FXRepository
+}
+
+func NewReportHandler(milestoneRepo *repositories.MilestoneRepository, incomeRepo *repositories.IncomeRepository,
+	sponsorshipRepo *repositories.SponsorshipRepository, expenseRepo *repositories.ExpenseRepository,
+	settingsRepo *repositories.SettingsRepository, fxRepo *repositories.FXRepository) *ReportHandler {
+	return &ReportHandler{
+		milestoneRepo:   milestoneRepo,
+		incomeRepo:      incomeRepo,
+		sponsorshipRepo: sponsorshipRepo,
+		expenseRepo:     expenseRepo,
+		settingsRepo:    settingsRepo,
+		fxRepo:          fxRepo,
+	}
+}
+
+// GetReceivablesAging groups the creator's unpaid milestones by how long they are overdue.
+// The report is as of today unless an asOf date (YYYY-MM-DD) is given, and totals are in the
+// creator's base currency.
+func (h *ReportHandler) GetReceivablesAging(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	now := time.Now().UTC()
+	asOf := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if v := r.URL.Query().Get("asOf"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+				"asOf": "asOf must be a date in YYYY-MM-DD format",
+			}))
+			return
+		}
+		asOf = parsed
+	}
+
+	receivables, err := h.milestoneRepo.ListUnpaidReceivables(creatorID)
+	if err != nil {
+		logger.Error("Failed to load receivables for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	converter, err := newCreatorConverter(h.settingsRepo, h.fxRepo, creatorID)
+	if err != nil {
+		logger.Error("Failed to get base currency for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	report, err := buildReceivablesAging(receivables, asOf, converter)
+	if err != nil {
+		logger.Error("Failed to convert receivables for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, report)
+}
+
+// buildReceivablesAging totals receivables in the converter's currency. Each is converted
+// at its deal's contract date; receivables with no rate are listed but not totalled.
+func buildReceivablesAging(receivables []*models.Receivable, asOf time.Time,
+	converter *currencyConverter) (*models.ReceivablesAging, error) {
+	currency := converter.base
+	report := &models.ReceivablesAging{
+		AsOf:         asOf,
+		Currency:     currency,
+		Current:      money.Zero(currency),
+		TotalOverdue: money.Zero(currency),
+		Buckets:      make([]models.AgingBucket, len(agingBuckets)),
+		Overdue:      []*models.Receivable{},
+	}
+	for i, b := range agingBuckets {
+		report.Buckets[i] = models.AgingBucket{Label: b.label, MinDays: b.minDays, Total: money.Zero(currency)}
+		if b.maxDays > 0 {
+			maxDays := b.maxDays
+			report.Buckets[i].MaxDays = &maxDays
+		}
+	}
+
+	for _, rec := range receivables {
+		due := time.Date(rec.DueDate.Year(), rec.DueDate.Month(), rec.DueDate.Day(), 0, 0, 0, 0, time.UTC)
+		rec.DaysOverdue = int(asOf.Sub(due).Hours() / 24)
+
+		amount, ok, err := converter.convert(rec.Amount, rec.ContractedAt)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			rec.BaseAmount = &amount
+		}
+
+		if rec.DaysOverdue <= 0 {
+			rec.DaysOverdue = 0
+			if ok {
+				report.Current = report.Current.Add(amount)
+			}
+			continue
+		}
+
+		report.Overdue = append(report.Overdue, rec)
+		if !ok {
+			continue
+		}
+		report.TotalOverdue = report.TotalOverdue.Add(amount)
+		for i, b := range agingBuckets {
+			if rec.DaysOverdue >= b.minDays && (b.maxDays == 0 || rec.DaysOverdue <= b.maxDays) {
+				report.Buckets[i].Count++
+				report.Buckets[i].Total = report.Buckets[i].Total.Add(amount)
+				break
+			}
+		}
+	}
+	report.MissingRates = converter.missingRates()
+
+	return report, nil
+}
+
+// GetDealProfitability reports gross, expenses and net profit for every won deal and every
+// deal with expenses. Gross is the deal amount of a won deal, converted at its contract
+// date; expenses are converted at their own date.
+func (h *ReportHandler) GetDealProfitability(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	deals, err := h.sponsorshipRepo.ListDealValues(creatorID, "")
+	if err != nil {
+		logger.Error("Failed to load deals for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	expenses, err := h.expenseRepo.ListCreatorExpenses(creatorID, nil, nil)
+	if err != nil {
+		logger.Error("Failed to load expenses for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	converter, err := newCreatorConverter(h.settingsRepo, h.fxRepo, creatorID)
+	if err != nil {
+		logger.Error("Failed to get base currency for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	report, err := buildDealProfitability(deals, expenses, converter)
+	if err != nil {
+		logger.Error("Failed to convert deal profitability for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, report)
+}
+
+func buildDealProfitability(deals []*models.DealValue, expenses []*models.Expense,
+	converter *currencyConverter) (*models.ProfitabilityByDeal, error) {
+	report := &models.ProfitabilityByDeal{
+		Currency: converter.base,
+		Deals:    []*models.DealProfit{},
+		Total:    zeroProfit(converter.base),
+	}
+
+	byID := map[string]*models.DealProfit{}
+	for _, d := range deals {
+		byID[d.SponsorshipID] = &models.DealProfit{
+			SponsorshipID: d.SponsorshipID,
+			BrandName:     d.BrandName,
+			Status:        d.Status,
+			ContractedAt:  d.ContractedAt,
+			ProfitTotals:  zeroProfit(converter.base),
+		}
+	}
+
+	included := map[string]bool{}
+	for _, d := range deals {
+		if !contains(models.WonStatuses, d.Status) {
+			continue
+		}
+		included[d.SponsorshipID] = true
+		gross, ok, err := converter.convert(d.Amount, d.ContractedAt)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			byID[d.SponsorshipID].Gross = gross
+		}
+	}
+	for _, e := range expenses {
+		deal, found := byID[e.SponsorshipID]
+		if !found {
+			continue
+		}
+		included[e.SponsorshipID] = true
+		amount, ok, err := converter.convert(e.Amount, &e.ExpenseDate)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			deal.Expenses = deal.Expenses.Add(amount)
+		}
+	}
+
+	for _, d := range deals {
+		if !included[d.SponsorshipID] {
+			continue
+		}
+		deal := byID[d.SponsorshipID]
+		deal.Net = deal.Gross.Sub(deal.Expenses)
+		report.Deals = append(report.Deals, deal)
+		addProfit(&report.Total, deal.ProfitTotals)
+	}
+	report.MissingRates = converter.missingRates()
+
+	return report, nil
+}
+
+// GetMonthlyProfitability reports gross, expenses and net profit for each month of a year
+// (default the current year). A won deal's amount counts in the month it was contracted
+// and each expense in the month it was incurred.
+func (h *ReportHandler) GetMonthlyProfitability(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	year, ok := reportYear(w, r)
+	if !ok {
+		return
+	}
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+
+	deals, err := h.sponsorshipRepo.ListDealValues(creatorID, "")
+	if err != nil {
+		logger.Error("Failed to load deals for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	expenses, err := h.expenseRepo.ListCreatorExpenses(creatorID, &from, &to)
+	if err != nil {
+		logger.Error("Failed to load expenses for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	converter, err := newCreatorConverter(h.settingsRepo, h.fxRepo, creatorID)
+	if err != nil {
+		logger.Error("Failed to get base currency for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	report, err := buildMonthlyProfitability(year, deals, expenses, converter)
+	if err != nil {
+		logger.Error("Failed to convert monthly profitability for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, report)
+}
+
+func buildMonthlyProfitability(year int, deals []*models.DealValue, expenses []*models.Expense,
+	converter *currencyConverter) (*models.ProfitabilityByMonth, error) {
+	report := &models.ProfitabilityByMonth{
+		Year:     year,
+		Currency: converter.base,
+		Months:   make([]models.MonthProfit, 12),
+		Total:    zeroProfit(converter.base),
+	}
+	for i := range report.Months {
+		month := time.Date(year, time.Month(i+1), 1, 0, 0, 0, 0, time.UTC)
+		report.Months[i] = models.MonthProfit{Month: month.Format("2006-01"), ProfitTotals: zeroProfit(converter.base)}
+	}
+
+	for _, d := range deals {
+		if !contains(models.WonStatuses, d.Status) || d.ContractedAt == nil || d.ContractedAt.UTC().Year() != year {
+			continue
+		}
+		gross, ok, err := converter.convert(d.Amount, d.ContractedAt)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			month := &report.Months[d.ContractedAt.UTC().Month()-1]
+			month.Gross = month.Gross.Add(gross)
+		}
+	}
+	for _, e := range expenses {
+		amount, ok, err := converter.convert(e.Amount, &e.ExpenseDate)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			month := &report.Months[e.ExpenseDate.Month()-1]
+			month.Expenses = month.Expenses.Add(amount)
+		}
+	}
+
+	for i := range report.Months {
+		month := &report.Months[i]
+		month.Net = month.Gross.Sub(month.Expenses)
+		addProfit(&report.Total, month.ProfitTotals)
+	}
+	report.MissingRates = converter.missingRates()
+
+	return report, nil
+}
+
+// GetTaxSummary totals a tax year's income (default the current year) by brand, payer
+// country and month, with commissions and expenses, in the creator's base currency.
+// Income is converted at the rate of the day it was received. Pass format=csv or
+// format=pdf to download the report.
+func (h *ReportHandler) GetTaxSummary(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	year, ok := reportYear(w, r)
+	if !ok {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "json" && format != "csv" && format != "pdf" {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"format": "format must be json, csv or pdf",
+		}))
+		return
+	}
+	from := time.Date(year, time.January, 1, 0, 0, 0, 0, time.UTC)
+	to := from.AddDate(1, 0, 0)
+
+	income, err := h.incomeRepo.ListIncome(creatorID, from, to)
+	if err != nil {
+		logger.Error("Failed to load income for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	expenses, err := h.expenseRepo.ListCreatorExpenses(creatorID, &from, &to)
+	if err != nil {
+		logger.Error("Failed to load expenses for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	deals, err := h.sponsorshipRepo.ListDealValues(creatorID, "")
+	if err != nil {
+		logger.Error("Failed to load deals for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	converter, err := newCreatorConverter(h.settingsRepo, h.fxRepo, creatorID)
+	if err != nil {
+		logger.Error("Failed to get base currency for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	summary, err := buildTaxSummary(year, income, expenses, deals, converter)
+	if err != nil {
+		logger.Error("Failed to convert tax summary for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	fileName := fmt.Sprintf("tax-summary-%d", year)
+	switch format {
+	case "csv":
+		body, err := renderTaxSummaryCSV(summary)
+		if err != nil {
+			logger.Error("Failed to render tax summary CSV for creator %s: %v", creatorID, err)
+			api.WriteError(w, apierrors.ErrInternalError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName+".csv"))
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	case "pdf":
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName+".pdf"))
+		w.WriteHeader(http.StatusOK)
+		w.Write(renderTaxSummaryPDF(summary))
+	default:
+		api.WriteSuccess(w, http.StatusOK, summary)
+	}
+}
+
+// buildTaxSummary totals income and its commission, and expenses, per brand, country and
+// month. Expenses are attributed to the brand and country of their deal.
+func buildTaxSummary(year int, income []*models.IncomeEntry, expenses []*models.Expense,
+	deals []*models.DealValue, converter *currencyConverter) (*models.TaxSummary, error) {
+	currency := converter.base
+	newLine := func(label string) *models.TaxSummaryLine {
+		return &models.TaxSummaryLine{
+			Label:       label,
+			Income:      money.Zero(currency),
+			Commissions: money.Zero(currency),
+			Expenses:    money.Zero(currency),
+			Net:         money.Zero(currency),
+		}
+	}
+
+	total := newLine("Total")
+	months := make([]*models.TaxSummaryLine, 12)
+	for i := range months {
+		months[i] = newLine(time.Date(year, time.Month(i+1), 1, 0, 0, 0, 0, time.UTC).Format("2006-01"))
+	}
+	brands := map[string]*models.TaxSummaryLine{}
+	countries := map[string]*models.TaxSummaryLine{}
+	lines := func(brand, country string, month time.Month) []*models.TaxSummaryLine {
+		if country == "" {
+			country = "Unknown"
+		}
+		if brands[brand] == nil {
+			brands[brand] = newLine(brand)
+		}
+		if countries[country] == nil {
+			countries[country] = newLine(country)
+		}
+		return []*models.TaxSummaryLine{total, months[month-1], brands[brand], countries[country]}
+	}
+
+	for _, e := range income {
+		amount, ok, err := converter.convert(e.Amount, &e.ReceivedOn)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		commission := money.Zero(currency)
+		if e.CommissionRate != nil {
+			commission = amount.Percent(*e.CommissionRate)
+		}
+		for _, line := range lines(e.BrandName, e.Country, e.ReceivedOn.Month()) {
+			line.Income = line.Income.Add(amount)
+			line.Commissions = line.Commissions.Add(commission)
+		}
+	}
+
+	dealsByID := make(map[string]*models.DealValue, len(deals))
+	for _, d := range deals {
+		dealsByID[d.SponsorshipID] = d
+	}
+	for _, e := range expenses {
+		deal := dealsByID[e.SponsorshipID]
+		if deal == nil {
+			continue
+		}
+		amount, ok, err := converter.convert(e.Amount, &e.ExpenseDate)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		for _, line := range lines(deal.BrandName, deal.Country, e.ExpenseDate.Month()) {
+			line.Expenses = line.Expenses.Add(amount)
+		}
+	}
+
+	summary := &models.TaxSummary{
+		Year:         year,
+		Currency:     currency,
+		ByBrand:      sortedTaxLines(brands),
+		ByCountry:    sortedTaxLines(countries),
+		ByMonth:      make([]models.TaxSummaryLine, 0, len(months)),
+		MissingRates: converter.missingRates(),
+	}
+	for _, line := range months {
+		line.Net = line.Income.Sub(line.Commissions).Sub(line.Expenses)
+		summary.ByMonth = append(summary.ByMonth, *line)
+	}
+	total.Net = total.Income.Sub(total.Commissions).Sub(total.Expenses)
+	summary.Total = *total
+
+	return summary, nil
+}
+
+// sortedTaxLines lists lines by income, largest first, then by label
+func sortedTaxLines(lines map[string]*models.TaxSummaryLine) []models.TaxSummaryLine {
+	sorted := make([]models.TaxSummaryLine, 0, len(lines))
+	for _, line := range lines {
+		line.Net = line.Income.Sub(line.Commissions).Sub(line.Expenses)
+		sorted = append(sorted, *line)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if c := sorted[i].Income.Cmp(sorted[j].Income); c != 0 {
+			return c > 0
+		}
+		return sorted[i].Label < sorted[j].Label
+	})
+	return sorted
+}
+
+// reportYear reads the year query parameter, defaulting to the current year. It writes a
+// validation error and returns false if the year is malformed.
+func reportYear(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("year")
+	if v == "" {
+		return time.Now().UTC().Year(), true
+	}
+	parsed, err := time.Parse("2006", v)
+	if err != nil {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"year": "year must be a four-digit year",
+		}))
+		return 0, false
+	}
+	return parsed.Year(), true
+}
+
+func zeroProfit(currency string) models.ProfitTotals {
+	return models.ProfitTotals{Gross: money.Zero(currency), Expenses: money.Zero(currency), Net: money.Zero(currency)}
+}
+
+func addProfit(total *models.ProfitTotals, p models.ProfitTotals) {
+	total.Gross = total.Gross.Add(p.Gross)
+	total.Expenses = total.Expenses.Add(p.Expenses)
+	total.Net = total.Net.Add(p.Net)
+}
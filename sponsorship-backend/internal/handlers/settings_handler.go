@@ -0,0 +1,111 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/money"
+)
+
+type SettingsHandler struct {
+	repo   *repositories.SettingsRepository
+	fxRepo *repositories.FXRepository
+}
+
+type SettingsRequest struct {
+	BaseCurrency string `json:"baseCurrency"`
+}
+
+func NewSettingsHandler(repo *repositories.SettingsRepository, fxRepo *repositories.FXRepository) *SettingsHandler {
+	return &SettingsHandler{repo: repo, fxRepo: fxRepo}
+}
+
+// GetSettings returns the creator's settings
+func (h *SettingsHandler) GetSettings(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	settings, err := h.repo.GetSettings(creatorID)
+	if err != nil {
+		logger.Error("Failed to get settings for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, settings)
+}
+
+// SaveSettings updates the creator's settings
+func (h *SettingsHandler) SaveSettings(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req SettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode settings request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	settings, err := h.repo.GetSettings(creatorID)
+	if err != nil {
+		logger.Error("Failed to get settings for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if req.BaseCurrency != "" {
+		currency := strings.ToUpper(req.BaseCurrency)
+		if !money.ValidCurrency(currency) {
+			api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+				"baseCurrency": "baseCurrency must be a supported ISO 4217 currency code",
+			}))
+			return
+		}
+		settings.BaseCurrency = currency
+	}
+
+	if err := h.repo.SaveSettings(settings); err != nil {
+		logger.Error("Failed to save settings for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Settings saved for creator %s: BaseCurrency=%s", creatorID, settings.BaseCurrency)
+	api.WriteSuccess(w, http.StatusOK, settings)
+}
+
+// ListFXRates lists the latest exchange rate of every currency pair as of a date
+// (YYYY-MM-DD, default today)
+func (h *SettingsHandler) ListFXRates(w http.ResponseWriter, r *http.Request) {
+	asOf := time.Now().UTC()
+	if v := r.URL.Query().Get("date"); v != "" {
+		parsed, err := time.Parse("2006-01-02", v)
+		if err != nil {
+			api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+				"date": "date must be in YYYY-MM-DD format",
+			}))
+			return
+		}
+		asOf = parsed
+	}
+
+	rates, err := h.fxRepo.LatestRates(asOf)
+	if err != nil {
+		logger.Error("Failed to list fx rates as of %s: %v", asOf.Format("2006-01-02"), err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if rates == nil {
+		rates = []*models.FXRate{}
+	}
+
+	api.WriteSuccess(w, http.StatusOK, rates)
+}
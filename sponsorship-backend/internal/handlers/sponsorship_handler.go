@@ -2,8 +2,11 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"sponsorship-backend/internal/api"
@@ -11,42 +14,111 @@ import (
 	"sponsorship-backend/internal/repositories"
 
 	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/fx"
 	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/mailer"
+	"sponsorship-backend/pkg/money"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
 )
 
+const commissionRateError = "commissionRate must be a percentage between 0 and 100"
+
+// activeDealLimit enforces the plan's limit on active deals, as EntitlementMiddleware does
+type activeDealLimit interface {
+	CheckActiveDeals(userID, creatorID string) error
+}
+
+// brandResolver finds or creates the creator's brand record for a brand name
+type brandResolver interface {
+	FindOrCreateBrand(creatorID, name, normalizedName string) (*models.Brand, error)
+}
+
+// triageRuleLister lists the triage rules run on a creator's incoming pitches
+type triageRuleLister interface {
+	ListRules(creatorID string) ([]*models.TriageRule, error)
+}
+
+// blocklistMatcher finds the creator's blocklist entries that a deal matches
+type blocklistMatcher interface {
+	FindMatches(creatorID, normalizedBrand, normalizedCategory string) ([]*models.BlocklistEntry, error)
+}
+
+// exclusivityFinder finds the exclusivity windows a deal would breach
+type exclusivityFinder interface {
+	FindConflicts(sponsorship *models.Sponsorship) ([]*models.ExclusivityConflict, error)
+}
+
 type SponsorshipHandler struct {
-	repo *repositories.SponsorshipRepository
+	repo            *repositories.SponsorshipRepository
+	brandRepo       brandResolver
+	ruleRepo        triageRuleLister
+	exclusivityRepo exclusivityFinder
+	blocklistRepo   blocklistMatcher
+	deliverableRepo *repositories.DeliverableRepository
+	settingsRepo    *repositories.SettingsRepository
+	fxRepo          rateSource
+	dealLimit       activeDealLimit
+	mailer          mailer.Mailer
 }
 
 type CreateSponsorshipRequest struct {
-	BrandName      string    `json:"brandName"`
-	ProductService string    `json:"productService"`
-	DealAmount     float64   `json:"dealAmount"`
-	Priority       string    `json:"priority"`
-	ContactName    string    `json:"contactName"`
-	ContactEmail   string    `json:"contactEmail"`
-	ContactPhone   string    `json:"contactPhone"`
-	Description    string    `json:"description"`
-	Deliverables   []string  `json:"deliverables"`
-	TargetAudience string    `json:"targetAudience"`
-	StartDate      time.Time `json:"startDate"`
-	EndDate        time.Time `json:"endDate"`
-	Status         string    `json:"status"`
+	BrandName      string      `json:"brandName"`
+	ProductService string      `json:"productService"`
+	DealAmount     json.Number `json:"dealAmount"`
+	Currency       string      `json:"currency"`
+	Priority       string      `json:"priority"`
+	ContactName    string      `json:"contactName"`
+	ContactEmail   string      `json:"contactEmail"`
+	ContactPhone   string      `json:"contactPhone"`
+	Description    string      `json:"description"`
+	Deliverables   []string    `json:"deliverables"`
+	TargetAudience string      `json:"targetAudience"`
+	Category       string      `json:"category"`
+	RevisionLimit  *int        `json:"revisionLimit"`
+	CommissionRate *float64    `json:"commissionRate"`
+	StartDate      time.Time   `json:"startDate"`
+	EndDate        time.Time   `json:"endDate"`
+	Status         string      `json:"status"`
+}
+
+type CreateSponsorshipResponse struct {
+	*models.Sponsorship
+	Warnings []SponsorshipWarning `json:"warnings,omitempty"`
+}
+
+type MergeSponsorshipRequest struct {
+	DuplicateID string `json:"duplicateId"`
 }
 
 type DashboardStats struct {
-	ActiveDeals       int     `json:"activeDeals"`
-	PendingApproval   int     `json:"pendingApproval"`
-	CompletedDeals    int     `json:"completedDeals"`
-	PipelineValue     float64 `json:"pipelineValue"`
-	AverageDealAmount float64 `json:"averageDealAmount"`
+	ActiveDeals       int         `json:"activeDeals"`
+	PendingApproval   int         `json:"pendingApproval"`
+	CompletedDeals    int         `json:"completedDeals"`
+	Currency          string      `json:"currency"`
+	PipelineValue     money.Money `json:"pipelineValue"`
+	AverageDealAmount money.Money `json:"averageDealAmount"`
+	MissingRates      []string    `json:"missingRates,omitempty"` // deals left out for want of a rate
 }
 
-func NewSponsorshipHandler(repo *repositories.SponsorshipRepository) *SponsorshipHandler {
-	return &SponsorshipHandler{repo: repo}
+func NewSponsorshipHandler(repo *repositories.SponsorshipRepository, brandRepo brandResolver,
+	ruleRepo triageRuleLister, exclusivityRepo exclusivityFinder,
+	blocklistRepo blocklistMatcher, deliverableRepo *repositories.DeliverableRepository,
+	settingsRepo *repositories.SettingsRepository, fxRepo rateSource, dealLimit activeDealLimit,
+	m mailer.Mailer) *SponsorshipHandler {
+	return &SponsorshipHandler{
+		repo:            repo,
+		brandRepo:       brandRepo,
+		ruleRepo:        ruleRepo,
+		exclusivityRepo: exclusivityRepo,
+		blocklistRepo:   blocklistRepo,
+		deliverableRepo: deliverableRepo,
+		settingsRepo:    settingsRepo,
+		fxRepo:          fxRepo,
+		dealLimit:       dealLimit,
+		mailer:          m,
+	}
 }
 
 // ListSponsorships lists all sponsorships for the creator
@@ -94,16 +166,6 @@ func (h *SponsorshipHandler) CreateSponsorship(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// Validate required fields
-	if req.BrandName == "" || req.DealAmount <= 0 {
-		logger.Warn("Create sponsorship validation failed: invalid brand name or deal amount")
-		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
-			"brandName":  "Brand name is required",
-			"dealAmount": "Deal amount must be greater than 0",
-		}))
-		return
-	}
-
 	creatorID := r.Header.Get("X-Creator-ID")
 	if creatorID == "" {
 		logger.Warn("Create sponsorship failed: missing creator ID")
@@ -111,12 +173,50 @@ func (h *SponsorshipHandler) CreateSponsorship(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	// Deals are in the creator's base currency unless another is given
+	currency := strings.ToUpper(req.Currency)
+	if currency == "" {
+		var err error
+		if currency, err = h.baseCurrency(creatorID); err != nil {
+			logger.Error("Failed to get base currency for creator %s: %v", creatorID, err)
+			api.WriteError(w, apierrors.ErrInternalError)
+			return
+		}
+	}
+
+	// Validate required fields
+	details := map[string]string{}
+	if req.BrandName == "" {
+		details["brandName"] = "Brand name is required"
+	}
+	if !money.ValidCurrency(currency) {
+		details["currency"] = "currency must be a supported ISO 4217 currency code"
+	} else {
+		dealAmount, amountErr := parseAmount(req.DealAmount, currency)
+		if amountErr == "" && !dealAmount.IsPositive() {
+			amountErr = "Deal amount must be greater than 0"
+		}
+		if amountErr != "" {
+			details["dealAmount"] = amountErr
+		}
+	}
+	if req.CommissionRate != nil && (*req.CommissionRate < 0 || *req.CommissionRate > 100) {
+		details["commissionRate"] = commissionRateError
+	}
+	if len(details) > 0 {
+		logger.Warn("Create sponsorship validation failed: %v", details)
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(details))
+		return
+	}
+	dealAmount, _ := parseAmount(req.DealAmount, currency)
+
 	sponsorship := &models.Sponsorship{
 		ID:             uuid.New().String(),
 		CreatorID:      creatorID,
 		BrandName:      req.BrandName,
 		ProductService: req.ProductService,
-		DealAmount:     req.DealAmount,
+		DealAmount:     dealAmount,
+		Currency:       currency,
 		Priority:       req.Priority,
 		ContactName:    req.ContactName,
 		ContactEmail:   req.ContactEmail,
@@ -124,6 +224,8 @@ func (h *SponsorshipHandler) CreateSponsorship(w http.ResponseWriter, r *http.Re
 		Description:    req.Description,
 		Deliverables:   req.Deliverables,
 		TargetAudience: req.TargetAudience,
+		Category:       req.Category,
+		CommissionRate: req.CommissionRate,
 		StartDate:      req.StartDate,
 		EndDate:        req.EndDate,
 		Status:         "pitch-received",
@@ -131,18 +233,34 @@ func (h *SponsorshipHandler) CreateSponsorship(w http.ResponseWriter, r *http.Re
 		UpdatedAt:      time.Now(),
 	}
 
-	logger.Debug("Creating sponsorship: ID=%s, Brand=%s, Amount=%.2f, Creator=%s",
-		sponsorship.ID, sponsorship.BrandName, sponsorship.DealAmount, creatorID)
+	logger.Debug("Creating sponsorship: ID=%s, Brand=%s, Amount=%s %s, Creator=%s",
+		sponsorship.ID, sponsorship.BrandName, sponsorship.DealAmount, sponsorship.Currency, creatorID)
 
-	if err := h.repo.CreateSponsorship(sponsorship); err != nil {
+	warnings, err := h.createPitch(sponsorship, r.Header.Get("X-User-ID"), "Deal created")
+	var appErr *apierrors.AppError
+	if errors.As(err, &appErr) && errors.Is(appErr, apierrors.ErrBlocklisted) {
+		// The error carries this creator's matching entries
+		logger.Warn("Create sponsorship blocked: Brand=%s, Category=%s, Creator=%s",
+			sponsorship.BrandName, sponsorship.Category, creatorID)
+		api.WriteError(w, appErr)
+		return
+	}
+	if errors.As(err, &appErr) && errors.Is(appErr, apierrors.ErrPlanLimitReached) {
+		logger.Warn("Create sponsorship rejected: creator %s is at the plan's active deal limit", creatorID)
+		api.WriteError(w, appErr)
+		return
+	}
+	if err != nil {
 		logger.Error("Failed to create sponsorship %s: %v", sponsorship.ID, err)
 		api.WriteError(w, apierrors.ErrInternalError)
 		return
 	}
 
+	response := CreateSponsorshipResponse{Sponsorship: sponsorship, Warnings: warnings}
+
 	logger.Info("Sponsorship created successfully: ID=%s, Brand=%s, Creator=%s",
 		sponsorship.ID, sponsorship.BrandName, creatorID)
-	api.WriteSuccess(w, http.StatusCreated, sponsorship)
+	api.WriteSuccess(w, http.StatusCreated, response)
 }
 
 // GetSponsorship retrieves a specific sponsorship
@@ -184,15 +302,56 @@ func (h *SponsorshipHandler) UpdateSponsorship(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	oldStatus := sponsorship.Status
+
 	// Update fields - only update non-empty fields for partial updates (e.g., status-only changes)
-	if req.BrandName != "" {
+	if req.BrandName != "" && req.BrandName != sponsorship.BrandName {
 		sponsorship.BrandName = req.BrandName
+		if err := h.assignBrand(sponsorship); err != nil {
+			logger.Error("Failed to resolve brand %q for creator %s: %v", sponsorship.BrandName, creatorID, err)
+			api.WriteError(w, apierrors.ErrInternalError)
+			return
+		}
 	}
 	if req.ProductService != "" {
 		sponsorship.ProductService = req.ProductService
 	}
-	if req.DealAmount > 0 {
-		sponsorship.DealAmount = req.DealAmount
+	if currency := strings.ToUpper(req.Currency); currency != "" && currency != sponsorship.Currency {
+		if !money.ValidCurrency(currency) {
+			api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+				"currency": "currency must be a supported ISO 4217 currency code",
+			}))
+			return
+		}
+		// Milestones and invoices are recorded in the deal's currency
+		hasPayments, err := h.repo.HasPaymentRecords(id)
+		if err != nil {
+			logger.Error("Failed to check payment records of sponsorship %s: %v", id, err)
+			api.WriteError(w, apierrors.ErrInternalError)
+			return
+		}
+		if hasPayments {
+			logger.Warn("Currency change rejected for sponsorship %s with payment records", id)
+			api.WriteError(w, apierrors.ErrInvalidStateTransition.WithDetails(map[string]string{
+				"currency": "the currency cannot change once the deal has milestones or invoices",
+			}))
+			return
+		}
+		sponsorship.Currency = currency
+		if req.DealAmount == "" {
+			req.DealAmount = json.Number(sponsorship.DealAmount.String())
+		}
+	}
+	if req.DealAmount != "" {
+		dealAmount, amountErr := parseAmount(req.DealAmount, sponsorship.Currency)
+		if amountErr == "" && !dealAmount.IsPositive() {
+			amountErr = "Deal amount must be greater than 0"
+		}
+		if amountErr != "" {
+			api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{"dealAmount": amountErr}))
+			return
+		}
+		sponsorship.DealAmount = dealAmount
 	}
 	if req.Priority != "" {
 		sponsorship.Priority = req.Priority
@@ -215,6 +374,21 @@ func (h *SponsorshipHandler) UpdateSponsorship(w http.ResponseWriter, r *http.Re
 	if req.TargetAudience != "" {
 		sponsorship.TargetAudience = req.TargetAudience
 	}
+	if req.Category != "" {
+		sponsorship.Category = req.Category
+	}
+	if req.RevisionLimit != nil {
+		sponsorship.RevisionLimit = req.RevisionLimit
+	}
+	if req.CommissionRate != nil {
+		if *req.CommissionRate < 0 || *req.CommissionRate > 100 {
+			api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+				"commissionRate": commissionRateError,
+			}))
+			return
+		}
+		sponsorship.CommissionRate = req.CommissionRate
+	}
 	if !req.StartDate.IsZero() {
 		sponsorship.StartDate = req.StartDate
 	}
@@ -225,12 +399,49 @@ func (h *SponsorshipHandler) UpdateSponsorship(w http.ResponseWriter, r *http.Re
 		sponsorship.Status = req.Status
 	}
 
-	if err := h.repo.UpdateSponsorship(sponsorship); err != nil {
+	// Reopening a completed or declined deal makes it active again
+	if isClosedStatus(oldStatus) && !isClosedStatus(sponsorship.Status) {
+		var appErr *apierrors.AppError
+		if err := h.dealLimit.CheckActiveDeals(r.Header.Get("X-User-ID"), creatorID); errors.As(err, &appErr) &&
+			errors.Is(appErr, apierrors.ErrPlanLimitReached) {
+			logger.Warn("Sponsorship %s cannot be reopened: creator %s is at the plan's active deal limit", id, creatorID)
+			api.WriteError(w, appErr)
+			return
+		} else if err != nil {
+			logger.Error("Failed to check the active deal limit for creator %s: %v", creatorID, err)
+			api.WriteError(w, apierrors.ErrInternalError)
+			return
+		}
+	}
+
+	// Signing a deal is where blocklist and exclusivity conflicts become binding
+	if contains(models.WonStatuses, sponsorship.Status) && !contains(models.WonStatuses, oldStatus) {
+		if appErr, err := h.checkContractable(sponsorship); err != nil {
+			logger.Error("Failed to check sponsorship %s for conflicts: %v", id, err)
+			api.WriteError(w, apierrors.ErrInternalError)
+			return
+		} else if appErr != nil {
+			logger.Warn("Sponsorship %s cannot move to %s: %s", id, sponsorship.Status, appErr.Code)
+			api.WriteError(w, appErr)
+			return
+		}
+	}
+
+	if err := h.repo.UpdateSponsorship(sponsorship, oldStatus, r.Header.Get("X-User-ID"), ""); err != nil {
 		logger.Error("Failed to update sponsorship %s: %v", id, err)
 		api.WriteError(w, apierrors.ErrInternalError)
 		return
 	}
 
+	if len(req.Deliverables) > 0 {
+		tracked, err := h.deliverableRepo.ListDeliverables(id, creatorID)
+		if err != nil {
+			logger.Error("Failed to list deliverables of sponsorship %s: %v", id, err)
+		} else {
+			h.trackDeliverables(sponsorship, tracked)
+		}
+	}
+
 	logger.Info("Sponsorship updated successfully: ID=%s, Brand=%s, Status=%s, Creator=%s",
 		id, sponsorship.BrandName, sponsorship.Status, creatorID)
 	api.WriteSuccess(w, http.StatusOK, sponsorship)
@@ -253,36 +464,324 @@ func (h *SponsorshipHandler) DeleteSponsorship(w http.ResponseWriter, r *http.Re
 	api.WriteSuccess(w, http.StatusOK, map[string]bool{"deleted": true})
 }
 
+// MergeSponsorship folds a duplicate deal into the deal identified by the URL, moving its
+// history, deliverables, billing and files over and soft deleting the duplicate
+func (h *SponsorshipHandler) MergeSponsorship(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+	userID := r.Header.Get("X-User-ID")
+
+	var req MergeSponsorshipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode merge sponsorship request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	if req.DuplicateID == "" || req.DuplicateID == id {
+		logger.Warn("Merge sponsorship validation failed: ID=%s, Duplicate=%s", id, req.DuplicateID)
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+			"duplicateId": "duplicateId is required and must differ from the target deal",
+		}))
+		return
+	}
+
+	logger.Debug("Merging sponsorship %s into %s for creator %s", req.DuplicateID, id, creatorID)
+
+	target, err := h.repo.GetSponsorshipByID(id, creatorID)
+	if err != nil {
+		logger.Warn("Merge target not found: ID=%s, Creator=%s", id, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	duplicate, err := h.repo.GetSponsorshipByID(req.DuplicateID, creatorID)
+	if err != nil {
+		logger.Warn("Merge duplicate not found: ID=%s, Creator=%s", req.DuplicateID, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	mergeSponsorships(target, duplicate)
+
+	if err := h.repo.MergeSponsorships(target, duplicate.ID, userID); err != nil {
+		if err == apierrors.ErrNotFound {
+			logger.Warn("Merge failed, deal already deleted: Target=%s, Duplicate=%s", id, duplicate.ID)
+			api.WriteError(w, apierrors.ErrNotFound)
+			return
+		}
+		var appErr *apierrors.AppError
+		if errors.As(err, &appErr) && errors.Is(appErr, apierrors.ErrConflict) {
+			logger.Warn("Merge refused, currencies differ: Target=%s, Duplicate=%s", id, duplicate.ID)
+			api.WriteError(w, appErr)
+			return
+		}
+		logger.Error("Failed to merge sponsorship %s into %s: %v", duplicate.ID, id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Sponsorship merged successfully: Target=%s, Duplicate=%s, Creator=%s", id, duplicate.ID, creatorID)
+	api.WriteSuccess(w, http.StatusOK, target)
+}
+
+// trackDeliverables creates a tracked deliverable for each title in the deal's plain
+// deliverables array that is not already tracked. Titles removed from the array are left
+// tracked, since they may have drafts or be billed.
+func (h *SponsorshipHandler) trackDeliverables(sponsorship *models.Sponsorship, tracked []*models.Deliverable) {
+	seen := make(map[string]bool, len(tracked))
+	for _, d := range tracked {
+		seen[strings.ToLower(d.Title)] = true
+	}
+
+	for _, title := range sponsorship.Deliverables {
+		if title == "" || seen[strings.ToLower(title)] {
+			continue
+		}
+		seen[strings.ToLower(title)] = true
+
+		deliverable := &models.Deliverable{
+			SponsorshipID: sponsorship.ID,
+			CreatorID:     sponsorship.CreatorID,
+			Type:          defaultDeliverableType,
+			Title:         title,
+			DueDate:       &sponsorship.EndDate,
+			Status:        "pending",
+		}
+		if err := h.deliverableRepo.CreateDeliverable(deliverable); err != nil {
+			logger.Error("Failed to create deliverable %q for sponsorship %s: %v", title, sponsorship.ID, err)
+		}
+	}
+}
+
+// createPitch stores a new deal and runs the checks every incoming pitch goes through,
+// whether it was entered by the creator or submitted through the public form
+func (h *SponsorshipHandler) createPitch(sponsorship *models.Sponsorship, changedBy, reason string) ([]SponsorshipWarning, error) {
+	blocked, err := h.blocklistRepo.FindMatches(sponsorship.CreatorID,
+		normalizeBrandName(sponsorship.BrandName), normalizeCategory(sponsorship.Category))
+	if err != nil {
+		return nil, err
+	}
+	if len(blocked) > 0 {
+		return nil, apierrors.ErrBlocklisted.WithDetails(blocked)
+	}
+
+	if err := h.assignBrand(sponsorship); err != nil {
+		return nil, fmt.Errorf("failed to resolve brand %q: %w", sponsorship.BrandName, err)
+	}
+
+	// Rules run before the insert so the deal is stored with its triaged status and priority
+	receivedStatus := sponsorship.Status
+	var outcomes []triageOutcome
+	rules, err := h.ruleRepo.ListRules(sponsorship.CreatorID)
+	if err != nil {
+		logger.Error("Failed to load triage rules for creator %s: %v", sponsorship.CreatorID, err)
+	} else {
+		// Amount conditions in another currency compare the pitch's amount at today's rate
+		var rates *fx.Table
+		if ruleRatesNeeded(rules, sponsorship.Currency) {
+			if rates, err = loadRateTable(h.fxRepo, time.Now().UTC()); err != nil {
+				logger.Error("Failed to load exchange rates for triage of creator %s: %v", sponsorship.CreatorID, err)
+			}
+		}
+		outcomes = applyTriageRules(rules, sponsorship, rates)
+	}
+
+	// A pitch triage declines straight away never counts against the plan. The creator's
+	// ID is also its user's ID, whose plan applies to public pitches too.
+	if !isClosedStatus(sponsorship.Status) {
+		if err := h.dealLimit.CheckActiveDeals(sponsorship.CreatorID, sponsorship.CreatorID); err != nil {
+			return nil, err
+		}
+	}
+
+	// The received status and every triage action are saved with the deal, and triage has
+	// run, so the sponsorship.created event stored with it has its triaged status
+	history := []*models.SponsorshipStatusHistory{{NewStatus: receivedStatus, ChangedBy: changedBy, Reason: reason}}
+	for _, outcome := range outcomes {
+		history = append(history, &models.SponsorshipStatusHistory{
+			OldStatus: outcome.OldStatus,
+			NewStatus: outcome.NewStatus,
+			Reason:    outcome.Reason,
+		})
+	}
+	if err := h.repo.CreateSponsorship(sponsorship, history); err != nil {
+		return nil, err
+	}
+
+	h.trackDeliverables(sponsorship, nil)
+	for _, outcome := range outcomes {
+		logger.Info("Sponsorship %s triaged: %s", sponsorship.ID, outcome.Reason)
+
+		if outcome.Rule.Action.Type == ruleActionDecline && outcome.Rule.Action.Template != "" && sponsorship.ContactEmail != "" {
+			go h.sendDeclineEmail(outcome.Rule, sponsorship)
+		}
+	}
+
+	var warnings []SponsorshipWarning
+	candidates, err := h.repo.FindOverlappingSponsorships(sponsorship.CreatorID, sponsorship.StartDate, sponsorship.EndDate)
+	if err != nil {
+		logger.Error("Failed to check sponsorship %s for duplicates: %v", sponsorship.ID, err)
+	} else {
+		warnings = findDuplicateWarnings(sponsorship, candidates)
+	}
+	if len(warnings) > 0 {
+		logger.Warn("Sponsorship %s may duplicate %d existing deal(s) for creator %s",
+			sponsorship.ID, len(warnings), sponsorship.CreatorID)
+	}
+
+	if sponsorship.Status != "declined" {
+		conflicts, err := h.exclusivityRepo.FindConflicts(sponsorship)
+		if err != nil {
+			logger.Error("Failed to check sponsorship %s for exclusivity conflicts: %v", sponsorship.ID, err)
+		}
+		for _, c := range conflicts {
+			warnings = append(warnings, SponsorshipWarning{
+				Type: warningExclusivityConflict,
+				Message: fmt.Sprintf("%s holds %s exclusivity from %s to %s",
+					c.BrandName, c.Category, c.StartsOn.Format("2006-01-02"), c.EndsOn.Format("2006-01-02")),
+				SponsorshipID: c.SponsorshipID,
+				Link:          "/api/sponsorships/" + c.SponsorshipID,
+			})
+		}
+	}
+
+	return warnings, nil
+}
+
+// isClosedStatus reports whether a deal in status is over, and so not active
+func isClosedStatus(status string) bool {
+	return status == "completed" || status == "declined"
+}
+
+// checkContractable returns the error that stops a deal from being signed, if any:
+// a blocklisted brand or category, or an active exclusivity window it would breach
+func (h *SponsorshipHandler) checkContractable(sponsorship *models.Sponsorship) (*apierrors.AppError, error) {
+	blocked, err := h.blocklistRepo.FindMatches(sponsorship.CreatorID,
+		normalizeBrandName(sponsorship.BrandName), normalizeCategory(sponsorship.Category))
+	if err != nil {
+		return nil, err
+	}
+	if len(blocked) > 0 {
+		return apierrors.ErrBlocklisted.WithDetails(blocked), nil
+	}
+
+	conflicts, err := h.exclusivityRepo.FindConflicts(sponsorship)
+	if err != nil {
+		return nil, err
+	}
+	if len(conflicts) > 0 {
+		return apierrors.ErrExclusivityConflict.WithDetails(conflicts), nil
+	}
+
+	return nil, nil
+}
+
+// sendDeclineEmail answers a pitch with the auto-decline template of the rule that declined it
+func (h *SponsorshipHandler) sendDeclineEmail(rule *models.TriageRule, sponsorship *models.Sponsorship) {
+	body, err := renderDeclineTemplate(rule.Action.Template, sponsorship)
+	if err != nil {
+		logger.Error("Failed to render decline template of rule %s: %v", rule.ID, err)
+		return
+	}
+
+	subject := rule.Action.Subject
+	if subject == "" {
+		subject = fmt.Sprintf("Re: %s sponsorship pitch", sponsorship.BrandName)
+	}
+
+	if err := h.mailer.Send(&mailer.Message{
+		To:      []string{sponsorship.ContactEmail},
+		Subject: subject,
+		Body:    body,
+	}); err != nil {
+		logger.Error("Failed to send decline email for sponsorship %s: %v", sponsorship.ID, err)
+	}
+}
+
+// assignBrand links a sponsorship to the creator's brand record for its brand name
+func (h *SponsorshipHandler) assignBrand(sponsorship *models.Sponsorship) error {
+	normalized := normalizeBrandName(sponsorship.BrandName)
+	if normalized == "" {
+		sponsorship.BrandID = ""
+		return nil
+	}
+
+	brand, err := h.brandRepo.FindOrCreateBrand(sponsorship.CreatorID, sponsorship.BrandName, normalized)
+	if err != nil {
+		return err
+	}
+
+	sponsorship.BrandID = brand.ID
+	return nil
+}
+
+// baseCurrency returns the currency the creator's new deals and reports default to
+func (h *SponsorshipHandler) baseCurrency(creatorID string) (string, error) {
+	settings, err := h.settingsRepo.GetSettings(creatorID)
+	if err != nil {
+		return "", err
+	}
+	return settings.BaseCurrency, nil
+}
+
 // GetDashboardStats returns dashboard statistics
 func (h *SponsorshipHandler) GetDashboardStats(w http.ResponseWriter, r *http.Request) {
 	creatorID := r.Header.Get("X-Creator-ID")
 
 	logger.Debug("Fetching dashboard stats for creator: %s", creatorID)
 
-	sponsorships, _, err := h.repo.ListSponsorships(creatorID, 0, 10000)
+	deals, err := h.repo.ListDealValues(creatorID, "")
 	if err != nil {
 		logger.Error("Failed to fetch dashboard stats for creator %s: %v", creatorID, err)
 		api.WriteError(w, apierrors.ErrInternalError)
 		return
 	}
 
-	stats := &DashboardStats{}
-	for _, s := range sponsorships {
-		if s.Status != "completed" {
+	converter, err := newCreatorConverter(h.settingsRepo, h.fxRepo, creatorID)
+	if err != nil {
+		logger.Error("Failed to get base currency for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	// Amounts are converted to the base currency at each deal's contract date
+	stats := &DashboardStats{
+		Currency:          converter.base,
+		PipelineValue:     money.Zero(converter.base),
+		AverageDealAmount: money.Zero(converter.base),
+	}
+	// The average is over the deals counted in the pipeline value, not every deal
+	pipelineDeals := 0
+	for _, d := range deals {
+		if d.Status == "declined" {
+			continue
+		}
+		if d.Status != "completed" {
 			stats.ActiveDeals++
-			stats.PipelineValue += s.DealAmount
+			amount, ok, err := converter.convert(d.Amount, d.ContractedAt)
+			if err != nil {
+				logger.Error("Failed to convert deal %s for creator %s: %v", d.SponsorshipID, creatorID, err)
+				api.WriteError(w, apierrors.ErrInternalError)
+				return
+			}
+			if ok {
+				stats.PipelineValue = stats.PipelineValue.Add(amount)
+				pipelineDeals++
+			}
 		}
-		if s.Status == "completed" {
+		if d.Status == "completed" {
 			stats.CompletedDeals++
 		}
-		if s.Status == "negotiating" || s.Status == "approved" {
+		if d.Status == "negotiating" || d.Status == "approved" {
 			stats.PendingApproval++
 		}
 	}
 
-	if len(sponsorships) > 0 {
-		stats.AverageDealAmount = stats.PipelineValue / float64(len(sponsorships))
+	if pipelineDeals > 0 {
+		stats.AverageDealAmount = stats.PipelineValue.Div(int64(pipelineDeals))
 	}
+	stats.MissingRates = converter.missingRates()
 
 	api.WriteSuccess(w, http.StatusOK, stats)
 }
@@ -0,0 +1,183 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"sponsorship-backend/internal/models"
+
+	apierrors "sponsorship-backend/pkg/errors"
+)
+
+const otherCreatorID = "33333333-3333-3333-3333-333333333333"
+
+// fakeBlocklist holds each creator's entries; every entry matches every deal
+type fakeBlocklist map[string][]*models.BlocklistEntry
+
+func (f fakeBlocklist) FindMatches(creatorID, normalizedBrand, normalizedCategory string) ([]*models.BlocklistEntry, error) {
+	return f[creatorID], nil
+}
+
+// fakeExclusivity holds each creator's conflicting windows
+type fakeExclusivity map[string][]*models.ExclusivityConflict
+
+func (f fakeExclusivity) FindConflicts(sponsorship *models.Sponsorship) ([]*models.ExclusivityConflict, error) {
+	return f[sponsorship.CreatorID], nil
+}
+
+// fakeBrands gives every brand name the same brand record
+type fakeBrands struct{}
+
+func (fakeBrands) FindOrCreateBrand(creatorID, name, normalizedName string) (*models.Brand, error) {
+	return &models.Brand{ID: "55555555-5555-5555-5555-555555555555", CreatorID: creatorID, Name: name}, nil
+}
+
+// fakeTriageRules holds the rules run on every creator's pitches
+type fakeTriageRules []*models.TriageRule
+
+func (f fakeTriageRules) ListRules(creatorID string) ([]*models.TriageRule, error) {
+	return f, nil
+}
+
+func createSponsorshipRequest(t *testing.T, creatorID, brandName string) *http.Request {
+	body, err := json.Marshal(map[string]string{"brandName": brandName, "dealAmount": "500.00", "currency": "USD"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/api/sponsorships", bytes.NewReader(body))
+	r.Header.Set("X-Creator-ID", creatorID)
+	r.Header.Set("X-User-ID", creatorID)
+	return r
+}
+
+func TestCreateSponsorshipReportsOwnBlocklistEntries(t *testing.T) {
+	blocklist := fakeBlocklist{
+		testCreatorID:  {{ID: "a", CreatorID: testCreatorID, Kind: "brand", Value: "Acme"}},
+		otherCreatorID: {{ID: "b", CreatorID: otherCreatorID, Kind: "category", Value: "gambling"}},
+	}
+	h := NewSponsorshipHandler(nil, nil, nil, fakeExclusivity{}, blocklist, nil, nil, nil, nil, nil)
+
+	// Requests for both creators run at once; each must only see its own entries
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		for _, creatorID := range []string{testCreatorID, otherCreatorID} {
+			wg.Add(1)
+			go func(creatorID string) {
+				defer wg.Done()
+				w := httptest.NewRecorder()
+				h.CreateSponsorship(w, createSponsorshipRequest(t, creatorID, "Acme"))
+
+				var resp struct {
+					Error struct {
+						Code    string                   `json:"code"`
+						Details []*models.BlocklistEntry `json:"details"`
+					} `json:"error"`
+				}
+				if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+					t.Errorf("failed to decode response: %v", err)
+					return
+				}
+				if w.Code != http.StatusConflict || resp.Error.Code != apierrors.ErrBlocklisted.Code {
+					t.Errorf("status = %d, code = %s; want %d %s", w.Code, resp.Error.Code, http.StatusConflict,
+						apierrors.ErrBlocklisted.Code)
+					return
+				}
+				if len(resp.Error.Details) != 1 || resp.Error.Details[0].CreatorID != creatorID {
+					t.Errorf("creator %s was shown blocklist entries %+v", creatorID, resp.Error.Details)
+				}
+			}(creatorID)
+		}
+	}
+	wg.Wait()
+
+	if apierrors.ErrBlocklisted.Details != nil {
+		t.Errorf("ErrBlocklisted.Details = %v, want nil", apierrors.ErrBlocklisted.Details)
+	}
+}
+
+func TestCheckContractable(t *testing.T) {
+	blocklist := fakeBlocklist{
+		testCreatorID: {{ID: "a", CreatorID: testCreatorID, Kind: "brand", Value: "Acme"}},
+	}
+	exclusivity := fakeExclusivity{
+		testCreatorID:  {{ClauseID: "c1", BrandName: "Rival", Category: "energy drinks"}},
+		otherCreatorID: {{ClauseID: "c2", BrandName: "Other", Category: "energy drinks"}},
+	}
+	h := NewSponsorshipHandler(nil, nil, nil, exclusivity, blocklist, nil, nil, nil, nil, nil)
+
+	tests := []struct {
+		name      string
+		creatorID string
+		target    *apierrors.AppError
+		clauseID  string
+	}{
+		// The blocklist is checked before exclusivity
+		{"blocklisted brand", testCreatorID, apierrors.ErrBlocklisted, ""},
+		{"exclusivity conflict", otherCreatorID, apierrors.ErrExclusivityConflict, "c2"},
+		{"nothing in the way", "44444444-4444-4444-4444-444444444444", nil, ""},
+	}
+
+	for _, tt := range tests {
+		appErr, err := h.checkContractable(&models.Sponsorship{CreatorID: tt.creatorID, BrandName: "Acme"})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if tt.target == nil {
+			if appErr != nil {
+				t.Errorf("%s: got %v, want no error", tt.name, appErr)
+			}
+			continue
+		}
+		if !errors.Is(appErr, tt.target) {
+			t.Errorf("%s: got %v, want %v", tt.name, appErr, tt.target)
+			continue
+		}
+		if appErr == tt.target || tt.target.Details != nil {
+			t.Errorf("%s: the shared %s error was changed", tt.name, tt.target.Code)
+		}
+		if conflicts, ok := appErr.Details.([]*models.ExclusivityConflict); tt.clauseID != "" &&
+			(!ok || len(conflicts) != 1 || conflicts[0].ClauseID != tt.clauseID) {
+			t.Errorf("%s: details = %+v, want clause %s", tt.name, appErr.Details, tt.clauseID)
+		}
+	}
+}
+
+// fakeDealLimit puts each creator at the active deal limit of its plan
+type fakeDealLimit map[string]string
+
+func (f fakeDealLimit) CheckActiveDeals(userID, creatorID string) error {
+	return apierrors.ErrPlanLimitReached.WithDetails(map[string]interface{}{"plan": f[creatorID], "maxActiveDeals": 3})
+}
+
+func TestCreateSponsorshipReportsOwnPlanLimit(t *testing.T) {
+	limits := fakeDealLimit{testCreatorID: "free", otherCreatorID: "base"}
+	h := NewSponsorshipHandler(nil, fakeBrands{}, fakeTriageRules{}, fakeExclusivity{}, fakeBlocklist{}, nil, nil, nil, limits,
+		nil)
+
+	for _, creatorID := range []string{testCreatorID, otherCreatorID, testCreatorID} {
+		w := httptest.NewRecorder()
+		h.CreateSponsorship(w, createSponsorshipRequest(t, creatorID, "Acme"))
+
+		var resp struct {
+			Error struct {
+				Code    string                 `json:"code"`
+				Details map[string]interface{} `json:"details"`
+			} `json:"error"`
+		}
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if w.Code != http.StatusPaymentRequired || resp.Error.Code != apierrors.ErrPlanLimitReached.Code {
+			t.Fatalf("status = %d, code = %s; want %d %s", w.Code, resp.Error.Code, http.StatusPaymentRequired,
+				apierrors.ErrPlanLimitReached.Code)
+		}
+		if plan, _ := resp.Error.Details["plan"].(string); plan != limits[creatorID] {
+			t.Errorf("creator %s was told plan %q, want %q", creatorID, plan, limits[creatorID])
+		}
+	}
+}
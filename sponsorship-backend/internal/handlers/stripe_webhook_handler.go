@@ -0,0 +1,332 @@
+package handlers
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stripe/stripe-go/v76"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/money"
+	"sponsorship-backend/pkg/payments"
+)
+
+// maxWebhookBytes is the largest event body accepted, as recommended by Stripe
+const maxWebhookBytes = 65536
+
+// paymentRecorder records our customers' payments and refunds, as PaymentRepository does
+type paymentRecorder interface {
+	RecordPayment(eventID, eventType string, p *models.Payment) (bool, error)
+	RecordRefund(eventID, eventType, paymentIntentID string, refunded money.Money) (bool, error)
+}
+
+// subscriptionSyncer mirrors Stripe subscriptions, as SubscriptionRepository does
+type subscriptionSyncer interface {
+	LinkSubscription(eventID, eventType string, sub *models.Subscription) (bool, error)
+	SyncSubscription(eventID, eventType string, sub *models.Subscription) (bool, error)
+}
+
+// paymentLinkSettler records the outcome of brands' payment link checkouts, as
+// PaymentLinkRepository does
+type paymentLinkSettler interface {
+	MarkLinkPaid(eventID, eventType, sessionID string, paidAt time.Time) (bool, error)
+	CloseLink(eventID, eventType, sessionID, status string) (bool, error)
+}
+
+type StripeWebhookHandler struct {
+	provider         payments.Provider
+	paymentRepo      paymentRecorder
+	subscriptionRepo subscriptionSyncer
+	paymentLinkRepo  paymentLinkSettler
+	couponRepo       couponRedemptions
+}
+
+func NewStripeWebhookHandler(provider payments.Provider, paymentRepo paymentRecorder,
+	subscriptionRepo subscriptionSyncer, paymentLinkRepo paymentLinkSettler,
+	couponRepo couponRedemptions) *StripeWebhookHandler {
+	return &StripeWebhookHandler{
+		provider:         provider,
+		paymentRepo:      paymentRepo,
+		subscriptionRepo: subscriptionRepo,
+		paymentLinkRepo:  paymentLinkRepo,
+		couponRepo:       couponRepo,
+	}
+}
+
+// HandleEvent verifies and applies a Stripe webhook event. Each event ID is applied once;
+// redeliveries are acknowledged without changes. Any error other than a bad request is
+// answered with a 500 so Stripe retries the event later.
+func (h *StripeWebhookHandler) HandleEvent(w http.ResponseWriter, r *http.Request) {
+	payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBytes))
+	if err != nil {
+		logger.Warn("Failed to read Stripe webhook body: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	event, err := h.provider.ConstructEvent(payload, r.Header.Get("Stripe-Signature"))
+	if err == payments.ErrNotConfigured {
+		logger.Error("Stripe webhook received but STRIPE_WEBHOOK_SECRET is not configured")
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+	if err != nil {
+		logger.Warn("Rejected Stripe webhook: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidSignature)
+		return
+	}
+
+	applied, err := h.apply(event)
+	if err != nil {
+		logger.Error("Failed to handle Stripe event %s (%s): %v", event.ID, event.Type, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+	if applied {
+		logger.Info("Handled Stripe event %s (%s)", event.ID, event.Type)
+	} else {
+		logger.Debug("Skipped Stripe event %s (%s)", event.ID, event.Type)
+	}
+
+	api.WriteSuccess(w, http.StatusOK, map[string]bool{"received": true})
+}
+
+// apply records the effect of an event. It returns false for events that were already
+// handled or that we do not act on.
+func (h *StripeWebhookHandler) apply(event stripe.Event) (bool, error) {
+	switch event.Type {
+	case stripe.EventTypeCheckoutSessionCompleted,
+		stripe.EventTypeCheckoutSessionAsyncPaymentSucceeded,
+		stripe.EventTypeCheckoutSessionAsyncPaymentFailed,
+		stripe.EventTypeCheckoutSessionExpired:
+		var sess stripe.CheckoutSession
+		if err := json.Unmarshal(event.Data.Raw, &sess); err != nil {
+			return false, err
+		}
+		// Brands paying a creator's invoice or milestone are not our customers, so their
+		// payments are not recorded as payments
+		if sess.Metadata["payment_link_id"] != "" {
+			return h.applyPaymentLink(event, &sess)
+		}
+		// The coupon is counted before the payment is recorded; both are safe to repeat if
+		// recording fails and Stripe redelivers the event
+		couponApplied, err := h.applyCoupon(event, &sess)
+		if err != nil {
+			return false, err
+		}
+		if event.Type == stripe.EventTypeCheckoutSessionExpired {
+			return couponApplied, nil
+		}
+		// A subscription's payments are recorded from the invoice.paid of each billing
+		// period; the session only tells us whose subscription it is
+		if sess.Mode == stripe.CheckoutSessionModeSubscription {
+			if sess.Subscription == nil || event.Type != stripe.EventTypeCheckoutSessionCompleted {
+				return false, nil
+			}
+			sub := &models.Subscription{
+				UserID:                 clientReferenceUser(sess.ClientReferenceID),
+				ProductID:              sess.Metadata["product_id"],
+				ProviderSubscriptionID: sess.Subscription.ID,
+				Status:                 "incomplete",
+			}
+			if sess.PaymentStatus != stripe.CheckoutSessionPaymentStatusUnpaid {
+				sub.Status = "active"
+			}
+			if sess.Customer != nil {
+				sub.CustomerID = sess.Customer.ID
+			}
+			return h.subscriptionRepo.LinkSubscription(event.ID, string(event.Type), sub)
+		}
+		if sess.Mode != stripe.CheckoutSessionModePayment {
+			return false, nil
+		}
+
+		status := "pending"
+		switch {
+		case event.Type == stripe.EventTypeCheckoutSessionAsyncPaymentFailed:
+			status = "failed"
+		case sess.PaymentStatus == stripe.CheckoutSessionPaymentStatusPaid:
+			status = "paid"
+		}
+		payment, ok := newStripePayment(sess.ID, sess.AmountTotal, sess.Currency, status, event.Created)
+		if !ok {
+			return false, nil
+		}
+		payment.UserID = clientReferenceUser(sess.ClientReferenceID)
+		payment.ProductID = sess.Metadata["product_id"]
+		payment.Description = sess.Metadata["product_name"]
+		if sess.PaymentIntent != nil {
+			payment.PaymentIntentID = sess.PaymentIntent.ID
+		}
+		if sess.Customer != nil {
+			payment.CustomerID = sess.Customer.ID
+		}
+		return h.paymentRepo.RecordPayment(event.ID, string(event.Type), payment)
+
+	case stripe.EventTypeInvoicePaid:
+		var inv stripe.Invoice
+		if err := json.Unmarshal(event.Data.Raw, &inv); err != nil {
+			return false, err
+		}
+		paidAt := event.Created
+		if inv.StatusTransitions != nil && inv.StatusTransitions.PaidAt != 0 {
+			paidAt = inv.StatusTransitions.PaidAt
+		}
+		payment, ok := newStripePayment(inv.ID, inv.AmountPaid, inv.Currency, "paid", paidAt)
+		if !ok {
+			return false, nil
+		}
+		payment.Description = inv.Description
+		if inv.SubscriptionDetails != nil {
+			payment.UserID = clientReferenceUser(inv.SubscriptionDetails.Metadata["user_id"])
+			payment.ProductID = inv.SubscriptionDetails.Metadata["product_id"]
+		}
+		if inv.PaymentIntent != nil {
+			payment.PaymentIntentID = inv.PaymentIntent.ID
+		}
+		if inv.Customer != nil {
+			payment.CustomerID = inv.Customer.ID
+		}
+		return h.paymentRepo.RecordPayment(event.ID, string(event.Type), payment)
+
+	case stripe.EventTypeCustomerSubscriptionCreated,
+		stripe.EventTypeCustomerSubscriptionUpdated,
+		stripe.EventTypeCustomerSubscriptionDeleted:
+		var stripeSub stripe.Subscription
+		if err := json.Unmarshal(event.Data.Raw, &stripeSub); err != nil {
+			return false, err
+		}
+		sub := &models.Subscription{
+			UserID:                 clientReferenceUser(stripeSub.Metadata["user_id"]),
+			ProductID:              stripeSub.Metadata["product_id"],
+			ProviderSubscriptionID: stripeSub.ID,
+			Status:                 string(stripeSub.Status),
+			CancelAtPeriodEnd:      stripeSub.CancelAtPeriodEnd,
+			LastEventAt:            time.Unix(event.Created, 0),
+		}
+		if stripeSub.CurrentPeriodEnd != 0 {
+			periodEnd := time.Unix(stripeSub.CurrentPeriodEnd, 0)
+			sub.CurrentPeriodEnd = &periodEnd
+		}
+		if stripeSub.Customer != nil {
+			sub.CustomerID = stripeSub.Customer.ID
+		}
+		return h.subscriptionRepo.SyncSubscription(event.ID, string(event.Type), sub)
+
+	case stripe.EventTypeChargeRefunded:
+		var charge stripe.Charge
+		if err := json.Unmarshal(event.Data.Raw, &charge); err != nil {
+			return false, err
+		}
+		currency := strings.ToUpper(string(charge.Currency))
+		if charge.PaymentIntent == nil || !money.ValidCurrency(currency) {
+			return false, nil
+		}
+		applied, err := h.paymentRepo.RecordRefund(event.ID, string(event.Type), charge.PaymentIntent.ID,
+			money.New(charge.AmountRefunded, currency))
+		if err == apierrors.ErrNotFound {
+			logger.Warn("Stripe event %s refunds payment intent %s, which has no recorded payment",
+				event.ID, charge.PaymentIntent.ID)
+			return applied, nil
+		}
+		return applied, err
+	}
+
+	return false, nil
+}
+
+// applyPaymentLink records the outcome of a brand's checkout on the payment link it was
+// started from
+func (h *StripeWebhookHandler) applyPaymentLink(event stripe.Event, sess *stripe.CheckoutSession) (bool, error) {
+	switch {
+	case event.Type == stripe.EventTypeCheckoutSessionExpired:
+		return h.paymentLinkRepo.CloseLink(event.ID, string(event.Type), sess.ID, "expired")
+	case event.Type == stripe.EventTypeCheckoutSessionAsyncPaymentFailed:
+		return h.paymentLinkRepo.CloseLink(event.ID, string(event.Type), sess.ID, "failed")
+	case sess.PaymentStatus != stripe.CheckoutSessionPaymentStatusPaid:
+		// Delayed payment methods complete the session unpaid; async_payment_succeeded follows
+		return false, nil
+	}
+
+	applied, err := h.paymentLinkRepo.MarkLinkPaid(event.ID, string(event.Type), sess.ID,
+		time.Unix(event.Created, 0))
+	if err == apierrors.ErrNotFound {
+		logger.Warn("Stripe event %s pays checkout session %s, which has no payment link", event.ID, sess.ID)
+		return true, nil
+	}
+	return applied, err
+}
+
+// applyCoupon redeems the coupon redemption held for a checkout once it is paid, or
+// releases it if the checkout expires or its payment fails. It returns false if the
+// session has no redemption or it was already redeemed or released.
+func (h *StripeWebhookHandler) applyCoupon(event stripe.Event, sess *stripe.CheckoutSession) (bool, error) {
+	redemptionID := sess.Metadata["coupon_redemption_id"]
+	if _, err := uuid.Parse(redemptionID); err != nil {
+		return false, nil
+	}
+
+	switch {
+	case event.Type == stripe.EventTypeCheckoutSessionExpired,
+		event.Type == stripe.EventTypeCheckoutSessionAsyncPaymentFailed:
+		return h.couponRepo.ReleaseRedemption(redemptionID)
+	case sess.PaymentStatus == stripe.CheckoutSessionPaymentStatusUnpaid:
+		return false, nil
+	}
+
+	currency := strings.ToUpper(string(sess.Currency))
+	if !money.ValidCurrency(currency) {
+		return false, nil
+	}
+	var discount int64
+	if sess.TotalDetails != nil {
+		discount = sess.TotalDetails.AmountDiscount
+	}
+	redeemed, err := h.couponRepo.RedeemRedemption(redemptionID, sess.ID, money.New(discount, currency),
+		time.Unix(event.Created, 0))
+	if redeemed {
+		logger.Info("Coupon %s redeemed by checkout session %s", sess.Metadata["coupon_code"], sess.ID)
+	}
+	return redeemed, err
+}
+
+// newStripePayment builds a payment from a Stripe amount in minor units. It returns false
+// for currencies we cannot represent.
+func newStripePayment(reference string, amount int64, currency stripe.Currency, status string,
+	created int64) (*models.Payment, bool) {
+	code := strings.ToUpper(string(currency))
+	if !money.ValidCurrency(code) {
+		logger.Warn("Ignoring Stripe payment %s in unsupported currency %q", reference, currency)
+		return nil, false
+	}
+
+	payment := &models.Payment{
+		ProviderReference: reference,
+		Status:            status,
+		Amount:            money.New(amount, code),
+		AmountRefunded:    money.Zero(code),
+		Currency:          code,
+	}
+	if status == "paid" {
+		paidAt := time.Unix(created, 0)
+		payment.PaidAt = &paidAt
+	}
+	return payment, true
+}
+
+// clientReferenceUser returns the user ID checkout passed as the client reference, or ""
+// if the reference is not a user ID
+func clientReferenceUser(reference string) string {
+	if _, err := uuid.Parse(reference); err != nil {
+		return ""
+	}
+	return reference
+}
@@ -0,0 +1,269 @@
+package handlers
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stripe/stripe-go/v76"
+
+	"sponsorship-backend/internal/models"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/money"
+	"sponsorship-backend/pkg/payments"
+)
+
+type stripeWebhookTest struct {
+	store    *fakeBillingStore
+	provider *payments.FakeProvider
+	checkout *CheckoutHandler
+	webhook  *StripeWebhookHandler
+}
+
+func newStripeWebhookTest() *stripeWebhookTest {
+	store := newFakeBillingStore()
+	provider := payments.NewFakeProvider(testWebhookSecret)
+	return &stripeWebhookTest{
+		store:    store,
+		provider: provider,
+		checkout: NewCheckoutHandler(provider, store, store, store, "https://app.example.com"),
+		webhook:  NewStripeWebhookHandler(provider, store, store, store, store),
+	}
+}
+
+// startCheckout starts a checkout for a product and returns its session ID
+func (tt *stripeWebhookTest) startCheckout(t *testing.T, body map[string]string) string {
+	w := httptest.NewRecorder()
+	tt.checkout.CreateCheckoutSession(w, checkoutRequest(t, body))
+	if w.Code != http.StatusOK {
+		t.Fatalf("checkout status = %d: %s", w.Code, w.Body.String())
+	}
+	return checkoutSessionID(t, w)
+}
+
+// deliver posts a signed webhook event to the handler
+func (tt *stripeWebhookTest) deliver(event payments.SignedEvent) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/api/webhooks/stripe", bytes.NewReader(event.Payload))
+	r.Header.Set("Stripe-Signature", event.Signature)
+	w := httptest.NewRecorder()
+	tt.webhook.HandleEvent(w, r)
+	return w
+}
+
+func mustConstructEvent(t *testing.T, provider payments.Provider, event payments.SignedEvent) stripe.Event {
+	e, err := provider.ConstructEvent(event.Payload, event.Signature)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestStripeWebhookRecordsCompletedCheckout(t *testing.T) {
+	tt := newStripeWebhookTest()
+	sessionID := tt.startCheckout(t, map[string]string{"productId": "report"})
+
+	events, err := tt.provider.CompleteCheckout(sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 {
+		t.Fatalf("CompleteCheckout returned %d events, want 1", len(events))
+	}
+	if w := tt.deliver(events[0]); w.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+	}
+
+	if len(tt.store.payments) != 1 {
+		t.Fatalf("recorded %d payments, want 1", len(tt.store.payments))
+	}
+	p := tt.store.payments[0]
+	sess, _ := tt.provider.Session(sessionID)
+	if p.ProviderReference != sessionID || p.Status != "paid" || p.PaidAt == nil {
+		t.Errorf("payment = %+v, want a paid payment for session %s", p, sessionID)
+	}
+	if p.Amount != money.New(4900, "USD") || p.Currency != "USD" {
+		t.Errorf("payment amount = %s %s, want 49.00 USD", p.Amount, p.Currency)
+	}
+	if p.UserID != testUserID || p.ProductID != "report" || p.PaymentIntentID != sess.PaymentIntent {
+		t.Errorf("payment user %q, product %q, payment intent %q", p.UserID, p.ProductID, p.PaymentIntentID)
+	}
+}
+
+func TestStripeWebhookSkipsReplayedEvent(t *testing.T) {
+	tt := newStripeWebhookTest()
+	sessionID := tt.startCheckout(t, map[string]string{"productId": "report"})
+	events, err := tt.provider.CompleteCheckout(sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		if w := tt.deliver(events[0]); w.Code != http.StatusOK {
+			t.Fatalf("delivery %d status = %d, want %d", i+1, w.Code, http.StatusOK)
+		}
+	}
+
+	if len(tt.store.payments) != 1 {
+		t.Fatalf("recorded %d payments from one event, want 1", len(tt.store.payments))
+	}
+	applied, err := tt.webhook.apply(mustConstructEvent(t, tt.provider, events[0]))
+	if err != nil || applied {
+		t.Errorf("apply of a replayed event = %v, %v; want false, nil", applied, err)
+	}
+}
+
+func TestStripeWebhookRecordsRefund(t *testing.T) {
+	tt := newStripeWebhookTest()
+	sessionID := tt.startCheckout(t, map[string]string{"productId": "report"})
+	events, err := tt.provider.CompleteCheckout(sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt.deliver(events[0])
+	sess, _ := tt.provider.Session(sessionID)
+
+	refund := func(minor int64) {
+		if _, err := tt.provider.Refund(&payments.RefundParams{
+			PaymentIntentID: sess.PaymentIntent,
+			Amount:          money.New(minor, "USD"),
+		}); err != nil {
+			t.Fatal(err)
+		}
+		event, err := tt.provider.ChargeRefunded(sess.PaymentIntent)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if w := tt.deliver(event); w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	p := tt.store.payments[0]
+	refund(900)
+	if p.AmountRefunded != money.New(900, "USD") || p.Status != "partially_refunded" {
+		t.Errorf("after a partial refund: refunded %s, status %s", p.AmountRefunded, p.Status)
+	}
+	refund(4000)
+	if p.AmountRefunded != money.New(4900, "USD") || p.Status != "refunded" {
+		t.Errorf("after a full refund: refunded %s, status %s", p.AmountRefunded, p.Status)
+	}
+}
+
+func TestStripeWebhookRefundOfUnknownPaymentIsAcknowledged(t *testing.T) {
+	tt := newStripeWebhookTest()
+	sessionID := tt.startCheckout(t, map[string]string{"productId": "report"})
+	if _, err := tt.provider.CompleteCheckout(sessionID); err != nil {
+		t.Fatal(err)
+	}
+	sess, _ := tt.provider.Session(sessionID)
+	if _, err := tt.provider.Refund(&payments.RefundParams{
+		PaymentIntentID: sess.PaymentIntent,
+		Amount:          money.New(4900, "USD"),
+	}); err != nil {
+		t.Fatal(err)
+	}
+	event, err := tt.provider.ChargeRefunded(sess.PaymentIntent)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The checkout's own event never arrived, so there is no payment to refund
+	if w := tt.deliver(event); w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d so Stripe does not retry", w.Code, http.StatusOK)
+	}
+}
+
+func TestStripeWebhookRedeemsAndReleasesCoupons(t *testing.T) {
+	tt := newStripeWebhookTest()
+	percentOff := 20.0
+	tt.store.coupons["LAUNCH20"] = &models.Coupon{Code: "LAUNCH20", PercentOff: &percentOff, Duration: "once",
+		Active: true}
+
+	paid := tt.startCheckout(t, map[string]string{"productId": "report", "couponCode": "LAUNCH20"})
+	expired := tt.startCheckout(t, map[string]string{"productId": "report", "couponCode": "LAUNCH20"})
+
+	events, err := tt.provider.CompleteCheckout(paid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt.deliver(events[0])
+	expiry, err := tt.provider.ExpireCheckout(expired)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tt.deliver(expiry)
+
+	paidSess, _ := tt.provider.Session(paid)
+	r := tt.store.redemptions[paidSess.Params.Metadata["coupon_redemption_id"]]
+	if r.Status != "redeemed" || r.ProviderReference != paid || *r.DiscountAmount != money.New(980, "USD") {
+		t.Errorf("paid checkout's redemption = %+v, want redeemed for 9.80 USD", r)
+	}
+	if got := tt.store.payments[0].Amount; got != money.New(3920, "USD") {
+		t.Errorf("payment amount = %s, want the discounted 39.20", got)
+	}
+
+	expiredSess, _ := tt.provider.Session(expired)
+	if r := tt.store.redemptions[expiredSess.Params.Metadata["coupon_redemption_id"]]; r.Status != "released" {
+		t.Errorf("expired checkout's redemption status = %s, want released", r.Status)
+	}
+	if n := tt.store.coupons["LAUNCH20"].TimesRedeemed; n != 1 {
+		t.Errorf("coupon redeemed %d times, want 1", n)
+	}
+}
+
+func TestStripeWebhookRecordsSubscription(t *testing.T) {
+	tt := newStripeWebhookTest()
+	sessionID := tt.startCheckout(t, map[string]string{"productId": "base"})
+
+	events, err := tt.provider.CompleteCheckout(sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, event := range events {
+		if w := tt.deliver(event); w.Code != http.StatusOK {
+			t.Fatalf("status = %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	sess, _ := tt.provider.Session(sessionID)
+	if len(tt.store.subscriptions) != 1 {
+		t.Fatalf("recorded %d subscriptions, want 1", len(tt.store.subscriptions))
+	}
+	sub := tt.store.subscriptions[0]
+	if sub.ProviderSubscriptionID != sess.SubscriptionID || sub.UserID != testUserID || sub.Status != "active" {
+		t.Errorf("subscription = %+v", sub)
+	}
+
+	// The first period's payment comes from its invoice, not the checkout session
+	if len(tt.store.payments) != 1 {
+		t.Fatalf("recorded %d payments, want 1", len(tt.store.payments))
+	}
+	if p := tt.store.payments[0]; p.Amount != money.New(2900, "USD") || p.UserID != testUserID || p.Status != "paid" {
+		t.Errorf("payment = %+v", p)
+	}
+}
+
+func TestStripeWebhookRejectsBadSignature(t *testing.T) {
+	tt := newStripeWebhookTest()
+	sessionID := tt.startCheckout(t, map[string]string{"productId": "report"})
+	events, err := tt.provider.CompleteCheckout(sessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	forged := payments.NewFakeProvider("whsec_other")
+	tt.webhook = NewStripeWebhookHandler(forged, tt.store, tt.store, tt.store, tt.store)
+	w := tt.deliver(events[0])
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	if code := errorCode(t, w); code != apierrors.ErrInvalidSignature.Code {
+		t.Errorf("error code = %s, want %s", code, apierrors.ErrInvalidSignature.Code)
+	}
+	if len(tt.store.payments) != 0 {
+		t.Error("a payment was recorded from an event with a bad signature")
+	}
+}
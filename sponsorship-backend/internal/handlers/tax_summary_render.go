@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/ledger"
+	"sponsorship-backend/pkg/pdf"
+)
+
+// renderTaxSummaryCSV writes a tax summary as one CSV table, with a section column
+// telling the brand, country and month rows apart. Amounts left out for want of an
+// exchange rate follow as missing_rate rows, so the totals are not taken as complete.
+// Labels are brand names and the like, so they are neutralised against formulas.
+func renderTaxSummaryCSV(summary *models.TaxSummary) ([]byte, error) {
+	var buf bytes.Buffer
+	out := csv.NewWriter(&buf)
+
+	out.Write([]string{"section", "label", "currency", "income", "commissions", "expenses", "net"})
+	write := func(section string, lines ...models.TaxSummaryLine) {
+		for _, line := range lines {
+			out.Write([]string{
+				section, ledger.CSVText(line.Label), summary.Currency,
+				line.Income.String(), line.Commissions.String(), line.Expenses.String(), line.Net.String(),
+			})
+		}
+	}
+	write("brand", summary.ByBrand...)
+	write("country", summary.ByCountry...)
+	write("month", summary.ByMonth...)
+	write("total", summary.Total)
+	for _, missing := range summary.MissingRates {
+		out.Write([]string{"missing_rate", ledger.CSVText(missing), "", "", "", "", ""})
+	}
+
+	out.Flush()
+	return buf.Bytes(), out.Error()
+}
+
+// renderTaxSummaryPDF lays out a tax summary as tables on A4 pages
+func renderTaxSummaryPDF(summary *models.TaxSummary) []byte {
+	const (
+		margin     = 50.0
+		lineHeight = 14.0
+		bodySize   = 10.0
+		labelWidth = 180.0
+	)
+	right := pdf.PageWidth - margin
+	columns := []float64{300, 385, 470, right}
+
+	doc := pdf.New()
+	page := doc.AddPage()
+	y := margin + 20
+
+	newPageIfNeeded := func(needed float64) {
+		if y+needed > pdf.PageHeight-margin {
+			page = doc.AddPage()
+			y = margin + 20
+		}
+	}
+
+	page.Text(margin, y, 20, true, fmt.Sprintf("Income summary %d", summary.Year))
+	y += 20
+	page.Text(margin, y, bodySize, false, "All amounts in "+summary.Currency)
+	y += 2 * lineHeight
+
+	row := func(line models.TaxSummaryLine, bold bool) {
+		label := line.Label
+		if wrapped := pdf.Wrap(label, bodySize, bold, labelWidth); len(wrapped) > 0 {
+			label = wrapped[0]
+		}
+		page.Text(margin, y, bodySize, bold, label)
+		for i, amount := range []string{
+			line.Income.Format(), line.Commissions.Format(), line.Expenses.Format(), line.Net.Format(),
+		} {
+			page.TextRight(columns[i], y, bodySize, bold, amount)
+		}
+		y += lineHeight
+	}
+	header := func(title string) {
+		page.Text(margin, y, 12, true, title)
+		for i, heading := range []string{"Income", "Commissions", "Expenses", "Net"} {
+			page.TextRight(columns[i], y, bodySize, true, heading)
+		}
+		y += 5
+		page.Line(margin, y, right, y, 0.8)
+		y += lineHeight
+	}
+	table := func(title string, lines []models.TaxSummaryLine) {
+		newPageIfNeeded(3 * lineHeight)
+		header(title)
+		for _, line := range lines {
+			if y+lineHeight > pdf.PageHeight-margin {
+				page = doc.AddPage()
+				y = margin + 20
+				header(title + " (continued)")
+			}
+			row(line, false)
+		}
+		y += lineHeight
+	}
+
+	newPageIfNeeded(2 * lineHeight)
+	header("Total")
+	row(summary.Total, true)
+	y += lineHeight
+
+	table("By month", summary.ByMonth)
+	table("By brand", summary.ByBrand)
+	table("By payer country", summary.ByCountry)
+
+	if len(summary.MissingRates) > 0 {
+		text := "Left out for want of an exchange rate: " + strings.Join(summary.MissingRates, ", ")
+		lines := pdf.Wrap(text, bodySize, false, right-margin)
+		newPageIfNeeded(float64(len(lines)) * lineHeight)
+		for _, line := range lines {
+			page.Text(margin, y, bodySize, false, line)
+			y += lineHeight
+		}
+	}
+
+	return doc.Bytes()
+}
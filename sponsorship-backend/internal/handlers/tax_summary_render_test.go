@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"strings"
+	"testing"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/money"
+)
+
+func TestRenderTaxSummaryCSV(t *testing.T) {
+	usd := func(minor int64) money.Money { return money.New(minor, "USD") }
+	line := func(label string, income int64) models.TaxSummaryLine {
+		return models.TaxSummaryLine{Label: label, Income: usd(income), Commissions: usd(0), Expenses: usd(0),
+			Net: usd(income)}
+	}
+	summary := &models.TaxSummary{
+		Year:         2024,
+		Currency:     "USD",
+		Total:        line("Total", 150000),
+		ByBrand:      []models.TaxSummaryLine{line("Acme", 100000), line(`=HYPERLINK("http://x")`, 50000)},
+		ByCountry:    []models.TaxSummaryLine{line("US", 150000)},
+		ByMonth:      []models.TaxSummaryLine{line("2024-03", 150000)},
+		MissingRates: []string{"EUR/USD 2024-03-01"},
+	}
+
+	body, err := renderTaxSummaryCSV(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(strings.NewReader(string(body))).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"section", "label", "currency", "income", "commissions", "expenses", "net"},
+		{"brand", "Acme", "USD", "1000.00", "0.00", "0.00", "1000.00"},
+		{"brand", `'=HYPERLINK("http://x")`, "USD", "500.00", "0.00", "0.00", "500.00"},
+		{"country", "US", "USD", "1500.00", "0.00", "0.00", "1500.00"},
+		{"month", "2024-03", "USD", "1500.00", "0.00", "0.00", "1500.00"},
+		{"total", "Total", "USD", "1500.00", "0.00", "0.00", "1500.00"},
+		{"missing_rate", "EUR/USD 2024-03-01", "", "", "", "", ""},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d:\n%s", len(rows), len(want), body)
+	}
+	for i := range want {
+		if strings.Join(rows[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("row %d = %q, want %q", i, rows[i], want[i])
+		}
+	}
+}
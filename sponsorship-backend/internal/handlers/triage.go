@@ -0,0 +1,308 @@
+package handlers
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"text/template"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/fx"
+	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/money"
+)
+
+const (
+	ruleActionDecline     = "decline"
+	ruleActionReject      = "reject"
+	ruleActionSetPriority = "set_priority"
+)
+
+var ruleFields = map[string]bool{
+	"dealAmount":         true,
+	"category":           true,
+	"brandName":          true,
+	"contactEmailDomain": true,
+	"productService":     true,
+	"priority":           true,
+}
+
+var ruleOperators = map[string]bool{
+	"eq": true, "neq": true, "lt": true, "lte": true, "gt": true, "gte": true,
+	"contains": true, "in": true, "not_in": true,
+}
+
+// triageOutcome records one rule that fired and what it did to the pitch
+type triageOutcome struct {
+	Rule      *models.TriageRule
+	OldStatus string
+	NewStatus string
+	Reason    string
+}
+
+// declineTemplateData is what a decline email template can reference
+type declineTemplateData struct {
+	BrandName      string
+	ContactName    string
+	ProductService string
+	DealAmount     money.Money
+}
+
+// defaultRuleCurrency gives dealAmount conditions without a currency the creator's base
+// currency, and upper-cases the others
+func defaultRuleCurrency(rule *models.TriageRule, base string) {
+	for i := range rule.Conditions {
+		c := &rule.Conditions[i]
+		if c.Field != "dealAmount" {
+			continue
+		}
+		c.Currency = strings.ToUpper(strings.TrimSpace(c.Currency))
+		if c.Currency == "" {
+			c.Currency = base
+		}
+	}
+}
+
+// ruleRatesNeeded reports whether any enabled rule compares a dealAmount in a currency
+// other than the pitch's, so exchange rates must be loaded to evaluate the rules
+func ruleRatesNeeded(rules []*models.TriageRule, currency string) bool {
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		for _, c := range rule.Conditions {
+			if c.Field == "dealAmount" && ruleCurrency(c) != currency {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ruleCurrency is the currency of a dealAmount condition's value. Conditions saved before
+// they had a currency were validated as USD amounts.
+func ruleCurrency(c models.RuleCondition) string {
+	if c.Currency == "" {
+		return money.DefaultCurrency
+	}
+	return c.Currency
+}
+
+// validateTriageRule checks a rule's conditions and action, returning field errors
+func validateTriageRule(rule *models.TriageRule) map[string]string {
+	details := map[string]string{}
+
+	if strings.TrimSpace(rule.Name) == "" {
+		details["name"] = "name is required"
+	}
+	if len(rule.Conditions) == 0 {
+		details["conditions"] = "at least one condition is required"
+	}
+	for i, c := range rule.Conditions {
+		key := fmt.Sprintf("conditions[%d]", i)
+		switch {
+		case !ruleFields[c.Field]:
+			details[key] = fmt.Sprintf("unknown field %q", c.Field)
+		case !ruleOperators[c.Operator]:
+			details[key] = fmt.Sprintf("unknown operator %q", c.Operator)
+		case (c.Operator == "in" || c.Operator == "not_in") && len(c.Values) == 0:
+			details[key] = "values is required for in and not_in"
+		case isOrderingOperator(c.Operator) && c.Field != "dealAmount":
+			details[key] = "lt, lte, gt and gte only apply to dealAmount"
+		case c.Field == "dealAmount" && !isNumericOperator(c.Operator):
+			details[key] = "dealAmount supports eq, neq, lt, lte, gt and gte"
+		case c.Field == "dealAmount" && !money.ValidCurrency(c.Currency):
+			details[key] = "currency must be a supported ISO 4217 currency code"
+		case c.Field == "dealAmount":
+			if _, err := money.Parse(c.Value, c.Currency); err != nil {
+				details[key] = "value must be a decimal amount in the condition's currency"
+			}
+		}
+	}
+
+	switch rule.Action.Type {
+	case ruleActionDecline:
+		if rule.Action.Template != "" {
+			if _, err := template.New("decline").Parse(rule.Action.Template); err != nil {
+				details["action.template"] = fmt.Sprintf("invalid template: %v", err)
+			}
+		}
+	case ruleActionReject:
+	case ruleActionSetPriority:
+		if !contains(models.ValidPriorities, rule.Action.Priority) {
+			details["action.priority"] = "priority must be one of high, medium, low"
+		}
+	default:
+		details["action.type"] = "type must be one of decline, reject, set_priority"
+	}
+
+	return details
+}
+
+// applyTriageRules evaluates enabled rules in order against a new pitch and applies the
+// actions of those that match. Decline and reject end evaluation. rates converts the
+// pitch's amount for dealAmount conditions in another currency; it may be nil if
+// ruleRatesNeeded is false.
+func applyTriageRules(rules []*models.TriageRule, sponsorship *models.Sponsorship, rates *fx.Table) []triageOutcome {
+	var outcomes []triageOutcome
+
+	for _, rule := range rules {
+		if !rule.Enabled || !ruleMatches(rule, sponsorship, rates) {
+			continue
+		}
+
+		outcome := triageOutcome{Rule: rule, OldStatus: sponsorship.Status}
+		switch rule.Action.Type {
+		case ruleActionDecline:
+			sponsorship.Status = "declined"
+			outcome.Reason = fmt.Sprintf("Triage rule %q: auto-declined", rule.Name)
+		case ruleActionReject:
+			sponsorship.Status = "declined"
+			outcome.Reason = fmt.Sprintf("Triage rule %q: rejected", rule.Name)
+		case ruleActionSetPriority:
+			outcome.Reason = fmt.Sprintf("Triage rule %q: priority %s -> %s", rule.Name, sponsorship.Priority, rule.Action.Priority)
+			sponsorship.Priority = rule.Action.Priority
+		default:
+			continue
+		}
+		outcome.NewStatus = sponsorship.Status
+		outcomes = append(outcomes, outcome)
+
+		if sponsorship.Status == "declined" {
+			break
+		}
+	}
+
+	return outcomes
+}
+
+func ruleMatches(rule *models.TriageRule, sponsorship *models.Sponsorship, rates *fx.Table) bool {
+	if len(rule.Conditions) == 0 {
+		return false
+	}
+	for _, c := range rule.Conditions {
+		if !conditionMatches(c, sponsorship, rates) {
+			return false
+		}
+	}
+	return true
+}
+
+// conditionMatches reports whether a pitch meets one condition. A dealAmount is compared
+// in the condition's currency, converting the pitch's amount at today's rate; without a
+// rate the condition does not match.
+func conditionMatches(c models.RuleCondition, sponsorship *models.Sponsorship, rates *fx.Table) bool {
+	if c.Field == "dealAmount" {
+		currency := ruleCurrency(c)
+		want, err := money.Parse(c.Value, currency)
+		if err != nil {
+			return false
+		}
+		amount := sponsorship.DealAmount
+		if amount.Currency() != currency {
+			var rate *big.Rat
+			ok := false
+			if rates != nil {
+				rate, ok = rates.Lookup(amount.Currency(), currency)
+			}
+			if !ok {
+				logger.Warn("Triage condition dealAmount %s %s skipped for a %s pitch: no exchange rate",
+					c.Operator, want, amount.Currency())
+				return false
+			}
+			amount = amount.Convert(currency, rate)
+		}
+		cmp := amount.Cmp(want)
+		switch c.Operator {
+		case "eq":
+			return cmp == 0
+		case "neq":
+			return cmp != 0
+		case "lt":
+			return cmp < 0
+		case "lte":
+			return cmp <= 0
+		case "gt":
+			return cmp > 0
+		case "gte":
+			return cmp >= 0
+		}
+		return false
+	}
+
+	normalize := func(v string) string { return strings.ToLower(strings.TrimSpace(v)) }
+	if c.Field == "brandName" {
+		normalize = normalizeBrandName
+	}
+
+	got := normalize(ruleFieldValue(c.Field, sponsorship))
+	switch c.Operator {
+	case "eq":
+		return got == normalize(c.Value)
+	case "neq":
+		return got != normalize(c.Value)
+	case "contains":
+		return strings.Contains(got, normalize(c.Value))
+	case "in", "not_in":
+		found := false
+		for _, v := range c.Values {
+			if got == normalize(v) {
+				found = true
+				break
+			}
+		}
+		return found == (c.Operator == "in")
+	}
+
+	return false
+}
+
+func ruleFieldValue(field string, sponsorship *models.Sponsorship) string {
+	switch field {
+	case "category":
+		return sponsorship.Category
+	case "brandName":
+		return sponsorship.BrandName
+	case "contactEmailDomain":
+		return emailDomain(sponsorship.ContactEmail)
+	case "productService":
+		return sponsorship.ProductService
+	case "priority":
+		return sponsorship.Priority
+	}
+	return ""
+}
+
+func isOrderingOperator(op string) bool {
+	return op == "lt" || op == "lte" || op == "gt" || op == "gte"
+}
+
+func isNumericOperator(op string) bool {
+	return op == "eq" || op == "neq" || isOrderingOperator(op)
+}
+
+// renderDeclineTemplate fills a rule's decline template for the pitch
+func renderDeclineTemplate(tmpl string, sponsorship *models.Sponsorship) (string, error) {
+	t, err := template.New("decline").Parse(tmpl)
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	err = t.Execute(&b, declineTemplateData{
+		BrandName:      sponsorship.BrandName,
+		ContactName:    sponsorship.ContactName,
+		ProductService: sponsorship.ProductService,
+		DealAmount:     sponsorship.DealAmount,
+	})
+	return b.String(), err
+}
+
+func contains(values []string, v string) bool {
+	for _, candidate := range values {
+		if candidate == v {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type TriageRuleHandler struct {
+	repo         *repositories.TriageRuleRepository
+	settingsRepo *repositories.SettingsRepository
+}
+
+type TriageRuleRequest struct {
+	Name       string                 `json:"name"`
+	Enabled    *bool                  `json:"enabled"`
+	Conditions []models.RuleCondition `json:"conditions"`
+	Action     models.RuleAction      `json:"action"`
+}
+
+type ReorderTriageRulesRequest struct {
+	IDs []string `json:"ids"`
+}
+
+func NewTriageRuleHandler(repo *repositories.TriageRuleRepository,
+	settingsRepo *repositories.SettingsRepository) *TriageRuleHandler {
+	return &TriageRuleHandler{repo: repo, settingsRepo: settingsRepo}
+}
+
+// ListRules lists the creator's triage rules in evaluation order
+func (h *TriageRuleHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	rules, err := h.repo.ListRules(creatorID)
+	if err != nil {
+		logger.Error("Failed to list triage rules for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	if rules == nil {
+		rules = []*models.TriageRule{}
+	}
+
+	api.WriteSuccess(w, http.StatusOK, rules)
+}
+
+// CreateRule adds a triage rule after the creator's existing rules
+func (h *TriageRuleHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req TriageRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode create triage rule request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	rule := &models.TriageRule{
+		CreatorID:  creatorID,
+		Name:       req.Name,
+		Enabled:    req.Enabled == nil || *req.Enabled,
+		Conditions: req.Conditions,
+		Action:     req.Action,
+	}
+
+	if !h.defaultCurrency(w, rule) {
+		return
+	}
+	if details := validateTriageRule(rule); len(details) > 0 {
+		logger.Warn("Create triage rule validation failed for creator %s: %v", creatorID, details)
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(details))
+		return
+	}
+
+	if err := h.repo.CreateRule(rule); err != nil {
+		logger.Error("Failed to create triage rule for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Triage rule created: ID=%s, Name=%s, Creator=%s", rule.ID, rule.Name, creatorID)
+	api.WriteSuccess(w, http.StatusCreated, rule)
+}
+
+// UpdateRule replaces a triage rule's definition
+func (h *TriageRuleHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req TriageRuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode update triage rule request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	rule, err := h.repo.GetRuleByID(id, creatorID)
+	if err != nil {
+		logger.Warn("Triage rule not found: ID=%s, Creator=%s", id, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	rule.Name = req.Name
+	rule.Conditions = req.Conditions
+	rule.Action = req.Action
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+
+	if !h.defaultCurrency(w, rule) {
+		return
+	}
+	if details := validateTriageRule(rule); len(details) > 0 {
+		logger.Warn("Update triage rule validation failed: ID=%s, %v", id, details)
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(details))
+		return
+	}
+
+	if err := h.repo.UpdateRule(rule); err != nil {
+		logger.Error("Failed to update triage rule %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Triage rule updated: ID=%s, Creator=%s", id, creatorID)
+	api.WriteSuccess(w, http.StatusOK, rule)
+}
+
+// DeleteRule removes a triage rule
+func (h *TriageRuleHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	if err := h.repo.DeleteRule(id, creatorID); err != nil {
+		logger.Warn("Failed to delete triage rule: ID=%s, Creator=%s, Error: %v", id, creatorID, err)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+
+	logger.Info("Triage rule deleted: ID=%s, Creator=%s", id, creatorID)
+	api.WriteSuccess(w, http.StatusOK, map[string]bool{"deleted": true})
+}
+
+// ReorderRules sets the evaluation order of the creator's triage rules
+func (h *TriageRuleHandler) ReorderRules(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req ReorderTriageRulesRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode reorder triage rules request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	seen := make(map[string]bool, len(req.IDs))
+	for _, id := range req.IDs {
+		if seen[id] {
+			api.WriteError(w, apierrors.ErrValidationError.WithDetails(map[string]string{
+				"ids": "ids must list every triage rule exactly once",
+			}))
+			return
+		}
+		seen[id] = true
+	}
+
+	if err := h.repo.ReorderRules(creatorID, req.IDs); err != nil {
+		if appErr, ok := err.(*apierrors.AppError); ok {
+			logger.Warn("Reorder triage rules rejected for creator %s: %v", creatorID, err)
+			api.WriteError(w, appErr)
+			return
+		}
+		logger.Error("Failed to reorder triage rules for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	rules, err := h.repo.ListRules(creatorID)
+	if err != nil {
+		logger.Error("Failed to list triage rules for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Triage rules reordered for creator %s", creatorID)
+	api.WriteSuccess(w, http.StatusOK, rules)
+}
+
+// defaultCurrency gives the rule's dealAmount conditions without a currency the creator's
+// base currency. It writes the error and returns false if the settings cannot be loaded.
+func (h *TriageRuleHandler) defaultCurrency(w http.ResponseWriter, rule *models.TriageRule) bool {
+	settings, err := h.settingsRepo.GetSettings(rule.CreatorID)
+	if err != nil {
+		logger.Error("Failed to get settings for creator %s: %v", rule.CreatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return false
+	}
+	defaultRuleCurrency(rule, settings.BaseCurrency)
+	return true
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"math/big"
+	"testing"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/fx"
+	"sponsorship-backend/pkg/money"
+)
+
+func TestConditionMatchesDealAmountCurrency(t *testing.T) {
+	rates := fx.NewTable([]fx.Rate{
+		{Base: "USD", Quote: "JPY", Rate: big.NewRat(150, 1)},
+		{Base: "EUR", Quote: "USD", Rate: big.NewRat(11, 10)},
+	})
+	below500 := func(currency string) models.RuleCondition {
+		return models.RuleCondition{Field: "dealAmount", Operator: "lt", Value: "500", Currency: currency}
+	}
+
+	tests := []struct {
+		name  string
+		c     models.RuleCondition
+		deal  money.Money
+		rates *fx.Table
+		want  bool
+	}{
+		{"same currency below", below500("USD"), money.New(49999, "USD"), nil, true},
+		{"same currency equal", below500("USD"), money.New(50000, "USD"), nil, false},
+		// 10,000 yen is about 66.67 dollars
+		{"yen converted into dollars", below500("USD"), money.New(10000, "JPY"), rates, true},
+		{"large yen amount", below500("USD"), money.New(100000, "JPY"), rates, false},
+		// 460 euros are 506 dollars, not below 500
+		{"euros converted into dollars", below500("USD"), money.New(46000, "EUR"), rates, false},
+		{"euros through the inverse rate", below500("EUR"), money.New(50000, "USD"), rates, true},
+		{"dollars into yen", models.RuleCondition{Field: "dealAmount", Operator: "gte", Value: "75000",
+			Currency: "JPY"}, money.New(50000, "USD"), rates, true},
+		{"no rate for the pair", below500("GBP"), money.New(100, "USD"), rates, false},
+		{"no rates loaded", below500("USD"), money.New(100, "EUR"), nil, false},
+		// Conditions saved before they had a currency were validated as dollars
+		{"condition without currency", below500(""), money.New(10000, "JPY"), rates, true},
+		{"decimals in a yen condition", models.RuleCondition{Field: "dealAmount", Operator: "lt", Value: "500.50",
+			Currency: "JPY"}, money.New(100, "JPY"), nil, false},
+	}
+
+	for _, tt := range tests {
+		s := &models.Sponsorship{DealAmount: tt.deal, Currency: tt.deal.Currency()}
+		if got := conditionMatches(tt.c, s, tt.rates); got != tt.want {
+			t.Errorf("%s: conditionMatches = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestConditionMatchesStringFields(t *testing.T) {
+	s := &models.Sponsorship{
+		BrandName:      "Acme Corp.",
+		Category:       "Gaming",
+		ContactEmail:   "Jo@Acme.COM",
+		ProductService: "  Mechanical keyboards ",
+		Priority:       "high",
+	}
+	cond := func(field, operator, value string, values ...string) models.RuleCondition {
+		return models.RuleCondition{Field: field, Operator: operator, Value: value, Values: values}
+	}
+
+	tests := []struct {
+		name string
+		c    models.RuleCondition
+		want bool
+	}{
+		{"eq ignores case", cond("category", "eq", "gaming"), true},
+		{"eq ignores surrounding space", cond("productService", "eq", "mechanical keyboards "), true},
+		{"eq on another value", cond("category", "eq", "beauty"), false},
+		{"neq on another value", cond("category", "neq", "beauty"), true},
+		{"neq on the same value", cond("priority", "neq", "HIGH"), false},
+		{"contains a word", cond("productService", "contains", "Keyboard"), true},
+		{"contains a missing word", cond("productService", "contains", "mice"), false},
+		// Brand names are compared without case, punctuation or company suffixes
+		{"brand without suffix", cond("brandName", "eq", "ACME"), true},
+		{"brand with another suffix", cond("brandName", "eq", "Acme, Inc."), true},
+		{"brand contains", cond("brandName", "contains", "cme"), true},
+		{"email domain", cond("contactEmailDomain", "eq", "acme.com"), true},
+		{"email domain of another brand", cond("contactEmailDomain", "eq", "example.com"), false},
+		{"in a listed value", cond("category", "in", "", "Beauty", " GAMING"), true},
+		{"in no listed value", cond("category", "in", "", "Beauty", "Tech"), false},
+		{"in an empty list", cond("category", "in", ""), false},
+		{"not_in a listed value", cond("category", "not_in", "", "gaming"), false},
+		{"not_in no listed value", cond("category", "not_in", "", "Beauty"), true},
+		{"not_in an empty list", cond("category", "not_in", ""), true},
+		{"ordering operator on text", cond("category", "lt", "z"), false},
+		{"unknown operator", cond("category", "startsWith", "gam"), false},
+	}
+
+	for _, tt := range tests {
+		if got := conditionMatches(tt.c, s, nil); got != tt.want {
+			t.Errorf("%s: conditionMatches(%+v) = %v, want %v", tt.name, tt.c, got, tt.want)
+		}
+	}
+}
+
+func TestRuleRatesNeeded(t *testing.T) {
+	rule := func(enabled bool, currency string) *models.TriageRule {
+		return &models.TriageRule{Enabled: enabled, Conditions: []models.RuleCondition{
+			{Field: "category", Operator: "eq", Value: "gambling"},
+			{Field: "dealAmount", Operator: "lt", Value: "500", Currency: currency},
+		}}
+	}
+
+	tests := []struct {
+		name  string
+		rules []*models.TriageRule
+		want  bool
+	}{
+		{"same currency", []*models.TriageRule{rule(true, "EUR")}, false},
+		{"other currency", []*models.TriageRule{rule(true, "EUR"), rule(true, "USD")}, true},
+		{"other currency on a disabled rule", []*models.TriageRule{rule(false, "USD")}, false},
+		{"no currency means dollars", []*models.TriageRule{rule(true, "")}, true},
+		{"no rules", nil, false},
+	}
+
+	for _, tt := range tests {
+		if got := ruleRatesNeeded(tt.rules, "EUR"); got != tt.want {
+			t.Errorf("%s: ruleRatesNeeded = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTriageRuleAmountCurrency(t *testing.T) {
+	tests := []struct {
+		value, currency string
+		valid           bool
+	}{
+		{"500", "USD", true},
+		{"499.99", "EUR", true},
+		{"10000", "JPY", true},
+		{"500.50", "JPY", false},
+		{"500", "XXX", false},
+		{"500", "", false},
+		{"five hundred", "USD", false},
+	}
+
+	for _, tt := range tests {
+		rule := &models.TriageRule{
+			Name:       "Too small",
+			Conditions: []models.RuleCondition{{Field: "dealAmount", Operator: "lt", Value: tt.value, Currency: tt.currency}},
+			Action:     models.RuleAction{Type: ruleActionDecline},
+		}
+		details := validateTriageRule(rule)
+		if valid := len(details) == 0; valid != tt.valid {
+			t.Errorf("%s %s: valid = %v, want %v (%v)", tt.value, tt.currency, valid, tt.valid, details)
+		}
+	}
+
+	rule := &models.TriageRule{Conditions: []models.RuleCondition{
+		{Field: "dealAmount", Operator: "lt", Value: "500"},
+		{Field: "dealAmount", Operator: "gt", Value: "5", Currency: " eur "},
+		{Field: "category", Operator: "eq", Value: "gambling"},
+	}}
+	defaultRuleCurrency(rule, "GBP")
+	for i, want := range []string{"GBP", "EUR", ""} {
+		if got := rule.Conditions[i].Currency; got != want {
+			t.Errorf("condition %d currency = %q, want %q", i, got, want)
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"sponsorship-backend/internal/api"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+	"sponsorship-backend/internal/webhooks"
+
+	apierrors "sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+)
+
+const (
+	// maxWebhookURLLength is the longest endpoint URL accepted
+	maxWebhookURLLength = 2048
+	// webhookDeliveryListLimit is how many recent deliveries are listed for an endpoint
+	webhookDeliveryListLimit = 50
+)
+
+// WebhookEndpointHandler manages the endpoints a creator's deal events are sent to
+type WebhookEndpointHandler struct {
+	repo      *repositories.WebhookRepository
+	allowHTTP bool
+}
+
+// WebhookEndpointRequest registers or changes an endpoint. Active defaults to true.
+type WebhookEndpointRequest struct {
+	URL         string   `json:"url"`
+	Description string   `json:"description"`
+	Events      []string `json:"events"`
+	Active      *bool    `json:"active"`
+}
+
+// NewWebhookEndpointHandler returns a handler that accepts https endpoint URLs, and http
+// ones too if allowHTTP is set for development
+func NewWebhookEndpointHandler(repo *repositories.WebhookRepository, allowHTTP bool) *WebhookEndpointHandler {
+	return &WebhookEndpointHandler{repo: repo, allowHTTP: allowHTTP}
+}
+
+// ListEndpoints lists the creator's webhook endpoints
+func (h *WebhookEndpointHandler) ListEndpoints(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	endpoints, err := h.repo.ListEndpoints(creatorID)
+	if err != nil {
+		logger.Error("Failed to list webhook endpoints for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+	if endpoints == nil {
+		endpoints = []*models.WebhookEndpoint{}
+	}
+
+	api.WriteSuccess(w, http.StatusOK, endpoints)
+}
+
+// CreateEndpoint registers an endpoint and returns it with its signing secret, which is
+// not shown again
+func (h *WebhookEndpointHandler) CreateEndpoint(w http.ResponseWriter, r *http.Request) {
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req WebhookEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode create webhook endpoint request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	endpoint := &models.WebhookEndpoint{CreatorID: creatorID}
+	if !h.apply(w, endpoint, &req) {
+		return
+	}
+
+	secret, err := webhooks.NewSecret()
+	if err != nil {
+		logger.Error("Failed to generate webhook secret: %v", err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+	endpoint.Secret = secret
+
+	if err := h.repo.CreateEndpoint(endpoint); err != nil {
+		logger.Error("Failed to create webhook endpoint for creator %s: %v", creatorID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Webhook endpoint created: ID=%s, Events=%v, Creator=%s", endpoint.ID, endpoint.Events, creatorID)
+	api.WriteSuccess(w, http.StatusCreated, endpoint)
+}
+
+// UpdateEndpoint changes an endpoint's URL, description, events or whether it is active.
+// Fields left out are unchanged.
+func (h *WebhookEndpointHandler) UpdateEndpoint(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	var req WebhookEndpointRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		logger.Error("Failed to decode update webhook endpoint request: %v", err)
+		api.WriteError(w, apierrors.ErrInvalidRequest)
+		return
+	}
+
+	endpoint, ok := h.loadEndpoint(w, id, creatorID)
+	if !ok {
+		return
+	}
+
+	if req.URL == "" {
+		req.URL = endpoint.URL
+	}
+	if req.Events == nil {
+		req.Events = endpoint.Events
+	}
+	if req.Description == "" {
+		req.Description = endpoint.Description
+	}
+	if req.Active == nil {
+		req.Active = &endpoint.Active
+	}
+	if !h.apply(w, endpoint, &req) {
+		return
+	}
+
+	if err := h.repo.UpdateEndpoint(endpoint); err != nil {
+		logger.Error("Failed to update webhook endpoint %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Webhook endpoint updated: ID=%s, Active=%t, Creator=%s", id, endpoint.Active, creatorID)
+	api.WriteSuccess(w, http.StatusOK, endpoint)
+}
+
+// DeleteEndpoint removes an endpoint and its delivery history
+func (h *WebhookEndpointHandler) DeleteEndpoint(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	err := h.repo.DeleteEndpoint(id, creatorID)
+	if err == apierrors.ErrNotFound {
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to delete webhook endpoint %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Webhook endpoint deleted: ID=%s, Creator=%s", id, creatorID)
+	api.WriteSuccess(w, http.StatusOK, map[string]bool{"deleted": true})
+}
+
+// ListDeliveries lists an endpoint's most recent deliveries, newest first
+func (h *WebhookEndpointHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	if _, ok := h.loadEndpoint(w, id, creatorID); !ok {
+		return
+	}
+
+	deliveries, err := h.repo.ListDeliveries(id, creatorID, webhookDeliveryListLimit)
+	if err != nil {
+		logger.Error("Failed to list deliveries of webhook endpoint %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []*models.WebhookDelivery{}
+	}
+
+	api.WriteSuccess(w, http.StatusOK, deliveries)
+}
+
+// GetDelivery returns a delivery with every attempt made to send it
+func (h *WebhookEndpointHandler) GetDelivery(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	deliveryID := chi.URLParam(r, "deliveryId")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	delivery, err := h.repo.GetDelivery(deliveryID, id, creatorID)
+	if err == apierrors.ErrNotFound {
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to get webhook delivery %s: %v", deliveryID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	api.WriteSuccess(w, http.StatusOK, delivery)
+}
+
+// Redeliver sends a finished delivery again, as a new delivery with the same event ID
+// and payload
+func (h *WebhookEndpointHandler) Redeliver(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	deliveryID := chi.URLParam(r, "deliveryId")
+	creatorID := r.Header.Get("X-Creator-ID")
+
+	endpoint, ok := h.loadEndpoint(w, id, creatorID)
+	if !ok {
+		return
+	}
+	if !endpoint.Active {
+		api.WriteError(w, apierrors.ErrInvalidStateTransition.WithDetails("enable the endpoint to redeliver to it"))
+		return
+	}
+
+	original, err := h.repo.GetDelivery(deliveryID, id, creatorID)
+	if err == apierrors.ErrNotFound {
+		api.WriteError(w, apierrors.ErrNotFound)
+		return
+	}
+	if err != nil {
+		logger.Error("Failed to get webhook delivery %s: %v", deliveryID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+	if original.Status == "pending" {
+		api.WriteError(w, apierrors.ErrInvalidStateTransition.WithDetails("delivery is still being attempted"))
+		return
+	}
+
+	delivery, err := h.repo.Redeliver(original)
+	if err != nil {
+		logger.Error("Failed to redeliver webhook delivery %s: %v", deliveryID, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return
+	}
+
+	logger.Info("Webhook delivery %s queued again as %s: Event=%s, Endpoint=%s", deliveryID, delivery.ID,
+		delivery.EventID, id)
+	api.WriteSuccess(w, http.StatusAccepted, delivery)
+}
+
+func (h *WebhookEndpointHandler) loadEndpoint(w http.ResponseWriter, id, creatorID string) (*models.WebhookEndpoint, bool) {
+	endpoint, err := h.repo.GetEndpoint(id, creatorID)
+	if err == apierrors.ErrNotFound {
+		logger.Warn("Webhook endpoint not found: ID=%s, Creator=%s", id, creatorID)
+		api.WriteError(w, apierrors.ErrNotFound)
+		return nil, false
+	}
+	if err != nil {
+		logger.Error("Failed to get webhook endpoint %s: %v", id, err)
+		api.WriteError(w, apierrors.ErrInternalError)
+		return nil, false
+	}
+	return endpoint, true
+}
+
+// apply validates a request and copies it onto an endpoint. It writes the validation
+// error and returns false if the request is invalid.
+func (h *WebhookEndpointHandler) apply(w http.ResponseWriter, endpoint *models.WebhookEndpoint,
+	req *WebhookEndpointRequest) bool {
+	details := map[string]string{}
+
+	endpointURL := strings.TrimSpace(req.URL)
+	if len(endpointURL) > maxWebhookURLLength {
+		details["url"] = "url must be an absolute http or https URL"
+	} else if err := webhooks.CheckURL(endpointURL, h.allowHTTP); err != nil {
+		details["url"] = err.Error()
+	}
+
+	var events []string
+	for _, event := range req.Events {
+		if !contains(models.ValidWebhookEvents, event) {
+			details["events"] = "events must be among " + strings.Join(models.ValidWebhookEvents, ", ")
+			break
+		}
+		if !contains(events, event) {
+			events = append(events, event)
+		}
+	}
+	if len(req.Events) == 0 {
+		details["events"] = "subscribe to at least one event"
+	}
+
+	if len(details) > 0 {
+		api.WriteError(w, apierrors.ErrValidationError.WithDetails(details))
+		return false
+	}
+
+	endpoint.URL = endpointURL
+	endpoint.Description = strings.TrimSpace(req.Description)
+	endpoint.Events = events
+	endpoint.Active = req.Active == nil || *req.Active
+	return true
+}
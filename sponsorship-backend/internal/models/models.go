@@ -1,7 +1,11 @@
 package models
 
 import (
+	"encoding/json"
+	"strings"
 	"time"
+
+	"sponsorship-backend/pkg/money"
 )
 
 // User represents a system user
@@ -29,25 +33,652 @@ type Creator struct {
 
 // Sponsorship represents a sponsorship deal
 type Sponsorship struct {
+	ID             string      `json:"id" db:"id"`
+	CreatorID      string      `json:"creatorId" db:"creator_id"`
+	BrandID        string      `json:"brandId,omitempty" db:"brand_id"`
+	BrandName      string      `json:"brandName" db:"brand_name"`
+	ProductService string      `json:"productService" db:"product_service"`
+	DealAmount     money.Money `json:"dealAmount" db:"deal_amount"`
+	Currency       string      `json:"currency" db:"currency"` // ISO 4217 code of DealAmount
+	Priority       string      `json:"priority" db:"priority"` // high, medium, low
+	ContactName    string      `json:"contactName" db:"contact_name"`
+	ContactEmail   string      `json:"contactEmail" db:"contact_email"`
+	ContactPhone   string      `json:"contactPhone" db:"contact_phone"`
+	Description    string      `json:"description" db:"description"`
+	Deliverables   []string    `json:"deliverables" db:"deliverables"` // titles only; see Deliverable
+	TargetAudience string      `json:"targetAudience" db:"target_audience"`
+	Category       string      `json:"category" db:"category"`
+	RevisionLimit  *int        `json:"revisionLimit" db:"revision_limit"`   // nil means unlimited
+	CommissionRate *float64    `json:"commissionRate" db:"commission_rate"` // percent paid to an agent or manager
+	StartDate      time.Time   `json:"startDate" db:"start_date"`
+	EndDate        time.Time   `json:"endDate" db:"end_date"`
+	Status         string      `json:"status" db:"status"`
+	Notes          string      `json:"notes" db:"notes"`
+	CreatedAt      time.Time   `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time   `json:"updatedAt" db:"updated_at"`
+	DeletedAt      *time.Time  `json:"deletedAt" db:"deleted_at"`
+}
+
+// Deliverable is one piece of content owed under a sponsorship
+type Deliverable struct {
+	ID            string     `json:"id" db:"id"`
+	SponsorshipID string     `json:"sponsorshipId" db:"sponsorship_id"`
+	CreatorID     string     `json:"creatorId" db:"creator_id"`
+	Type          string     `json:"type" db:"type"` // dedicated-video, integration, short, story
+	Platform      string     `json:"platform" db:"platform"`
+	Title         string     `json:"title" db:"title"`
+	DueDate       *time.Time `json:"dueDate" db:"due_date"`
+	PublishDate   *time.Time `json:"publishDate" db:"publish_date"`
+	PublishedURL  string     `json:"publishedUrl" db:"published_url"`
+	Status        string     `json:"status" db:"status"`
+	RevisionCount int        `json:"revisionCount" db:"revision_count"`
+	CreatedAt     time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// PaymentMilestone is one installment of a deal's payment schedule
+type PaymentMilestone struct {
+	ID            string      `json:"id" db:"id"`
+	SponsorshipID string      `json:"sponsorshipId" db:"sponsorship_id"`
+	CreatorID     string      `json:"creatorId" db:"creator_id"`
+	Label         string      `json:"label" db:"label"`
+	Amount        money.Money `json:"amount" db:"amount"`
+	Currency      string      `json:"currency" db:"currency"`
+	DueDate       time.Time   `json:"dueDate" db:"due_date"`
+	Status        string      `json:"status" db:"status"` // unpaid, paid
+	PaidOn        *time.Time  `json:"paidOn" db:"paid_on"`
+	CreatedAt     time.Time   `json:"createdAt" db:"created_at"`
+	UpdatedAt     time.Time   `json:"updatedAt" db:"updated_at"`
+}
+
+// Receivable is an unpaid milestone together with the deal it belongs to
+type Receivable struct {
+	MilestoneID   string       `json:"milestoneId"`
+	SponsorshipID string       `json:"sponsorshipId"`
+	BrandName     string       `json:"brandName"`
+	Label         string       `json:"label"`
+	Amount        money.Money  `json:"amount"`
+	Currency      string       `json:"currency"`
+	BaseAmount    *money.Money `json:"baseAmount"` // in the report currency; nil without a rate
+	DueDate       time.Time    `json:"dueDate"`
+	DaysOverdue   int          `json:"daysOverdue"`
+	ContractedAt  *time.Time   `json:"-"` // the deal's contract date, whose rate converts the amount
+}
+
+// AgingBucket totals receivables overdue by a range of days
+type AgingBucket struct {
+	Label   string      `json:"label"`
+	MinDays int         `json:"minDays"`
+	MaxDays *int        `json:"maxDays"` // nil for the open-ended last bucket
+	Count   int         `json:"count"`
+	Total   money.Money `json:"total"`
+}
+
+// ReceivablesAging is a creator's outstanding receivables grouped by days overdue
+type ReceivablesAging struct {
+	AsOf         time.Time     `json:"asOf"`
+	Currency     string        `json:"currency"`
+	Current      money.Money   `json:"current"` // unpaid but not yet due
+	TotalOverdue money.Money   `json:"totalOverdue"`
+	Buckets      []AgingBucket `json:"buckets"`
+	Overdue      []*Receivable `json:"overdue"`
+	MissingRates []string      `json:"missingRates,omitempty"` // receivables left out for want of a rate
+}
+
+// BillingDetails are the creator's own details as printed on invoices
+type BillingDetails struct {
+	CreatorID           string    `json:"creatorId" db:"creator_id"`
+	LegalName           string    `json:"legalName" db:"legal_name"`
+	Email               string    `json:"email" db:"email"`
+	AddressLine1        string    `json:"addressLine1" db:"address_line1"`
+	AddressLine2        string    `json:"addressLine2" db:"address_line2"`
+	City                string    `json:"city" db:"city"`
+	PostalCode          string    `json:"postalCode" db:"postal_code"`
+	Region              string    `json:"region" db:"region"`
+	Country             string    `json:"country" db:"country"` // ISO 3166-1 alpha-2
+	TaxID               string    `json:"taxId" db:"tax_id"`
+	PaymentInstructions string    `json:"paymentInstructions" db:"payment_instructions"`
+	InvoicePrefix       string    `json:"invoicePrefix" db:"invoice_prefix"`
+	UpdatedAt           time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// Address formats the postal address as lines
+func (d *BillingDetails) Address() string {
+	var lines []string
+	for _, line := range []string{
+		d.AddressLine1,
+		d.AddressLine2,
+		strings.TrimSpace(d.PostalCode + " " + d.City),
+		strings.TrimSpace(strings.Join([]string{d.Region, d.Country}, " ")),
+	} {
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Invoice bills a brand for a sponsorship or one of its payment milestones. Drafts have
+// no number; the number is assigned when the invoice is sent.
+type Invoice struct {
+	ID             string             `json:"id" db:"id"`
+	CreatorID      string             `json:"creatorId" db:"creator_id"`
+	SponsorshipID  string             `json:"sponsorshipId" db:"sponsorship_id"`
+	MilestoneID    string             `json:"milestoneId,omitempty" db:"milestone_id"`
+	SequenceNumber *int               `json:"sequenceNumber" db:"sequence_number"`
+	InvoiceNumber  string             `json:"invoiceNumber" db:"invoice_number"`
+	Status         string             `json:"status" db:"status"` // draft, sent, paid, void
+	IssueDate      *time.Time         `json:"issueDate" db:"issue_date"`
+	DueDate        *time.Time         `json:"dueDate" db:"due_date"`
+	BillToName     string             `json:"billToName" db:"bill_to_name"`
+	BillToContact  string             `json:"billToContact" db:"bill_to_contact"`
+	BillToEmail    string             `json:"billToEmail" db:"bill_to_email"`
+	BillToAddress  string             `json:"billToAddress" db:"bill_to_address"`
+	BillToTaxID    string             `json:"billToTaxId" db:"bill_to_tax_id"`
+	Seller         *BillingDetails    `json:"seller" db:"seller_details"` // snapshot taken when sent
+	Currency       string             `json:"currency" db:"currency"`
+	Subtotal       money.Money        `json:"subtotal" db:"subtotal"`
+	TaxLabel       string             `json:"taxLabel" db:"tax_label"`
+	TaxRate        float64            `json:"taxRate" db:"tax_rate"` // percent
+	TaxAmount      money.Money        `json:"taxAmount" db:"tax_amount"`
+	Total          money.Money        `json:"total" db:"total"`
+	Notes          string             `json:"notes" db:"notes"`
+	SentAt         *time.Time         `json:"sentAt" db:"sent_at"`
+	PaidAt         *time.Time         `json:"paidAt" db:"paid_at"`
+	VoidedAt       *time.Time         `json:"voidedAt" db:"voided_at"`
+	CreatedAt      time.Time          `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time          `json:"updatedAt" db:"updated_at"`
+	LineItems      []*InvoiceLineItem `json:"lineItems,omitempty"`
+}
+
+// InvoiceLineItem is one billed line of an invoice
+type InvoiceLineItem struct {
+	ID            string      `json:"id" db:"id"`
+	InvoiceID     string      `json:"invoiceId" db:"invoice_id"`
+	DeliverableID string      `json:"deliverableId,omitempty" db:"deliverable_id"`
+	Position      int         `json:"position" db:"position"`
+	Description   string      `json:"description" db:"description"`
+	Quantity      float64     `json:"quantity" db:"quantity"`
+	UnitPrice     money.Money `json:"unitPrice" db:"unit_price"`
+	Amount        money.Money `json:"amount" db:"amount"`
+}
+
+// CreatorSettings are a creator's account-wide preferences
+type CreatorSettings struct {
+	CreatorID    string    `json:"creatorId" db:"creator_id"`
+	BaseCurrency string    `json:"baseCurrency" db:"base_currency"` // reports are converted to this
+	UpdatedAt    time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// FXRate is one day's exchange rate: one unit of Base buys Rate units of Quote
+type FXRate struct {
+	Date  time.Time `json:"date" db:"rate_date"`
+	Base  string    `json:"base" db:"base_currency"`
+	Quote string    `json:"quote" db:"quote_currency"`
+	Rate  string    `json:"rate" db:"rate"`
+}
+
+// DealValue is a deal's amount with the date its exchange rate is taken from
+type DealValue struct {
+	SponsorshipID string      `json:"sponsorshipId"`
+	BrandName     string      `json:"brandName"`
+	Country       string      `json:"country"` // the brand's country, if recorded
+	Status        string      `json:"status"`
+	Amount        money.Money `json:"amount"`
+	Currency      string      `json:"currency"`
+	ContractedAt  *time.Time  `json:"contractedAt"` // first move to contracted or later
+}
+
+// Expense is a production cost of a deal, such as props, an editor or travel
+type Expense struct {
+	ID                  string      `json:"id" db:"id"`
+	SponsorshipID       string      `json:"sponsorshipId" db:"sponsorship_id"`
+	CreatorID           string      `json:"creatorId" db:"creator_id"`
+	Category            string      `json:"category" db:"category"`
+	Description         string      `json:"description" db:"description"`
+	Amount              money.Money `json:"amount" db:"amount"`
+	Currency            string      `json:"currency" db:"currency"`
+	ExpenseDate         time.Time   `json:"expenseDate" db:"expense_date"`
+	ReceiptAttachmentID string      `json:"receiptAttachmentId" db:"receipt_attachment_id"`
+	CreatedAt           time.Time   `json:"createdAt" db:"created_at"`
+	UpdatedAt           time.Time   `json:"updatedAt" db:"updated_at"`
+}
+
+// ProfitTotals are revenue, costs and what is left, in a report's currency
+type ProfitTotals struct {
+	Gross    money.Money `json:"gross"`
+	Expenses money.Money `json:"expenses"`
+	Net      money.Money `json:"net"`
+}
+
+// DealProfit is the profitability of one deal
+type DealProfit struct {
+	SponsorshipID string     `json:"sponsorshipId"`
+	BrandName     string     `json:"brandName"`
+	Status        string     `json:"status"`
+	ContractedAt  *time.Time `json:"contractedAt"`
+	ProfitTotals
+}
+
+// ProfitabilityByDeal reports gross, expenses and net profit per deal
+type ProfitabilityByDeal struct {
+	Currency     string        `json:"currency"`
+	Deals        []*DealProfit `json:"deals"`
+	Total        ProfitTotals  `json:"total"`
+	MissingRates []string      `json:"missingRates,omitempty"` // amounts left out for want of a rate
+}
+
+// MonthProfit is the profitability of one calendar month
+type MonthProfit struct {
+	Month string `json:"month"` // YYYY-MM
+	ProfitTotals
+}
+
+// ProfitabilityByMonth reports gross, expenses and net profit per month of a year
+type ProfitabilityByMonth struct {
+	Year         int           `json:"year"`
+	Currency     string        `json:"currency"`
+	Months       []MonthProfit `json:"months"`
+	Total        ProfitTotals  `json:"total"`
+	MissingRates []string      `json:"missingRates,omitempty"` // amounts left out for want of a rate
+}
+
+// IncomeEntry is money received for a deal: a paid invoice, a paid milestone that was not
+// invoiced, or the amount of a completed deal with no payment records
+type IncomeEntry struct {
+	SponsorshipID  string      `json:"sponsorshipId"`
+	BrandName      string      `json:"brandName"`
+	Country        string      `json:"country"` // the brand's country, if recorded
+	Source         string      `json:"source"`  // invoice, milestone, deal
+	SourceID       string      `json:"sourceId"`
+	Reference      string      `json:"reference"` // invoice number or milestone label
+	Amount         money.Money `json:"amount"`    // before sales tax
+	Currency       string      `json:"currency"`
+	ReceivedOn     time.Time   `json:"receivedOn"`
+	CommissionRate *float64    `json:"commissionRate"`
+}
+
+// TaxSummaryLine totals a year's income and costs for one brand, country or month
+type TaxSummaryLine struct {
+	Label       string      `json:"label"`
+	Income      money.Money `json:"income"`
+	Commissions money.Money `json:"commissions"`
+	Expenses    money.Money `json:"expenses"`
+	Net         money.Money `json:"net"`
+}
+
+// TaxSummary is a tax year's income by brand, payer country and month
+type TaxSummary struct {
+	Year         int              `json:"year"`
+	Currency     string           `json:"currency"`
+	Total        TaxSummaryLine   `json:"total"`
+	ByBrand      []TaxSummaryLine `json:"byBrand"`
+	ByCountry    []TaxSummaryLine `json:"byCountry"`
+	ByMonth      []TaxSummaryLine `json:"byMonth"`
+	MissingRates []string         `json:"missingRates,omitempty"` // amounts left out for want of a rate
+}
+
+// Product is something users can buy at checkout, at the price stored with it. A product
+// with a billing interval is a subscription plan and grants its limits while subscribed.
+type Product struct {
+	ID              string      `json:"id" db:"id"`
+	Name            string      `json:"name" db:"name"`
+	Description     string      `json:"description" db:"description"`
+	Amount          money.Money `json:"amount" db:"amount"`
+	Currency        string      `json:"currency" db:"currency"`
+	BillingInterval string      `json:"billingInterval,omitempty" db:"billing_interval"` // month, year; empty for one-off
+	MaxActiveDeals  *int        `json:"maxActiveDeals" db:"max_active_deals"`            // nil for unlimited
+	APIAccess       bool        `json:"apiAccess" db:"api_access"`
+	Active          bool        `json:"active" db:"active"`
+	CreatedAt       time.Time   `json:"createdAt" db:"created_at"`
+	UpdatedAt       time.Time   `json:"updatedAt" db:"updated_at"`
+}
+
+// Subscription is a user's subscription to a plan, mirrored from Stripe
+type Subscription struct {
+	ID                     string     `json:"id" db:"id"`
+	UserID                 string     `json:"userId" db:"user_id"`
+	ProductID              string     `json:"productId" db:"product_id"`
+	ProviderSubscriptionID string     `json:"providerSubscriptionId" db:"provider_subscription_id"`
+	CustomerID             string     `json:"customerId" db:"customer_id"`
+	Status                 string     `json:"status" db:"status"`
+	CurrentPeriodEnd       *time.Time `json:"currentPeriodEnd" db:"current_period_end"`
+	CancelAtPeriodEnd      bool       `json:"cancelAtPeriodEnd" db:"cancel_at_period_end"`
+	LastEventAt            time.Time  `json:"-" db:"last_event_at"`
+	CreatedAt              time.Time  `json:"createdAt" db:"created_at"`
+	UpdatedAt              time.Time  `json:"updatedAt" db:"updated_at"`
+}
+
+// Entitlements are what a user's plan allows
+type Entitlements struct {
+	Plan              string     `json:"plan"`   // product ID of the plan, or "free"
+	Status            string     `json:"status"` // subscription status; empty on the free plan
+	MaxActiveDeals    *int       `json:"maxActiveDeals"`
+	APIAccess         bool       `json:"apiAccess"`
+	CurrentPeriodEnd  *time.Time `json:"currentPeriodEnd"`
+	CancelAtPeriodEnd bool       `json:"cancelAtPeriodEnd"`
+}
+
+// Payment is money a user paid us through Stripe, recorded from webhook events
+type Payment struct {
+	ID                string      `json:"id" db:"id"`
+	UserID            string      `json:"userId" db:"user_id"`
+	ProviderReference string      `json:"providerReference" db:"provider_reference"` // Checkout Session or Stripe invoice ID
+	PaymentIntentID   string      `json:"paymentIntentId" db:"payment_intent_id"`
+	CustomerID        string      `json:"customerId" db:"customer_id"`
+	ProductID         string      `json:"productId" db:"product_id"`
+	Description       string      `json:"description" db:"description"`
+	Status            string      `json:"status" db:"status"` // pending, paid, failed, refunded, partially_refunded
+	Amount            money.Money `json:"amount" db:"amount"`
+	AmountRefunded    money.Money `json:"amountRefunded" db:"amount_refunded"`
+	Currency          string      `json:"currency" db:"currency"`
+	PaidAt            *time.Time  `json:"paidAt" db:"paid_at"`
+	CreatedAt         time.Time   `json:"createdAt" db:"created_at"`
+	UpdatedAt         time.Time   `json:"updatedAt" db:"updated_at"`
+}
+
+// PaymentLink is a hosted checkout where a brand pays an invoice or milestone by card
+type PaymentLink struct {
+	ID                string      `json:"id" db:"id"`
+	CreatorID         string      `json:"creatorId" db:"creator_id"`
+	InvoiceID         string      `json:"invoiceId,omitempty" db:"invoice_id"`
+	MilestoneID       string      `json:"milestoneId,omitempty" db:"milestone_id"`
+	ProviderSessionID string      `json:"providerSessionId" db:"provider_session_id"`
+	URL               string      `json:"url" db:"url"`
+	Amount            money.Money `json:"amount" db:"amount"`
+	Currency          string      `json:"currency" db:"currency"`
+	Status            string      `json:"status" db:"status"` // open, paid, failed, expired
+	ExpiresAt         time.Time   `json:"expiresAt" db:"expires_at"`
+	PaidAt            *time.Time  `json:"paidAt" db:"paid_at"`
+	CreatedAt         time.Time   `json:"createdAt" db:"created_at"`
+	UpdatedAt         time.Time   `json:"updatedAt" db:"updated_at"`
+}
+
+// Refund is money given back on a payment, issued by an admin through the API
+type Refund struct {
+	ID               string      `json:"id" db:"id"`
+	PaymentID        string      `json:"paymentId" db:"payment_id"`
+	ProviderRefundID string      `json:"providerRefundId" db:"provider_refund_id"`
+	Amount           money.Money `json:"amount" db:"amount"`
+	Currency         string      `json:"currency" db:"currency"`
+	Reason           string      `json:"reason" db:"reason"` // duplicate, fraudulent, requested_by_customer or empty
+	Status           string      `json:"status" db:"status"` // pending, succeeded, failed, canceled, requires_action
+	IssuedBy         string      `json:"issuedBy" db:"issued_by"`
+	CreatedAt        time.Time   `json:"createdAt" db:"created_at"`
+}
+
+// Coupon is a discount code users can enter at checkout. It takes either a percentage or a
+// fixed amount in one currency off the price.
+type Coupon struct {
+	Code           string       `json:"code" db:"code"`
+	Description    string       `json:"description" db:"description"`
+	PercentOff     *float64     `json:"percentOff" db:"percent_off"`
+	AmountOff      *money.Money `json:"amountOff" db:"amount_off"`
+	Currency       string       `json:"currency,omitempty" db:"currency"` // currency of AmountOff
+	Duration       string       `json:"duration" db:"duration"`           // once, forever
+	ExpiresAt      *time.Time   `json:"expiresAt" db:"expires_at"`
+	MaxRedemptions *int         `json:"maxRedemptions" db:"max_redemptions"` // nil for unlimited
+	TimesRedeemed  int          `json:"timesRedeemed" db:"times_redeemed"`
+	ProductIDs     []string     `json:"productIds"` // empty if it applies to every product
+	Active         bool         `json:"active" db:"active"`
+	CreatedAt      time.Time    `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time    `json:"updatedAt" db:"updated_at"`
+}
+
+// CouponRedemption is a coupon used in a checkout: held while the checkout is open, then
+// redeemed when it is paid or released when it expires
+type CouponRedemption struct {
+	ID                string       `json:"id" db:"id"`
+	CouponCode        string       `json:"couponCode" db:"coupon_code"`
+	UserID            string       `json:"userId" db:"user_id"`
+	ProductID         string       `json:"productId" db:"product_id"`
+	Status            string       `json:"status" db:"status"` // held, redeemed, released
+	ProviderReference string       `json:"providerReference" db:"provider_reference"`
+	DiscountAmount    *money.Money `json:"discountAmount" db:"discount_amount"`
+	HeldUntil         time.Time    `json:"heldUntil" db:"held_until"`
+	RedeemedAt        *time.Time   `json:"redeemedAt" db:"redeemed_at"`
+	CreatedAt         time.Time    `json:"createdAt" db:"created_at"`
+}
+
+// Webhook event types a creator's endpoints can subscribe to
+const (
+	WebhookEventSponsorshipCreated       = "sponsorship.created"
+	WebhookEventSponsorshipStatusChanged = "sponsorship.status_changed"
+	WebhookEventSponsorshipDeleted       = "sponsorship.deleted"
+	WebhookEventPaymentReceived          = "payment.received"
+)
+
+// ValidWebhookEvents are the event types endpoints can subscribe to
+var ValidWebhookEvents = []string{
+	WebhookEventSponsorshipCreated,
+	WebhookEventSponsorshipStatusChanged,
+	WebhookEventSponsorshipDeleted,
+	WebhookEventPaymentReceived,
+}
+
+// WebhookEndpoint is a URL a creator has registered to be sent deal events
+type WebhookEndpoint struct {
+	ID          string    `json:"id" db:"id"`
+	CreatorID   string    `json:"creatorId" db:"creator_id"`
+	URL         string    `json:"url" db:"url"`
+	Description string    `json:"description" db:"description"`
+	Events      []string  `json:"events" db:"events"`
+	Secret      string    `json:"secret,omitempty" db:"secret"` // only returned when the endpoint is created
+	Active      bool      `json:"active" db:"active"`
+	CreatedAt   time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt   time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// WebhookDelivery is one event sent, or to be sent, to one endpoint
+type WebhookDelivery struct {
+	ID             string                    `json:"id" db:"id"`
+	EndpointID     string                    `json:"endpointId" db:"endpoint_id"`
+	EventID        string                    `json:"eventId" db:"event_id"`
+	EventType      string                    `json:"eventType" db:"event_type"`
+	Payload        json.RawMessage           `json:"payload" db:"payload"`
+	Status         string                    `json:"status" db:"status"` // pending, succeeded, failed
+	Attempts       int                       `json:"attempts" db:"attempts"`
+	NextAttemptAt  *time.Time                `json:"nextAttemptAt" db:"next_attempt_at"`
+	LastAttemptAt  *time.Time                `json:"lastAttemptAt" db:"last_attempt_at"`
+	LastStatusCode *int                      `json:"lastStatusCode" db:"last_status_code"`
+	LastError      string                    `json:"lastError,omitempty" db:"last_error"`
+	AttemptLog     []*WebhookDeliveryAttempt `json:"attemptLog,omitempty"`
+	CreatedAt      time.Time                 `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time                 `json:"updatedAt" db:"updated_at"`
+
+	// Where to send it, filled in when the delivery is claimed for sending
+	URL    string `json:"-"`
+	Secret string `json:"-"`
+}
+
+// WebhookDeliveryAttempt is one try at sending a delivery
+type WebhookDeliveryAttempt struct {
+	ID           string    `json:"id" db:"id"`
+	DeliveryID   string    `json:"deliveryId" db:"delivery_id"`
+	Attempt      int       `json:"attempt" db:"attempt"`
+	StatusCode   *int      `json:"statusCode" db:"status_code"` // nil if no response was received
+	Error        string    `json:"error,omitempty" db:"error"`
+	ResponseBody string    `json:"responseBody,omitempty" db:"response_body"`
+	DurationMS   int64     `json:"durationMs" db:"duration_ms"`
+	AttemptedAt  time.Time `json:"attemptedAt" db:"attempted_at"`
+}
+
+// SponsorshipStatusChange is the data of a sponsorship.status_changed event
+type SponsorshipStatusChange struct {
+	Sponsorship    *Sponsorship `json:"sponsorship"`
+	PreviousStatus string       `json:"previousStatus"`
+	Status         string       `json:"status"`
+	Reason         string       `json:"reason,omitempty"`
+}
+
+// SponsorshipDeletion is the data of a sponsorship.deleted event
+type SponsorshipDeletion struct {
+	ID         string `json:"id"`
+	MergedInto string `json:"mergedInto,omitempty"` // the deal a merged duplicate was folded into
+}
+
+// PaymentReceipt is the data of a payment.received event: a brand paid a milestone or
+// invoice of a deal
+type PaymentReceipt struct {
+	SponsorshipID string      `json:"sponsorshipId"`
+	InvoiceID     string      `json:"invoiceId,omitempty"`
+	MilestoneID   string      `json:"milestoneId,omitempty"`
+	Amount        money.Money `json:"amount"`
+	Currency      string      `json:"currency"`
+	PaidAt        time.Time   `json:"paidAt"`
+	Source        string      `json:"source"` // manual, or payment_link when paid by card
+}
+
+// OutboxEvent is an event about a sponsorship change or payment, stored with it and handed
+// to subscribers at least once. Its ID is the event ID subscribers see, on every attempt.
+type OutboxEvent struct {
+	ID            string          `json:"id" db:"id"`
+	CreatorID     string          `json:"creatorId" db:"creator_id"`
+	AggregateID   string          `json:"aggregateId" db:"aggregate_id"`
+	EventType     string          `json:"eventType" db:"event_type"`
+	Payload       json.RawMessage `json:"payload" db:"payload"`
+	Status        string          `json:"status" db:"status"` // pending, published, failed
+	Attempts      int             `json:"attempts" db:"attempts"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt" db:"next_attempt_at"`
+	LastError     string          `json:"lastError,omitempty" db:"last_error"`
+	CreatedAt     time.Time       `json:"createdAt" db:"created_at"`
+	PublishedAt   *time.Time      `json:"publishedAt" db:"published_at"`
+}
+
+// IdempotencyKey is a request made with an Idempotency-Key header and, once it has
+// finished, the response to replay for retries
+type IdempotencyKey struct {
+	UserID       string    `json:"userId" db:"user_id"`
+	Key          string    `json:"key" db:"idempotency_key"`
+	RequestHash  string    `json:"requestHash" db:"request_hash"`
+	StatusCode   *int      `json:"statusCode" db:"status_code"` // nil while the request is running
+	ContentType  string    `json:"contentType" db:"content_type"`
+	ResponseBody []byte    `json:"-" db:"response_body"`
+	CreatedAt    time.Time `json:"createdAt" db:"created_at"`
+	ExpiresAt    time.Time `json:"expiresAt" db:"expires_at"`
+}
+
+// Attachment is a file stored with a deal, such as a contract, brief, invoice or draft
+type Attachment struct {
+	ID            string    `json:"id" db:"id"`
+	SponsorshipID string    `json:"sponsorshipId" db:"sponsorship_id"`
+	CreatorID     string    `json:"creatorId" db:"creator_id"`
+	Kind          string    `json:"kind" db:"kind"` // contract, brief, invoice, draft, receipt, other
+	FileName      string    `json:"fileName" db:"file_name"`
+	ContentType   string    `json:"contentType" db:"content_type"`
+	SizeBytes     int64     `json:"sizeBytes" db:"size_bytes"`
+	StorageKey    string    `json:"-" db:"storage_key"`
+	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+}
+
+// DeliverableDraft is one version of a deliverable submitted for brand review
+type DeliverableDraft struct {
 	ID             string     `json:"id" db:"id"`
+	DeliverableID  string     `json:"deliverableId" db:"deliverable_id"`
+	SponsorshipID  string     `json:"sponsorshipId" db:"sponsorship_id"`
 	CreatorID      string     `json:"creatorId" db:"creator_id"`
-	BrandName      string     `json:"brandName" db:"brand_name"`
-	ProductService string     `json:"productService" db:"product_service"`
-	DealAmount     float64    `json:"dealAmount" db:"deal_amount"`
-	Priority       string     `json:"priority" db:"priority"` // high, medium, low
-	ContactName    string     `json:"contactName" db:"contact_name"`
-	ContactEmail   string     `json:"contactEmail" db:"contact_email"`
-	ContactPhone   string     `json:"contactPhone" db:"contact_phone"`
-	Description    string     `json:"description" db:"description"`
-	Deliverables   []string   `json:"deliverables" db:"deliverables"`
-	TargetAudience string     `json:"targetAudience" db:"target_audience"`
-	StartDate      time.Time  `json:"startDate" db:"start_date"`
-	EndDate        time.Time  `json:"endDate" db:"end_date"`
-	Status         string     `json:"status" db:"status"`
+	Version        int        `json:"version" db:"version"`
+	LinkURL        string     `json:"linkUrl" db:"link_url"`
+	AttachmentID   string     `json:"attachmentId,omitempty" db:"attachment_id"`
+	FileName       string     `json:"fileName" db:"file_name"`
 	Notes          string     `json:"notes" db:"notes"`
-	CreatedAt      time.Time  `json:"createdAt" db:"created_at"`
-	UpdatedAt      time.Time  `json:"updatedAt" db:"updated_at"`
-	DeletedAt      *time.Time `json:"deletedAt" db:"deleted_at"`
+	SubmittedAt    time.Time  `json:"submittedAt" db:"submitted_at"`
+	ReviewToken    string     `json:"reviewToken,omitempty" db:"review_token"`
+	Status         string     `json:"status" db:"status"` // pending-review, approved, changes-requested, superseded
+	ReviewerName   string     `json:"reviewerName" db:"reviewer_name"`
+	ReviewComments string     `json:"reviewComments" db:"review_comments"`
+	ReviewedAt     *time.Time `json:"reviewedAt" db:"reviewed_at"`
+}
+
+// Brand groups a creator's deals with the same normalised brand name
+type Brand struct {
+	ID             string    `json:"id" db:"id"`
+	CreatorID      string    `json:"creatorId" db:"creator_id"`
+	Name           string    `json:"name" db:"name"`
+	NormalizedName string    `json:"normalizedName" db:"normalized_name"`
+	Country        string    `json:"country" db:"country"` // ISO 3166-1 alpha-2 code of the paying entity
+	CreatedAt      time.Time `json:"createdAt" db:"created_at"`
+	UpdatedAt      time.Time `json:"updatedAt" db:"updated_at"`
+}
+
+// BrandSummary aggregates a creator's relationship history with one brand
+type BrandSummary struct {
+	BrandID               string      `json:"brandId"`
+	BrandName             string      `json:"brandName"`
+	TotalDeals            int         `json:"totalDeals"`
+	Currency              string      `json:"currency"`
+	TotalDealAmount       money.Money `json:"totalDealAmount"`
+	AverageDealAmount     money.Money `json:"averageDealAmount"`
+	Wins                  int         `json:"wins"`
+	Losses                int         `json:"losses"`
+	WinLossRatio          *float64    `json:"winLossRatio"`
+	AverageDaysToContract *float64    `json:"averageDaysToContract"`
+	LastContactAt         *time.Time  `json:"lastContactAt"`
+	MissingRates          []string    `json:"missingRates,omitempty"` // deals left out for want of a rate
+}
+
+// TriageRule is a creator-defined rule evaluated against every new pitch.
+// A rule fires when all of its conditions match.
+type TriageRule struct {
+	ID         string          `json:"id" db:"id"`
+	CreatorID  string          `json:"creatorId" db:"creator_id"`
+	Name       string          `json:"name" db:"name"`
+	Position   int             `json:"position" db:"position"`
+	Enabled    bool            `json:"enabled" db:"enabled"`
+	Conditions []RuleCondition `json:"conditions" db:"conditions"`
+	Action     RuleAction      `json:"action" db:"action"`
+	CreatedAt  time.Time       `json:"createdAt" db:"created_at"`
+	UpdatedAt  time.Time       `json:"updatedAt" db:"updated_at"`
+}
+
+// RuleCondition compares one pitch field against a value or list of values
+type RuleCondition struct {
+	Field    string   `json:"field"`    // dealAmount, category, brandName, contactEmailDomain, productService, priority
+	Operator string   `json:"operator"` // eq, neq, lt, lte, gt, gte, contains, in, not_in
+	Value    string   `json:"value,omitempty"`
+	Values   []string `json:"values,omitempty"`
+	Currency string   `json:"currency,omitempty"` // of a dealAmount value; the creator's base currency if not given
+}
+
+// RuleAction is what a matching rule does to the pitch
+type RuleAction struct {
+	Type     string `json:"type"`               // decline, reject, set_priority
+	Priority string `json:"priority,omitempty"` // for set_priority
+	Template string `json:"template,omitempty"` // email body sent to the brand contact on decline
+	Subject  string `json:"subject,omitempty"`
+}
+
+// ExclusivityClause grants a deal exclusivity over a category for a date window
+type ExclusivityClause struct {
+	ID            string    `json:"id" db:"id"`
+	SponsorshipID string    `json:"sponsorshipId" db:"sponsorship_id"`
+	CreatorID     string    `json:"creatorId" db:"creator_id"`
+	Category      string    `json:"category" db:"category"`
+	StartsOn      time.Time `json:"startsOn" db:"starts_on"`
+	EndsOn        time.Time `json:"endsOn" db:"ends_on"`
+	Notes         string    `json:"notes" db:"notes"`
+	CreatedAt     time.Time `json:"createdAt" db:"created_at"`
+}
+
+// ExclusivityConflict describes an active exclusivity window a deal runs into
+type ExclusivityConflict struct {
+	ClauseID      string    `json:"clauseId"`
+	SponsorshipID string    `json:"sponsorshipId"`
+	BrandName     string    `json:"brandName"`
+	Category      string    `json:"category"`
+	StartsOn      time.Time `json:"startsOn"`
+	EndsOn        time.Time `json:"endsOn"`
+}
+
+// BlocklistEntry is a brand or category the creator will not work with
+type BlocklistEntry struct {
+	ID              string    `json:"id" db:"id"`
+	CreatorID       string    `json:"creatorId" db:"creator_id"`
+	Kind            string    `json:"kind" db:"kind"` // brand, category
+	Value           string    `json:"value" db:"value"`
+	NormalizedValue string    `json:"-" db:"normalized_value"`
+	Reason          string    `json:"reason" db:"reason"`
+	CreatedAt       time.Time `json:"createdAt" db:"created_at"`
 }
 
 // SponsorshipStatusHistory tracks status changes
@@ -72,6 +703,64 @@ var ValidStatuses = []string{
 	"awaiting-review",
 	"published",
 	"completed",
+	"declined",
+}
+
+// WonStatuses are the statuses of deals that reached a signed contract
+var WonStatuses = []string{
+	"contracted",
+	"content-creation",
+	"awaiting-review",
+	"published",
+	"completed",
+}
+
+// EntitledSubscriptionStatuses are the subscription statuses that grant the plan's
+// entitlements. past_due keeps them while Stripe retries the payment.
+var EntitledSubscriptionStatuses = []string{
+	"active",
+	"trialing",
+	"past_due",
+}
+
+// Valid deliverable types
+var ValidDeliverableTypes = []string{
+	"dedicated-video",
+	"integration",
+	"short",
+	"story",
+}
+
+// Valid deliverable statuses, in workflow order
+var ValidDeliverableStatuses = []string{
+	"pending",
+	"in-progress",
+	"awaiting-review",
+	"approved",
+	"published",
+	"completed",
+}
+
+// Valid attachment kinds
+var ValidAttachmentKinds = []string{
+	"contract",
+	"brief",
+	"invoice",
+	"draft",
+	"receipt",
+	"other",
+}
+
+// ValidExpenseCategories are the kinds of production cost an expense can record
+var ValidExpenseCategories = []string{
+	"props",
+	"equipment",
+	"editing",
+	"travel",
+	"talent",
+	"software",
+	"advertising",
+	"other",
 }
 
 // Valid priorities
@@ -0,0 +1,91 @@
+// Package notifications emails creators about events in the outbox that they did not
+// cause themselves. It is an outbox subscriber, so an email is sent at least once for
+// each event; a creator can get the same email twice if another subscriber fails and the
+// event is handed out again.
+package notifications
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/mailer"
+	"sponsorship-backend/pkg/money"
+)
+
+// userFinder looks up the account a creator signs in with, as repositories.UserRepository does
+type userFinder interface {
+	GetUserByID(userID string) (*models.User, error)
+}
+
+// sponsorshipFinder looks up a creator's deal, as repositories.SponsorshipRepository does
+type sponsorshipFinder interface {
+	GetSponsorshipByID(id, creatorID string) (*models.Sponsorship, error)
+}
+
+// Notifier emails the creator when a brand pays through a payment link. Payments the
+// creator records by hand are not emailed, and neither are new deals, whose event does not
+// tell a public pitch from a deal the creator entered.
+type Notifier struct {
+	users        userFinder
+	sponsorships sponsorshipFinder
+	mailer       mailer.Mailer
+}
+
+func NewNotifier(users userFinder, sponsorships sponsorshipFinder, m mailer.Mailer) *Notifier {
+	return &Notifier{users: users, sponsorships: sponsorships, mailer: m}
+}
+
+// paymentReceipt is the stored payment.received payload; amounts are decoded as written,
+// since money.Money is only ever encoded
+type paymentReceipt struct {
+	SponsorshipID string      `json:"sponsorshipId"`
+	Amount        json.Number `json:"amount"`
+	Currency      string      `json:"currency"`
+	Source        string      `json:"source"`
+}
+
+// Handle emails the creator about an outbox event, as the outbox's notifications subscriber
+func (n *Notifier) Handle(event *models.OutboxEvent) error {
+	if event.EventType != models.WebhookEventPaymentReceived {
+		return nil
+	}
+
+	var receipt paymentReceipt
+	if err := json.Unmarshal(event.Payload, &receipt); err != nil {
+		return fmt.Errorf("failed to decode %s event: %w", event.EventType, err)
+	}
+	if receipt.Source != "payment_link" {
+		return nil
+	}
+	amount, err := money.Parse(receipt.Amount.String(), receipt.Currency)
+	if err != nil {
+		return fmt.Errorf("failed to parse amount of %s event: %w", event.EventType, err)
+	}
+
+	user, err := n.users.GetUserByID(event.CreatorID)
+	if err == errors.ErrNotFound {
+		logger.Warn("Payment notification %s skipped: creator %s no longer exists", event.ID, event.CreatorID)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	brand := "A brand"
+	if sponsorship, err := n.sponsorships.GetSponsorshipByID(receipt.SponsorshipID, event.CreatorID); err == nil {
+		brand = sponsorship.BrandName
+	} else if err != errors.ErrNotFound {
+		return err
+	}
+
+	return n.mailer.Send(&mailer.Message{
+		To:      []string{user.Email},
+		Subject: fmt.Sprintf("Payment received: %s %s from %s", amount.Format(), receipt.Currency, brand),
+		Body: fmt.Sprintf("Hi %s,\n\n%s paid %s %s through a payment link. The payment is recorded on the deal "+
+			"and the invoice or milestone it pays is marked paid.\n", user.Username, brand, amount.Format(),
+			receipt.Currency),
+	})
+}
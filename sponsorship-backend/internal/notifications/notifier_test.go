@@ -0,0 +1,89 @@
+package notifications
+
+import (
+	"encoding/json"
+	"testing"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/mailer"
+)
+
+const testCreatorID = "11111111-1111-1111-1111-111111111111"
+
+type fakeUsers map[string]*models.User
+
+func (f fakeUsers) GetUserByID(userID string) (*models.User, error) {
+	if user, ok := f[userID]; ok {
+		return user, nil
+	}
+	return nil, errors.ErrNotFound
+}
+
+type fakeSponsorships map[string]*models.Sponsorship
+
+func (f fakeSponsorships) GetSponsorshipByID(id, creatorID string) (*models.Sponsorship, error) {
+	if s, ok := f[id]; ok && s.CreatorID == creatorID {
+		return s, nil
+	}
+	return nil, errors.ErrNotFound
+}
+
+// fakeMailer collects the messages sent
+type fakeMailer struct {
+	sent []*mailer.Message
+}
+
+func (m *fakeMailer) Send(msg *mailer.Message) error {
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func paymentEvent(t *testing.T, creatorID, source string) *models.OutboxEvent {
+	payload, err := json.Marshal(map[string]interface{}{
+		"sponsorshipId": "deal-1",
+		"amount":        json.Number("1500.00"),
+		"currency":      "USD",
+		"source":        source,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.OutboxEvent{ID: "event-1", CreatorID: creatorID, EventType: models.WebhookEventPaymentReceived,
+		Payload: payload}
+}
+
+func TestNotifierHandle(t *testing.T) {
+	users := fakeUsers{testCreatorID: {ID: testCreatorID, Username: "creator1", Email: "creator@example.com"}}
+	sponsorships := fakeSponsorships{"deal-1": {ID: "deal-1", CreatorID: testCreatorID, BrandName: "Acme"}}
+
+	tests := []struct {
+		name    string
+		event   *models.OutboxEvent
+		subject string
+	}{
+		{"paid through a payment link", paymentEvent(t, testCreatorID, "payment_link"),
+			"Payment received: 1,500.00 USD from Acme"},
+		{"recorded by hand", paymentEvent(t, testCreatorID, "manual"), ""},
+		{"creator no longer exists", paymentEvent(t, "22222222-2222-2222-2222-222222222222", "payment_link"), ""},
+		{"not a payment", &models.OutboxEvent{ID: "event-2", CreatorID: testCreatorID,
+			EventType: models.WebhookEventSponsorshipCreated, Payload: json.RawMessage(`{}`)}, ""},
+	}
+
+	for _, tt := range tests {
+		m := &fakeMailer{}
+		if err := NewNotifier(users, sponsorships, m).Handle(tt.event); err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		if tt.subject == "" {
+			if len(m.sent) != 0 {
+				t.Errorf("%s: sent %q, want no email", tt.name, m.sent[0].Subject)
+			}
+			continue
+		}
+		if len(m.sent) != 1 || m.sent[0].Subject != tt.subject || m.sent[0].To[0] != "creator@example.com" {
+			t.Errorf("%s: sent %+v, want one email %q to the creator", tt.name, m.sent, tt.subject)
+		}
+	}
+}
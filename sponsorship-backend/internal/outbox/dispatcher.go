@@ -0,0 +1,164 @@
+// Package outbox hands the events stored with sponsorship changes and payments to
+// in-process subscribers. Events are written to the outbox_events table in the same
+// transaction as the change they report, so none is lost if the process stops after the
+// change is saved, and a Dispatcher passes each one to every subscriber at least once.
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/logger"
+)
+
+const (
+	// batchSize is how many due events are claimed at a time
+	batchSize = 50
+	// lease is how long a claimed event is left to its dispatcher before another may take it
+	lease = 5 * time.Minute
+	// maxAttempts is how many times an event is handed out before it is marked failed
+	maxAttempts = 20
+	// firstRetryDelay is the wait after the first failed attempt; each later wait doubles it
+	firstRetryDelay = 10 * time.Second
+	// maxRetryDelay caps the wait between attempts
+	maxRetryDelay = time.Hour
+)
+
+// Subscriber handles the events in the outbox. An event is handed out again until every
+// subscriber has handled it, so a subscriber can see the same event, with the same ID,
+// more than once and must handle it idempotently.
+type Subscriber interface {
+	Handle(event *models.OutboxEvent) error
+}
+
+// eventStore claims outbox events and records what became of them, as
+// repositories.OutboxRepository does
+type eventStore interface {
+	ClaimDueEvents(limit int, lease time.Duration) ([]*models.OutboxEvent, error)
+	MarkPublished(id string, publishedAt time.Time) error
+	RecordFailure(id, lastError, status string, nextAttemptAt time.Time) error
+}
+
+type subscription struct {
+	name       string
+	subscriber Subscriber
+}
+
+// Dispatcher hands due outbox events to its subscribers. Several instances can run
+// against one database; each event is claimed by one of them at a time.
+type Dispatcher struct {
+	repo          eventStore
+	pollInterval  time.Duration
+	subscriptions []subscription
+}
+
+func NewDispatcher(repo eventStore, pollInterval time.Duration) *Dispatcher {
+	return &Dispatcher{repo: repo, pollInterval: pollInterval}
+}
+
+// Subscribe adds a subscriber under a name used in logs and errors. Subscribers are
+// called in the order they were added, before Run is started.
+func (d *Dispatcher) Subscribe(name string, subscriber Subscriber) {
+	d.subscriptions = append(d.subscriptions, subscription{name: name, subscriber: subscriber})
+}
+
+// Run hands out due events every poll interval until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	logger.Info("Outbox dispatcher started: polling every %s, %d subscriber(s)", d.pollInterval, len(d.subscriptions))
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.DispatchDue()
+		}
+	}
+}
+
+// DispatchDue hands every due event to the subscribers, a batch at a time and oldest
+// first, and returns how many were handed out
+func (d *Dispatcher) DispatchDue() int {
+	dispatched := 0
+	for {
+		events, err := d.repo.ClaimDueEvents(batchSize, lease)
+		if err != nil {
+			logger.Error("Failed to claim outbox events: %v", err)
+			return dispatched
+		}
+
+		for _, event := range events {
+			d.dispatch(event)
+		}
+
+		dispatched += len(events)
+		if len(events) < batchSize {
+			return dispatched
+		}
+	}
+}
+
+// dispatch hands an event to every subscriber and records the outcome. If any subscriber
+// fails the event is retried later, for all of them.
+func (d *Dispatcher) dispatch(event *models.OutboxEvent) {
+	var failures []string
+	for _, s := range d.subscriptions {
+		if err := d.handle(s, event); err != nil {
+			failures = append(failures, s.name+": "+err.Error())
+		}
+	}
+
+	now := time.Now()
+	if len(failures) == 0 {
+		if err := d.repo.MarkPublished(event.ID, now); err != nil {
+			logger.Error("Failed to mark outbox event %s published: %v", event.ID, err)
+		}
+		return
+	}
+
+	attempt := event.Attempts + 1
+	lastError := strings.Join(failures, "; ")
+	status := "pending"
+	if attempt >= maxAttempts {
+		status = "failed"
+	}
+	if err := d.repo.RecordFailure(event.ID, lastError, status, now.Add(retryDelay(attempt))); err != nil {
+		logger.Error("Failed to record failure of outbox event %s: %v", event.ID, err)
+		return
+	}
+
+	if status == "failed" {
+		logger.Error("Outbox event %s (%s) abandoned after %d attempts: %s", event.ID, event.EventType, attempt, lastError)
+		return
+	}
+	logger.Warn("Outbox event %s (%s) failed, attempt %d of %d: %s", event.ID, event.EventType, attempt, maxAttempts,
+		lastError)
+}
+
+// handle calls one subscriber, turning a panic into an error so it cannot stop the
+// dispatcher or the other subscribers
+func (d *Dispatcher) handle(s subscription, event *models.OutboxEvent) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return s.subscriber.Handle(event)
+}
+
+// retryDelay is the wait after an event's attempt-th failed attempt
+func retryDelay(attempt int) time.Duration {
+	delay := firstRetryDelay
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,111 @@
+package outbox
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"sponsorship-backend/internal/models"
+)
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		attempt int
+		want    time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{3, 40 * time.Second},
+		{9, 2560 * time.Second},
+		{10, time.Hour},
+		{19, time.Hour},
+	}
+
+	for _, tt := range tests {
+		if got := retryDelay(tt.attempt); got != tt.want {
+			t.Errorf("retryDelay(%d) = %s, want %s", tt.attempt, got, tt.want)
+		}
+	}
+}
+
+// outcome is what the dispatcher recorded for an event
+type outcome struct {
+	published bool
+	lastError string
+	status    string
+	nextAt    time.Time
+}
+
+// fakeStore records what becomes of each event
+type fakeStore map[string]*outcome
+
+func (f fakeStore) ClaimDueEvents(limit int, lease time.Duration) ([]*models.OutboxEvent, error) {
+	return nil, nil
+}
+
+func (f fakeStore) MarkPublished(id string, publishedAt time.Time) error {
+	f[id] = &outcome{published: true}
+	return nil
+}
+
+func (f fakeStore) RecordFailure(id, lastError, status string, nextAttemptAt time.Time) error {
+	f[id] = &outcome{lastError: lastError, status: status, nextAt: nextAttemptAt}
+	return nil
+}
+
+// subscriberFunc adapts a function to Subscriber
+type subscriberFunc func(event *models.OutboxEvent) error
+
+func (f subscriberFunc) Handle(event *models.OutboxEvent) error {
+	return f(event)
+}
+
+func TestDispatch(t *testing.T) {
+	ok := subscriberFunc(func(*models.OutboxEvent) error { return nil })
+	failing := subscriberFunc(func(*models.OutboxEvent) error { return errors.New("endpoint store down") })
+	panicking := subscriberFunc(func(*models.OutboxEvent) error { panic("nil map") })
+
+	tests := []struct {
+		name        string
+		subscribers []Subscriber
+		attempts    int
+		published   bool
+		status      string
+		lastError   string
+		delay       time.Duration
+	}{
+		{"every subscriber succeeds", []Subscriber{ok, ok}, 0, true, "", "", 0},
+		{"one subscriber fails", []Subscriber{ok, failing}, 0, false, "pending", "second: endpoint store down",
+			10 * time.Second},
+		{"a panic is a failure", []Subscriber{panicking, ok}, 2, false, "pending", "first: panic: nil map",
+			40 * time.Second},
+		{"last attempt fails", []Subscriber{failing}, maxAttempts - 1, false, "failed",
+			"first: endpoint store down", time.Hour},
+	}
+
+	for _, tt := range tests {
+		store := fakeStore{}
+		d := NewDispatcher(store, time.Second)
+		for i, s := range tt.subscribers {
+			d.Subscribe([]string{"first", "second"}[i], s)
+		}
+
+		before := time.Now()
+		d.dispatch(&models.OutboxEvent{ID: "event-1", EventType: models.WebhookEventSponsorshipCreated,
+			Attempts: tt.attempts})
+
+		got := store["event-1"]
+		if got == nil {
+			t.Errorf("%s: nothing was recorded", tt.name)
+			continue
+		}
+		if got.published != tt.published || got.status != tt.status || !strings.Contains(got.lastError, tt.lastError) {
+			t.Errorf("%s: recorded %+v, want published=%v status=%q error %q", tt.name, got, tt.published,
+				tt.status, tt.lastError)
+		}
+		if !tt.published && (got.nextAt.Before(before.Add(tt.delay)) || got.nextAt.After(time.Now().Add(tt.delay))) {
+			t.Errorf("%s: next attempt at %s, want %s after dispatch", tt.name, got.nextAt, tt.delay)
+		}
+	}
+}
@@ -0,0 +1,167 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+)
+
+type AttachmentRepository struct {
+	db *sql.DB
+}
+
+func NewAttachmentRepository(db *sql.DB) *AttachmentRepository {
+	return &AttachmentRepository{db: db}
+}
+
+const attachmentColumns = `
+	id, sponsorship_id, creator_id, kind, file_name, content_type, size_bytes, storage_key, created_at
+`
+
+func scanAttachment(row scanner) (*models.Attachment, error) {
+	a := &models.Attachment{}
+	if err := row.Scan(
+		&a.ID, &a.SponsorshipID, &a.CreatorID, &a.Kind, &a.FileName,
+		&a.ContentType, &a.SizeBytes, &a.StorageKey, &a.CreatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return a, nil
+}
+
+// CreateAttachment records a stored file. The ID and storage key are chosen by the
+// caller, since the object is written before the row. It records nothing and returns
+// ErrStorageQuotaExceeded, with the quota and usage as details, if the creator's
+// attachments would then take more than quotaBytes.
+func (r *AttachmentRepository) CreateAttachment(a *models.Attachment, quotaBytes int64) error {
+	a.CreatedAt = time.Now()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin attachment transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the creator's user row serialises their uploads, so two cannot both fit
+	// in the space left
+	var lockedID string
+	err = tx.QueryRow(`SELECT id FROM users WHERE id = $1 FOR UPDATE`, a.CreatorID).Scan(&lockedID)
+	if err == sql.ErrNoRows {
+		return errors.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock creator: %w", err)
+	}
+
+	var used int64
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(size_bytes), 0) FROM attachments WHERE creator_id = $1
+	`, a.CreatorID).Scan(&used)
+	if err != nil {
+		return fmt.Errorf("failed to get storage used: %w", err)
+	}
+	if used+a.SizeBytes > quotaBytes {
+		return errors.ErrStorageQuotaExceeded.WithDetails(map[string]int64{
+			"quotaBytes": quotaBytes,
+			"usedBytes":  used,
+		})
+	}
+
+	query := `
+		INSERT INTO attachments (
+			id, sponsorship_id, creator_id, kind, file_name, content_type, size_bytes, storage_key, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+	`
+
+	_, err = tx.Exec(query, a.ID, a.SponsorshipID, a.CreatorID, a.Kind, a.FileName,
+		a.ContentType, a.SizeBytes, a.StorageKey, a.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit attachment: %w", err)
+	}
+
+	return nil
+}
+
+// GetAttachment retrieves an attachment of a sponsorship
+func (r *AttachmentRepository) GetAttachment(id, sponsorshipID, creatorID string) (*models.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + `
+		FROM attachments
+		WHERE id = $1 AND sponsorship_id = $2 AND creator_id = $3
+	`
+
+	a, err := scanAttachment(r.db.QueryRow(query, id, sponsorshipID, creatorID))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+
+	return a, nil
+}
+
+// ListAttachments retrieves the attachments of a sponsorship, newest first
+func (r *AttachmentRepository) ListAttachments(sponsorshipID, creatorID string) ([]*models.Attachment, error) {
+	query := `SELECT ` + attachmentColumns + `
+		FROM attachments
+		WHERE sponsorship_id = $1 AND creator_id = $2
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(query, sponsorshipID, creatorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []*models.Attachment
+	for rows.Next() {
+		a, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, a)
+	}
+
+	return attachments, rows.Err()
+}
+
+// DeleteAttachment removes an attachment record
+func (r *AttachmentRepository) DeleteAttachment(id, sponsorshipID, creatorID string) error {
+	result, err := r.db.Exec(`
+		DELETE FROM attachments WHERE id = $1 AND sponsorship_id = $2 AND creator_id = $3
+	`, id, sponsorshipID, creatorID)
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// GetStorageUsed returns the total size of a creator's attachments in bytes
+func (r *AttachmentRepository) GetStorageUsed(creatorID string) (int64, error) {
+	var used int64
+	err := r.db.QueryRow(`
+		SELECT COALESCE(SUM(size_bytes), 0) FROM attachments WHERE creator_id = $1
+	`, creatorID).Scan(&used)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get storage used: %w", err)
+	}
+
+	return used, nil
+}
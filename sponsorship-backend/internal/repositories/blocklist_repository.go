@@ -0,0 +1,121 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type BlocklistRepository struct {
+	db *sql.DB
+}
+
+func NewBlocklistRepository(db *sql.DB) *BlocklistRepository {
+	return &BlocklistRepository{db: db}
+}
+
+// CreateEntry adds a brand or category to the creator's blocklist
+func (r *BlocklistRepository) CreateEntry(entry *models.BlocklistEntry) error {
+	entry.ID = uuid.New().String()
+	entry.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO blocklist_entries (id, creator_id, kind, value, normalized_value, reason, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7)
+	`
+
+	_, err := r.db.Exec(query, entry.ID, entry.CreatorID, entry.Kind, entry.Value,
+		entry.NormalizedValue, entry.Reason, entry.CreatedAt)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return errors.ErrConflict.WithDetails("entry is already on the blocklist")
+		}
+		return fmt.Errorf("failed to create blocklist entry: %w", err)
+	}
+
+	return nil
+}
+
+// ListEntries retrieves the creator's blocklist
+func (r *BlocklistRepository) ListEntries(creatorID string) ([]*models.BlocklistEntry, error) {
+	query := `
+		SELECT id, creator_id, kind, value, normalized_value, COALESCE(reason, ''), created_at
+		FROM blocklist_entries
+		WHERE creator_id = $1
+		ORDER BY kind, value
+	`
+
+	rows, err := r.db.Query(query, creatorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list blocklist entries: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.BlocklistEntry
+	for rows.Next() {
+		entry := &models.BlocklistEntry{}
+		if err := rows.Scan(
+			&entry.ID, &entry.CreatorID, &entry.Kind, &entry.Value,
+			&entry.NormalizedValue, &entry.Reason, &entry.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan blocklist entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
+
+// DeleteEntry removes an entry from the creator's blocklist
+func (r *BlocklistRepository) DeleteEntry(id, creatorID string) error {
+	result, err := r.db.Exec(`DELETE FROM blocklist_entries WHERE id = $1 AND creator_id = $2`, id, creatorID)
+	if err != nil {
+		return fmt.Errorf("failed to delete blocklist entry: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// FindMatches returns the blocklist entries matching a normalised brand name or category
+func (r *BlocklistRepository) FindMatches(creatorID, normalizedBrand, normalizedCategory string) ([]*models.BlocklistEntry, error) {
+	query := `
+		SELECT id, creator_id, kind, value, normalized_value, COALESCE(reason, ''), created_at
+		FROM blocklist_entries
+		WHERE creator_id = $1
+		  AND ((kind = 'brand' AND normalized_value = $2) OR (kind = 'category' AND normalized_value = $3))
+	`
+
+	rows, err := r.db.Query(query, creatorID, normalizedBrand, normalizedCategory)
+	if err != nil {
+		return nil, fmt.Errorf("failed to match blocklist: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.BlocklistEntry
+	for rows.Next() {
+		entry := &models.BlocklistEntry{}
+		if err := rows.Scan(
+			&entry.ID, &entry.CreatorID, &entry.Kind, &entry.Value,
+			&entry.NormalizedValue, &entry.Reason, &entry.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan blocklist entry: %w", err)
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,287 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type BrandRepository struct {
+	db *sql.DB
+}
+
+func NewBrandRepository(db *sql.DB) *BrandRepository {
+	return &BrandRepository{db: db}
+}
+
+// FindOrCreateBrand returns the creator's brand with the given normalised name, creating it if needed
+func (r *BrandRepository) FindOrCreateBrand(creatorID, name, normalizedName string) (*models.Brand, error) {
+	brand := &models.Brand{}
+	query := `
+		INSERT INTO brands (id, creator_id, name, normalized_name, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		ON CONFLICT (creator_id, normalized_name) DO UPDATE SET updated_at = brands.updated_at
+		RETURNING id, creator_id, name, normalized_name, COALESCE(country, ''), created_at, updated_at
+	`
+
+	err := r.db.QueryRow(query, uuid.New().String(), creatorID, name, normalizedName).Scan(
+		&brand.ID, &brand.CreatorID, &brand.Name, &brand.NormalizedName, &brand.Country, &brand.CreatedAt, &brand.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find or create brand: %w", err)
+	}
+
+	return brand, nil
+}
+
+// ListUnbrandedSponsorships retrieves the ID, creator and brand name of every deal not yet
+// linked to a brand, newest first
+func (r *BrandRepository) ListUnbrandedSponsorships() ([]*models.Sponsorship, error) {
+	rows, err := r.db.Query(`
+		SELECT id, creator_id, brand_name FROM sponsorships WHERE brand_id IS NULL ORDER BY created_at DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list unbranded sponsorships: %w", err)
+	}
+	defer rows.Close()
+
+	var sponsorships []*models.Sponsorship
+	for rows.Next() {
+		s := &models.Sponsorship{}
+		if err := rows.Scan(&s.ID, &s.CreatorID, &s.BrandName); err != nil {
+			return nil, fmt.Errorf("failed to scan sponsorship: %w", err)
+		}
+		sponsorships = append(sponsorships, s)
+	}
+
+	return sponsorships, rows.Err()
+}
+
+// ListAllBrands retrieves every creator's brands
+func (r *BrandRepository) ListAllBrands() ([]*models.Brand, error) {
+	rows, err := r.db.Query(`
+		SELECT id, creator_id, name, normalized_name, COALESCE(country, ''), created_at, updated_at
+		FROM brands
+		ORDER BY creator_id, created_at
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list brands: %w", err)
+	}
+	defer rows.Close()
+
+	var brands []*models.Brand
+	for rows.Next() {
+		brand := &models.Brand{}
+		if err := rows.Scan(
+			&brand.ID, &brand.CreatorID, &brand.Name, &brand.NormalizedName, &brand.Country, &brand.CreatedAt, &brand.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan brand: %w", err)
+		}
+		brands = append(brands, brand)
+	}
+
+	return brands, rows.Err()
+}
+
+// RenormalizeBrand gives a brand a new normalised name. If the creator already has a brand
+// under that name, the brand is merged into it instead: its deals are moved over, its
+// country fills the other's if that has none, and it is deleted.
+func (r *BrandRepository) RenormalizeBrand(brand *models.Brand, normalizedName string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin brand transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var existingID string
+	err = tx.QueryRow(`
+		SELECT id FROM brands WHERE creator_id = $1 AND normalized_name = $2 AND id <> $3 FOR UPDATE
+	`, brand.CreatorID, normalizedName, brand.ID).Scan(&existingID)
+	switch {
+	case err == sql.ErrNoRows:
+		if _, err := tx.Exec(`
+			UPDATE brands SET normalized_name = $2, updated_at = NOW() WHERE id = $1
+		`, brand.ID, normalizedName); err != nil {
+			return fmt.Errorf("failed to update brand: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to find brand: %w", err)
+	default:
+		if _, err := tx.Exec(`UPDATE sponsorships SET brand_id = $2 WHERE brand_id = $1`, brand.ID, existingID); err != nil {
+			return fmt.Errorf("failed to move brand deals: %w", err)
+		}
+		if _, err := tx.Exec(`
+			UPDATE brands SET country = COALESCE(country, NULLIF($2, '')), updated_at = NOW() WHERE id = $1
+		`, existingID, brand.Country); err != nil {
+			return fmt.Errorf("failed to update brand: %w", err)
+		}
+		if _, err := tx.Exec(`DELETE FROM brands WHERE id = $1`, brand.ID); err != nil {
+			return fmt.Errorf("failed to delete merged brand: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit brand: %w", err)
+	}
+
+	brand.NormalizedName = normalizedName
+	return nil
+}
+
+// SetSponsorshipBrand links a deal to a brand, unless it has been linked meanwhile
+func (r *BrandRepository) SetSponsorshipBrand(sponsorshipID, brandID string) error {
+	_, err := r.db.Exec(`
+		UPDATE sponsorships SET brand_id = $2 WHERE id = $1 AND brand_id IS NULL
+	`, sponsorshipID, brandID)
+	if err != nil {
+		return fmt.Errorf("failed to set sponsorship brand: %w", err)
+	}
+	return nil
+}
+
+// GetBrandByID retrieves a brand by ID
+func (r *BrandRepository) GetBrandByID(id, creatorID string) (*models.Brand, error) {
+	brand := &models.Brand{}
+	query := `
+		SELECT id, creator_id, name, normalized_name, COALESCE(country, ''), created_at, updated_at
+		FROM brands
+		WHERE id = $1 AND creator_id = $2
+	`
+
+	err := r.db.QueryRow(query, id, creatorID).Scan(
+		&brand.ID, &brand.CreatorID, &brand.Name, &brand.NormalizedName, &brand.Country, &brand.CreatedAt, &brand.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get brand: %w", err)
+	}
+
+	return brand, nil
+}
+
+// UpdateBrand saves the editable fields of a brand
+func (r *BrandRepository) UpdateBrand(brand *models.Brand) error {
+	brand.UpdatedAt = time.Now()
+
+	result, err := r.db.Exec(`
+		UPDATE brands SET country = NULLIF($1, ''), updated_at = $2
+		WHERE id = $3 AND creator_id = $4
+	`, brand.Country, brand.UpdatedAt, brand.ID, brand.CreatorID)
+	if err != nil {
+		return fmt.Errorf("failed to update brand: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListBrands retrieves all brands for a creator
+func (r *BrandRepository) ListBrands(creatorID string) ([]*models.Brand, error) {
+	query := `
+		SELECT id, creator_id, name, normalized_name, COALESCE(country, ''), created_at, updated_at
+		FROM brands
+		WHERE creator_id = $1
+		ORDER BY name
+	`
+
+	rows, err := r.db.Query(query, creatorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list brands: %w", err)
+	}
+	defer rows.Close()
+
+	var brands []*models.Brand
+	for rows.Next() {
+		brand := &models.Brand{}
+		if err := rows.Scan(
+			&brand.ID, &brand.CreatorID, &brand.Name, &brand.NormalizedName, &brand.Country, &brand.CreatedAt, &brand.UpdatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan brand: %w", err)
+		}
+		brands = append(brands, brand)
+	}
+
+	return brands, rows.Err()
+}
+
+// GetBrandSummary aggregates deal counts, outcomes and timing for one brand. Deal amounts
+// may be in several currencies, so they are totalled by the caller.
+func (r *BrandRepository) GetBrandSummary(brand *models.Brand) (*models.BrandSummary, error) {
+	summary := &models.BrandSummary{
+		BrandID:   brand.ID,
+		BrandName: brand.Name,
+	}
+
+	var lastContact sql.NullTime
+	query := `
+		SELECT COUNT(*),
+		       COUNT(*) FILTER (WHERE status = ANY($3)),
+		       COUNT(*) FILTER (WHERE status = 'declined'),
+		       GREATEST(
+		           MAX(updated_at),
+		           (SELECT MAX(h.changed_at)
+		            FROM sponsorship_status_history h
+		            JOIN sponsorships hs ON hs.id = h.sponsorship_id
+		            WHERE hs.brand_id = $1 AND hs.creator_id = $2 AND hs.deleted_at IS NULL)
+		       )
+		FROM sponsorships
+		WHERE brand_id = $1 AND creator_id = $2 AND deleted_at IS NULL
+	`
+
+	err := r.db.QueryRow(query, brand.ID, brand.CreatorID, pq.Array(models.WonStatuses)).Scan(
+		&summary.TotalDeals,
+		&summary.Wins, &summary.Losses, &lastContact,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarise brand deals: %w", err)
+	}
+
+	if lastContact.Valid {
+		summary.LastContactAt = &lastContact.Time
+	}
+	if summary.Losses > 0 {
+		ratio := float64(summary.Wins) / float64(summary.Losses)
+		summary.WinLossRatio = &ratio
+	}
+
+	// A deal is pitch-received from the moment it is created; the first move to a won
+	// status in its history ends the negotiation window, since a deal can skip straight
+	// past contracted.
+	var avgSeconds sql.NullFloat64
+	timingQuery := `
+		SELECT AVG(EXTRACT(EPOCH FROM (c.contracted_at - s.created_at)))
+		FROM sponsorships s
+		JOIN (
+		    SELECT sponsorship_id, MIN(changed_at) AS contracted_at
+		    FROM sponsorship_status_history
+		    WHERE new_status = ANY($3)
+		    GROUP BY sponsorship_id
+		) c ON c.sponsorship_id = s.id
+		WHERE s.brand_id = $1 AND s.creator_id = $2 AND s.deleted_at IS NULL
+	`
+
+	if err := r.db.QueryRow(timingQuery, brand.ID, brand.CreatorID, pq.Array(models.WonStatuses)).Scan(&avgSeconds); err != nil {
+		return nil, fmt.Errorf("failed to compute time to contract: %w", err)
+	}
+	if avgSeconds.Valid {
+		days := avgSeconds.Float64 / (24 * time.Hour).Seconds()
+		summary.AverageDaysToContract = &days
+	}
+
+	return summary, nil
+}
@@ -0,0 +1,255 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/money"
+)
+
+type CouponRepository struct {
+	db *sql.DB
+}
+
+func NewCouponRepository(db *sql.DB) *CouponRepository {
+	return &CouponRepository{db: db}
+}
+
+const couponColumns = `
+	code, COALESCE(description, ''), percent_off, amount_off, COALESCE(currency, ''), duration, expires_at,
+	max_redemptions, times_redeemed,
+	ARRAY(SELECT product_id FROM coupon_products WHERE coupon_code = coupons.code ORDER BY product_id),
+	active, created_at, updated_at
+`
+
+func scanCoupon(row scanner) (*models.Coupon, error) {
+	c := &models.Coupon{}
+	var amountOff sql.NullString
+	if err := row.Scan(
+		&c.Code, &c.Description, &c.PercentOff, &amountOff, &c.Currency, &c.Duration, &c.ExpiresAt,
+		&c.MaxRedemptions, &c.TimesRedeemed, pq.Array(&c.ProductIDs), &c.Active, &c.CreatedAt, &c.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if amountOff.Valid {
+		m, err := parseAmount(amountOff.String, c.Currency)
+		if err != nil {
+			return nil, err
+		}
+		c.AmountOff = &m
+	}
+	return c, nil
+}
+
+// CreateCoupon stores a new coupon and the products it is limited to. It returns
+// ErrConflict if the code is taken.
+func (r *CouponRepository) CreateCoupon(c *models.Coupon) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin coupon transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	var amountOff interface{}
+	if c.AmountOff != nil {
+		amountOff = *c.AmountOff
+	}
+	_, err = tx.Exec(`
+		INSERT INTO coupons (
+			code, description, percent_off, amount_off, currency, duration, expires_at, max_redemptions,
+			times_redeemed, active, created_at, updated_at
+		) VALUES ($1, NULLIF($2, ''), $3, $4, NULLIF($5, ''), $6, $7, $8, 0, TRUE, $9, $9)
+	`, c.Code, c.Description, c.PercentOff, amountOff, c.Currency, c.Duration, c.ExpiresAt, c.MaxRedemptions, now)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return errors.ErrConflict.WithDetails("coupon code already exists")
+		}
+		return fmt.Errorf("failed to create coupon: %w", err)
+	}
+
+	for _, productID := range c.ProductIDs {
+		if _, err := tx.Exec(`
+			INSERT INTO coupon_products (coupon_code, product_id) VALUES ($1, $2)
+			ON CONFLICT DO NOTHING
+		`, c.Code, productID); err != nil {
+			return fmt.Errorf("failed to limit coupon to product %s: %w", productID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit coupon: %w", err)
+	}
+
+	c.Active = true
+	c.TimesRedeemed = 0
+	c.CreatedAt = now
+	c.UpdatedAt = now
+	return nil
+}
+
+// GetCoupon retrieves a coupon by code, whether or not it can still be used
+func (r *CouponRepository) GetCoupon(code string) (*models.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupons WHERE code = $1`
+
+	c, err := scanCoupon(r.db.QueryRow(query, code))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get coupon: %w", err)
+	}
+
+	return c, nil
+}
+
+// ListCoupons retrieves every coupon, newest first
+func (r *CouponRepository) ListCoupons() ([]*models.Coupon, error) {
+	query := `SELECT ` + couponColumns + ` FROM coupons ORDER BY created_at DESC, code`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list coupons: %w", err)
+	}
+	defer rows.Close()
+
+	var coupons []*models.Coupon
+	for rows.Next() {
+		c, err := scanCoupon(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan coupon: %w", err)
+		}
+		coupons = append(coupons, c)
+	}
+
+	return coupons, rows.Err()
+}
+
+// DeactivateCoupon stops a coupon being accepted at checkout. Checkouts already started
+// with it keep their discount.
+func (r *CouponRepository) DeactivateCoupon(code string) error {
+	result, err := r.db.Exec(`UPDATE coupons SET active = FALSE, updated_at = NOW() WHERE code = $1`, code)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate coupon: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to deactivate coupon: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+// HoldRedemption holds one of a coupon's redemptions for a checkout until heldUntil.
+// Redemptions and unexpired holds together never exceed max_redemptions; it returns
+// ErrConflict if none are left.
+func (r *CouponRepository) HoldRedemption(redemption *models.CouponRedemption) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin coupon transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Locking the coupon serialises holds, so two checkouts cannot take the last redemption
+	var maxRedemptions sql.NullInt64
+	err = tx.QueryRow(`SELECT max_redemptions FROM coupons WHERE code = $1 FOR UPDATE`,
+		redemption.CouponCode).Scan(&maxRedemptions)
+	if err == sql.ErrNoRows {
+		return errors.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock coupon: %w", err)
+	}
+
+	now := time.Now()
+	if maxRedemptions.Valid {
+		var used int64
+		err = tx.QueryRow(`
+			SELECT COUNT(*) FROM coupon_redemptions
+			WHERE coupon_code = $1 AND (status = 'redeemed' OR (status = 'held' AND held_until > $2))
+		`, redemption.CouponCode, now).Scan(&used)
+		if err != nil {
+			return fmt.Errorf("failed to count coupon redemptions: %w", err)
+		}
+		if used >= maxRedemptions.Int64 {
+			return errors.ErrConflict
+		}
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO coupon_redemptions (coupon_code, user_id, product_id, status, held_until, created_at)
+		VALUES ($1, NULLIF($2, '')::uuid, NULLIF($3, ''), 'held', $4, $5)
+		RETURNING id
+	`, redemption.CouponCode, redemption.UserID, redemption.ProductID, redemption.HeldUntil, now).Scan(&redemption.ID)
+	if err != nil {
+		return fmt.Errorf("failed to hold coupon redemption: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit coupon redemption: %w", err)
+	}
+
+	redemption.Status = "held"
+	redemption.CreatedAt = now
+	return nil
+}
+
+// RedeemRedemption counts a held redemption once its checkout is paid, even if the hold
+// has lapsed or been released meanwhile. It returns false if it was already redeemed.
+func (r *CouponRepository) RedeemRedemption(id, providerReference string, discount money.Money, redeemedAt time.Time) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin coupon transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var code string
+	err = tx.QueryRow(`
+		UPDATE coupon_redemptions
+		SET status = 'redeemed', provider_reference = $2, discount_amount = $3, currency = $4, redeemed_at = $5
+		WHERE id = $1 AND status <> 'redeemed'
+		RETURNING coupon_code
+	`, id, providerReference, discount, discount.Currency(), redeemedAt).Scan(&code)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to redeem coupon: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE coupons SET times_redeemed = times_redeemed + 1, updated_at = NOW() WHERE code = $1
+	`, code); err != nil {
+		return false, fmt.Errorf("failed to count coupon redemption: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit coupon redemption: %w", err)
+	}
+	return true, nil
+}
+
+// ReleaseRedemption gives a held redemption back, as when its checkout expires or cannot
+// be started. It returns false if the redemption was not held.
+func (r *CouponRepository) ReleaseRedemption(id string) (bool, error) {
+	result, err := r.db.Exec(`
+		UPDATE coupon_redemptions SET status = 'released' WHERE id = $1 AND status = 'held'
+	`, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to release coupon redemption: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to release coupon redemption: %w", err)
+	}
+	return rows > 0, nil
+}
@@ -0,0 +1,157 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+type DeliverableRepository struct {
+	db *sql.DB
+}
+
+func NewDeliverableRepository(db *sql.DB) *DeliverableRepository {
+	return &DeliverableRepository{db: db}
+}
+
+const deliverableColumns = `
+	id, sponsorship_id, creator_id, type, COALESCE(platform, ''), title, due_date,
+	publish_date, COALESCE(published_url, ''), status, revision_count, created_at, updated_at
+`
+
+func scanDeliverable(row scanner) (*models.Deliverable, error) {
+	d := &models.Deliverable{}
+	var dueDate, publishDate sql.NullTime
+	if err := row.Scan(
+		&d.ID, &d.SponsorshipID, &d.CreatorID, &d.Type, &d.Platform, &d.Title, &dueDate,
+		&publishDate, &d.PublishedURL, &d.Status, &d.RevisionCount, &d.CreatedAt, &d.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if dueDate.Valid {
+		d.DueDate = &dueDate.Time
+	}
+	if publishDate.Valid {
+		d.PublishDate = &publishDate.Time
+	}
+	return d, nil
+}
+
+// CreateDeliverable adds a deliverable to a sponsorship
+func (r *DeliverableRepository) CreateDeliverable(d *models.Deliverable) error {
+	d.ID = uuid.New().String()
+	d.CreatedAt = time.Now()
+	d.UpdatedAt = d.CreatedAt
+
+	query := `
+		INSERT INTO deliverables (
+			id, sponsorship_id, creator_id, type, platform, title, due_date,
+			publish_date, published_url, status, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, NULLIF($9, ''), $10, $11, $12)
+	`
+
+	_, err := r.db.Exec(query, d.ID, d.SponsorshipID, d.CreatorID, d.Type, d.Platform, d.Title,
+		d.DueDate, d.PublishDate, d.PublishedURL, d.Status, d.CreatedAt, d.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create deliverable: %w", err)
+	}
+
+	return nil
+}
+
+// GetDeliverable retrieves a deliverable of a sponsorship
+func (r *DeliverableRepository) GetDeliverable(id, sponsorshipID, creatorID string) (*models.Deliverable, error) {
+	query := `SELECT ` + deliverableColumns + `
+		FROM deliverables
+		WHERE id = $1 AND sponsorship_id = $2 AND creator_id = $3
+	`
+
+	d, err := scanDeliverable(r.db.QueryRow(query, id, sponsorshipID, creatorID))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deliverable: %w", err)
+	}
+
+	return d, nil
+}
+
+// ListDeliverables retrieves the deliverables of a sponsorship ordered by due date
+func (r *DeliverableRepository) ListDeliverables(sponsorshipID, creatorID string) ([]*models.Deliverable, error) {
+	query := `SELECT ` + deliverableColumns + `
+		FROM deliverables
+		WHERE sponsorship_id = $1 AND creator_id = $2
+		ORDER BY due_date NULLS LAST, created_at
+	`
+
+	rows, err := r.db.Query(query, sponsorshipID, creatorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deliverables: %w", err)
+	}
+	defer rows.Close()
+
+	var deliverables []*models.Deliverable
+	for rows.Next() {
+		d, err := scanDeliverable(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan deliverable: %w", err)
+		}
+		deliverables = append(deliverables, d)
+	}
+
+	return deliverables, rows.Err()
+}
+
+// UpdateDeliverable saves all editable fields of a deliverable
+func (r *DeliverableRepository) UpdateDeliverable(d *models.Deliverable) error {
+	d.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE deliverables
+		SET type = $1, platform = NULLIF($2, ''), title = $3, due_date = $4, publish_date = $5,
+		    published_url = NULLIF($6, ''), status = $7, updated_at = $8
+		WHERE id = $9 AND sponsorship_id = $10 AND creator_id = $11
+	`
+
+	result, err := r.db.Exec(query, d.Type, d.Platform, d.Title, d.DueDate, d.PublishDate,
+		d.PublishedURL, d.Status, d.UpdatedAt, d.ID, d.SponsorshipID, d.CreatorID)
+	if err != nil {
+		return fmt.Errorf("failed to update deliverable: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteDeliverable removes a deliverable from a sponsorship
+func (r *DeliverableRepository) DeleteDeliverable(id, sponsorshipID, creatorID string) error {
+	result, err := r.db.Exec(`
+		DELETE FROM deliverables WHERE id = $1 AND sponsorship_id = $2 AND creator_id = $3
+	`, id, sponsorshipID, creatorID)
+	if err != nil {
+		return fmt.Errorf("failed to delete deliverable: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
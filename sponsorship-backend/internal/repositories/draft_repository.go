@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+type DraftRepository struct {
+	db *sql.DB
+}
+
+func NewDraftRepository(db *sql.DB) *DraftRepository {
+	return &DraftRepository{db: db}
+}
+
+const draftColumns = `
+	id, deliverable_id, sponsorship_id, creator_id, version, COALESCE(link_url, ''),
+	COALESCE(attachment_id::text, ''), COALESCE(file_name, ''),
+	COALESCE(notes, ''), submitted_at, review_token, status, COALESCE(reviewer_name, ''),
+	COALESCE(review_comments, ''), reviewed_at
+`
+
+func scanDraft(row scanner) (*models.DeliverableDraft, error) {
+	d := &models.DeliverableDraft{}
+	var reviewedAt sql.NullTime
+	if err := row.Scan(
+		&d.ID, &d.DeliverableID, &d.SponsorshipID, &d.CreatorID, &d.Version, &d.LinkURL,
+		&d.AttachmentID, &d.FileName,
+		&d.Notes, &d.SubmittedAt, &d.ReviewToken, &d.Status, &d.ReviewerName,
+		&d.ReviewComments, &reviewedAt,
+	); err != nil {
+		return nil, err
+	}
+	if reviewedAt.Valid {
+		d.ReviewedAt = &reviewedAt.Time
+	}
+	return d, nil
+}
+
+// newReviewToken returns an unguessable token for the brand-side review link
+func newReviewToken() (string, error) {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// SubmitDraft stores the next version of a deliverable, supersedes any draft still waiting
+// for review and moves the deliverable to awaiting-review, all in one transaction
+func (r *DraftRepository) SubmitDraft(draft *models.DeliverableDraft) error {
+	token, err := newReviewToken()
+	if err != nil {
+		return fmt.Errorf("failed to generate review token: %w", err)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin draft transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the deliverable so concurrent submissions get distinct versions
+	var status string
+	err = tx.QueryRow(`
+		SELECT status FROM deliverables
+		WHERE id = $1 AND sponsorship_id = $2 AND creator_id = $3
+		FOR UPDATE
+	`, draft.DeliverableID, draft.SponsorshipID, draft.CreatorID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return errors.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock deliverable: %w", err)
+	}
+
+	if err := tx.QueryRow(`
+		SELECT COALESCE(MAX(version), 0) + 1 FROM deliverable_drafts WHERE deliverable_id = $1
+	`, draft.DeliverableID).Scan(&draft.Version); err != nil {
+		return fmt.Errorf("failed to get next draft version: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE deliverable_drafts SET status = 'superseded'
+		WHERE deliverable_id = $1 AND status = 'pending-review'
+	`, draft.DeliverableID); err != nil {
+		return fmt.Errorf("failed to supersede drafts: %w", err)
+	}
+
+	draft.ID = uuid.New().String()
+	draft.ReviewToken = token
+	draft.Status = "pending-review"
+	draft.SubmittedAt = time.Now()
+
+	if _, err := tx.Exec(`
+		INSERT INTO deliverable_drafts (
+			id, deliverable_id, sponsorship_id, creator_id, version, link_url, file_name,
+			notes, submitted_at, review_token, status, attachment_id
+		) VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), NULLIF($7, ''), NULLIF($8, ''), $9, $10, $11, NULLIF($12, '')::uuid)
+	`, draft.ID, draft.DeliverableID, draft.SponsorshipID, draft.CreatorID, draft.Version, draft.LinkURL,
+		draft.FileName, draft.Notes, draft.SubmittedAt, draft.ReviewToken, draft.Status, draft.AttachmentID); err != nil {
+		return fmt.Errorf("failed to create draft: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE deliverables SET status = 'awaiting-review', updated_at = NOW() WHERE id = $1
+	`, draft.DeliverableID); err != nil {
+		return fmt.Errorf("failed to update deliverable status: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit draft: %w", err)
+	}
+
+	return nil
+}
+
+// ListDrafts retrieves every version submitted for a deliverable, newest first
+func (r *DraftRepository) ListDrafts(deliverableID, sponsorshipID, creatorID string) ([]*models.DeliverableDraft, error) {
+	query := `SELECT ` + draftColumns + `
+		FROM deliverable_drafts
+		WHERE deliverable_id = $1 AND sponsorship_id = $2 AND creator_id = $3
+		ORDER BY version DESC
+	`
+
+	rows, err := r.db.Query(query, deliverableID, sponsorshipID, creatorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list drafts: %w", err)
+	}
+	defer rows.Close()
+
+	var drafts []*models.DeliverableDraft
+	for rows.Next() {
+		d, err := scanDraft(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan draft: %w", err)
+		}
+		drafts = append(drafts, d)
+	}
+
+	return drafts, rows.Err()
+}
+
+// GetDraftByToken retrieves the draft a brand-side review link points to
+func (r *DraftRepository) GetDraftByToken(token string) (*models.DeliverableDraft, error) {
+	query := `SELECT ` + draftColumns + ` FROM deliverable_drafts WHERE review_token = $1`
+
+	d, err := scanDraft(r.db.QueryRow(query, token))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get draft: %w", err)
+	}
+
+	return d, nil
+}
+
+// ReviewDraft records the brand's decision on a draft still waiting for review and moves
+// the deliverable along. Requesting changes uses up one revision.
+func (r *DraftRepository) ReviewDraft(draft *models.DeliverableDraft) error {
+	deliverableStatus := "approved"
+	revisions := 0
+	if draft.Status == "changes-requested" {
+		deliverableStatus = "in-progress"
+		revisions = 1
+	}
+
+	now := time.Now()
+	draft.ReviewedAt = &now
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin review transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE deliverable_drafts
+		SET status = $1, reviewer_name = NULLIF($2, ''), review_comments = NULLIF($3, ''), reviewed_at = $4
+		WHERE id = $5 AND status = 'pending-review'
+	`, draft.Status, draft.ReviewerName, draft.ReviewComments, now, draft.ID)
+	if err != nil {
+		return fmt.Errorf("failed to review draft: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if rows == 0 {
+		return errors.ErrConflict
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE deliverables
+		SET status = $1, revision_count = revision_count + $2, updated_at = NOW()
+		WHERE id = $3
+	`, deliverableStatus, revisions, draft.DeliverableID); err != nil {
+		return fmt.Errorf("failed to update deliverable after review: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit review: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type ExclusivityRepository struct {
+	db *sql.DB
+}
+
+func NewExclusivityRepository(db *sql.DB) *ExclusivityRepository {
+	return &ExclusivityRepository{db: db}
+}
+
+// CreateClause adds an exclusivity clause to a deal
+func (r *ExclusivityRepository) CreateClause(clause *models.ExclusivityClause) error {
+	clause.ID = uuid.New().String()
+	clause.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO exclusivity_clauses (id, sponsorship_id, creator_id, category, starts_on, ends_on, notes, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, ''), $8)
+	`
+
+	_, err := r.db.Exec(query, clause.ID, clause.SponsorshipID, clause.CreatorID, clause.Category,
+		clause.StartsOn, clause.EndsOn, clause.Notes, clause.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create exclusivity clause: %w", err)
+	}
+
+	return nil
+}
+
+// ListClauses retrieves the exclusivity clauses of a deal
+func (r *ExclusivityRepository) ListClauses(sponsorshipID, creatorID string) ([]*models.ExclusivityClause, error) {
+	query := `
+		SELECT id, sponsorship_id, creator_id, category, starts_on, ends_on, COALESCE(notes, ''), created_at
+		FROM exclusivity_clauses
+		WHERE sponsorship_id = $1 AND creator_id = $2
+		ORDER BY starts_on
+	`
+
+	rows, err := r.db.Query(query, sponsorshipID, creatorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list exclusivity clauses: %w", err)
+	}
+	defer rows.Close()
+
+	var clauses []*models.ExclusivityClause
+	for rows.Next() {
+		clause := &models.ExclusivityClause{}
+		if err := rows.Scan(
+			&clause.ID, &clause.SponsorshipID, &clause.CreatorID, &clause.Category,
+			&clause.StartsOn, &clause.EndsOn, &clause.Notes, &clause.CreatedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan exclusivity clause: %w", err)
+		}
+		clauses = append(clauses, clause)
+	}
+
+	return clauses, rows.Err()
+}
+
+// DeleteClause removes an exclusivity clause from a deal
+func (r *ExclusivityRepository) DeleteClause(id, sponsorshipID, creatorID string) error {
+	result, err := r.db.Exec(`
+		DELETE FROM exclusivity_clauses WHERE id = $1 AND sponsorship_id = $2 AND creator_id = $3
+	`, id, sponsorshipID, creatorID)
+	if err != nil {
+		return fmt.Errorf("failed to delete exclusivity clause: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// FindConflicts returns active exclusivity windows held by other brands' signed deals
+// that cover the given category and overlap the deal's dates
+func (r *ExclusivityRepository) FindConflicts(sponsorship *models.Sponsorship) ([]*models.ExclusivityConflict, error) {
+	if sponsorship.Category == "" {
+		return nil, nil
+	}
+
+	query := `
+		SELECT c.id, c.sponsorship_id, s.brand_name, c.category, c.starts_on, c.ends_on
+		FROM exclusivity_clauses c
+		JOIN sponsorships s ON s.id = c.sponsorship_id
+		WHERE c.creator_id = $1
+		  AND s.deleted_at IS NULL
+		  AND s.status = ANY($2)
+		  AND lower(c.category) = lower($3)
+		  AND c.starts_on <= $5 AND c.ends_on >= $4
+		  AND c.sponsorship_id::text <> $6
+		  AND ($7 = '' OR s.brand_id IS NULL OR s.brand_id::text <> $7)
+		ORDER BY c.starts_on
+	`
+
+	rows, err := r.db.Query(query, sponsorship.CreatorID, pq.Array(models.WonStatuses), sponsorship.Category,
+		sponsorship.StartDate, sponsorship.EndDate, sponsorship.ID, sponsorship.BrandID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find exclusivity conflicts: %w", err)
+	}
+	defer rows.Close()
+
+	var conflicts []*models.ExclusivityConflict
+	for rows.Next() {
+		conflict := &models.ExclusivityConflict{}
+		if err := rows.Scan(
+			&conflict.ClauseID, &conflict.SponsorshipID, &conflict.BrandName,
+			&conflict.Category, &conflict.StartsOn, &conflict.EndsOn,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan exclusivity conflict: %w", err)
+		}
+		conflicts = append(conflicts, conflict)
+	}
+
+	return conflicts, rows.Err()
+}
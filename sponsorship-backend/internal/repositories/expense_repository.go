@@ -0,0 +1,174 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+type ExpenseRepository struct {
+	db *sql.DB
+}
+
+func NewExpenseRepository(db *sql.DB) *ExpenseRepository {
+	return &ExpenseRepository{db: db}
+}
+
+const expenseColumns = `
+	id, sponsorship_id, creator_id, category, COALESCE(description, ''), amount, currency, expense_date,
+	COALESCE(receipt_attachment_id::text, ''), created_at, updated_at
+`
+
+func scanExpense(row scanner) (*models.Expense, error) {
+	e := &models.Expense{}
+	var amount string
+	if err := row.Scan(
+		&e.ID, &e.SponsorshipID, &e.CreatorID, &e.Category, &e.Description, &amount, &e.Currency,
+		&e.ExpenseDate, &e.ReceiptAttachmentID, &e.CreatedAt, &e.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	var err error
+	if e.Amount, err = parseAmount(amount, e.Currency); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+// CreateExpense records an expense against a sponsorship
+func (r *ExpenseRepository) CreateExpense(e *models.Expense) error {
+	e.ID = uuid.New().String()
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = e.CreatedAt
+
+	query := `
+		INSERT INTO expenses (
+			id, sponsorship_id, creator_id, category, description, amount, currency, expense_date,
+			receipt_attachment_id, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, NULLIF($5, ''), $6, $7, $8, NULLIF($9, '')::uuid, $10, $11)
+	`
+
+	_, err := r.db.Exec(query, e.ID, e.SponsorshipID, e.CreatorID, e.Category, e.Description, e.Amount,
+		e.Currency, e.ExpenseDate, e.ReceiptAttachmentID, e.CreatedAt, e.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create expense: %w", err)
+	}
+
+	return nil
+}
+
+// GetExpense retrieves an expense of a sponsorship
+func (r *ExpenseRepository) GetExpense(id, sponsorshipID, creatorID string) (*models.Expense, error) {
+	query := `SELECT ` + expenseColumns + `
+		FROM expenses
+		WHERE id = $1 AND sponsorship_id = $2 AND creator_id = $3
+	`
+
+	e, err := scanExpense(r.db.QueryRow(query, id, sponsorshipID, creatorID))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expense: %w", err)
+	}
+
+	return e, nil
+}
+
+// ListExpenses retrieves a sponsorship's expenses, most recent first
+func (r *ExpenseRepository) ListExpenses(sponsorshipID, creatorID string) ([]*models.Expense, error) {
+	query := `SELECT ` + expenseColumns + `
+		FROM expenses
+		WHERE sponsorship_id = $1 AND creator_id = $2
+		ORDER BY expense_date DESC, created_at DESC
+	`
+
+	return r.list(query, sponsorshipID, creatorID)
+}
+
+// ListCreatorExpenses retrieves the expenses on a creator's live deals dated within
+// [from, to), oldest first. A nil bound leaves that end of the range open.
+func (r *ExpenseRepository) ListCreatorExpenses(creatorID string, from, to *time.Time) ([]*models.Expense, error) {
+	query := `SELECT ` + expenseColumns + `
+		FROM expenses
+		WHERE creator_id = $1
+		  AND ($2::date IS NULL OR expense_date >= $2)
+		  AND ($3::date IS NULL OR expense_date < $3)
+		  AND sponsorship_id IN (SELECT id FROM sponsorships WHERE creator_id = $1 AND deleted_at IS NULL)
+		ORDER BY expense_date, created_at
+	`
+
+	return r.list(query, creatorID, from, to)
+}
+
+func (r *ExpenseRepository) list(query string, args ...interface{}) ([]*models.Expense, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list expenses: %w", err)
+	}
+	defer rows.Close()
+
+	var expenses []*models.Expense
+	for rows.Next() {
+		e, err := scanExpense(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan expense: %w", err)
+		}
+		expenses = append(expenses, e)
+	}
+
+	return expenses, rows.Err()
+}
+
+// UpdateExpense saves all editable fields of an expense
+func (r *ExpenseRepository) UpdateExpense(e *models.Expense) error {
+	e.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE expenses
+		SET category = $1, description = NULLIF($2, ''), amount = $3, currency = $4, expense_date = $5,
+		    receipt_attachment_id = NULLIF($6, '')::uuid, updated_at = $7
+		WHERE id = $8 AND sponsorship_id = $9 AND creator_id = $10
+	`
+
+	result, err := r.db.Exec(query, e.Category, e.Description, e.Amount, e.Currency, e.ExpenseDate,
+		e.ReceiptAttachmentID, e.UpdatedAt, e.ID, e.SponsorshipID, e.CreatorID)
+	if err != nil {
+		return fmt.Errorf("failed to update expense: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteExpense removes an expense. Its receipt stays with the deal's attachments.
+func (r *ExpenseRepository) DeleteExpense(id, sponsorshipID, creatorID string) error {
+	result, err := r.db.Exec(`
+		DELETE FROM expenses WHERE id = $1 AND sponsorship_id = $2 AND creator_id = $3
+	`, id, sponsorshipID, creatorID)
+	if err != nil {
+		return fmt.Errorf("failed to delete expense: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
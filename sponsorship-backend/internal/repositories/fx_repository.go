@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/fx"
+)
+
+type FXRepository struct {
+	db *sql.DB
+}
+
+func NewFXRepository(db *sql.DB) *FXRepository {
+	return &FXRepository{db: db}
+}
+
+// UpsertRates stores rates, replacing any already stored for the same pair and day
+func (r *FXRepository) UpsertRates(rates []fx.Rate) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin fx rate transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`
+		INSERT INTO fx_rates (rate_date, base_currency, quote_currency, rate)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (base_currency, quote_currency, rate_date) DO UPDATE SET rate = EXCLUDED.rate
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare fx rate insert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, rate := range rates {
+		if _, err := stmt.Exec(rate.Date, rate.Base, rate.Quote, rate.Rate.FloatString(10)); err != nil {
+			return fmt.Errorf("failed to store fx rate %s/%s on %s: %w",
+				rate.Base, rate.Quote, rate.Date.Format("2006-01-02"), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit fx rates: %w", err)
+	}
+
+	return nil
+}
+
+// LatestRates retrieves, for every currency pair, its most recent rate on or before a day
+func (r *FXRepository) LatestRates(asOf time.Time) ([]*models.FXRate, error) {
+	query := `
+		SELECT DISTINCT ON (base_currency, quote_currency) rate_date, base_currency, quote_currency, rate::text
+		FROM fx_rates
+		WHERE rate_date <= $1
+		ORDER BY base_currency, quote_currency, rate_date DESC
+	`
+
+	rows, err := r.db.Query(query, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list fx rates: %w", err)
+	}
+	defer rows.Close()
+
+	var rates []*models.FXRate
+	for rows.Next() {
+		rate := &models.FXRate{}
+		if err := rows.Scan(&rate.Date, &rate.Base, &rate.Quote, &rate.Rate); err != nil {
+			return nil, fmt.Errorf("failed to scan fx rate: %w", err)
+		}
+		if strings.Contains(rate.Rate, ".") {
+			rate.Rate = strings.TrimRight(strings.TrimRight(rate.Rate, "0"), ".")
+		}
+		rates = append(rates, rate)
+	}
+
+	return rates, rows.Err()
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sponsorship-backend/internal/models"
+)
+
+type IdempotencyRepository struct {
+	db *sql.DB
+}
+
+func NewIdempotencyRepository(db *sql.DB) *IdempotencyRepository {
+	return &IdempotencyRepository{db: db}
+}
+
+// Reserve claims an idempotency key for a request that is about to run. It returns true if
+// the key was free or had expired. Otherwise it returns false and the key as first used,
+// which has no status code while that request is still running.
+func (r *IdempotencyRepository) Reserve(userID, key, requestHash string, ttl time.Duration) (*models.IdempotencyKey, bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to begin idempotency transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// The conflicting row stays locked by this transaction even when it is not updated,
+	// so it cannot be released before it is read below
+	now := time.Now()
+	result, err := tx.Exec(`
+		INSERT INTO idempotency_keys (user_id, idempotency_key, request_hash, created_at, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (user_id, idempotency_key) DO UPDATE SET
+			request_hash = EXCLUDED.request_hash,
+			status_code = NULL,
+			content_type = NULL,
+			response_body = NULL,
+			created_at = EXCLUDED.created_at,
+			expires_at = EXCLUDED.expires_at
+		WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+	`, userID, key, requestHash, now, now.Add(ttl))
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+	reserved, err := result.RowsAffected()
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to reserve idempotency key: %w", err)
+	}
+
+	if reserved == 0 {
+		existing := &models.IdempotencyKey{}
+		err = tx.QueryRow(`
+			SELECT user_id, idempotency_key, request_hash, status_code, COALESCE(content_type, ''),
+			       response_body, created_at, expires_at
+			FROM idempotency_keys
+			WHERE user_id = $1 AND idempotency_key = $2
+		`, userID, key).Scan(&existing.UserID, &existing.Key, &existing.RequestHash, &existing.StatusCode,
+			&existing.ContentType, &existing.ResponseBody, &existing.CreatedAt, &existing.ExpiresAt)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get idempotency key: %w", err)
+		}
+		return existing, false, nil
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, false, fmt.Errorf("failed to commit idempotency key: %w", err)
+	}
+	return nil, true, nil
+}
+
+// Complete stores the response to replay for a reserved key
+func (r *IdempotencyRepository) Complete(userID, key string, statusCode int, contentType string, body []byte) error {
+	_, err := r.db.Exec(`
+		UPDATE idempotency_keys
+		SET status_code = $3, content_type = NULLIF($4, ''), response_body = $5
+		WHERE user_id = $1 AND idempotency_key = $2
+	`, userID, key, statusCode, contentType, body)
+	if err != nil {
+		return fmt.Errorf("failed to complete idempotency key: %w", err)
+	}
+	return nil
+}
+
+// Release frees a reserved key whose request failed, so the client can retry with it
+func (r *IdempotencyRepository) Release(userID, key string) error {
+	_, err := r.db.Exec(`
+		DELETE FROM idempotency_keys
+		WHERE user_id = $1 AND idempotency_key = $2 AND status_code IS NULL
+	`, userID, key)
+	if err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes keys whose responses are no longer replayed
+func (r *IdempotencyRepository) DeleteExpired(now time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM idempotency_keys WHERE expires_at <= $1`, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired idempotency keys: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sponsorship-backend/internal/models"
+)
+
+type IncomeRepository struct {
+	db *sql.DB
+}
+
+func NewIncomeRepository(db *sql.DB) *IncomeRepository {
+	return &IncomeRepository{db: db}
+}
+
+// ListIncome retrieves the money a creator received within [from, to), oldest first.
+// Paid invoices count at their subtotal, so sales tax collected is not income; paid
+// milestones count unless a sent or paid invoice bills them; a completed deal with no
+// milestones or sent or paid invoices counts its deal amount on the day it was completed.
+// Draft invoices do not count: until one is sent, the money is not billed through it.
+func (r *IncomeRepository) ListIncome(creatorID string, from, to time.Time) ([]*models.IncomeEntry, error) {
+	query := `
+		WITH deals AS (
+		    SELECT s.id, s.brand_name, COALESCE(b.country, '') AS country, s.commission_rate,
+		           s.status, s.deal_amount, s.currency
+		    FROM sponsorships s
+		    LEFT JOIN brands b ON b.id = s.brand_id
+		    WHERE s.creator_id = $1 AND s.deleted_at IS NULL
+		)
+		SELECT d.id, d.brand_name, d.country, d.commission_rate, 'invoice', i.id::text,
+		       i.invoice_number, i.subtotal, i.currency, i.paid_at
+		FROM invoices i
+		JOIN deals d ON d.id = i.sponsorship_id
+		WHERE i.status = 'paid' AND i.paid_at >= $2 AND i.paid_at < $3
+
+		UNION ALL
+
+		SELECT d.id, d.brand_name, d.country, d.commission_rate, 'milestone', m.id::text,
+		       m.label, m.amount, m.currency, m.paid_on
+		FROM payment_milestones m
+		JOIN deals d ON d.id = m.sponsorship_id
+		WHERE m.status = 'paid' AND m.paid_on >= $2 AND m.paid_on < $3
+		  AND NOT EXISTS (SELECT 1 FROM invoices i WHERE i.milestone_id = m.id AND i.status IN ('sent', 'paid'))
+
+		UNION ALL
+
+		SELECT d.id, d.brand_name, d.country, d.commission_rate, 'deal', d.id::text,
+		       d.brand_name, d.deal_amount, d.currency, c.completed_at
+		FROM deals d
+		JOIN (
+		    SELECT sponsorship_id, MIN(changed_at) AS completed_at
+		    FROM sponsorship_status_history
+		    WHERE new_status = 'completed'
+		    GROUP BY sponsorship_id
+		) c ON c.sponsorship_id = d.id
+		WHERE d.status = 'completed' AND c.completed_at >= $2 AND c.completed_at < $3
+		  AND NOT EXISTS (SELECT 1 FROM payment_milestones m WHERE m.sponsorship_id = d.id)
+		  AND NOT EXISTS (SELECT 1 FROM invoices i WHERE i.sponsorship_id = d.id AND i.status IN ('sent', 'paid'))
+
+		ORDER BY 10, 1
+	`
+
+	rows, err := r.db.Query(query, creatorID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list income: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []*models.IncomeEntry
+	for rows.Next() {
+		e := &models.IncomeEntry{}
+		var amount string
+		var reference sql.NullString
+		if err := rows.Scan(
+			&e.SponsorshipID, &e.BrandName, &e.Country, &e.CommissionRate, &e.Source, &e.SourceID,
+			&reference, &amount, &e.Currency, &e.ReceivedOn,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan income: %w", err)
+		}
+		e.Reference = reference.String
+		if e.Amount, err = parseAmount(amount, e.Currency); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
@@ -0,0 +1,500 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+type InvoiceRepository struct {
+	db *sql.DB
+}
+
+func NewInvoiceRepository(db *sql.DB) *InvoiceRepository {
+	return &InvoiceRepository{db: db}
+}
+
+// GetBillingDetails retrieves the creator's billing details
+func (r *InvoiceRepository) GetBillingDetails(creatorID string) (*models.BillingDetails, error) {
+	d := &models.BillingDetails{}
+	query := `
+		SELECT creator_id, legal_name, COALESCE(email, ''), COALESCE(address_line1, ''), COALESCE(address_line2, ''),
+		       COALESCE(city, ''), COALESCE(postal_code, ''), COALESCE(region, ''), COALESCE(country, ''),
+		       COALESCE(tax_id, ''), COALESCE(payment_instructions, ''), invoice_prefix, updated_at
+		FROM billing_details
+		WHERE creator_id = $1
+	`
+
+	err := r.db.QueryRow(query, creatorID).Scan(
+		&d.CreatorID, &d.LegalName, &d.Email, &d.AddressLine1, &d.AddressLine2,
+		&d.City, &d.PostalCode, &d.Region, &d.Country,
+		&d.TaxID, &d.PaymentInstructions, &d.InvoicePrefix, &d.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get billing details: %w", err)
+	}
+
+	return d, nil
+}
+
+// SaveBillingDetails creates or replaces the creator's billing details
+func (r *InvoiceRepository) SaveBillingDetails(d *models.BillingDetails) error {
+	d.UpdatedAt = time.Now()
+
+	query := `
+		INSERT INTO billing_details (
+			creator_id, legal_name, email, address_line1, address_line2, city, postal_code,
+			region, country, tax_id, payment_instructions, invoice_prefix, updated_at
+		) VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, ''),
+		          NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''), NULLIF($11, ''), $12, $13)
+		ON CONFLICT (creator_id) DO UPDATE SET
+			legal_name = EXCLUDED.legal_name, email = EXCLUDED.email,
+			address_line1 = EXCLUDED.address_line1, address_line2 = EXCLUDED.address_line2,
+			city = EXCLUDED.city, postal_code = EXCLUDED.postal_code, region = EXCLUDED.region,
+			country = EXCLUDED.country, tax_id = EXCLUDED.tax_id,
+			payment_instructions = EXCLUDED.payment_instructions,
+			invoice_prefix = EXCLUDED.invoice_prefix, updated_at = EXCLUDED.updated_at
+	`
+
+	_, err := r.db.Exec(query, d.CreatorID, d.LegalName, d.Email, d.AddressLine1, d.AddressLine2,
+		d.City, d.PostalCode, d.Region, d.Country, d.TaxID, d.PaymentInstructions, d.InvoicePrefix, d.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save billing details: %w", err)
+	}
+
+	return nil
+}
+
+const invoiceColumns = `
+	id, creator_id, sponsorship_id, COALESCE(milestone_id::text, ''), sequence_number,
+	COALESCE(invoice_number, ''), status, issue_date, due_date, bill_to_name,
+	COALESCE(bill_to_contact, ''), COALESCE(bill_to_email, ''), COALESCE(bill_to_address, ''),
+	COALESCE(bill_to_tax_id, ''), seller_details, currency, subtotal, COALESCE(tax_label, ''), tax_rate,
+	tax_amount, total, COALESCE(notes, ''), sent_at, paid_at, voided_at, created_at, updated_at
+`
+
+func scanInvoice(row scanner) (*models.Invoice, error) {
+	inv := &models.Invoice{}
+	var sequence sql.NullInt64
+	var issueDate, dueDate, sentAt, paidAt, voidedAt sql.NullTime
+	var seller []byte
+	var subtotal, taxAmount, total string
+	if err := row.Scan(
+		&inv.ID, &inv.CreatorID, &inv.SponsorshipID, &inv.MilestoneID, &sequence,
+		&inv.InvoiceNumber, &inv.Status, &issueDate, &dueDate, &inv.BillToName,
+		&inv.BillToContact, &inv.BillToEmail, &inv.BillToAddress,
+		&inv.BillToTaxID, &seller, &inv.Currency, &subtotal, &inv.TaxLabel, &inv.TaxRate,
+		&taxAmount, &total, &inv.Notes, &sentAt, &paidAt, &voidedAt, &inv.CreatedAt, &inv.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+
+	var err error
+	if inv.Subtotal, err = parseAmount(subtotal, inv.Currency); err != nil {
+		return nil, err
+	}
+	if inv.TaxAmount, err = parseAmount(taxAmount, inv.Currency); err != nil {
+		return nil, err
+	}
+	if inv.Total, err = parseAmount(total, inv.Currency); err != nil {
+		return nil, err
+	}
+	if sequence.Valid {
+		n := int(sequence.Int64)
+		inv.SequenceNumber = &n
+	}
+	inv.IssueDate = nullTimePtr(issueDate)
+	inv.DueDate = nullTimePtr(dueDate)
+	inv.SentAt = nullTimePtr(sentAt)
+	inv.PaidAt = nullTimePtr(paidAt)
+	inv.VoidedAt = nullTimePtr(voidedAt)
+	if len(seller) > 0 {
+		inv.Seller = &models.BillingDetails{}
+		if err := json.Unmarshal(seller, inv.Seller); err != nil {
+			return nil, fmt.Errorf("failed to decode seller details: %w", err)
+		}
+	}
+
+	return inv, nil
+}
+
+func nullTimePtr(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+// CreateInvoice stores a draft invoice with its line items
+func (r *InvoiceRepository) CreateInvoice(inv *models.Invoice) error {
+	inv.ID = uuid.New().String()
+	inv.Status = "draft"
+	inv.CreatedAt = time.Now()
+	inv.UpdatedAt = inv.CreatedAt
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin invoice transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO invoices (
+			id, creator_id, sponsorship_id, milestone_id, status, due_date, bill_to_name,
+			bill_to_contact, bill_to_email, bill_to_address, bill_to_tax_id, subtotal, tax_label,
+			tax_rate, tax_amount, total, notes, created_at, updated_at, currency
+		) VALUES ($1, $2, $3, NULLIF($4, '')::uuid, $5, $6, $7, NULLIF($8, ''), NULLIF($9, ''), NULLIF($10, ''),
+		          NULLIF($11, ''), $12, NULLIF($13, ''), $14, $15, $16, NULLIF($17, ''), $18, $19, $20)
+	`, inv.ID, inv.CreatorID, inv.SponsorshipID, inv.MilestoneID, inv.Status, inv.DueDate, inv.BillToName,
+		inv.BillToContact, inv.BillToEmail, inv.BillToAddress, inv.BillToTaxID, inv.Subtotal, inv.TaxLabel,
+		inv.TaxRate, inv.TaxAmount, inv.Total, inv.Notes, inv.CreatedAt, inv.UpdatedAt, inv.Currency)
+	if err != nil {
+		return fmt.Errorf("failed to create invoice: %w", err)
+	}
+
+	if err := insertLineItems(tx, inv); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit invoice: %w", err)
+	}
+
+	return nil
+}
+
+func insertLineItems(tx *sql.Tx, inv *models.Invoice) error {
+	for i, item := range inv.LineItems {
+		item.ID = uuid.New().String()
+		item.InvoiceID = inv.ID
+		item.Position = i + 1
+		if _, err := tx.Exec(`
+			INSERT INTO invoice_line_items (id, invoice_id, deliverable_id, position, description, quantity, unit_price, amount)
+			VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8)
+		`, item.ID, item.InvoiceID, item.DeliverableID, item.Position, item.Description,
+			item.Quantity, item.UnitPrice, item.Amount); err != nil {
+			return fmt.Errorf("failed to create invoice line item: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetInvoice retrieves an invoice with its line items
+func (r *InvoiceRepository) GetInvoice(id, creatorID string) (*models.Invoice, error) {
+	query := `SELECT ` + invoiceColumns + ` FROM invoices WHERE id = $1 AND creator_id = $2`
+
+	inv, err := scanInvoice(r.db.QueryRow(query, id, creatorID))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice: %w", err)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, invoice_id, COALESCE(deliverable_id::text, ''), position, description, quantity, unit_price, amount
+		FROM invoice_line_items
+		WHERE invoice_id = $1
+		ORDER BY position
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invoice line items: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		item := &models.InvoiceLineItem{}
+		var unitPrice, amount string
+		if err := rows.Scan(
+			&item.ID, &item.InvoiceID, &item.DeliverableID, &item.Position, &item.Description,
+			&item.Quantity, &unitPrice, &amount,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan invoice line item: %w", err)
+		}
+		if item.UnitPrice, err = parseAmount(unitPrice, inv.Currency); err != nil {
+			return nil, err
+		}
+		if item.Amount, err = parseAmount(amount, inv.Currency); err != nil {
+			return nil, err
+		}
+		inv.LineItems = append(inv.LineItems, item)
+	}
+
+	return inv, rows.Err()
+}
+
+// ListInvoices retrieves the creator's invoices, optionally filtered by status, without line items
+func (r *InvoiceRepository) ListInvoices(creatorID, status string) ([]*models.Invoice, error) {
+	query := `SELECT ` + invoiceColumns + `
+		FROM invoices
+		WHERE creator_id = $1 AND ($2 = '' OR status = $2)
+		ORDER BY sequence_number DESC NULLS FIRST, created_at DESC
+	`
+
+	rows, err := r.db.Query(query, creatorID, status)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invoices: %w", err)
+	}
+	defer rows.Close()
+
+	var invoices []*models.Invoice
+	for rows.Next() {
+		inv, err := scanInvoice(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invoice: %w", err)
+		}
+		invoices = append(invoices, inv)
+	}
+
+	return invoices, rows.Err()
+}
+
+// ListInvoiceActivity retrieves the creator's invoices that were issued, paid or voided
+// within [from, to), oldest first. Invoices of deleted deals are left out, as they are
+// from income. Line items are not loaded.
+func (r *InvoiceRepository) ListInvoiceActivity(creatorID string, from, to time.Time) ([]*models.Invoice, error) {
+	query := `SELECT ` + invoiceColumns + `
+		FROM invoices
+		WHERE creator_id = $1 AND status <> 'draft'
+		  AND sponsorship_id IN (SELECT id FROM sponsorships WHERE creator_id = $1 AND deleted_at IS NULL)
+		  AND ((issue_date >= $2 AND issue_date < $3)
+		    OR (paid_at >= $2 AND paid_at < $3)
+		    OR (voided_at >= $2 AND voided_at < $3))
+		ORDER BY sequence_number
+	`
+
+	rows, err := r.db.Query(query, creatorID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list invoice activity: %w", err)
+	}
+	defer rows.Close()
+
+	var invoices []*models.Invoice
+	for rows.Next() {
+		inv, err := scanInvoice(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan invoice: %w", err)
+		}
+		invoices = append(invoices, inv)
+	}
+
+	return invoices, rows.Err()
+}
+
+// UpdateDraftInvoice saves a draft invoice and replaces its line items. It returns
+// ErrInvalidStateTransition if the invoice is no longer a draft.
+func (r *InvoiceRepository) UpdateDraftInvoice(inv *models.Invoice) error {
+	inv.UpdatedAt = time.Now()
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin invoice transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE invoices
+		SET due_date = $1, bill_to_name = $2, bill_to_contact = NULLIF($3, ''), bill_to_email = NULLIF($4, ''),
+		    bill_to_address = NULLIF($5, ''), bill_to_tax_id = NULLIF($6, ''), subtotal = $7,
+		    tax_label = NULLIF($8, ''), tax_rate = $9, tax_amount = $10, total = $11, notes = NULLIF($12, ''),
+		    updated_at = $13
+		WHERE id = $14 AND creator_id = $15 AND status = 'draft'
+	`, inv.DueDate, inv.BillToName, inv.BillToContact, inv.BillToEmail, inv.BillToAddress, inv.BillToTaxID,
+		inv.Subtotal, inv.TaxLabel, inv.TaxRate, inv.TaxAmount, inv.Total, inv.Notes, inv.UpdatedAt,
+		inv.ID, inv.CreatorID)
+	if err != nil {
+		return fmt.Errorf("failed to update invoice: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if rows == 0 {
+		return errors.ErrInvalidStateTransition
+	}
+
+	if _, err := tx.Exec(`DELETE FROM invoice_line_items WHERE invoice_id = $1`, inv.ID); err != nil {
+		return fmt.Errorf("failed to clear invoice line items: %w", err)
+	}
+	if err := insertLineItems(tx, inv); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit invoice: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteDraftInvoice removes a draft invoice. Sent invoices keep their number and can only be voided.
+func (r *InvoiceRepository) DeleteDraftInvoice(id, creatorID string) error {
+	result, err := r.db.Exec(`
+		DELETE FROM invoices WHERE id = $1 AND creator_id = $2 AND status = 'draft'
+	`, id, creatorID)
+	if err != nil {
+		return fmt.Errorf("failed to delete invoice: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrInvalidStateTransition
+	}
+
+	return nil
+}
+
+// IssueInvoice numbers a draft invoice and marks it sent. The number comes from the
+// creator's sequence inside the same transaction, so numbers are gapless.
+func (r *InvoiceRepository) IssueInvoice(inv *models.Invoice, seller *models.BillingDetails, issueDate time.Time) error {
+	sellerJSON, err := json.Marshal(seller)
+	if err != nil {
+		return fmt.Errorf("failed to encode seller details: %w", err)
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin invoice transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow(`
+		SELECT status FROM invoices WHERE id = $1 AND creator_id = $2 FOR UPDATE
+	`, inv.ID, inv.CreatorID).Scan(&status)
+	if err == sql.ErrNoRows {
+		return errors.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to lock invoice: %w", err)
+	}
+	if status != "draft" {
+		return errors.ErrInvalidStateTransition
+	}
+
+	var number int
+	err = tx.QueryRow(`
+		INSERT INTO invoice_sequences (creator_id, next_number) VALUES ($1, 2)
+		ON CONFLICT (creator_id) DO UPDATE SET next_number = invoice_sequences.next_number + 1
+		RETURNING next_number - 1
+	`, inv.CreatorID).Scan(&number)
+	if err != nil {
+		return fmt.Errorf("failed to take invoice number: %w", err)
+	}
+
+	now := time.Now()
+	invoiceNumber := fmt.Sprintf("%s%04d", seller.InvoicePrefix, number)
+	if _, err := tx.Exec(`
+		UPDATE invoices
+		SET status = 'sent', sequence_number = $1, invoice_number = $2, issue_date = $3,
+		    due_date = COALESCE(due_date, $4), seller_details = $5, sent_at = $6, updated_at = $6
+		WHERE id = $7
+	`, number, invoiceNumber, issueDate, issueDate.AddDate(0, 0, 30), sellerJSON, now, inv.ID); err != nil {
+		return fmt.Errorf("failed to issue invoice: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit invoice: %w", err)
+	}
+
+	inv.Status = "sent"
+	inv.SequenceNumber = &number
+	inv.InvoiceNumber = invoiceNumber
+	inv.IssueDate = &issueDate
+	if inv.DueDate == nil {
+		due := issueDate.AddDate(0, 0, 30)
+		inv.DueDate = &due
+	}
+	inv.Seller = seller
+	inv.SentAt = &now
+	inv.UpdatedAt = now
+
+	return nil
+}
+
+// MarkInvoicePaid marks a sent invoice paid, and the milestone it bills with it, storing
+// the payment.received event in the outbox in the same transaction
+func (r *InvoiceRepository) MarkInvoicePaid(inv *models.Invoice, paidAt time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin invoice transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE invoices SET status = 'paid', paid_at = $1, updated_at = NOW()
+		WHERE id = $2 AND creator_id = $3 AND status = 'sent'
+	`, paidAt, inv.ID, inv.CreatorID)
+	if err != nil {
+		return fmt.Errorf("failed to mark invoice paid: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if rows == 0 {
+		return errors.ErrInvalidStateTransition
+	}
+
+	if inv.MilestoneID != "" {
+		if _, err := tx.Exec(`
+			UPDATE payment_milestones SET status = 'paid', paid_on = $1, updated_at = NOW()
+			WHERE id = $2 AND status = 'unpaid'
+		`, paidAt, inv.MilestoneID); err != nil {
+			return fmt.Errorf("failed to mark milestone paid: %w", err)
+		}
+	}
+
+	if err := insertOutboxEvent(tx, inv.CreatorID, inv.SponsorshipID, models.WebhookEventPaymentReceived,
+		&models.PaymentReceipt{
+			SponsorshipID: inv.SponsorshipID,
+			InvoiceID:     inv.ID,
+			MilestoneID:   inv.MilestoneID,
+			Amount:        inv.Total,
+			Currency:      inv.Currency,
+			PaidAt:        paidAt,
+			Source:        "manual",
+		}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit invoice: %w", err)
+	}
+
+	inv.Status = "paid"
+	inv.PaidAt = &paidAt
+	return nil
+}
+
+// VoidInvoice cancels a sent invoice. It keeps its number so the sequence stays gapless.
+func (r *InvoiceRepository) VoidInvoice(inv *models.Invoice) error {
+	now := time.Now()
+	result, err := r.db.Exec(`
+		UPDATE invoices SET status = 'void', voided_at = $1, updated_at = $1
+		WHERE id = $2 AND creator_id = $3 AND status = 'sent'
+	`, now, inv.ID, inv.CreatorID)
+	if err != nil {
+		return fmt.Errorf("failed to void invoice: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrInvalidStateTransition
+	}
+
+	inv.Status = "void"
+	inv.VoidedAt = &now
+	return nil
+}
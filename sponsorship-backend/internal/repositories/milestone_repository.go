@@ -0,0 +1,253 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type MilestoneRepository struct {
+	db *sql.DB
+}
+
+func NewMilestoneRepository(db *sql.DB) *MilestoneRepository {
+	return &MilestoneRepository{db: db}
+}
+
+const milestoneColumns = `
+	id, sponsorship_id, creator_id, label, amount, currency, due_date, status, paid_on, created_at, updated_at
+`
+
+func scanMilestone(row scanner) (*models.PaymentMilestone, error) {
+	m := &models.PaymentMilestone{}
+	var amount string
+	var paidOn sql.NullTime
+	if err := row.Scan(
+		&m.ID, &m.SponsorshipID, &m.CreatorID, &m.Label, &amount, &m.Currency, &m.DueDate,
+		&m.Status, &paidOn, &m.CreatedAt, &m.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	var err error
+	if m.Amount, err = parseAmount(amount, m.Currency); err != nil {
+		return nil, err
+	}
+	if paidOn.Valid {
+		m.PaidOn = &paidOn.Time
+	}
+	return m, nil
+}
+
+// CreateMilestone adds a payment milestone to a sponsorship
+func (r *MilestoneRepository) CreateMilestone(m *models.PaymentMilestone) error {
+	m.ID = uuid.New().String()
+	m.CreatedAt = time.Now()
+	m.UpdatedAt = m.CreatedAt
+
+	query := `
+		INSERT INTO payment_milestones (
+			id, sponsorship_id, creator_id, label, amount, currency, due_date, status, paid_on, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+	`
+
+	_, err := r.db.Exec(query, m.ID, m.SponsorshipID, m.CreatorID, m.Label, m.Amount, m.Currency, m.DueDate,
+		m.Status, m.PaidOn, m.CreatedAt, m.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create payment milestone: %w", err)
+	}
+
+	return nil
+}
+
+// GetMilestone retrieves a payment milestone of a sponsorship
+func (r *MilestoneRepository) GetMilestone(id, sponsorshipID, creatorID string) (*models.PaymentMilestone, error) {
+	query := `SELECT ` + milestoneColumns + `
+		FROM payment_milestones
+		WHERE id = $1 AND sponsorship_id = $2 AND creator_id = $3
+	`
+
+	m, err := scanMilestone(r.db.QueryRow(query, id, sponsorshipID, creatorID))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment milestone: %w", err)
+	}
+
+	return m, nil
+}
+
+// ListMilestones retrieves a sponsorship's payment schedule ordered by due date
+func (r *MilestoneRepository) ListMilestones(sponsorshipID, creatorID string) ([]*models.PaymentMilestone, error) {
+	query := `SELECT ` + milestoneColumns + `
+		FROM payment_milestones
+		WHERE sponsorship_id = $1 AND creator_id = $2
+		ORDER BY due_date, created_at
+	`
+
+	rows, err := r.db.Query(query, sponsorshipID, creatorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payment milestones: %w", err)
+	}
+	defer rows.Close()
+
+	var milestones []*models.PaymentMilestone
+	for rows.Next() {
+		m, err := scanMilestone(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payment milestone: %w", err)
+		}
+		milestones = append(milestones, m)
+	}
+
+	return milestones, rows.Err()
+}
+
+// UpdateMilestone saves all editable fields of a payment milestone
+func (r *MilestoneRepository) UpdateMilestone(m *models.PaymentMilestone) error {
+	m.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE payment_milestones
+		SET label = $1, amount = $2, due_date = $3, status = $4, paid_on = $5, updated_at = $6
+		WHERE id = $7 AND sponsorship_id = $8 AND creator_id = $9
+	`
+
+	result, err := r.db.Exec(query, m.Label, m.Amount, m.DueDate, m.Status, m.PaidOn, m.UpdatedAt,
+		m.ID, m.SponsorshipID, m.CreatorID)
+	if err != nil {
+		return fmt.Errorf("failed to update payment milestone: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// MarkMilestonePaid records the date a milestone's payment was received. A milestone that
+// was unpaid stores its payment.received event in the outbox in the same transaction; one
+// already paid only has its date corrected.
+func (r *MilestoneRepository) MarkMilestonePaid(m *models.PaymentMilestone, paidOn time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin payment milestone transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var wasUnpaid bool
+	err = tx.QueryRow(`
+		SELECT status = 'unpaid' FROM payment_milestones
+		WHERE id = $1 AND sponsorship_id = $2 AND creator_id = $3
+		FOR UPDATE
+	`, m.ID, m.SponsorshipID, m.CreatorID).Scan(&wasUnpaid)
+	if err == sql.ErrNoRows {
+		return errors.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get payment milestone: %w", err)
+	}
+
+	m.UpdatedAt = time.Now()
+	if _, err := tx.Exec(`
+		UPDATE payment_milestones SET status = 'paid', paid_on = $1, updated_at = $2
+		WHERE id = $3
+	`, paidOn, m.UpdatedAt, m.ID); err != nil {
+		return fmt.Errorf("failed to mark payment milestone paid: %w", err)
+	}
+
+	if wasUnpaid {
+		if err := insertOutboxEvent(tx, m.CreatorID, m.SponsorshipID, models.WebhookEventPaymentReceived,
+			&models.PaymentReceipt{
+				SponsorshipID: m.SponsorshipID,
+				MilestoneID:   m.ID,
+				Amount:        m.Amount,
+				Currency:      m.Currency,
+				PaidAt:        paidOn,
+				Source:        "manual",
+			}); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit payment milestone: %w", err)
+	}
+
+	m.Status = "paid"
+	m.PaidOn = &paidOn
+	return nil
+}
+
+// DeleteMilestone removes a payment milestone
+func (r *MilestoneRepository) DeleteMilestone(id, sponsorshipID, creatorID string) error {
+	result, err := r.db.Exec(`
+		DELETE FROM payment_milestones WHERE id = $1 AND sponsorship_id = $2 AND creator_id = $3
+	`, id, sponsorshipID, creatorID)
+	if err != nil {
+		return fmt.Errorf("failed to delete payment milestone: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// ListUnpaidReceivables retrieves the creator's unpaid milestones on live deals, oldest due first
+func (r *MilestoneRepository) ListUnpaidReceivables(creatorID string) ([]*models.Receivable, error) {
+	query := `
+		SELECT m.id, m.sponsorship_id, s.brand_name, m.label, m.amount, m.currency, m.due_date,
+		       (SELECT MIN(h.changed_at) FROM sponsorship_status_history h
+		        WHERE h.sponsorship_id = s.id AND h.new_status = ANY($2))
+		FROM payment_milestones m
+		JOIN sponsorships s ON s.id = m.sponsorship_id
+		WHERE m.creator_id = $1 AND m.status = 'unpaid'
+		  AND s.deleted_at IS NULL AND s.status <> 'declined'
+		ORDER BY m.due_date, s.brand_name
+	`
+
+	rows, err := r.db.Query(query, creatorID, pq.Array(models.WonStatuses))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list receivables: %w", err)
+	}
+	defer rows.Close()
+
+	var receivables []*models.Receivable
+	for rows.Next() {
+		rec := &models.Receivable{}
+		var amount string
+		var contractedAt sql.NullTime
+		if err := rows.Scan(
+			&rec.MilestoneID, &rec.SponsorshipID, &rec.BrandName, &rec.Label, &amount, &rec.Currency, &rec.DueDate,
+			&contractedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan receivable: %w", err)
+		}
+		if contractedAt.Valid {
+			rec.ContractedAt = &contractedAt.Time
+		}
+		if rec.Amount, err = parseAmount(amount, rec.Currency); err != nil {
+			return nil, err
+		}
+		receivables = append(receivables, rec)
+	}
+
+	return receivables, rows.Err()
+}
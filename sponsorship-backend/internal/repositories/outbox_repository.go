@@ -0,0 +1,119 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"sponsorship-backend/internal/models"
+)
+
+type OutboxRepository struct {
+	db *sql.DB
+}
+
+func NewOutboxRepository(db *sql.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// insertOutboxEvent stores an event about a change to aggregateID in the change's own
+// transaction, so the event is saved if and only if the change is
+func insertOutboxEvent(tx *sql.Tx, creatorID, aggregateID, eventType string, data interface{}) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	now := time.Now()
+	_, err = tx.Exec(`
+		INSERT INTO outbox_events (id, creator_id, aggregate_id, event_type, payload, status, next_attempt_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, 'pending', $6, $6)
+	`, uuid.New().String(), creatorID, aggregateID, eventType, string(payload), now)
+	if err != nil {
+		return fmt.Errorf("failed to store %s event: %w", eventType, err)
+	}
+
+	return nil
+}
+
+// ClaimDueEvents takes up to limit pending events that are due, oldest first, and pushes
+// their next attempt back by lease. An event whose dispatcher stops before recording the
+// outcome is thus handed out again once the lease runs out. Events claimed by another
+// instance are skipped.
+func (r *OutboxRepository) ClaimDueEvents(limit int, lease time.Duration) ([]*models.OutboxEvent, error) {
+	query := `
+		WITH due AS (
+			SELECT id
+			FROM outbox_events
+			WHERE status = 'pending' AND next_attempt_at <= $2
+			ORDER BY created_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		)
+		UPDATE outbox_events o
+		SET next_attempt_at = $3
+		FROM due
+		WHERE o.id = due.id
+		RETURNING o.id, o.creator_id, o.aggregate_id, o.event_type, o.payload, o.status, o.attempts,
+		          o.next_attempt_at, COALESCE(o.last_error, ''), o.created_at, o.published_at
+	`
+
+	now := time.Now()
+	rows, err := r.db.Query(query, limit, now, now.Add(lease))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []*models.OutboxEvent
+	for rows.Next() {
+		e := &models.OutboxEvent{}
+		var payload string
+		if err := rows.Scan(
+			&e.ID, &e.CreatorID, &e.AggregateID, &e.EventType, &payload, &e.Status, &e.Attempts,
+			&e.NextAttemptAt, &e.LastError, &e.CreatedAt, &e.PublishedAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan outbox event: %w", err)
+		}
+		e.Payload = json.RawMessage(payload)
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+	}
+
+	// RETURNING does not keep the order the events were selected in
+	sort.SliceStable(events, func(i, j int) bool { return events[i].CreatedAt.Before(events[j].CreatedAt) })
+	return events, nil
+}
+
+// MarkPublished records that every subscriber has handled an event
+func (r *OutboxRepository) MarkPublished(id string, publishedAt time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE outbox_events
+		SET status = 'published', attempts = attempts + 1, last_error = NULL, published_at = $2
+		WHERE id = $1
+	`, id, publishedAt)
+	if err != nil {
+		return fmt.Errorf("failed to mark outbox event published: %w", err)
+	}
+	return nil
+}
+
+// RecordFailure records a failed attempt at handing out an event and moves it to status,
+// due again at nextAttemptAt if it is still pending
+func (r *OutboxRepository) RecordFailure(id, lastError, status string, nextAttemptAt time.Time) error {
+	_, err := r.db.Exec(`
+		UPDATE outbox_events
+		SET status = $2, attempts = attempts + 1, last_error = $3, next_attempt_at = $4
+		WHERE id = $1
+	`, id, status, lastError, nextAttemptAt)
+	if err != nil {
+		return fmt.Errorf("failed to record outbox event failure: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+)
+
+type PaymentLinkRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentLinkRepository(db *sql.DB) *PaymentLinkRepository {
+	return &PaymentLinkRepository{db: db}
+}
+
+const paymentLinkColumns = `
+	id, creator_id, COALESCE(invoice_id::text, ''), COALESCE(milestone_id::text, ''), provider_session_id,
+	url, amount, currency, status, expires_at, paid_at, created_at, updated_at
+`
+
+func scanPaymentLink(row scanner) (*models.PaymentLink, error) {
+	l := &models.PaymentLink{}
+	var amount string
+	if err := row.Scan(
+		&l.ID, &l.CreatorID, &l.InvoiceID, &l.MilestoneID, &l.ProviderSessionID,
+		&l.URL, &amount, &l.Currency, &l.Status, &l.ExpiresAt, &l.PaidAt, &l.CreatedAt, &l.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	var err error
+	if l.Amount, err = parseAmount(amount, l.Currency); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+// CreateLink stores a new open payment link. Its ID is chosen by the caller, since it is
+// given to the provider before the link is stored.
+func (r *PaymentLinkRepository) CreateLink(l *models.PaymentLink) error {
+	query := `
+		INSERT INTO payment_links (
+			id, creator_id, invoice_id, milestone_id, provider_session_id, url, amount, currency,
+			status, expires_at, created_at, updated_at
+		) VALUES ($1, $2, NULLIF($3, '')::uuid, NULLIF($4, '')::uuid, $5, $6, $7, $8, 'open', $9, $10, $10)
+	`
+
+	now := time.Now()
+	_, err := r.db.Exec(query, l.ID, l.CreatorID, l.InvoiceID, l.MilestoneID, l.ProviderSessionID, l.URL,
+		l.Amount, l.Currency, l.ExpiresAt, now)
+	if err != nil {
+		return fmt.Errorf("failed to create payment link: %w", err)
+	}
+
+	l.Status = "open"
+	l.CreatedAt = now
+	l.UpdatedAt = now
+	return nil
+}
+
+// GetOpenLink retrieves the newest link for an invoice or milestone that can still be
+// paid. It returns ErrNotFound if there is none.
+func (r *PaymentLinkRepository) GetOpenLink(creatorID, invoiceID, milestoneID string) (*models.PaymentLink, error) {
+	query := `SELECT ` + paymentLinkColumns + `
+		FROM payment_links
+		WHERE creator_id = $1
+		  AND invoice_id IS NOT DISTINCT FROM NULLIF($2, '')::uuid
+		  AND milestone_id IS NOT DISTINCT FROM NULLIF($3, '')::uuid
+		  AND status = 'open' AND expires_at > NOW()
+		ORDER BY created_at DESC
+		LIMIT 1
+	`
+
+	l, err := scanPaymentLink(r.db.QueryRow(query, creatorID, invoiceID, milestoneID))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment link: %w", err)
+	}
+
+	return l, nil
+}
+
+// MarkLinkPaid marks the link for a checkout session paid on behalf of a Stripe event,
+// together with the invoice it bills and the milestone that invoice or the link bills.
+// An invoice that is no longer sent, or a milestone already paid, is left alone. The
+// payment.received event is stored in the outbox in the same transaction. It returns
+// false, changing nothing, if the event was already handled, and ErrNotFound (with the
+// event still marked handled) if no link uses the session.
+func (r *PaymentLinkRepository) MarkLinkPaid(eventID, eventType, sessionID string, paidAt time.Time) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin payment link transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	claimed, err := claimEvent(tx, eventID, eventType)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	receipt := &models.PaymentReceipt{PaidAt: paidAt, Source: "payment_link"}
+	var creatorID, amount string
+	err = tx.QueryRow(`
+		UPDATE payment_links SET status = 'paid', paid_at = $1, updated_at = NOW()
+		WHERE provider_session_id = $2 AND status <> 'paid'
+		RETURNING creator_id, COALESCE(invoice_id::text, ''), COALESCE(milestone_id::text, ''), amount, currency
+	`, paidAt, sessionID).Scan(&creatorID, &receipt.InvoiceID, &receipt.MilestoneID, &amount, &receipt.Currency)
+	if err == sql.ErrNoRows {
+		if err := tx.Commit(); err != nil {
+			return false, fmt.Errorf("failed to commit payment link: %w", err)
+		}
+		return false, errors.ErrNotFound
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to mark payment link paid: %w", err)
+	}
+	if receipt.Amount, err = parseAmount(amount, receipt.Currency); err != nil {
+		return false, err
+	}
+
+	if receipt.InvoiceID != "" {
+		var invoiceMilestoneID string
+		err = tx.QueryRow(`
+			UPDATE invoices SET status = 'paid', paid_at = $1, updated_at = NOW()
+			WHERE id = $2 AND status = 'sent'
+			RETURNING COALESCE(milestone_id::text, '')
+		`, paidAt, receipt.InvoiceID).Scan(&invoiceMilestoneID)
+		if err != nil && err != sql.ErrNoRows {
+			return false, fmt.Errorf("failed to mark invoice paid: %w", err)
+		}
+		if receipt.MilestoneID == "" {
+			receipt.MilestoneID = invoiceMilestoneID
+		}
+		if err := tx.QueryRow(`SELECT sponsorship_id FROM invoices WHERE id = $1`,
+			receipt.InvoiceID).Scan(&receipt.SponsorshipID); err != nil {
+			return false, fmt.Errorf("failed to get sponsorship of invoice: %w", err)
+		}
+	}
+
+	if receipt.MilestoneID != "" {
+		if _, err := tx.Exec(`
+			UPDATE payment_milestones SET status = 'paid', paid_on = $1, updated_at = NOW()
+			WHERE id = $2 AND status = 'unpaid'
+		`, paidAt, receipt.MilestoneID); err != nil {
+			return false, fmt.Errorf("failed to mark milestone paid: %w", err)
+		}
+		if receipt.SponsorshipID == "" {
+			if err := tx.QueryRow(`SELECT sponsorship_id FROM payment_milestones WHERE id = $1`,
+				receipt.MilestoneID).Scan(&receipt.SponsorshipID); err != nil {
+				return false, fmt.Errorf("failed to get sponsorship of milestone: %w", err)
+			}
+		}
+	}
+
+	if err := insertOutboxEvent(tx, creatorID, receipt.SponsorshipID, models.WebhookEventPaymentReceived,
+		receipt); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit payment link: %w", err)
+	}
+	return true, nil
+}
+
+// CloseLink sets an open link's status to failed or expired on behalf of a Stripe event.
+// It returns false, changing nothing, if the event was already handled.
+func (r *PaymentLinkRepository) CloseLink(eventID, eventType, sessionID, status string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin payment link transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	claimed, err := claimEvent(tx, eventID, eventType)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE payment_links SET status = $1, updated_at = NOW()
+		WHERE provider_session_id = $2 AND status = 'open'
+	`, status, sessionID); err != nil {
+		return false, fmt.Errorf("failed to close payment link: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit payment link: %w", err)
+	}
+	return true, nil
+}
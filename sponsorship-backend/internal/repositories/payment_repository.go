@@ -0,0 +1,271 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/money"
+
+	"github.com/google/uuid"
+)
+
+type PaymentRepository struct {
+	db *sql.DB
+}
+
+func NewPaymentRepository(db *sql.DB) *PaymentRepository {
+	return &PaymentRepository{db: db}
+}
+
+// claimEvent records a Stripe event as handled. It returns false if it already was.
+func claimEvent(tx *sql.Tx, eventID, eventType string) (bool, error) {
+	result, err := tx.Exec(`
+		INSERT INTO stripe_events (id, type, processed_at)
+		VALUES ($1, $2, NOW())
+		ON CONFLICT (id) DO NOTHING
+	`, eventID, eventType)
+	if err != nil {
+		return false, fmt.Errorf("failed to record stripe event: %w", err)
+	}
+	claimed, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record stripe event: %w", err)
+	}
+	return claimed == 1, nil
+}
+
+// RecordPayment creates or updates the payment for p.ProviderReference on behalf of a
+// Stripe event. It returns false, changing nothing, if the event was already handled.
+// A payment without a known user is linked to the user of a subscription or earlier
+// payment of the same customer, and a payment that is no longer pending keeps its status.
+func (r *PaymentRepository) RecordPayment(eventID, eventType string, p *models.Payment) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin payment transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	claimed, err := claimEvent(tx, eventID, eventType)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	now := time.Now()
+	query := `
+		INSERT INTO payments (
+			id, user_id, provider_reference, payment_intent_id, customer_id, description,
+			status, amount, currency, paid_at, created_at, updated_at, product_id
+		) VALUES (
+			$1,
+			COALESCE(
+				(SELECT id FROM users WHERE id = NULLIF($2, '')::uuid),
+				(SELECT user_id FROM subscriptions WHERE customer_id = NULLIF($5, '') AND user_id IS NOT NULL
+				 ORDER BY created_at LIMIT 1),
+				(SELECT user_id FROM payments WHERE customer_id = NULLIF($5, '') AND user_id IS NOT NULL
+				 ORDER BY created_at LIMIT 1)
+			),
+			$3, NULLIF($4, ''), NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9, $10, $11, $11,
+			(SELECT id FROM products WHERE id = NULLIF($12, ''))
+		)
+		ON CONFLICT (provider_reference) DO UPDATE SET
+			user_id = COALESCE(payments.user_id, EXCLUDED.user_id),
+			payment_intent_id = COALESCE(EXCLUDED.payment_intent_id, payments.payment_intent_id),
+			customer_id = COALESCE(EXCLUDED.customer_id, payments.customer_id),
+			product_id = COALESCE(payments.product_id, EXCLUDED.product_id),
+			status = CASE WHEN payments.status = 'pending' THEN EXCLUDED.status ELSE payments.status END,
+			paid_at = COALESCE(payments.paid_at, EXCLUDED.paid_at),
+			updated_at = EXCLUDED.updated_at
+		RETURNING id, COALESCE(user_id::text, ''), status, paid_at, created_at, updated_at
+	`
+
+	err = tx.QueryRow(query, uuid.New().String(), p.UserID, p.ProviderReference, p.PaymentIntentID,
+		p.CustomerID, p.Description, p.Status, p.Amount, p.Currency, p.PaidAt, now, p.ProductID,
+	).Scan(&p.ID, &p.UserID, &p.Status, &p.PaidAt, &p.CreatedAt, &p.UpdatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to record payment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit payment: %w", err)
+	}
+	return true, nil
+}
+
+// RecordRefund sets the total refunded on the payment made with a payment intent, on
+// behalf of a Stripe event. It returns false if the event was already handled, and
+// ErrNotFound (with the event still marked handled) if no payment used that intent.
+func (r *PaymentRepository) RecordRefund(eventID, eventType, paymentIntentID string, refunded money.Money) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin refund transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	claimed, err := claimEvent(tx, eventID, eventType)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	result, err := tx.Exec(`
+		UPDATE payments
+		SET amount_refunded = $1,
+		    status = CASE WHEN $1 >= amount THEN 'refunded' WHEN $1 > 0 THEN 'partially_refunded' ELSE status END,
+		    updated_at = NOW()
+		WHERE payment_intent_id = $2 AND currency = $3
+	`, refunded, paymentIntentID, refunded.Currency())
+	if err != nil {
+		return false, fmt.Errorf("failed to record refund: %w", err)
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to record refund: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit refund: %w", err)
+	}
+	if updated == 0 {
+		return true, errors.ErrNotFound
+	}
+	return true, nil
+}
+
+const paymentColumns = `
+	id, COALESCE(user_id::text, ''), provider_reference, COALESCE(payment_intent_id, ''),
+	COALESCE(customer_id, ''), COALESCE(product_id, ''), COALESCE(description, ''), status,
+	amount, amount_refunded, currency, paid_at, created_at, updated_at
+`
+
+func scanPayment(row scanner) (*models.Payment, error) {
+	p := &models.Payment{}
+	var amount, refunded string
+	if err := row.Scan(
+		&p.ID, &p.UserID, &p.ProviderReference, &p.PaymentIntentID, &p.CustomerID, &p.ProductID,
+		&p.Description, &p.Status, &amount, &refunded, &p.Currency, &p.PaidAt, &p.CreatedAt, &p.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	var err error
+	if p.Amount, err = parseAmount(amount, p.Currency); err != nil {
+		return nil, err
+	}
+	if p.AmountRefunded, err = parseAmount(refunded, p.Currency); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetPayment retrieves a payment by ID
+func (r *PaymentRepository) GetPayment(id string) (*models.Payment, error) {
+	query := `SELECT ` + paymentColumns + ` FROM payments WHERE id = $1`
+
+	p, err := scanPayment(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payment: %w", err)
+	}
+
+	return p, nil
+}
+
+// ListUserPayments retrieves a user's payments, newest first
+func (r *PaymentRepository) ListUserPayments(userID string) ([]*models.Payment, error) {
+	query := `SELECT ` + paymentColumns + `
+		FROM payments
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list payments: %w", err)
+	}
+	defer rows.Close()
+
+	var payments []*models.Payment
+	for rows.Next() {
+		p, err := scanPayment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payment: %w", err)
+		}
+		payments = append(payments, p)
+	}
+
+	return payments, rows.Err()
+}
+
+// GetCustomerID returns the provider's customer ID for a user, taken from their latest
+// subscription or else their latest payment. It returns ErrNotFound if the user has never
+// paid.
+func (r *PaymentRepository) GetCustomerID(userID string) (string, error) {
+	var customerID string
+	err := r.db.QueryRow(`
+		SELECT customer_id FROM (
+			SELECT customer_id, 1 AS preference, created_at FROM subscriptions
+			WHERE user_id = $1 AND customer_id IS NOT NULL
+			UNION ALL
+			SELECT customer_id, 2, created_at FROM payments
+			WHERE user_id = $1 AND customer_id IS NOT NULL
+		) customers
+		ORDER BY preference, created_at DESC
+		LIMIT 1
+	`, userID).Scan(&customerID)
+	if err == sql.ErrNoRows {
+		return "", errors.ErrNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get customer ID: %w", err)
+	}
+	return customerID, nil
+}
+
+// RecordIssuedRefund stores a refund issued with the provider and adds it to the payment's
+// amount refunded, returning the updated payment. A charge.refunded event that already
+// counted the refund is not counted twice.
+func (r *PaymentRepository) RecordIssuedRefund(refund *models.Refund) (*models.Payment, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin refund transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	refund.ID = uuid.New().String()
+	err = tx.QueryRow(`
+		INSERT INTO refunds (id, payment_id, provider_refund_id, amount, currency, reason, status, issued_by, created_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, NULLIF($8, '')::uuid, NOW())
+		RETURNING created_at
+	`, refund.ID, refund.PaymentID, refund.ProviderRefundID, refund.Amount, refund.Currency, refund.Reason,
+		refund.Status, refund.IssuedBy).Scan(&refund.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record refund: %w", err)
+	}
+
+	// The provider's total from charge.refunded also counts refunds made outside the API,
+	// so the larger of it and our own total is kept
+	payment, err := scanPayment(tx.QueryRow(`
+		UPDATE payments p
+		SET amount_refunded = GREATEST(p.amount_refunded, issued.total),
+		    status = CASE WHEN GREATEST(p.amount_refunded, issued.total) >= p.amount THEN 'refunded'
+		                  WHEN GREATEST(p.amount_refunded, issued.total) > 0 THEN 'partially_refunded'
+		                  ELSE p.status END,
+		    updated_at = NOW()
+		FROM (
+			SELECT COALESCE(SUM(amount), 0) AS total FROM refunds
+			WHERE payment_id = $1 AND status NOT IN ('failed', 'canceled')
+		) issued
+		WHERE p.id = $1
+		RETURNING `+paymentColumns, refund.PaymentID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update refunded payment: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit refund: %w", err)
+	}
+	return payment, nil
+}
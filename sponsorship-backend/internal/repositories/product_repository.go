@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+)
+
+type ProductRepository struct {
+	db *sql.DB
+}
+
+func NewProductRepository(db *sql.DB) *ProductRepository {
+	return &ProductRepository{db: db}
+}
+
+const productColumns = `
+	id, name, COALESCE(description, ''), amount, currency, COALESCE(billing_interval, ''),
+	max_active_deals, api_access, active, created_at, updated_at
+`
+
+func scanProduct(row scanner) (*models.Product, error) {
+	p := &models.Product{}
+	var amount string
+	if err := row.Scan(
+		&p.ID, &p.Name, &p.Description, &amount, &p.Currency, &p.BillingInterval,
+		&p.MaxActiveDeals, &p.APIAccess, &p.Active, &p.CreatedAt, &p.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	var err error
+	if p.Amount, err = parseAmount(amount, p.Currency); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// GetProduct retrieves a product by ID, whether or not it is still on sale
+func (r *ProductRepository) GetProduct(id string) (*models.Product, error) {
+	query := `SELECT ` + productColumns + ` FROM products WHERE id = $1`
+
+	p, err := scanProduct(r.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+
+	return p, nil
+}
+
+// ListActiveProducts retrieves the products on sale, cheapest first
+func (r *ProductRepository) ListActiveProducts() ([]*models.Product, error) {
+	query := `SELECT ` + productColumns + `
+		FROM products
+		WHERE active
+		ORDER BY amount, id
+	`
+
+	rows, err := r.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list products: %w", err)
+	}
+	defer rows.Close()
+
+	var products []*models.Product
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan product: %w", err)
+		}
+		products = append(products, p)
+	}
+
+	return products, rows.Err()
+}
@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/money"
+)
+
+type SettingsRepository struct {
+	db *sql.DB
+}
+
+func NewSettingsRepository(db *sql.DB) *SettingsRepository {
+	return &SettingsRepository{db: db}
+}
+
+// GetSettings retrieves a creator's settings, or the defaults if they have none saved
+func (r *SettingsRepository) GetSettings(creatorID string) (*models.CreatorSettings, error) {
+	settings := &models.CreatorSettings{CreatorID: creatorID, BaseCurrency: money.DefaultCurrency}
+
+	err := r.db.QueryRow(`
+		SELECT base_currency, updated_at FROM creator_settings WHERE creator_id = $1
+	`, creatorID).Scan(&settings.BaseCurrency, &settings.UpdatedAt)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get creator settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// SaveSettings creates or replaces a creator's settings
+func (r *SettingsRepository) SaveSettings(settings *models.CreatorSettings) error {
+	settings.UpdatedAt = time.Now()
+
+	_, err := r.db.Exec(`
+		INSERT INTO creator_settings (creator_id, base_currency, updated_at)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (creator_id) DO UPDATE SET base_currency = EXCLUDED.base_currency, updated_at = EXCLUDED.updated_at
+	`, settings.CreatorID, settings.BaseCurrency, settings.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save creator settings: %w", err)
+	}
+
+	return nil
+}
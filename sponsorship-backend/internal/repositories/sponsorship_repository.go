@@ -7,6 +7,7 @@ import (
 
 	"sponsorship-backend/internal/models"
 	"sponsorship-backend/pkg/errors"
+	"sponsorship-backend/pkg/money"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -20,25 +21,35 @@ func NewSponsorshipRepository(db *sql.DB) *SponsorshipRepository {
 	return &SponsorshipRepository{db: db}
 }
 
-// CreateSponsorship creates a new sponsorship
-func (r *SponsorshipRepository) CreateSponsorship(sponsorship *models.Sponsorship) error {
+// CreateSponsorship creates a new sponsorship with its first status history entries and
+// stores its sponsorship.created event
+func (r *SponsorshipRepository) CreateSponsorship(sponsorship *models.Sponsorship,
+	history []*models.SponsorshipStatusHistory) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin sponsorship transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	sponsorship.ID = uuid.New().String()
 	sponsorship.CreatedAt = time.Now()
 	sponsorship.UpdatedAt = time.Now()
 
 	query := `
 		INSERT INTO sponsorships (
-			id, creator_id, brand_name, product_service, deal_amount, priority,
+			id, creator_id, brand_id, brand_name, product_service, deal_amount, priority,
 			contact_name, contact_email, contact_phone, description, deliverables,
-			target_audience, start_date, end_date, status, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17)
+			target_audience, start_date, end_date, status, created_at, updated_at, category, revision_limit, currency,
+			commission_rate
+		) VALUES ($1, $2, NULLIF($3, '')::uuid, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, NULLIF($19, ''), $20, $21, $22)
 		RETURNING id, created_at, updated_at
 	`
 
-	err := r.db.QueryRow(
+	err = tx.QueryRow(
 		query,
 		sponsorship.ID,
 		sponsorship.CreatorID,
+		sponsorship.BrandID,
 		sponsorship.BrandName,
 		sponsorship.ProductService,
 		sponsorship.DealAmount,
@@ -54,32 +65,59 @@ func (r *SponsorshipRepository) CreateSponsorship(sponsorship *models.Sponsorshi
 		sponsorship.Status,
 		sponsorship.CreatedAt,
 		sponsorship.UpdatedAt,
+		sponsorship.Category,
+		sponsorship.RevisionLimit,
+		sponsorship.Currency,
+		sponsorship.CommissionRate,
 	).Scan(&sponsorship.ID, &sponsorship.CreatedAt, &sponsorship.UpdatedAt)
 
 	if err != nil {
 		return fmt.Errorf("failed to create sponsorship: %w", err)
 	}
 
+	for _, entry := range history {
+		entry.ID = uuid.New().String()
+		entry.SponsorshipID = sponsorship.ID
+		entry.ChangedAt = time.Now()
+		if _, err := tx.Exec(`
+			INSERT INTO sponsorship_status_history (id, sponsorship_id, old_status, new_status, changed_at, changed_by, reason)
+			VALUES ($1, $2, NULLIF($3, ''), $4, $5, NULLIF($6, '')::uuid, NULLIF($7, ''))
+		`, entry.ID, entry.SponsorshipID, entry.OldStatus, entry.NewStatus, entry.ChangedAt, entry.ChangedBy,
+			entry.Reason); err != nil {
+			return fmt.Errorf("failed to record status change: %w", err)
+		}
+	}
+
+	if err := insertOutboxEvent(tx, sponsorship.CreatorID, sponsorship.ID, models.WebhookEventSponsorshipCreated,
+		sponsorship); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit sponsorship: %w", err)
+	}
+
 	return nil
 }
 
 // GetSponsorshipByID retrieves a sponsorship by ID
 func (r *SponsorshipRepository) GetSponsorshipByID(id, creatorID string) (*models.Sponsorship, error) {
 	sponsorship := &models.Sponsorship{}
+	var dealAmount string
 	query := `
-		SELECT id, creator_id, brand_name, product_service, deal_amount, priority,
+		SELECT id, creator_id, COALESCE(brand_id::text, ''), brand_name, product_service, deal_amount, currency, priority,
 		       contact_name, contact_email, contact_phone, description, deliverables,
-		       target_audience, start_date, end_date, status, created_at, updated_at
+		       target_audience, COALESCE(category, ''), revision_limit, commission_rate, start_date, end_date, status, COALESCE(notes, ''), created_at, updated_at
 		FROM sponsorships
 		WHERE id = $1 AND creator_id = $2
 	`
 
 	err := r.db.QueryRow(query, id, creatorID).Scan(
-		&sponsorship.ID, &sponsorship.CreatorID, &sponsorship.BrandName, &sponsorship.ProductService,
-		&sponsorship.DealAmount, &sponsorship.Priority, &sponsorship.ContactName, &sponsorship.ContactEmail,
+		&sponsorship.ID, &sponsorship.CreatorID, &sponsorship.BrandID, &sponsorship.BrandName, &sponsorship.ProductService,
+		&dealAmount, &sponsorship.Currency, &sponsorship.Priority, &sponsorship.ContactName, &sponsorship.ContactEmail,
 		&sponsorship.ContactPhone, &sponsorship.Description, pq.Array(&sponsorship.Deliverables),
-		&sponsorship.TargetAudience, &sponsorship.StartDate, &sponsorship.EndDate,
-		&sponsorship.Status, &sponsorship.CreatedAt, &sponsorship.UpdatedAt,
+		&sponsorship.TargetAudience, &sponsorship.Category, &sponsorship.RevisionLimit, &sponsorship.CommissionRate, &sponsorship.StartDate, &sponsorship.EndDate,
+		&sponsorship.Status, &sponsorship.Notes, &sponsorship.CreatedAt, &sponsorship.UpdatedAt,
 	)
 
 	if err == sql.ErrNoRows {
@@ -88,6 +126,9 @@ func (r *SponsorshipRepository) GetSponsorshipByID(id, creatorID string) (*model
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sponsorship: %w", err)
 	}
+	if sponsorship.DealAmount, err = parseAmount(dealAmount, sponsorship.Currency); err != nil {
+		return nil, err
+	}
 
 	return sponsorship, nil
 }
@@ -103,9 +144,9 @@ func (r *SponsorshipRepository) ListSponsorships(creatorID string, offset, limit
 	}
 
 	query := `
-		SELECT id, creator_id, brand_name, product_service, deal_amount, priority,
+		SELECT id, creator_id, COALESCE(brand_id::text, ''), brand_name, product_service, deal_amount, currency, priority,
 		       contact_name, contact_email, contact_phone, description, deliverables,
-		       target_audience, start_date, end_date, status, created_at, updated_at
+		       target_audience, COALESCE(category, ''), revision_limit, commission_rate, start_date, end_date, status, created_at, updated_at
 		FROM sponsorships
 		WHERE creator_id = $1
 		ORDER BY created_at DESC
@@ -121,24 +162,36 @@ func (r *SponsorshipRepository) ListSponsorships(creatorID string, offset, limit
 	var sponsorships []*models.Sponsorship
 	for rows.Next() {
 		sponsorship := &models.Sponsorship{}
+		var dealAmount string
 		err := rows.Scan(
-			&sponsorship.ID, &sponsorship.CreatorID, &sponsorship.BrandName, &sponsorship.ProductService,
-			&sponsorship.DealAmount, &sponsorship.Priority, &sponsorship.ContactName, &sponsorship.ContactEmail,
+			&sponsorship.ID, &sponsorship.CreatorID, &sponsorship.BrandID, &sponsorship.BrandName, &sponsorship.ProductService,
+			&dealAmount, &sponsorship.Currency, &sponsorship.Priority, &sponsorship.ContactName, &sponsorship.ContactEmail,
 			&sponsorship.ContactPhone, &sponsorship.Description, pq.Array(&sponsorship.Deliverables),
-			&sponsorship.TargetAudience, &sponsorship.StartDate, &sponsorship.EndDate,
+			&sponsorship.TargetAudience, &sponsorship.Category, &sponsorship.RevisionLimit, &sponsorship.CommissionRate, &sponsorship.StartDate, &sponsorship.EndDate,
 			&sponsorship.Status, &sponsorship.CreatedAt, &sponsorship.UpdatedAt,
 		)
 		if err != nil {
 			return nil, 0, fmt.Errorf("failed to scan sponsorship: %w", err)
 		}
+		if sponsorship.DealAmount, err = parseAmount(dealAmount, sponsorship.Currency); err != nil {
+			return nil, 0, err
+		}
 		sponsorships = append(sponsorships, sponsorship)
 	}
 
 	return sponsorships, total, nil
 }
 
-// UpdateSponsorship updates an existing sponsorship
-func (r *SponsorshipRepository) UpdateSponsorship(sponsorship *models.Sponsorship) error {
+// UpdateSponsorship updates an existing sponsorship. If its status is no longer oldStatus,
+// the change is added to its status history and a sponsorship.status_changed event is
+// stored, in the same transaction.
+func (r *SponsorshipRepository) UpdateSponsorship(sponsorship *models.Sponsorship, oldStatus, changedBy, reason string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin sponsorship transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	sponsorship.UpdatedAt = time.Now()
 
 	query := `
@@ -146,18 +199,20 @@ func (r *SponsorshipRepository) UpdateSponsorship(sponsorship *models.Sponsorshi
 		SET brand_name = $1, product_service = $2, deal_amount = $3, priority = $4,
 		    contact_name = $5, contact_email = $6, contact_phone = $7, description = $8,
 		    deliverables = $9, target_audience = $10, start_date = $11, end_date = $12,
-		    status = $13, updated_at = $14
+		    status = $13, updated_at = $14, brand_id = NULLIF($17, '')::uuid, category = NULLIF($18, ''),
+		    revision_limit = $19, currency = $20, commission_rate = $21
 		WHERE id = $15 AND creator_id = $16
 	`
 
-	result, err := r.db.Exec(
+	result, err := tx.Exec(
 		query,
 		sponsorship.BrandName, sponsorship.ProductService, sponsorship.DealAmount,
 		sponsorship.Priority, sponsorship.ContactName, sponsorship.ContactEmail,
 		sponsorship.ContactPhone, sponsorship.Description, pq.Array(sponsorship.Deliverables),
 		sponsorship.TargetAudience, sponsorship.StartDate, sponsorship.EndDate,
 		sponsorship.Status, sponsorship.UpdatedAt,
-		sponsorship.ID, sponsorship.CreatorID,
+		sponsorship.ID, sponsorship.CreatorID, sponsorship.BrandID, sponsorship.Category,
+		sponsorship.RevisionLimit, sponsorship.Currency, sponsorship.CommissionRate,
 	)
 
 	if err != nil {
@@ -173,18 +228,47 @@ func (r *SponsorshipRepository) UpdateSponsorship(sponsorship *models.Sponsorshi
 		return errors.ErrNotFound
 	}
 
+	if sponsorship.Status != oldStatus {
+		if _, err := tx.Exec(`
+			INSERT INTO sponsorship_status_history (id, sponsorship_id, old_status, new_status, changed_at, changed_by, reason)
+			VALUES ($1, $2, NULLIF($3, ''), $4, NOW(), NULLIF($5, '')::uuid, NULLIF($6, ''))
+		`, uuid.New().String(), sponsorship.ID, oldStatus, sponsorship.Status, changedBy, reason); err != nil {
+			return fmt.Errorf("failed to record status change: %w", err)
+		}
+
+		if err := insertOutboxEvent(tx, sponsorship.CreatorID, sponsorship.ID, models.WebhookEventSponsorshipStatusChanged,
+			&models.SponsorshipStatusChange{
+				Sponsorship:    sponsorship,
+				PreviousStatus: oldStatus,
+				Status:         sponsorship.Status,
+				Reason:         reason,
+			}); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit sponsorship: %w", err)
+	}
+
 	return nil
 }
 
-// DeleteSponsorship soft deletes a sponsorship
+// DeleteSponsorship soft deletes a sponsorship and stores its sponsorship.deleted event
 func (r *SponsorshipRepository) DeleteSponsorship(id, creatorID string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin sponsorship transaction: %w", err)
+	}
+	defer tx.Rollback()
+
 	query := `
 		UPDATE sponsorships
 		SET deleted_at = NOW(), updated_at = NOW()
 		WHERE id = $1 AND creator_id = $2 AND deleted_at IS NULL
 	`
 
-	result, err := r.db.Exec(query, id, creatorID)
+	result, err := tx.Exec(query, id, creatorID)
 	if err != nil {
 		return fmt.Errorf("failed to delete sponsorship: %w", err)
 	}
@@ -198,15 +282,24 @@ func (r *SponsorshipRepository) DeleteSponsorship(id, creatorID string) error {
 		return errors.ErrNotFound
 	}
 
+	if err := insertOutboxEvent(tx, creatorID, id, models.WebhookEventSponsorshipDeleted,
+		&models.SponsorshipDeletion{ID: id}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit sponsorship deletion: %w", err)
+	}
+
 	return nil
 }
 
 // GetSponsorshipsByStatus retrieves sponsorships by status
 func (r *SponsorshipRepository) GetSponsorshipsByStatus(creatorID, status string) ([]*models.Sponsorship, error) {
 	query := `
-		SELECT id, creator_id, brand_name, product_service, deal_amount, priority,
+		SELECT id, creator_id, COALESCE(brand_id::text, ''), brand_name, product_service, deal_amount, currency, priority,
 		       contact_name, contact_email, contact_phone, description, deliverables,
-		       target_audience, start_date, end_date, status, notes, created_at, updated_at
+		       target_audience, COALESCE(category, ''), revision_limit, commission_rate, start_date, end_date, status, notes, created_at, updated_at
 		FROM sponsorships
 		WHERE creator_id = $1 AND status = $2 AND deleted_at IS NULL
 		ORDER BY created_at DESC
@@ -221,18 +314,269 @@ func (r *SponsorshipRepository) GetSponsorshipsByStatus(creatorID, status string
 	var sponsorships []*models.Sponsorship
 	for rows.Next() {
 		sponsorship := &models.Sponsorship{}
+		var dealAmount string
 		err := rows.Scan(
-			&sponsorship.ID, &sponsorship.CreatorID, &sponsorship.BrandName, &sponsorship.ProductService,
-			&sponsorship.DealAmount, &sponsorship.Priority, &sponsorship.ContactName, &sponsorship.ContactEmail,
+			&sponsorship.ID, &sponsorship.CreatorID, &sponsorship.BrandID, &sponsorship.BrandName, &sponsorship.ProductService,
+			&dealAmount, &sponsorship.Currency, &sponsorship.Priority, &sponsorship.ContactName, &sponsorship.ContactEmail,
 			&sponsorship.ContactPhone, &sponsorship.Description, &sponsorship.Deliverables,
-			&sponsorship.TargetAudience, &sponsorship.StartDate, &sponsorship.EndDate,
+			&sponsorship.TargetAudience, &sponsorship.Category, &sponsorship.RevisionLimit, &sponsorship.CommissionRate, &sponsorship.StartDate, &sponsorship.EndDate,
 			&sponsorship.Status, &sponsorship.Notes, &sponsorship.CreatedAt, &sponsorship.UpdatedAt,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan sponsorship: %w", err)
 		}
+		if sponsorship.DealAmount, err = parseAmount(dealAmount, sponsorship.Currency); err != nil {
+			return nil, err
+		}
 		sponsorships = append(sponsorships, sponsorship)
 	}
 
 	return sponsorships, nil
 }
+
+// FindOverlappingSponsorships retrieves a creator's live deals whose date range overlaps [start, end]
+func (r *SponsorshipRepository) FindOverlappingSponsorships(creatorID string, start, end time.Time) ([]*models.Sponsorship, error) {
+	query := `
+		SELECT id, creator_id, COALESCE(brand_id::text, ''), brand_name, product_service, deal_amount, currency, priority,
+		       contact_name, contact_email, contact_phone, description, deliverables,
+		       target_audience, COALESCE(category, ''), revision_limit, commission_rate, start_date, end_date, status, created_at, updated_at
+		FROM sponsorships
+		WHERE creator_id = $1 AND deleted_at IS NULL
+		  AND start_date <= $3 AND end_date >= $2
+		ORDER BY created_at DESC
+	`
+
+	rows, err := r.db.Query(query, creatorID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find overlapping sponsorships: %w", err)
+	}
+	defer rows.Close()
+
+	var sponsorships []*models.Sponsorship
+	for rows.Next() {
+		sponsorship := &models.Sponsorship{}
+		var dealAmount string
+		err := rows.Scan(
+			&sponsorship.ID, &sponsorship.CreatorID, &sponsorship.BrandID, &sponsorship.BrandName, &sponsorship.ProductService,
+			&dealAmount, &sponsorship.Currency, &sponsorship.Priority, &sponsorship.ContactName, &sponsorship.ContactEmail,
+			&sponsorship.ContactPhone, &sponsorship.Description, pq.Array(&sponsorship.Deliverables),
+			&sponsorship.TargetAudience, &sponsorship.Category, &sponsorship.RevisionLimit, &sponsorship.CommissionRate, &sponsorship.StartDate, &sponsorship.EndDate,
+			&sponsorship.Status, &sponsorship.CreatedAt, &sponsorship.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan sponsorship: %w", err)
+		}
+		if sponsorship.DealAmount, err = parseAmount(dealAmount, sponsorship.Currency); err != nil {
+			return nil, err
+		}
+		sponsorships = append(sponsorships, sponsorship)
+	}
+
+	return sponsorships, rows.Err()
+}
+
+// MergeSponsorships saves the merged target, moves the duplicate's status history,
+// deliverables, billing, expenses and attachments onto the target and soft deletes the
+// duplicate, storing its sponsorship.deleted event, all in one transaction. Invoices and
+// milestones are in their deal's currency, so a duplicate that has them can only be merged
+// into a deal in the same currency.
+func (r *SponsorshipRepository) MergeSponsorships(target *models.Sponsorship, duplicateID, changedBy string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin merge transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	target.UpdatedAt = time.Now()
+
+	result, err := tx.Exec(`
+		UPDATE sponsorships
+		SET product_service = $1, deal_amount = $2, priority = $3, contact_name = $4,
+		    contact_email = $5, contact_phone = $6, description = $7, deliverables = $8,
+		    target_audience = $9, start_date = $10, end_date = $11, notes = $12, updated_at = $13,
+		    category = NULLIF($16, ''), currency = $17, commission_rate = $18
+		WHERE id = $14 AND creator_id = $15 AND deleted_at IS NULL
+	`,
+		target.ProductService, target.DealAmount, target.Priority, target.ContactName,
+		target.ContactEmail, target.ContactPhone, target.Description, pq.Array(target.Deliverables),
+		target.TargetAudience, target.StartDate, target.EndDate, target.Notes, target.UpdatedAt,
+		target.ID, target.CreatorID, target.Category, target.Currency, target.CommissionRate,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update merged sponsorship: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	var duplicateCurrency string
+	var billed bool
+	err = tx.QueryRow(`
+		SELECT currency,
+		       EXISTS (SELECT 1 FROM invoices WHERE sponsorship_id = $1)
+		       OR EXISTS (SELECT 1 FROM payment_milestones WHERE sponsorship_id = $1)
+		FROM sponsorships
+		WHERE id = $1 AND creator_id = $2 AND deleted_at IS NULL
+		FOR UPDATE
+	`, duplicateID, target.CreatorID).Scan(&duplicateCurrency, &billed)
+	if err == sql.ErrNoRows {
+		return errors.ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get duplicate sponsorship: %w", err)
+	}
+	if billed && duplicateCurrency != target.Currency {
+		return errors.ErrConflict.WithDetails(map[string]string{
+			"duplicateId": fmt.Sprintf("the duplicate has invoices or payment milestones in %s and cannot be merged into a deal in %s",
+				duplicateCurrency, target.Currency),
+		})
+	}
+
+	result, err = tx.Exec(`
+		UPDATE sponsorships
+		SET deleted_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND creator_id = $2 AND deleted_at IS NULL
+	`, duplicateID, target.CreatorID)
+	if err != nil {
+		return fmt.Errorf("failed to delete duplicate sponsorship: %w", err)
+	}
+	if rows, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	} else if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE sponsorship_status_history SET sponsorship_id = $1 WHERE sponsorship_id = $2
+	`, target.ID, duplicateID); err != nil {
+		return fmt.Errorf("failed to move status history: %w", err)
+	}
+
+	// Deliverables the target already tracks under the same title stay with the duplicate
+	if _, err := tx.Exec(`
+		UPDATE deliverables SET sponsorship_id = $1, updated_at = NOW()
+		WHERE sponsorship_id = $2
+		  AND lower(title) NOT IN (SELECT lower(title) FROM deliverables WHERE sponsorship_id = $1)
+	`, target.ID, duplicateID); err != nil {
+		return fmt.Errorf("failed to move deliverables: %w", err)
+	}
+
+	// Drafts follow their deliverable; drafts of a deliverable left behind stay with it
+	if _, err := tx.Exec(`
+		UPDATE deliverable_drafts SET sponsorship_id = $1
+		WHERE sponsorship_id = $2
+		  AND deliverable_id IN (SELECT id FROM deliverables WHERE sponsorship_id = $1)
+	`, target.ID, duplicateID); err != nil {
+		return fmt.Errorf("failed to move deliverable drafts: %w", err)
+	}
+
+	// Payment links belong to an invoice or milestone, so they move with them
+	for _, table := range []string{"payment_milestones", "invoices", "expenses", "attachments", "exclusivity_clauses"} {
+		if _, err := tx.Exec(`UPDATE `+table+` SET sponsorship_id = $1 WHERE sponsorship_id = $2`,
+			target.ID, duplicateID); err != nil {
+			return fmt.Errorf("failed to move %s: %w", table, err)
+		}
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO sponsorship_status_history (id, sponsorship_id, old_status, new_status, changed_at, changed_by, reason)
+		VALUES ($1, $2, $3, $3, NOW(), NULLIF($4, '')::uuid, $5)
+	`, uuid.New().String(), target.ID, target.Status, changedBy, "Merged duplicate deal "+duplicateID); err != nil {
+		return fmt.Errorf("failed to record merge history: %w", err)
+	}
+
+	if err := insertOutboxEvent(tx, target.CreatorID, duplicateID, models.WebhookEventSponsorshipDeleted,
+		&models.SponsorshipDeletion{ID: duplicateID, MergedInto: target.ID}); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit merge: %w", err)
+	}
+
+	return nil
+}
+
+// ListDealValues retrieves the amount, currency and contract date of a creator's live deals,
+// optionally only those with one brand. The contract date is the deal's first move to a
+// won status, since a deal can skip straight past contracted.
+func (r *SponsorshipRepository) ListDealValues(creatorID, brandID string) ([]*models.DealValue, error) {
+	query := `
+		SELECT s.id, s.brand_name, COALESCE(b.country, ''), s.status, s.deal_amount, s.currency, c.contracted_at
+		FROM sponsorships s
+		LEFT JOIN brands b ON b.id = s.brand_id
+		LEFT JOIN (
+		    SELECT sponsorship_id, MIN(changed_at) AS contracted_at
+		    FROM sponsorship_status_history
+		    WHERE new_status = ANY($3)
+		    GROUP BY sponsorship_id
+		) c ON c.sponsorship_id = s.id
+		WHERE s.creator_id = $1 AND s.deleted_at IS NULL
+		  AND ($2 = '' OR s.brand_id::text = $2)
+		ORDER BY s.created_at DESC
+	`
+
+	rows, err := r.db.Query(query, creatorID, brandID, pq.Array(models.WonStatuses))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deal values: %w", err)
+	}
+	defer rows.Close()
+
+	var values []*models.DealValue
+	for rows.Next() {
+		value := &models.DealValue{}
+		var amount string
+		var contractedAt sql.NullTime
+		if err := rows.Scan(&value.SponsorshipID, &value.BrandName, &value.Country, &value.Status, &amount, &value.Currency, &contractedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan deal value: %w", err)
+		}
+		if value.Amount, err = parseAmount(amount, value.Currency); err != nil {
+			return nil, err
+		}
+		if contractedAt.Valid {
+			value.ContractedAt = &contractedAt.Time
+		}
+		values = append(values, value)
+	}
+
+	return values, rows.Err()
+}
+
+// HasPaymentRecords reports whether a deal has milestones or invoices, whose amounts are
+// recorded in the deal's currency
+func (r *SponsorshipRepository) HasPaymentRecords(id string) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM payment_milestones WHERE sponsorship_id = $1)
+		    OR EXISTS (SELECT 1 FROM invoices WHERE sponsorship_id = $1)
+	`, id).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("failed to check payment records: %w", err)
+	}
+	return exists, nil
+}
+
+// CountActiveSponsorships counts the creator's deals that are neither completed nor declined
+func (r *SponsorshipRepository) CountActiveSponsorships(creatorID string) (int, error) {
+	var count int
+	err := r.db.QueryRow(`
+		SELECT COUNT(*)
+		FROM sponsorships
+		WHERE creator_id = $1 AND deleted_at IS NULL AND status NOT IN ('completed', 'declined')
+	`, creatorID).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count active sponsorships: %w", err)
+	}
+	return count, nil
+}
+
+// parseAmount reads a DECIMAL column scanned as text in the given currency
+func parseAmount(amount, currency string) (money.Money, error) {
+	m, err := money.Parse(amount, currency)
+	if err != nil {
+		return money.Money{}, fmt.Errorf("failed to read %s amount %q: %w", currency, amount, err)
+	}
+	return m, nil
+}
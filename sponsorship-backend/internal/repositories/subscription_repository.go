@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+type SubscriptionRepository struct {
+	db *sql.DB
+}
+
+func NewSubscriptionRepository(db *sql.DB) *SubscriptionRepository {
+	return &SubscriptionRepository{db: db}
+}
+
+// LinkSubscription records which user and plan a subscription started by checkout belongs
+// to, on behalf of a Stripe event. A subscription already known from its own events keeps
+// its status, and a new one is stored as if no subscription event had been applied yet.
+// It returns false, changing nothing, if the event was already handled.
+func (r *SubscriptionRepository) LinkSubscription(eventID, eventType string, sub *models.Subscription) (bool, error) {
+	query := `
+		INSERT INTO subscriptions (
+			id, user_id, product_id, provider_subscription_id, customer_id, status,
+			last_event_at, created_at, updated_at
+		) VALUES (
+			$1, (SELECT id FROM users WHERE id = NULLIF($2, '')::uuid),
+			(SELECT id FROM products WHERE id = NULLIF($3, '')), $4, NULLIF($5, ''), $6, TIMESTAMP 'epoch', $7, $7
+		)
+		ON CONFLICT (provider_subscription_id) DO UPDATE SET
+			user_id = COALESCE(subscriptions.user_id, EXCLUDED.user_id),
+			product_id = COALESCE(subscriptions.product_id, EXCLUDED.product_id),
+			customer_id = COALESCE(subscriptions.customer_id, EXCLUDED.customer_id),
+			updated_at = EXCLUDED.updated_at
+	`
+	return r.applyEvent(eventID, eventType, query, sub)
+}
+
+// SyncSubscription stores a subscription's state from a Stripe subscription event. Events
+// older than the last one applied are ignored, since Stripe does not guarantee order. It
+// returns false, changing nothing, if the event was already handled.
+func (r *SubscriptionRepository) SyncSubscription(eventID, eventType string, sub *models.Subscription) (bool, error) {
+	query := `
+		INSERT INTO subscriptions (
+			id, user_id, product_id, provider_subscription_id, customer_id, status,
+			last_event_at, created_at, updated_at, current_period_end, cancel_at_period_end
+		) VALUES (
+			$1, (SELECT id FROM users WHERE id = NULLIF($2, '')::uuid),
+			(SELECT id FROM products WHERE id = NULLIF($3, '')), $4, NULLIF($5, ''), $6, $8, $7, $7, $9, $10
+		)
+		ON CONFLICT (provider_subscription_id) DO UPDATE SET
+			user_id = COALESCE(subscriptions.user_id, EXCLUDED.user_id),
+			product_id = COALESCE(EXCLUDED.product_id, subscriptions.product_id),
+			customer_id = COALESCE(EXCLUDED.customer_id, subscriptions.customer_id),
+			status = EXCLUDED.status,
+			current_period_end = EXCLUDED.current_period_end,
+			cancel_at_period_end = EXCLUDED.cancel_at_period_end,
+			last_event_at = EXCLUDED.last_event_at,
+			updated_at = EXCLUDED.updated_at
+		WHERE subscriptions.last_event_at <= EXCLUDED.last_event_at
+	`
+	return r.applyEvent(eventID, eventType, query, sub, sub.LastEventAt, sub.CurrentPeriodEnd, sub.CancelAtPeriodEnd)
+}
+
+// applyEvent claims an event and runs an upsert of sub whose first seven parameters are
+// id, user, product, provider ID, customer, status and now
+func (r *SubscriptionRepository) applyEvent(eventID, eventType, query string, sub *models.Subscription,
+	extra ...interface{}) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to begin subscription transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	claimed, err := claimEvent(tx, eventID, eventType)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	args := append([]interface{}{
+		uuid.New().String(), sub.UserID, sub.ProductID, sub.ProviderSubscriptionID, sub.CustomerID,
+		sub.Status, time.Now(),
+	}, extra...)
+	if _, err := tx.Exec(query, args...); err != nil {
+		return false, fmt.Errorf("failed to save subscription: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit subscription: %w", err)
+	}
+	return true, nil
+}
+
+// GetEntitlements returns what the user's current plan allows. It returns ErrNotFound if
+// the user has no subscription in an entitled status, i.e. is on the free plan.
+func (r *SubscriptionRepository) GetEntitlements(userID string) (*models.Entitlements, error) {
+	e := &models.Entitlements{}
+	query := `
+		SELECT p.id, s.status, p.max_active_deals, p.api_access,
+		       s.current_period_end, s.cancel_at_period_end
+		FROM subscriptions s
+		JOIN products p ON p.id = s.product_id
+		WHERE s.user_id = $1 AND s.status = ANY($2)
+		ORDER BY s.created_at DESC
+		LIMIT 1
+	`
+
+	err := r.db.QueryRow(query, userID, pq.Array(models.EntitledSubscriptionStatuses)).Scan(
+		&e.Plan, &e.Status, &e.MaxActiveDeals, &e.APIAccess,
+		&e.CurrentPeriodEnd, &e.CancelAtPeriodEnd,
+	)
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get entitlements: %w", err)
+	}
+
+	return e, nil
+}
@@ -0,0 +1,214 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+
+	"github.com/google/uuid"
+)
+
+type TriageRuleRepository struct {
+	db *sql.DB
+}
+
+func NewTriageRuleRepository(db *sql.DB) *TriageRuleRepository {
+	return &TriageRuleRepository{db: db}
+}
+
+// scanner is satisfied by *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanTriageRule(row scanner) (*models.TriageRule, error) {
+	rule := &models.TriageRule{}
+	var conditions, action []byte
+	if err := row.Scan(
+		&rule.ID, &rule.CreatorID, &rule.Name, &rule.Position, &rule.Enabled,
+		&conditions, &action, &rule.CreatedAt, &rule.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(conditions, &rule.Conditions); err != nil {
+		return nil, fmt.Errorf("failed to decode rule conditions: %w", err)
+	}
+	if err := json.Unmarshal(action, &rule.Action); err != nil {
+		return nil, fmt.Errorf("failed to decode rule action: %w", err)
+	}
+	return rule, nil
+}
+
+// ListRules retrieves a creator's triage rules in evaluation order
+func (r *TriageRuleRepository) ListRules(creatorID string) ([]*models.TriageRule, error) {
+	query := `
+		SELECT id, creator_id, name, position, enabled, conditions, action, created_at, updated_at
+		FROM triage_rules
+		WHERE creator_id = $1
+		ORDER BY position, created_at
+	`
+
+	rows, err := r.db.Query(query, creatorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list triage rules: %w", err)
+	}
+	defer rows.Close()
+
+	var rules []*models.TriageRule
+	for rows.Next() {
+		rule, err := scanTriageRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan triage rule: %w", err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+// GetRuleByID retrieves a triage rule by ID
+func (r *TriageRuleRepository) GetRuleByID(id, creatorID string) (*models.TriageRule, error) {
+	query := `
+		SELECT id, creator_id, name, position, enabled, conditions, action, created_at, updated_at
+		FROM triage_rules
+		WHERE id = $1 AND creator_id = $2
+	`
+
+	rule, err := scanTriageRule(r.db.QueryRow(query, id, creatorID))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get triage rule: %w", err)
+	}
+
+	return rule, nil
+}
+
+// CreateRule appends a rule to the end of the creator's rule list
+func (r *TriageRuleRepository) CreateRule(rule *models.TriageRule) error {
+	conditions, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return fmt.Errorf("failed to encode rule conditions: %w", err)
+	}
+	action, err := json.Marshal(rule.Action)
+	if err != nil {
+		return fmt.Errorf("failed to encode rule action: %w", err)
+	}
+
+	rule.ID = uuid.New().String()
+	rule.CreatedAt = time.Now()
+	rule.UpdatedAt = rule.CreatedAt
+
+	query := `
+		INSERT INTO triage_rules (id, creator_id, name, position, enabled, conditions, action, created_at, updated_at)
+		VALUES ($1, $2, $3,
+		        (SELECT COALESCE(MAX(position), 0) + 1 FROM triage_rules WHERE creator_id = $2),
+		        $4, $5, $6, $7, $8)
+		RETURNING position
+	`
+
+	err = r.db.QueryRow(query, rule.ID, rule.CreatorID, rule.Name, rule.Enabled,
+		conditions, action, rule.CreatedAt, rule.UpdatedAt).Scan(&rule.Position)
+	if err != nil {
+		return fmt.Errorf("failed to create triage rule: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateRule updates a rule's name, enabled flag, conditions and action
+func (r *TriageRuleRepository) UpdateRule(rule *models.TriageRule) error {
+	conditions, err := json.Marshal(rule.Conditions)
+	if err != nil {
+		return fmt.Errorf("failed to encode rule conditions: %w", err)
+	}
+	action, err := json.Marshal(rule.Action)
+	if err != nil {
+		return fmt.Errorf("failed to encode rule action: %w", err)
+	}
+
+	rule.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE triage_rules
+		SET name = $1, enabled = $2, conditions = $3, action = $4, updated_at = $5
+		WHERE id = $6 AND creator_id = $7
+	`
+
+	result, err := r.db.Exec(query, rule.Name, rule.Enabled, conditions, action, rule.UpdatedAt, rule.ID, rule.CreatorID)
+	if err != nil {
+		return fmt.Errorf("failed to update triage rule: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// DeleteRule removes a triage rule
+func (r *TriageRuleRepository) DeleteRule(id, creatorID string) error {
+	result, err := r.db.Exec(`DELETE FROM triage_rules WHERE id = $1 AND creator_id = $2`, id, creatorID)
+	if err != nil {
+		return fmt.Errorf("failed to delete triage rule: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+
+	return nil
+}
+
+// ReorderRules sets rule positions to the order of ids, which must list every rule the creator has
+func (r *TriageRuleRepository) ReorderRules(creatorID string, ids []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin reorder transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM triage_rules WHERE creator_id = $1`, creatorID).Scan(&count); err != nil {
+		return fmt.Errorf("failed to count triage rules: %w", err)
+	}
+	if count != len(ids) {
+		return errors.ErrValidationError.WithDetails(map[string]string{
+			"ids": "ids must list every triage rule exactly once",
+		})
+	}
+
+	for i, id := range ids {
+		result, err := tx.Exec(`
+			UPDATE triage_rules SET position = $1, updated_at = NOW() WHERE id = $2 AND creator_id = $3
+		`, i+1, id, creatorID)
+		if err != nil {
+			return fmt.Errorf("failed to reorder triage rule: %w", err)
+		}
+		if rows, err := result.RowsAffected(); err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		} else if rows == 0 {
+			return errors.ErrNotFound
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit reorder: %w", err)
+	}
+
+	return nil
+}
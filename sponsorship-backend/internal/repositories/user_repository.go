@@ -99,3 +99,31 @@ func (r *UserRepository) GetUserByID(userID string) (*models.User, error) {
 
 	return user, nil
 }
+
+// GetUserByUsername retrieves a user by username
+func (r *UserRepository) GetUserByUsername(username string) (*models.User, error) {
+	user := &models.User{}
+	query := `
+		SELECT id, username, email, password_hash, created_at, updated_at
+		FROM users
+		WHERE username = $1
+	`
+
+	err := r.db.QueryRow(query, username).Scan(
+		&user.ID,
+		&user.Username,
+		&user.Email,
+		&user.Password,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return user, nil
+}
@@ -0,0 +1,347 @@
+package repositories
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/pkg/errors"
+)
+
+type WebhookRepository struct {
+	db *sql.DB
+}
+
+func NewWebhookRepository(db *sql.DB) *WebhookRepository {
+	return &WebhookRepository{db: db}
+}
+
+// The secret is not read back; it is only returned by CreateEndpoint
+const webhookEndpointColumns = `
+	id, creator_id, url, COALESCE(description, ''), events, active, created_at, updated_at
+`
+
+func scanWebhookEndpoint(row scanner) (*models.WebhookEndpoint, error) {
+	e := &models.WebhookEndpoint{}
+	if err := row.Scan(
+		&e.ID, &e.CreatorID, &e.URL, &e.Description, pq.Array(&e.Events), &e.Active, &e.CreatedAt, &e.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+const webhookDeliveryColumns = `
+	id, endpoint_id, event_id, event_type, payload, status, attempts, next_attempt_at, last_attempt_at,
+	last_status_code, COALESCE(last_error, ''), created_at, updated_at
+`
+
+func scanWebhookDelivery(row scanner) (*models.WebhookDelivery, error) {
+	d := &models.WebhookDelivery{}
+	var payload string
+	if err := row.Scan(
+		&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+		&d.LastAttemptAt, &d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.UpdatedAt,
+	); err != nil {
+		return nil, err
+	}
+	d.Payload = []byte(payload)
+	return d, nil
+}
+
+// CreateEndpoint registers a webhook endpoint with its signing secret
+func (r *WebhookRepository) CreateEndpoint(e *models.WebhookEndpoint) error {
+	e.ID = uuid.New().String()
+	e.CreatedAt = time.Now()
+	e.UpdatedAt = e.CreatedAt
+
+	query := `
+		INSERT INTO webhook_endpoints (id, creator_id, url, description, events, secret, active, created_at, updated_at)
+		VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6, $7, $8, $8)
+	`
+
+	_, err := r.db.Exec(query, e.ID, e.CreatorID, e.URL, e.Description, pq.Array(e.Events), e.Secret, e.Active,
+		e.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create webhook endpoint: %w", err)
+	}
+
+	return nil
+}
+
+// GetEndpoint retrieves one of the creator's webhook endpoints, without its secret
+func (r *WebhookRepository) GetEndpoint(id, creatorID string) (*models.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + ` FROM webhook_endpoints WHERE id = $1 AND creator_id = $2`
+
+	e, err := scanWebhookEndpoint(r.db.QueryRow(query, id, creatorID))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook endpoint: %w", err)
+	}
+
+	return e, nil
+}
+
+// ListEndpoints retrieves the creator's webhook endpoints, oldest first, without secrets
+func (r *WebhookRepository) ListEndpoints(creatorID string) ([]*models.WebhookEndpoint, error) {
+	query := `SELECT ` + webhookEndpointColumns + `
+		FROM webhook_endpoints
+		WHERE creator_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(query, creatorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook endpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var endpoints []*models.WebhookEndpoint
+	for rows.Next() {
+		e, err := scanWebhookEndpoint(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook endpoint: %w", err)
+		}
+		endpoints = append(endpoints, e)
+	}
+
+	return endpoints, rows.Err()
+}
+
+// UpdateEndpoint saves an endpoint's URL, description, events and whether it is active
+func (r *WebhookRepository) UpdateEndpoint(e *models.WebhookEndpoint) error {
+	e.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE webhook_endpoints
+		SET url = $1, description = NULLIF($2, ''), events = $3, active = $4, updated_at = $5
+		WHERE id = $6 AND creator_id = $7
+	`
+
+	result, err := r.db.Exec(query, e.URL, e.Description, pq.Array(e.Events), e.Active, e.UpdatedAt, e.ID,
+		e.CreatorID)
+	if err != nil {
+		return fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update webhook endpoint: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteEndpoint removes an endpoint with its deliveries
+func (r *WebhookRepository) DeleteEndpoint(id, creatorID string) error {
+	result, err := r.db.Exec(`DELETE FROM webhook_endpoints WHERE id = $1 AND creator_id = $2`, id, creatorID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook endpoint: %w", err)
+	}
+	if rows == 0 {
+		return errors.ErrNotFound
+	}
+	return nil
+}
+
+// EnqueueEvent queues an event for every active endpoint of the creator subscribed to its
+// type, due at once. Endpoints the event was already queued for are skipped, so an event
+// enqueued again is still delivered once. It returns how many deliveries were queued.
+func (r *WebhookRepository) EnqueueEvent(creatorID, eventID, eventType string, payload []byte) (int64, error) {
+	query := `
+		INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload, status, next_attempt_at, created_at, updated_at)
+		SELECT id, $2, $3, $4, 'pending', NOW(), NOW(), NOW()
+		FROM webhook_endpoints
+		WHERE creator_id = $1 AND active AND $3 = ANY(events)
+		  AND NOT EXISTS (
+			SELECT 1 FROM webhook_deliveries d WHERE d.endpoint_id = webhook_endpoints.id AND d.event_id = $2
+		  )
+	`
+
+	result, err := r.db.Exec(query, creatorID, eventID, eventType, string(payload))
+	if err != nil {
+		return 0, fmt.Errorf("failed to enqueue webhook event: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// ClaimDueDeliveries takes up to limit pending deliveries that are due, for active
+// endpoints, and pushes their next attempt back by lease. A delivery whose sender stops
+// before recording the attempt is thus retried once the lease runs out. Deliveries
+// claimed by another instance are skipped.
+func (r *WebhookRepository) ClaimDueDeliveries(limit int, lease time.Duration) ([]*models.WebhookDelivery, error) {
+	query := `
+		WITH due AS (
+			SELECT d.id
+			FROM webhook_deliveries d
+			JOIN webhook_endpoints e ON e.id = d.endpoint_id
+			WHERE d.status = 'pending' AND d.next_attempt_at <= $2 AND e.active
+			ORDER BY d.next_attempt_at
+			LIMIT $1
+			FOR UPDATE OF d SKIP LOCKED
+		)
+		UPDATE webhook_deliveries d
+		SET next_attempt_at = $3, updated_at = $2
+		FROM due, webhook_endpoints e
+		WHERE d.id = due.id AND e.id = d.endpoint_id
+		RETURNING d.id, d.endpoint_id, d.event_id, d.event_type, d.payload, d.status, d.attempts,
+		          d.next_attempt_at, d.last_attempt_at, d.last_status_code, COALESCE(d.last_error, ''),
+		          d.created_at, d.updated_at, e.url, e.secret
+	`
+
+	now := time.Now()
+	rows, err := r.db.Query(query, limit, now, now.Add(lease))
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		d := &models.WebhookDelivery{}
+		var payload string
+		if err := rows.Scan(
+			&d.ID, &d.EndpointID, &d.EventID, &d.EventType, &payload, &d.Status, &d.Attempts, &d.NextAttemptAt,
+			&d.LastAttemptAt, &d.LastStatusCode, &d.LastError, &d.CreatedAt, &d.UpdatedAt, &d.URL, &d.Secret,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		d.Payload = []byte(payload)
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// RecordAttempt stores an attempt at sending a delivery and moves the delivery to status,
+// due again at nextAttemptAt if it is still pending
+func (r *WebhookRepository) RecordAttempt(a *models.WebhookDeliveryAttempt, status string, nextAttemptAt *time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin webhook attempt transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	a.ID = uuid.New().String()
+	if _, err := tx.Exec(`
+		INSERT INTO webhook_delivery_attempts (
+			id, delivery_id, attempt, status_code, error, response_body, duration_ms, attempted_at
+		) VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8)
+	`, a.ID, a.DeliveryID, a.Attempt, a.StatusCode, a.Error, a.ResponseBody, a.DurationMS, a.AttemptedAt); err != nil {
+		return fmt.Errorf("failed to record webhook attempt: %w", err)
+	}
+
+	if _, err := tx.Exec(`
+		UPDATE webhook_deliveries
+		SET status = $1, attempts = $2, next_attempt_at = $3, last_attempt_at = $4, last_status_code = $5,
+		    last_error = NULLIF($6, ''), updated_at = NOW()
+		WHERE id = $7
+	`, status, a.Attempt, nextAttemptAt, a.AttemptedAt, a.StatusCode, a.Error, a.DeliveryID); err != nil {
+		return fmt.Errorf("failed to update webhook delivery: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit webhook attempt: %w", err)
+	}
+	return nil
+}
+
+// ListDeliveries retrieves an endpoint's most recent deliveries, newest first
+func (r *WebhookRepository) ListDeliveries(endpointID, creatorID string, limit int) ([]*models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE endpoint_id = $1
+		  AND EXISTS (SELECT 1 FROM webhook_endpoints WHERE id = $1 AND creator_id = $2)
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.Query(query, endpointID, creatorID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+	defer rows.Close()
+
+	var deliveries []*models.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook delivery: %w", err)
+		}
+		deliveries = append(deliveries, d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+// GetDelivery retrieves one of an endpoint's deliveries with its attempts, oldest first
+func (r *WebhookRepository) GetDelivery(id, endpointID, creatorID string) (*models.WebhookDelivery, error) {
+	query := `SELECT ` + webhookDeliveryColumns + `
+		FROM webhook_deliveries
+		WHERE id = $1 AND endpoint_id = $2
+		  AND EXISTS (SELECT 1 FROM webhook_endpoints WHERE id = $2 AND creator_id = $3)
+	`
+
+	d, err := scanWebhookDelivery(r.db.QueryRow(query, id, endpointID, creatorID))
+	if err == sql.ErrNoRows {
+		return nil, errors.ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook delivery: %w", err)
+	}
+
+	rows, err := r.db.Query(`
+		SELECT id, delivery_id, attempt, status_code, COALESCE(error, ''), COALESCE(response_body, ''),
+		       duration_ms, attempted_at
+		FROM webhook_delivery_attempts
+		WHERE delivery_id = $1
+		ORDER BY attempt
+	`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook attempts: %w", err)
+	}
+	defer rows.Close()
+
+	d.AttemptLog = []*models.WebhookDeliveryAttempt{}
+	for rows.Next() {
+		a := &models.WebhookDeliveryAttempt{}
+		if err := rows.Scan(&a.ID, &a.DeliveryID, &a.Attempt, &a.StatusCode, &a.Error, &a.ResponseBody,
+			&a.DurationMS, &a.AttemptedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan webhook attempt: %w", err)
+		}
+		d.AttemptLog = append(d.AttemptLog, a)
+	}
+
+	return d, rows.Err()
+}
+
+// Redeliver queues a finished delivery to be sent again as a new delivery, with the same
+// event ID and payload and a fresh set of attempts
+func (r *WebhookRepository) Redeliver(original *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	query := `
+		INSERT INTO webhook_deliveries (endpoint_id, event_id, event_type, payload, status, next_attempt_at, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, 'pending', NOW(), NOW(), NOW())
+		RETURNING ` + webhookDeliveryColumns
+
+	d, err := scanWebhookDelivery(r.db.QueryRow(query, original.EndpointID, original.EventID, original.EventType,
+		string(original.Payload)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeliver webhook: %w", err)
+	}
+
+	return d, nil
+}
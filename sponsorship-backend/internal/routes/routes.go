@@ -1,19 +1,37 @@
 package routes
 
 import (
+	"context"
 	"database/sql"
 	"net/http"
+	"os"
 
 	"sponsorship-backend/config"
 	"sponsorship-backend/internal/api/middleware"
 	"sponsorship-backend/internal/handlers"
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/notifications"
+	"sponsorship-backend/internal/outbox"
 	"sponsorship-backend/internal/repositories"
+	"sponsorship-backend/internal/webhooks"
+	"sponsorship-backend/pkg/fx"
 	"sponsorship-backend/pkg/jwt"
+	"sponsorship-backend/pkg/logger"
+	"sponsorship-backend/pkg/mailer"
+	"sponsorship-backend/pkg/payments"
+	"sponsorship-backend/pkg/storage"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func NewRouter(cfg *config.Config, db *sql.DB) http.Handler {
+// Worker is a background loop that runs until its context is cancelled
+type Worker interface {
+	Run(ctx context.Context)
+}
+
+// NewRouter builds the HTTP handler and the background workers it relies on. The caller
+// runs the workers and stops them on shutdown.
+func NewRouter(cfg *config.Config, db *sql.DB) (http.Handler, []Worker) {
 	r := chi.NewRouter()
 
 	// Global middleware - add request logging first
@@ -26,14 +44,108 @@ func NewRouter(cfg *config.Config, db *sql.DB) http.Handler {
 
 	userRepo := repositories.NewUserRepository(db)
 	sponsorshipRepo := repositories.NewSponsorshipRepository(db)
+	brandRepo := repositories.NewBrandRepository(db)
+	triageRuleRepo := repositories.NewTriageRuleRepository(db)
+	exclusivityRepo := repositories.NewExclusivityRepository(db)
+	blocklistRepo := repositories.NewBlocklistRepository(db)
+	deliverableRepo := repositories.NewDeliverableRepository(db)
+	draftRepo := repositories.NewDraftRepository(db)
+	attachmentRepo := repositories.NewAttachmentRepository(db)
+	milestoneRepo := repositories.NewMilestoneRepository(db)
+	invoiceRepo := repositories.NewInvoiceRepository(db)
+	settingsRepo := repositories.NewSettingsRepository(db)
+	fxRepo := repositories.NewFXRepository(db)
+	expenseRepo := repositories.NewExpenseRepository(db)
+	incomeRepo := repositories.NewIncomeRepository(db)
+	paymentRepo := repositories.NewPaymentRepository(db)
+	productRepo := repositories.NewProductRepository(db)
+	subscriptionRepo := repositories.NewSubscriptionRepository(db)
+	idempotencyRepo := repositories.NewIdempotencyRepository(db)
+	paymentLinkRepo := repositories.NewPaymentLinkRepository(db)
+	couponRepo := repositories.NewCouponRepository(db)
+	webhookRepo := repositories.NewWebhookRepository(db)
+	outboxRepo := repositories.NewOutboxRepository(db)
+
+	if cfg.FXRatesFile != "" {
+		count, err := loadFXRates(cfg.FXRatesFile, fxRepo)
+		if err != nil {
+			logger.Fatal("Failed to load exchange rates from %s: %v", cfg.FXRatesFile, err)
+		}
+		logger.Info("Loaded %d exchange rates from %s", count, cfg.FXRatesFile)
+	}
+
+	var mail mailer.Mailer = mailer.NewLogMailer()
+	if cfg.SMTPHost != "" {
+		mail = mailer.NewSMTPMailer(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.MailFrom)
+	}
+
+	store, err := newStorage(cfg)
+	if err != nil {
+		logger.Fatal("Failed to initialize %s file storage: %v", cfg.StorageBackend, err)
+	}
+
+	paymentProvider := newPaymentProvider(cfg)
+
+	webhookPublisher := webhooks.NewPublisher(webhookRepo)
+	webhookDispatcher := webhooks.NewDispatcher(webhookRepo, cfg.WebhookTimeout, cfg.WebhookMaxAttempts,
+		cfg.WebhookPollInterval, cfg.WebhookAllowHTTP)
+
+	// Sponsorship changes and payments store their events in the outbox, which hands them
+	// to these subscribers. Emails answering a request, such as pitch acknowledgements and
+	// triage declines, are still sent directly by the handlers.
+	outboxDispatcher := outbox.NewDispatcher(outboxRepo, cfg.OutboxPollInterval)
+	outboxDispatcher.Subscribe("webhooks", webhookPublisher)
+	outboxDispatcher.Subscribe("notifications", notifications.NewNotifier(userRepo, sponsorshipRepo, mail))
+
+	// Users without a subscription get the free plan
+	freePlan := models.Entitlements{Plan: "free"}
+	if cfg.FreePlanMaxActiveDeals > 0 {
+		freePlan.MaxActiveDeals = &cfg.FreePlanMaxActiveDeals
+	}
+	entitlements := middleware.NewEntitlementMiddleware(subscriptionRepo, sponsorshipRepo, freePlan)
+	idempotency := middleware.NewIdempotencyMiddleware(idempotencyRepo, cfg.IdempotencyKeyTTL)
+	admin := middleware.NewAdminMiddleware(cfg.AdminEmails)
 
 	authHandler := handlers.NewAuthHandler(userRepo, tokenManager)
-	sponsorshipHandler := handlers.NewSponsorshipHandler(sponsorshipRepo)
-	checkoutHandler := handlers.NewCheckoutHandler()
+	sponsorshipHandler := handlers.NewSponsorshipHandler(sponsorshipRepo, brandRepo, triageRuleRepo, exclusivityRepo, blocklistRepo, deliverableRepo, settingsRepo, fxRepo, entitlements, mail)
+	exclusivityHandler := handlers.NewExclusivityHandler(sponsorshipRepo, exclusivityRepo, blocklistRepo)
+	deliverableHandler := handlers.NewDeliverableHandler(deliverableRepo, sponsorshipRepo)
+	draftHandler := handlers.NewDraftHandler(draftRepo, deliverableRepo, sponsorshipRepo, attachmentRepo, store)
+	attachmentHandler := handlers.NewAttachmentHandler(attachmentRepo, sponsorshipRepo, store, cfg.MaxUploadBytes, cfg.CreatorStorageQuota)
+	milestoneHandler := handlers.NewMilestoneHandler(milestoneRepo, sponsorshipRepo)
+	reportHandler := handlers.NewReportHandler(milestoneRepo, incomeRepo, sponsorshipRepo, expenseRepo, settingsRepo, fxRepo)
+	exportHandler := handlers.NewExportHandler(invoiceRepo, incomeRepo, expenseRepo, sponsorshipRepo, settingsRepo, fxRepo)
+	expenseHandler := handlers.NewExpenseHandler(expenseRepo, sponsorshipRepo, attachmentRepo)
+	invoiceHandler := handlers.NewInvoiceHandler(invoiceRepo, sponsorshipRepo, milestoneRepo, deliverableRepo)
+	paymentLinkHandler := handlers.NewPaymentLinkHandler(paymentProvider, paymentLinkRepo, invoiceRepo, milestoneRepo,
+		sponsorshipRepo, cfg.FrontendURL)
+	brandHandler := handlers.NewBrandHandler(brandRepo, sponsorshipRepo, settingsRepo, fxRepo)
+	settingsHandler := handlers.NewSettingsHandler(settingsRepo, fxRepo)
+	triageRuleHandler := handlers.NewTriageRuleHandler(triageRuleRepo, settingsRepo)
+	checkoutHandler := handlers.NewCheckoutHandler(paymentProvider, productRepo, subscriptionRepo, couponRepo,
+		cfg.FrontendURL)
+	couponHandler := handlers.NewCouponHandler(couponRepo, productRepo)
+	billingHandler := handlers.NewBillingHandler(paymentProvider, subscriptionRepo, sponsorshipRepo, paymentRepo, freePlan,
+		cfg.FrontendURL)
+	stripeWebhookHandler := handlers.NewStripeWebhookHandler(paymentProvider, paymentRepo, subscriptionRepo, paymentLinkRepo,
+		couponRepo)
+	webhookEndpointHandler := handlers.NewWebhookEndpointHandler(webhookRepo, cfg.WebhookAllowHTTP)
+	publicPitchHandler := handlers.NewPublicPitchHandler(userRepo, sponsorshipHandler, mail, cfg.PublicPitchRequiredFields)
+
+	pitchRateLimiter := middleware.NewRateLimiter(cfg.PublicPitchRateLimit, cfg.PublicPitchRateWindow)
 
 	// Public routes
 	r.Post("/api/auth/login", authHandler.Login)
 	r.Post("/api/auth/register", authHandler.Register)
+	r.With(pitchRateLimiter.Middleware).Post("/api/public/creators/{handle}/pitches", publicPitchHandler.SubmitPitch)
+	r.Get("/api/public/reviews/{token}", draftHandler.GetReview)
+	r.Get("/api/public/reviews/{token}/file", draftHandler.DownloadReviewFile)
+	r.Post("/api/public/reviews/{token}/approve", draftHandler.ApproveDraft)
+	r.Post("/api/public/reviews/{token}/request-changes", draftHandler.RequestChanges)
+	r.Get("/api/products", checkoutHandler.ListProducts)
+
+	// Provider webhooks, authenticated by their signature
+	r.Post("/api/webhooks/stripe", stripeWebhookHandler.HandleEvent)
 
 	// Protected routes
 	r.Group(func(r chi.Router) {
@@ -41,17 +153,162 @@ func NewRouter(cfg *config.Config, db *sql.DB) http.Handler {
 
 		// Sponsorships
 		r.Get("/api/sponsorships", sponsorshipHandler.ListSponsorships)
-		r.Post("/api/sponsorships", sponsorshipHandler.CreateSponsorship)
+		// A replayed create is answered before the limit, which the first one may have reached
+		r.With(idempotency.Middleware).Post("/api/sponsorships", sponsorshipHandler.CreateSponsorship)
 		r.Get("/api/sponsorships/{id}", sponsorshipHandler.GetSponsorship)
 		r.Put("/api/sponsorships/{id}", sponsorshipHandler.UpdateSponsorship)
 		r.Delete("/api/sponsorships/{id}", sponsorshipHandler.DeleteSponsorship)
+		r.Post("/api/sponsorships/{id}/merge", sponsorshipHandler.MergeSponsorship)
+		r.Get("/api/sponsorships/{id}/exclusivity", exclusivityHandler.ListClauses)
+		r.Post("/api/sponsorships/{id}/exclusivity", exclusivityHandler.CreateClause)
+		r.Delete("/api/sponsorships/{id}/exclusivity/{clauseId}", exclusivityHandler.DeleteClause)
+
+		// Deliverables
+		r.Get("/api/sponsorships/{id}/deliverables", deliverableHandler.ListDeliverables)
+		r.Post("/api/sponsorships/{id}/deliverables", deliverableHandler.CreateDeliverable)
+		r.Put("/api/sponsorships/{id}/deliverables/{deliverableId}", deliverableHandler.UpdateDeliverable)
+		r.Delete("/api/sponsorships/{id}/deliverables/{deliverableId}", deliverableHandler.DeleteDeliverable)
+		r.Post("/api/sponsorships/{id}/deliverables/{deliverableId}/publish", deliverableHandler.PublishDeliverable)
+		r.Post("/api/sponsorships/{id}/deliverables/{deliverableId}/complete", deliverableHandler.CompleteDeliverable)
+		r.Get("/api/sponsorships/{id}/deliverables/{deliverableId}/drafts", draftHandler.ListDrafts)
+		r.Post("/api/sponsorships/{id}/deliverables/{deliverableId}/drafts", draftHandler.SubmitDraft)
+
+		// Attachments
+		r.Get("/api/sponsorships/{id}/attachments", attachmentHandler.ListAttachments)
+		r.Post("/api/sponsorships/{id}/attachments", attachmentHandler.UploadAttachment)
+		r.Get("/api/sponsorships/{id}/attachments/{attachmentId}", attachmentHandler.DownloadAttachment)
+		r.Delete("/api/sponsorships/{id}/attachments/{attachmentId}", attachmentHandler.DeleteAttachment)
+
+		// Payment milestones
+		r.Get("/api/sponsorships/{id}/milestones", milestoneHandler.ListMilestones)
+		r.Post("/api/sponsorships/{id}/milestones", milestoneHandler.CreateMilestone)
+		r.Put("/api/sponsorships/{id}/milestones/{milestoneId}", milestoneHandler.UpdateMilestone)
+		r.Delete("/api/sponsorships/{id}/milestones/{milestoneId}", milestoneHandler.DeleteMilestone)
+		r.Post("/api/sponsorships/{id}/milestones/{milestoneId}/paid", milestoneHandler.MarkMilestonePaid)
+		r.Post("/api/sponsorships/{id}/milestones/{milestoneId}/payment-link", paymentLinkHandler.CreateMilestonePaymentLink)
+
+		// Expenses
+		r.Get("/api/sponsorships/{id}/expenses", expenseHandler.ListExpenses)
+		r.Post("/api/sponsorships/{id}/expenses", expenseHandler.CreateExpense)
+		r.Put("/api/sponsorships/{id}/expenses/{expenseId}", expenseHandler.UpdateExpense)
+		r.Delete("/api/sponsorships/{id}/expenses/{expenseId}", expenseHandler.DeleteExpense)
+
+		// Invoices
+		r.Get("/api/billing-details", invoiceHandler.GetBillingDetails)
+		r.Put("/api/billing-details", invoiceHandler.SaveBillingDetails)
+		r.Get("/api/invoices", invoiceHandler.ListInvoices)
+		r.Post("/api/invoices", invoiceHandler.CreateInvoice)
+		r.Get("/api/invoices/{id}", invoiceHandler.GetInvoice)
+		r.Put("/api/invoices/{id}", invoiceHandler.UpdateInvoice)
+		r.Delete("/api/invoices/{id}", invoiceHandler.DeleteInvoice)
+		r.Post("/api/invoices/{id}/send", invoiceHandler.SendInvoice)
+		r.Post("/api/invoices/{id}/paid", invoiceHandler.MarkInvoicePaid)
+		r.Post("/api/invoices/{id}/void", invoiceHandler.VoidInvoice)
+		r.Post("/api/invoices/{id}/payment-link", paymentLinkHandler.CreateInvoicePaymentLink)
+		r.Get("/api/invoices/{id}/pdf", invoiceHandler.GetInvoicePDF)
+		r.Get("/api/invoices/{id}/html", invoiceHandler.GetInvoiceHTML)
+
+		// Settings and exchange rates
+		r.Get("/api/settings", settingsHandler.GetSettings)
+		r.Put("/api/settings", settingsHandler.SaveSettings)
+		r.Get("/api/fx-rates", settingsHandler.ListFXRates)
+
+		// Blocklist
+		r.Get("/api/blocklist", exclusivityHandler.ListBlocklist)
+		r.Post("/api/blocklist", exclusivityHandler.CreateBlocklistEntry)
+		r.Delete("/api/blocklist/{id}", exclusivityHandler.DeleteBlocklistEntry)
+
+		// Brands
+		r.Get("/api/brands", brandHandler.ListBrands)
+		r.Put("/api/brands/{id}", brandHandler.UpdateBrand)
+		r.Get("/api/brands/{id}/summary", brandHandler.GetBrandSummary)
+
+		// Triage rules
+		r.Get("/api/triage-rules", triageRuleHandler.ListRules)
+		r.Post("/api/triage-rules", triageRuleHandler.CreateRule)
+		r.Put("/api/triage-rules/order", triageRuleHandler.ReorderRules)
+		r.Put("/api/triage-rules/{id}", triageRuleHandler.UpdateRule)
+		r.Delete("/api/triage-rules/{id}", triageRuleHandler.DeleteRule)
 
 		// Dashboard
 		r.Get("/api/dashboard/stats", sponsorshipHandler.GetDashboardStats)
 
+		// Reports
+		r.Get("/api/reports/receivables/aging", reportHandler.GetReceivablesAging)
+		r.Get("/api/reports/profitability/deals", reportHandler.GetDealProfitability)
+		r.Get("/api/reports/profitability/monthly", reportHandler.GetMonthlyProfitability)
+		r.Get("/api/reports/tax-summary", reportHandler.GetTaxSummary)
+
+		// Accounting exports
+		r.Get("/api/exports/accounting", exportHandler.ExportAccounting)
+
+		// Outbound webhooks (plans with API access)
+		r.Group(func(r chi.Router) {
+			r.Use(entitlements.RequireFeature(middleware.FeatureAPIAccess))
+
+			r.Get("/api/webhook-endpoints", webhookEndpointHandler.ListEndpoints)
+			r.Post("/api/webhook-endpoints", webhookEndpointHandler.CreateEndpoint)
+			r.Put("/api/webhook-endpoints/{id}", webhookEndpointHandler.UpdateEndpoint)
+			r.Delete("/api/webhook-endpoints/{id}", webhookEndpointHandler.DeleteEndpoint)
+			r.Get("/api/webhook-endpoints/{id}/deliveries", webhookEndpointHandler.ListDeliveries)
+			r.Get("/api/webhook-endpoints/{id}/deliveries/{deliveryId}", webhookEndpointHandler.GetDelivery)
+			r.Post("/api/webhook-endpoints/{id}/deliveries/{deliveryId}/redeliver", webhookEndpointHandler.Redeliver)
+		})
+
+		// Plan and billing
+		r.Get("/api/entitlements", billingHandler.GetEntitlements)
+		r.Get("/api/billing", billingHandler.GetBilling)
+		r.Post("/api/billing/portal", billingHandler.CreatePortalSession)
+
 		// Checkout (requires authentication)
-		r.Post("/api/checkout", checkoutHandler.CreateCheckoutSession)
+		r.With(idempotency.Middleware).Post("/api/checkout", checkoutHandler.CreateCheckoutSession)
+
+		// Admin
+		r.Group(func(r chi.Router) {
+			r.Use(admin.RequireAdmin)
+
+			r.Post("/api/admin/payments/{id}/refunds", billingHandler.IssueRefund)
+			r.Get("/api/admin/coupons", couponHandler.ListCoupons)
+			r.Post("/api/admin/coupons", couponHandler.CreateCoupon)
+			r.Delete("/api/admin/coupons/{code}", couponHandler.DeactivateCoupon)
+		})
 	})
 
-	return r
+	return r, []Worker{webhookDispatcher, outboxDispatcher}
+}
+
+// newPaymentProvider builds the configured payment provider
+func newPaymentProvider(cfg *config.Config) payments.Provider {
+	if cfg.PaymentProvider == "fake" {
+		logger.Warn("Using the fake payment provider: checkouts are never charged")
+		return payments.NewFakeProvider(cfg.StripeWebhookSecret)
+	}
+	return payments.NewStripeProvider(cfg.StripeSecretKey, cfg.StripeWebhookSecret)
+}
+
+// newStorage builds the configured file storage backend
+func newStorage(cfg *config.Config) (storage.Storage, error) {
+	if cfg.StorageBackend == "s3" {
+		return storage.NewS3Storage(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket,
+			cfg.S3AccessKeyID, cfg.S3SecretAccessKey, cfg.S3ForcePathStyle)
+	}
+	return storage.NewLocalStorage(cfg.StorageLocalDir)
+}
+
+// loadFXRates stores the exchange rates in a CSV file, returning how many it read
+func loadFXRates(path string, repo *repositories.FXRepository) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	rates, err := fx.ParseCSV(f)
+	if err != nil {
+		return 0, err
+	}
+	if err := repo.UpsertRates(rates); err != nil {
+		return 0, err
+	}
+	return len(rates), nil
 }
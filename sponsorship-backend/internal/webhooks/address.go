@@ -0,0 +1,106 @@
+package webhooks
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"syscall"
+	"time"
+)
+
+// resolveTimeout bounds the DNS lookup made when an endpoint is registered
+const resolveTimeout = 5 * time.Second
+
+var (
+	ErrInvalidURL       = errors.New("url must be an absolute http or https URL")
+	ErrInsecureURL      = errors.New("url must use https")
+	ErrUnresolvableHost = errors.New("url host could not be resolved")
+	ErrPrivateAddress   = errors.New("url must not point to a loopback, private, link-local or unspecified address")
+)
+
+// blockedNetworks are ranges that are not reachable on the public internet but are not
+// covered by the net.IP predicates used in publicAddress
+var blockedNetworks = []*net.IPNet{
+	mustParseCIDR("0.0.0.0/8"),     // "this network"
+	mustParseCIDR("100.64.0.0/10"), // carrier-grade NAT
+	mustParseCIDR("192.0.0.0/24"),  // IETF protocol assignments
+	mustParseCIDR("198.18.0.0/15"), // benchmarking
+	mustParseCIDR("240.0.0.0/4"),   // reserved, and the broadcast address
+	mustParseCIDR("64:ff9b::/96"),  // NAT64, which can reach any IPv4 address
+}
+
+func mustParseCIDR(s string) *net.IPNet {
+	_, network, err := net.ParseCIDR(s)
+	if err != nil {
+		panic(err)
+	}
+	return network
+}
+
+// publicAddress reports whether deliveries may be sent to ip. Endpoints are registered by
+// creators, so an address inside our own network would let them reach internal services
+// such as the database or a cloud metadata endpoint.
+func publicAddress(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return false
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckURL reports whether an endpoint URL can receive deliveries: an absolute URL, using
+// https unless allowHTTP is set, whose host resolves only to public addresses. The
+// dispatcher checks the address again for every connection, as DNS can change after this.
+func CheckURL(rawURL string, allowHTTP bool) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || !u.IsAbs() || u.Hostname() == "" || u.User != nil ||
+		(u.Scheme != "https" && u.Scheme != "http") {
+		return ErrInvalidURL
+	}
+	if u.Scheme == "http" && !allowHTTP {
+		return ErrInsecureURL
+	}
+
+	host := u.Hostname()
+	if ip := net.ParseIP(host); ip != nil {
+		if !publicAddress(ip) {
+			return ErrPrivateAddress
+		}
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil || len(addrs) == 0 {
+		return ErrUnresolvableHost
+	}
+	for _, addr := range addrs {
+		if !publicAddress(addr.IP) {
+			return ErrPrivateAddress
+		}
+	}
+	return nil
+}
+
+// dialControl refuses connections to non-public addresses. It runs after the host name
+// has been resolved, for the address actually dialled, so a host that resolved to a
+// public address when it was registered cannot later be pointed inside our network.
+func dialControl(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || !publicAddress(ip) {
+		return fmt.Errorf("%w: %s", ErrPrivateAddress, host)
+	}
+	return nil
+}
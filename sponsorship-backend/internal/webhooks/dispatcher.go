@@ -0,0 +1,217 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+	"sponsorship-backend/pkg/logger"
+)
+
+const (
+	// batchSize is how many due deliveries are claimed and sent at a time
+	batchSize = 20
+	// firstRetryDelay is the wait before the second attempt; each later wait doubles it
+	firstRetryDelay = time.Minute
+	// maxRetryDelay caps the wait between attempts
+	maxRetryDelay = 6 * time.Hour
+	// maxResponseBytes is how much of an endpoint's response is kept with an attempt
+	maxResponseBytes = 1024
+)
+
+// Dispatcher sends queued deliveries. Several instances can run against one database;
+// each delivery is claimed by one of them at a time.
+type Dispatcher struct {
+	repo         *repositories.WebhookRepository
+	client       *http.Client
+	allowHTTP    bool
+	maxAttempts  int
+	pollInterval time.Duration
+	lease        time.Duration
+}
+
+// NewDispatcher returns a dispatcher that gives each request timeout to answer and gives
+// up on a delivery after maxAttempts attempts. Deliveries go to https endpoints only,
+// unless allowHTTP is set for development.
+func NewDispatcher(repo *repositories.WebhookRepository, timeout time.Duration, maxAttempts int,
+	pollInterval time.Duration, allowHTTP bool) *Dispatcher {
+	dialer := &net.Dialer{
+		Timeout:   timeout,
+		KeepAlive: 30 * time.Second,
+		// Every connection is checked, whatever the endpoint's host resolves to at the time
+		Control: dialControl,
+	}
+	return &Dispatcher{
+		repo: repo,
+		client: &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				// No proxy: the proxy, not the dialer, would connect to the endpoint
+				Proxy:               nil,
+				DialContext:         dialer.DialContext,
+				ForceAttemptHTTP2:   true,
+				MaxIdleConns:        100,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
+			// A redirect is answered like any other non-2xx response
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		},
+		allowHTTP:    allowHTTP,
+		maxAttempts:  maxAttempts,
+		pollInterval: pollInterval,
+		lease:        timeout + time.Minute,
+	}
+}
+
+// Run sends due deliveries every poll interval until ctx is cancelled
+func (d *Dispatcher) Run(ctx context.Context) {
+	logger.Info("Webhook dispatcher started: polling every %s, up to %d attempts", d.pollInterval, d.maxAttempts)
+	ticker := time.NewTicker(d.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			d.DeliverDue()
+		}
+	}
+}
+
+// DeliverDue sends every delivery that is due, a batch at a time, and returns how many
+// were attempted
+func (d *Dispatcher) DeliverDue() int {
+	attempted := 0
+	for {
+		deliveries, err := d.repo.ClaimDueDeliveries(batchSize, d.lease)
+		if err != nil {
+			logger.Error("Failed to claim webhook deliveries: %v", err)
+			return attempted
+		}
+		if len(deliveries) == 0 {
+			return attempted
+		}
+
+		var wg sync.WaitGroup
+		for _, delivery := range deliveries {
+			wg.Add(1)
+			go func(delivery *models.WebhookDelivery) {
+				defer wg.Done()
+				d.deliver(delivery)
+			}(delivery)
+		}
+		wg.Wait()
+
+		attempted += len(deliveries)
+		if len(deliveries) < batchSize {
+			return attempted
+		}
+	}
+}
+
+// deliver makes one attempt at sending a delivery and records it. A 2xx response
+// succeeds; anything else is retried until the delivery runs out of attempts.
+func (d *Dispatcher) deliver(delivery *models.WebhookDelivery) {
+	attempt := &models.WebhookDeliveryAttempt{
+		DeliveryID:  delivery.ID,
+		Attempt:     delivery.Attempts + 1,
+		AttemptedAt: time.Now(),
+	}
+
+	statusCode, body, err := d.send(delivery, attempt.AttemptedAt)
+	attempt.DurationMS = time.Since(attempt.AttemptedAt).Milliseconds()
+	attempt.ResponseBody = body
+	if statusCode != 0 {
+		attempt.StatusCode = &statusCode
+	}
+	switch {
+	case err != nil:
+		attempt.Error = err.Error()
+	case statusCode < 200 || statusCode > 299:
+		attempt.Error = fmt.Sprintf("endpoint answered %d", statusCode)
+	}
+
+	status := "succeeded"
+	var nextAttemptAt *time.Time
+	if attempt.Error != "" {
+		status = "failed"
+		if attempt.Attempt < d.maxAttempts {
+			status = "pending"
+			next := attempt.AttemptedAt.Add(retryDelay(attempt.Attempt))
+			nextAttemptAt = &next
+		}
+	}
+
+	if err := d.repo.RecordAttempt(attempt, status, nextAttemptAt); err != nil {
+		logger.Error("Failed to record attempt %d of webhook delivery %s: %v", attempt.Attempt, delivery.ID, err)
+		return
+	}
+
+	switch status {
+	case "succeeded":
+		logger.Debug("Webhook delivery %s (%s) sent to %s", delivery.ID, delivery.EventType, delivery.URL)
+	case "pending":
+		logger.Warn("Webhook delivery %s to %s failed, attempt %d of %d: %s", delivery.ID, delivery.URL,
+			attempt.Attempt, d.maxAttempts, attempt.Error)
+	default:
+		logger.Error("Webhook delivery %s to %s failed after %d attempts: %s", delivery.ID, delivery.URL,
+			attempt.Attempt, attempt.Error)
+	}
+}
+
+// send posts a delivery's payload, signed at now, and returns the response status and the
+// start of its body
+func (d *Dispatcher) send(delivery *models.WebhookDelivery, now time.Time) (int, string, error) {
+	// Endpoints registered while http was allowed are not sent to once it is not
+	u, err := url.Parse(delivery.URL)
+	if err != nil {
+		return 0, "", err
+	}
+	if u.Scheme != "https" && !(u.Scheme == "http" && d.allowHTTP) {
+		return 0, "", ErrInsecureURL
+	}
+
+	req, err := http.NewRequest(http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Sponsorship-Manager-Webhooks/1.0")
+	req.Header.Set(HeaderEventID, delivery.EventID)
+	req.Header.Set(HeaderEventType, delivery.EventType)
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, delivery.Payload, now))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	// The body is stored as text, which cannot hold NUL bytes or invalid UTF-8
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	text := strings.ToValidUTF8(strings.ReplaceAll(string(body), "\x00", ""), "\uFFFD")
+	return resp.StatusCode, text, nil
+}
+
+// retryDelay is the wait after a delivery's attempt-th failed attempt
+func retryDelay(attempt int) time.Duration {
+	delay := firstRetryDelay
+	for i := 1; i < attempt && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
@@ -0,0 +1,59 @@
+// Package webhooks sends deal events to the HTTP endpoints creators register. Events are
+// queued as deliveries in the database and sent by a Dispatcher, which signs each request
+// and retries failed ones with exponential backoff.
+package webhooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"sponsorship-backend/internal/models"
+	"sponsorship-backend/internal/repositories"
+	"sponsorship-backend/pkg/logger"
+)
+
+// Event is the JSON body of every delivery
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// Publisher queues events for the endpoints subscribed to them
+type Publisher struct {
+	repo *repositories.WebhookRepository
+}
+
+func NewPublisher(repo *repositories.WebhookRepository) *Publisher {
+	return &Publisher{repo: repo}
+}
+
+// Handle queues an outbox event for the creator's endpoints subscribed to its type, as
+// the outbox's webhooks subscriber. The webhook event keeps the outbox event's ID, so an
+// event handled again is not sent twice.
+func (p *Publisher) Handle(event *models.OutboxEvent) error {
+	return p.enqueue(event.CreatorID, Event{
+		ID:        event.ID,
+		Type:      event.EventType,
+		CreatedAt: event.CreatedAt.UTC(),
+		Data:      event.Payload,
+	})
+}
+
+func (p *Publisher) enqueue(creatorID string, event Event) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook event: %w", err)
+	}
+
+	queued, err := p.repo.EnqueueEvent(creatorID, event.ID, event.Type, payload)
+	if err != nil {
+		return err
+	}
+	if queued > 0 {
+		logger.Debug("Queued %s webhook event %s for %d endpoint(s) of creator %s", event.Type, event.ID, queued, creatorID)
+	}
+	return nil
+}
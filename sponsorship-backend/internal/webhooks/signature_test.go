@@ -0,0 +1,44 @@
+package webhooks
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	const secret = "whsec_test"
+	payload := []byte(`{"id":"evt_1","type":"payment.received"}`)
+	signedAt := time.Unix(1700000000, 0)
+	header := Sign(secret, payload, signedAt)
+
+	if !strings.HasPrefix(header, "t=1700000000,v1=") {
+		t.Fatalf("Sign = %q, want t=<unix time>,v1=<signature>", header)
+	}
+
+	tests := []struct {
+		name    string
+		secret  string
+		payload []byte
+		header  string
+		now     time.Time
+		want    bool
+	}{
+		{"valid", secret, payload, header, signedAt.Add(time.Minute), true},
+		{"at the tolerance", secret, payload, header, signedAt.Add(5 * time.Minute), true},
+		{"too old", secret, payload, header, signedAt.Add(5*time.Minute + time.Second), false},
+		{"other secret", "whsec_other", payload, header, signedAt, false},
+		{"changed payload", secret, []byte(`{"id":"evt_2","type":"payment.received"}`), header, signedAt, false},
+		{"changed time", secret, payload, strings.Replace(header, "t=1700000000", "t=1700000001", 1),
+			signedAt, false},
+		{"no signature", secret, payload, "t=1700000000", signedAt, false},
+		{"no time", secret, payload, header[strings.Index(header, "v1="):], signedAt, false},
+		{"empty header", secret, payload, "", signedAt, false},
+	}
+
+	for _, tt := range tests {
+		if got := Verify(tt.secret, tt.payload, tt.header, 5*time.Minute, tt.now); got != tt.want {
+			t.Errorf("%s: Verify = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
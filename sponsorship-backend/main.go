@@ -1,8 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
 
 	"sponsorship-backend/config"
 	"sponsorship-backend/internal/database"
@@ -41,12 +46,52 @@ func main() {
 
 	// Create router
 	logger.Debug("Creating router and registering handlers")
-	router := routes.NewRouter(cfg, db)
+	router, workers := routes.NewRouter(cfg, db)
+
+	// Stop on SIGINT or SIGTERM
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start background workers
+	var wg sync.WaitGroup
+	for _, worker := range workers {
+		wg.Add(1)
+		go func(worker routes.Worker) {
+			defer wg.Done()
+			worker.Run(ctx)
+		}(worker)
+	}
 
 	// Start server
 	addr := fmt.Sprintf(":%d", cfg.ServerPort)
-	logger.Info("Starting HTTP server on %s", addr)
-	if err := http.ListenAndServe(addr, router); err != nil {
-		logger.Fatal("Server failed: %v", err)
+	server := &http.Server{Addr: addr, Handler: router}
+	go func() {
+		logger.Info("Starting HTTP server on %s", addr)
+		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			logger.Fatal("Server failed: %v", err)
+		}
+	}()
+
+	<-ctx.Done()
+	logger.Info("Shutting down, waiting up to %s for requests and workers to finish", cfg.ShutdownTimeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		logger.Error("Failed to shut down HTTP server: %v", err)
+	}
+
+	// A worker stops after the round it is in; claimed events and deliveries left over
+	// are handed out again once their lease runs out
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		logger.Info("Shutdown complete")
+	case <-shutdownCtx.Done():
+		logger.Warn("Background workers did not stop within %s", cfg.ShutdownTimeout)
 	}
 }
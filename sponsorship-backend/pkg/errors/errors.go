@@ -41,16 +41,86 @@ var (
 		Message:    "Resource already exists",
 		StatusCode: 409,
 	}
+	ErrBlocklisted = &AppError{
+		Code:       "BLOCKLISTED",
+		Message:    "Brand or category is on the blocklist",
+		StatusCode: 409,
+	}
+	ErrExclusivityConflict = &AppError{
+		Code:       "EXCLUSIVITY_CONFLICT",
+		Message:    "Deal conflicts with an active exclusivity window",
+		StatusCode: 409,
+	}
+	ErrRevisionLimitReached = &AppError{
+		Code:       "REVISION_LIMIT_REACHED",
+		Message:    "The contractual revision limit has been reached",
+		StatusCode: 409,
+	}
+	ErrIdempotencyKeyReused = &AppError{
+		Code:       "IDEMPOTENCY_KEY_REUSED",
+		Message:    "The Idempotency-Key was already used for a different request",
+		StatusCode: 409,
+	}
+	ErrIdempotencyKeyInUse = &AppError{
+		Code:       "IDEMPOTENCY_KEY_IN_USE",
+		Message:    "A request with this Idempotency-Key is still being processed",
+		StatusCode: 409,
+	}
+	ErrPayloadTooLarge = &AppError{
+		Code:       "PAYLOAD_TOO_LARGE",
+		Message:    "File exceeds the maximum upload size",
+		StatusCode: 413,
+	}
+	ErrStorageQuotaExceeded = &AppError{
+		Code:       "STORAGE_QUOTA_EXCEEDED",
+		Message:    "Storage quota exceeded",
+		StatusCode: 413,
+	}
+	ErrUnsupportedMediaType = &AppError{
+		Code:       "UNSUPPORTED_MEDIA_TYPE",
+		Message:    "File type is not allowed",
+		StatusCode: 415,
+	}
+	ErrInvalidStateTransition = &AppError{
+		Code:       "INVALID_STATE_TRANSITION",
+		Message:    "The resource cannot change to the requested state",
+		StatusCode: 409,
+	}
+	ErrPlanLimitReached = &AppError{
+		Code:       "PLAN_LIMIT_REACHED",
+		Message:    "Your plan's limit has been reached; upgrade to continue",
+		StatusCode: 402,
+	}
+	ErrNotAcceptingPitches = &AppError{
+		Code:       "NOT_ACCEPTING_PITCHES",
+		Message:    "This creator is not accepting new pitches right now",
+		StatusCode: 409,
+	}
+	ErrFeatureNotInPlan = &AppError{
+		Code:       "FEATURE_NOT_IN_PLAN",
+		Message:    "Your plan does not include this feature",
+		StatusCode: 403,
+	}
 	ErrInvalidRequest = &AppError{
 		Code:       "INVALID_REQUEST",
 		Message:    "Invalid request",
 		StatusCode: 400,
 	}
+	ErrInvalidSignature = &AppError{
+		Code:       "INVALID_SIGNATURE",
+		Message:    "Webhook signature verification failed",
+		StatusCode: 400,
+	}
 	ErrValidationError = &AppError{
 		Code:       "VALIDATION_ERROR",
 		Message:    "Validation failed",
 		StatusCode: 400,
 	}
+	ErrTooManyRequests = &AppError{
+		Code:       "TOO_MANY_REQUESTS",
+		Message:    "Too many requests, please try again later",
+		StatusCode: 429,
+	}
 	ErrInternalError = &AppError{
 		Code:       "INTERNAL_ERROR",
 		Message:    "Internal server error",
@@ -67,8 +137,17 @@ func New(code, message string, statusCode int) *AppError {
 	}
 }
 
-// WithDetails adds details to an error
+// WithDetails returns a copy of the error with details added. The common errors above are
+// shared by every request, so they are never changed in place.
 func (e *AppError) WithDetails(details interface{}) *AppError {
-	e.Details = details
-	return e
+	withDetails := *e
+	withDetails.Details = details
+	return &withDetails
+}
+
+// Is reports whether target is an AppError with the same code, so errors.Is matches a
+// copy made by WithDetails against the common error it came from
+func (e *AppError) Is(target error) bool {
+	t, ok := target.(*AppError)
+	return ok && t.Code == e.Code
 }
@@ -0,0 +1,45 @@
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestWithDetailsLeavesCommonErrorUnchanged(t *testing.T) {
+	err := ErrNotFound.WithDetails("no billing account; buy a plan first")
+
+	if ErrNotFound.Details != nil {
+		t.Errorf("ErrNotFound.Details = %v after WithDetails, want nil", ErrNotFound.Details)
+	}
+	if err == ErrNotFound {
+		t.Error("WithDetails returned the common error itself")
+	}
+	if err.Details != "no billing account; buy a plan first" || err.Code != ErrNotFound.Code ||
+		err.StatusCode != ErrNotFound.StatusCode {
+		t.Errorf("WithDetails = %+v", err)
+	}
+}
+
+func TestIs(t *testing.T) {
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{"common error", ErrBlocklisted, ErrBlocklisted, true},
+		{"copy with details", ErrBlocklisted.WithDetails([]string{"Acme"}), ErrBlocklisted, true},
+		{"wrapped copy", fmt.Errorf("create pitch: %w", ErrPlanLimitReached.WithDetails(map[string]int{"maxActiveDeals": 3})),
+			ErrPlanLimitReached, true},
+		{"same code built with New", New("CONFLICT", "taken", 409), ErrConflict, true},
+		{"other code", ErrConflict.WithDetails("taken"), ErrBlocklisted, false},
+		{"not an AppError", errors.New("CONFLICT"), ErrConflict, false},
+	}
+
+	for _, tt := range tests {
+		if got := errors.Is(tt.err, tt.target); got != tt.want {
+			t.Errorf("%s: errors.Is = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
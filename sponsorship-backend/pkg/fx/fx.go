@@ -0,0 +1,128 @@
+// Package fx reads daily exchange rates and finds the rate between two currencies.
+package fx
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math/big"
+	"sort"
+	"strings"
+	"time"
+
+	"sponsorship-backend/pkg/money"
+)
+
+// Rate is one day's exchange rate: one unit of Base buys Rate units of Quote
+type Rate struct {
+	Date  time.Time
+	Base  string
+	Quote string
+	Rate  *big.Rat
+}
+
+// ParseCSV reads rates from CSV rows of date (YYYY-MM-DD), base, quote and rate, e.g.
+// "2024-03-01,EUR,USD,1.0812". A header row starting with "date" is skipped.
+func ParseCSV(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = 4
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var rates []Rate
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if line == 1 && strings.EqualFold(strings.TrimSpace(record[0]), "date") {
+			continue
+		}
+
+		date, err := time.Parse("2006-01-02", strings.TrimSpace(record[0]))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: date must be YYYY-MM-DD", line)
+		}
+		base := strings.ToUpper(strings.TrimSpace(record[1]))
+		quote := strings.ToUpper(strings.TrimSpace(record[2]))
+		if !money.ValidCurrency(base) || !money.ValidCurrency(quote) || base == quote {
+			return nil, fmt.Errorf("line %d: unknown currency pair %s/%s", line, base, quote)
+		}
+		rate, err := money.ParseRate(record[3])
+		if err != nil {
+			return nil, fmt.Errorf("line %d: rate must be a positive decimal", line)
+		}
+
+		rates = append(rates, Rate{Date: date, Base: base, Quote: quote, Rate: rate})
+	}
+
+	return rates, nil
+}
+
+type pair struct {
+	from, to string
+}
+
+// Table holds one rate per currency pair, such as the latest rates on a given day
+type Table struct {
+	rates      map[pair]*big.Rat
+	currencies []string
+}
+
+// NewTable builds a table from rates. If a pair appears more than once the last wins.
+func NewTable(rates []Rate) *Table {
+	t := &Table{rates: map[pair]*big.Rat{}}
+	seen := map[string]bool{}
+	for _, r := range rates {
+		t.rates[pair{r.Base, r.Quote}] = r.Rate
+		for _, c := range []string{r.Base, r.Quote} {
+			if !seen[c] {
+				seen[c] = true
+				t.currencies = append(t.currencies, c)
+			}
+		}
+	}
+	sort.Strings(t.currencies)
+	return t
+}
+
+// Lookup returns the rate from one currency to another. It uses the pair's own rate,
+// the inverse of the opposite pair, or failing both a cross rate through the first
+// currency (alphabetically) that has a rate to each side.
+func (t *Table) Lookup(from, to string) (*big.Rat, bool) {
+	if from == to {
+		return big.NewRat(1, 1), true
+	}
+	if rate, ok := t.direct(from, to); ok {
+		return rate, true
+	}
+	for _, via := range t.currencies {
+		if via == from || via == to {
+			continue
+		}
+		first, ok := t.direct(from, via)
+		if !ok {
+			continue
+		}
+		second, ok := t.direct(via, to)
+		if !ok {
+			continue
+		}
+		return new(big.Rat).Mul(first, second), true
+	}
+	return nil, false
+}
+
+func (t *Table) direct(from, to string) (*big.Rat, bool) {
+	if rate, ok := t.rates[pair{from, to}]; ok {
+		return rate, true
+	}
+	if rate, ok := t.rates[pair{to, from}]; ok {
+		return new(big.Rat).Inv(rate), true
+	}
+	return nil, false
+}
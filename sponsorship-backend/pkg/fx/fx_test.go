@@ -0,0 +1,70 @@
+package fx
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestParseCSV(t *testing.T) {
+	input := "date,base,quote,rate\n# comment\n2024-03-01, eur, usd, 1.0812\n2024-03-01,GBP,USD,1.2650\n"
+	rates, err := ParseCSV(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rates) != 2 {
+		t.Fatalf("got %d rates, want 2", len(rates))
+	}
+	if r := rates[0]; r.Base != "EUR" || r.Quote != "USD" || r.Rate.Cmp(big.NewRat(10812, 10000)) != 0 ||
+		r.Date.Format("2006-01-02") != "2024-03-01" {
+		t.Errorf("rates[0] = %+v", r)
+	}
+
+	for _, bad := range []string{
+		"03/01/2024,EUR,USD,1.08\n",
+		"2024-03-01,EUR,EUR,1\n",
+		"2024-03-01,EUR,XXX,1.08\n",
+		"2024-03-01,EUR,USD,0\n",
+		"2024-03-01,EUR,USD\n",
+	} {
+		if _, err := ParseCSV(strings.NewReader(bad)); err == nil {
+			t.Errorf("ParseCSV(%q) accepted an invalid row", bad)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	table := NewTable([]Rate{
+		{Base: "EUR", Quote: "USD", Rate: big.NewRat(11, 10)},
+		{Base: "GBP", Quote: "USD", Rate: big.NewRat(5, 4)},
+		{Base: "USD", Quote: "JPY", Rate: big.NewRat(150, 1)},
+		{Base: "EUR", Quote: "USD", Rate: big.NewRat(108, 100)},
+	})
+
+	tests := []struct {
+		from, to string
+		want     *big.Rat // nil if there is no rate
+	}{
+		{"EUR", "EUR", big.NewRat(1, 1)},
+		{"EUR", "USD", big.NewRat(108, 100)}, // the last rate for a pair wins
+		{"USD", "EUR", big.NewRat(100, 108)},
+		{"JPY", "USD", big.NewRat(1, 150)},
+		{"EUR", "GBP", big.NewRat(108*4, 100*5)}, // through USD
+		{"GBP", "JPY", big.NewRat(5*150, 4)},
+		{"EUR", "CHF", nil},
+		{"CHF", "AUD", nil},
+	}
+
+	for _, tt := range tests {
+		got, ok := table.Lookup(tt.from, tt.to)
+		if tt.want == nil {
+			if ok {
+				t.Errorf("Lookup(%s, %s) = %s, want no rate", tt.from, tt.to, got)
+			}
+			continue
+		}
+		if !ok || got.Cmp(tt.want) != 0 {
+			t.Errorf("Lookup(%s, %s) = %v, %v, want %s", tt.from, tt.to, got, ok, tt.want)
+		}
+	}
+}
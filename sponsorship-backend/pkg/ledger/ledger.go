@@ -0,0 +1,151 @@
+// Package ledger writes double-entry journals in formats accounting software imports:
+// a plain CSV journal, QuickBooks IIF and Xero's manual journal CSV.
+package ledger
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"sponsorship-backend/pkg/money"
+)
+
+// Account is a ledger account. Code is used by Xero, Name by QuickBooks.
+type Account struct {
+	Code string
+	Name string
+}
+
+// Line posts an amount to an account: positive amounts are debits, negative credits
+type Line struct {
+	Account Account
+	Amount  money.Money
+}
+
+// Entry is one balanced journal entry
+type Entry struct {
+	ID        string // stable across exports, so a re-import can be deduplicated
+	Date      time.Time
+	Reference string // e.g. an invoice number
+	Name      string // the customer or supplier
+	Memo      string
+	Lines     []Line
+}
+
+// Balanced reports whether the entry's debits equal its credits
+func (e Entry) Balanced() bool {
+	if len(e.Lines) == 0 {
+		return false
+	}
+	sum := money.Zero(e.Lines[0].Amount.Currency())
+	for _, l := range e.Lines {
+		sum = sum.Add(l.Amount)
+	}
+	return sum.IsZero()
+}
+
+// memo prefixes an entry's memo with its ID, for formats without an ID field
+func (e Entry) memo() string {
+	if e.Memo == "" {
+		return "[" + e.ID + "]"
+	}
+	return "[" + e.ID + "] " + e.Memo
+}
+
+// CSVText neutralises a text cell that a spreadsheet would otherwise run as a formula: one
+// starting with =, +, - or @, or with a tab or carriage return that some spreadsheets skip
+// before those, is prefixed with an apostrophe. Brand names, memos and labels are typed by
+// brands and creators, so every text cell of a CSV export goes through it. Amounts do
+// not, so negative amounts stay numbers.
+func CSVText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func debitCredit(m money.Money) (string, string) {
+	if m.IsNegative() {
+		return "", m.Neg().String()
+	}
+	return m.String(), ""
+}
+
+// WriteJournalCSV writes one row per line with separate debit and credit columns
+func WriteJournalCSV(w io.Writer, entries []Entry) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{
+		"entry_id", "date", "reference", "name", "memo", "account_code", "account_name", "currency", "debit", "credit",
+	})
+	for _, e := range entries {
+		for _, l := range e.Lines {
+			debit, credit := debitCredit(l.Amount)
+			out.Write([]string{
+				CSVText(e.ID), e.Date.Format("2006-01-02"), CSVText(e.Reference), CSVText(e.Name), CSVText(e.Memo),
+				CSVText(l.Account.Code), CSVText(l.Account.Name), l.Amount.Currency(), debit, credit,
+			})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
+
+// WriteIIF writes entries as QuickBooks Desktop general journal transactions. The entry ID
+// leads the memo since IIF transaction IDs are assigned by QuickBooks.
+func WriteIIF(w io.Writer, entries []Entry) error {
+	header := "!TRNS\tTRNSID\tTRNSTYPE\tDATE\tACCNT\tNAME\tAMOUNT\tDOCNUM\tMEMO\n" +
+		"!SPL\tSPLID\tTRNSTYPE\tDATE\tACCNT\tNAME\tAMOUNT\tDOCNUM\tMEMO\n" +
+		"!ENDTRNS\n"
+	if _, err := io.WriteString(w, header); err != nil {
+		return err
+	}
+
+	for _, e := range entries {
+		for i, l := range e.Lines {
+			kind := "SPL"
+			if i == 0 {
+				kind = "TRNS"
+			}
+			fields := []string{
+				kind, "", "GENERAL JOURNAL", e.Date.Format("01/02/2006"), iifField(l.Account.Name),
+				iifField(e.Name), l.Amount.String(), iifField(e.Reference), iifField(e.memo()),
+			}
+			if _, err := io.WriteString(w, strings.Join(fields, "\t")+"\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(w, "ENDTRNS\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// iifField removes the characters that would break an IIF row
+func iifField(s string) string {
+	return strings.NewReplacer("\t", " ", "\r", " ", "\n", " ", `"`, "'").Replace(s)
+}
+
+// WriteXeroCSV writes entries in the layout of Xero's manual journal import template.
+// Xero has no field for an external ID, so the entry ID leads the narration. taxRate is
+// the name of the organisation's zero tax rate, e.g. "Tax Exempt" or "No VAT".
+func WriteXeroCSV(w io.Writer, entries []Entry, taxRate string) error {
+	out := csv.NewWriter(w)
+	out.Write([]string{"*Narration", "*Date", "Description", "*AccountCode", "*TaxRate", "*Amount"})
+	for _, e := range entries {
+		for _, l := range e.Lines {
+			description := e.Name
+			if e.Reference != "" {
+				description = strings.TrimSpace(fmt.Sprintf("%s %s", e.Reference, e.Name))
+			}
+			out.Write([]string{
+				CSVText(e.memo()), e.Date.Format("2006-01-02"), CSVText(description), CSVText(l.Account.Code),
+				CSVText(taxRate), l.Amount.String(),
+			})
+		}
+	}
+	out.Flush()
+	return out.Error()
+}
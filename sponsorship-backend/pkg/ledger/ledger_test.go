@@ -0,0 +1,150 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/csv"
+	"strings"
+	"testing"
+	"time"
+
+	"sponsorship-backend/pkg/money"
+)
+
+var (
+	bank   = Account{Code: "090", Name: "Business Bank Account"}
+	income = Account{Code: "200", Name: "Sponsorship Income"}
+)
+
+func testEntry(name, memo string) Entry {
+	return Entry{
+		ID:        "invoice-1-paid",
+		Date:      time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC),
+		Reference: "INV-0001",
+		Name:      name,
+		Memo:      memo,
+		Lines: []Line{
+			{Account: bank, Amount: money.New(150000, "USD")},
+			{Account: income, Amount: money.New(-150000, "USD")},
+		},
+	}
+}
+
+func TestCSVText(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"", ""},
+		{"Acme", "Acme"},
+		{"=1+1", "'=1+1"},
+		{"+1", "'+1"},
+		{"-1", "'-1"},
+		{"@SUM(A1)", "'@SUM(A1)"},
+		{"\t=1", "'\t=1"},
+		{"\r=1", "'\r=1"},
+		{"A=1", "A=1"},
+	}
+
+	for _, tt := range tests {
+		if got := CSVText(tt.in); got != tt.want {
+			t.Errorf("CSVText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestBalanced(t *testing.T) {
+	e := testEntry("Acme", "")
+	if !e.Balanced() {
+		t.Error("balanced entry reported unbalanced")
+	}
+	e.Lines[1].Amount = money.New(-149999, "USD")
+	if e.Balanced() {
+		t.Error("unbalanced entry reported balanced")
+	}
+	if (Entry{}).Balanced() {
+		t.Error("entry without lines reported balanced")
+	}
+}
+
+func TestWriteJournalCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJournalCSV(&buf, []Entry{testEntry("=cmd|' /C calc'!A0", "Payment")}); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"entry_id", "date", "reference", "name", "memo", "account_code", "account_name", "currency", "debit", "credit"},
+		{"invoice-1-paid", "2024-03-05", "INV-0001", "'=cmd|' /C calc'!A0", "Payment", "090", "Business Bank Account",
+			"USD", "1500.00", ""},
+		{"invoice-1-paid", "2024-03-05", "INV-0001", "'=cmd|' /C calc'!A0", "Payment", "200", "Sponsorship Income",
+			"USD", "", "1500.00"},
+	}
+	assertRows(t, rows, want)
+}
+
+func TestWriteXeroCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteXeroCSV(&buf, []Entry{testEntry("@Acme", "Payment")}, "Tax Exempt"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := [][]string{
+		{"*Narration", "*Date", "Description", "*AccountCode", "*TaxRate", "*Amount"},
+		{"[invoice-1-paid] Payment", "2024-03-05", "INV-0001 @Acme", "090", "Tax Exempt", "1500.00"},
+		{"[invoice-1-paid] Payment", "2024-03-05", "INV-0001 @Acme", "200", "Tax Exempt", "-1500.00"},
+	}
+	assertRows(t, rows, want)
+
+	buf.Reset()
+	e := testEntry("@Acme", "")
+	e.Reference = ""
+	if err := WriteXeroCSV(&buf, []Entry{e}, "-"); err != nil {
+		t.Fatal(err)
+	}
+	rows, err = csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := rows[1]; got[0] != "[invoice-1-paid]" || got[2] != "'@Acme" || got[4] != "'-" {
+		t.Errorf("row without reference = %q", got)
+	}
+}
+
+func TestWriteIIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteIIF(&buf, []Entry{testEntry("Acme\t\"Ltd\"", "Line one\nline two")}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := strings.Join([]string{
+		"!TRNS\tTRNSID\tTRNSTYPE\tDATE\tACCNT\tNAME\tAMOUNT\tDOCNUM\tMEMO",
+		"!SPL\tSPLID\tTRNSTYPE\tDATE\tACCNT\tNAME\tAMOUNT\tDOCNUM\tMEMO",
+		"!ENDTRNS",
+		"TRNS\t\tGENERAL JOURNAL\t03/05/2024\tBusiness Bank Account\tAcme 'Ltd'\t1500.00\tINV-0001\t[invoice-1-paid] Line one line two",
+		"SPL\t\tGENERAL JOURNAL\t03/05/2024\tSponsorship Income\tAcme 'Ltd'\t-1500.00\tINV-0001\t[invoice-1-paid] Line one line two",
+		"ENDTRNS",
+		"",
+	}, "\n")
+	if got := buf.String(); got != want {
+		t.Errorf("WriteIIF =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func assertRows(t *testing.T, rows, want [][]string) {
+	t.Helper()
+	if len(rows) != len(want) {
+		t.Fatalf("got %d rows, want %d: %q", len(rows), len(want), rows)
+	}
+	for i := range want {
+		if strings.Join(rows[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("row %d = %q, want %q", i, rows[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,346 @@
+// Package money represents amounts exactly, as an integer number of a currency's minor
+// units (cents for USD, yen for JPY), so no amount ever passes through a float.
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// DefaultCurrency is used for amounts recorded before a currency was chosen
+const DefaultCurrency = "USD"
+
+var (
+	ErrInvalidAmount   = errors.New("amount must be a decimal number")
+	ErrTooPrecise      = errors.New("amount has more decimal places than the currency allows")
+	ErrUnknownCurrency = errors.New("unknown currency code")
+	ErrOverflow        = errors.New("amount is too large")
+)
+
+// minorUnits is the number of decimal places of each supported ISO 4217 currency
+var minorUnits = map[string]int{
+	"AED": 2, "ARS": 2, "AUD": 2, "BRL": 2, "CAD": 2, "CHF": 2, "CLP": 0, "CNY": 2,
+	"COP": 2, "CZK": 2, "DKK": 2, "EUR": 2, "GBP": 2, "HKD": 2, "HUF": 2, "IDR": 2,
+	"ILS": 2, "INR": 2, "ISK": 0, "JPY": 0, "KRW": 0, "MXN": 2, "MYR": 2, "NOK": 2,
+	"NZD": 2, "PHP": 2, "PLN": 2, "RON": 2, "SAR": 2, "SEK": 2, "SGD": 2, "THB": 2,
+	"TRY": 2, "TWD": 2, "UAH": 2, "USD": 2, "VND": 0, "ZAR": 2,
+}
+
+// Money is an amount in a currency. The zero value is zero with no currency, and adopts
+// the currency of whatever is added to it.
+type Money struct {
+	minor    int64
+	currency string
+}
+
+// ValidCurrency reports whether code is a supported ISO 4217 currency code
+func ValidCurrency(code string) bool {
+	_, ok := minorUnits[code]
+	return ok
+}
+
+// Decimals returns the number of minor-unit digits of a currency
+func Decimals(currency string) int {
+	if d, ok := minorUnits[currency]; ok {
+		return d
+	}
+	return 2
+}
+
+// New returns an amount given in minor units
+func New(minor int64, currency string) Money {
+	return Money{minor: minor, currency: currency}
+}
+
+// Zero returns a zero amount in a currency
+func Zero(currency string) Money {
+	return Money{currency: currency}
+}
+
+// Parse reads a decimal string such as "19.99" or "-5" exactly. Trailing zeros beyond the
+// currency's minor units are accepted ("1500.00" JPY), any other extra digit is not.
+func Parse(s, currency string) (Money, error) {
+	decimals, ok := minorUnits[currency]
+	if !ok {
+		return Money{}, ErrUnknownCurrency
+	}
+
+	s = strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(s, "-") || strings.HasPrefix(s, "+") {
+		negative = s[0] == '-'
+		s = s[1:]
+	}
+	whole, frac, hasPoint := strings.Cut(s, ".")
+	if whole == "" && (!hasPoint || frac == "") {
+		return Money{}, ErrInvalidAmount
+	}
+	if !isDigits(whole) || !isDigits(frac) {
+		return Money{}, ErrInvalidAmount
+	}
+
+	if len(frac) > decimals {
+		if strings.Trim(frac[decimals:], "0") != "" {
+			return Money{}, ErrTooPrecise
+		}
+		frac = frac[:decimals]
+	}
+	frac += strings.Repeat("0", decimals-len(frac))
+
+	var minor int64
+	for _, c := range strings.TrimLeft(whole, "0") + frac {
+		if minor > (math.MaxInt64-9)/10 {
+			return Money{}, ErrOverflow
+		}
+		minor = minor*10 + int64(c-'0')
+	}
+	if negative {
+		minor = -minor
+	}
+
+	return Money{minor: minor, currency: currency}, nil
+}
+
+// MustParse is Parse for amounts known to be valid, such as constants
+func MustParse(s, currency string) Money {
+	m, err := Parse(s, currency)
+	if err != nil {
+		panic(fmt.Sprintf("money: %q %s: %v", s, currency, err))
+	}
+	return m
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Minor returns the amount in minor units
+func (m Money) Minor() int64 {
+	return m.minor
+}
+
+// Currency returns the ISO 4217 currency code
+func (m Money) Currency() string {
+	return m.currency
+}
+
+func (m Money) IsZero() bool {
+	return m.minor == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.minor > 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.minor < 0
+}
+
+// Cmp compares two amounts in the same currency, returning -1, 0 or 1
+func (m Money) Cmp(o Money) int {
+	m.mustMatch(o)
+	switch {
+	case m.minor < o.minor:
+		return -1
+	case m.minor > o.minor:
+		return 1
+	}
+	return 0
+}
+
+// Add returns m + o. Both must be in the same currency.
+func (m Money) Add(o Money) Money {
+	currency := m.mustMatch(o)
+	return Money{minor: m.minor + o.minor, currency: currency}
+}
+
+// Sub returns m - o. Both must be in the same currency.
+func (m Money) Sub(o Money) Money {
+	currency := m.mustMatch(o)
+	return Money{minor: m.minor - o.minor, currency: currency}
+}
+
+// Neg returns -m
+func (m Money) Neg() Money {
+	return Money{minor: -m.minor, currency: m.currency}
+}
+
+// mustMatch returns the shared currency of two amounts. A zero amount with no currency
+// matches anything; mixing two currencies is a programming error.
+func (m Money) mustMatch(o Money) string {
+	switch {
+	case m.currency == o.currency || o.currency == "":
+		return m.currency
+	case m.currency == "" && m.minor == 0:
+		return o.currency
+	}
+	panic(fmt.Sprintf("money: mixing %s and %s", m.currency, o.currency))
+}
+
+// MulRat returns m * num / den, rounded half away from zero to the minor unit
+func (m Money) MulRat(num, den int64) Money {
+	return Money{minor: divRound(m.minor*num, den), currency: m.currency}
+}
+
+// Percent returns pct percent of m, rounded to the minor unit. pct is rounded to
+// hundredths of a percent first, so 7.25 is exact.
+func (m Money) Percent(pct float64) Money {
+	return m.MulRat(int64(math.Round(pct*100)), 10000)
+}
+
+// Mul returns m * q for a quantity q with up to two decimal places
+func (m Money) Mul(q float64) Money {
+	return m.MulRat(int64(math.Round(q*100)), 100)
+}
+
+// Div returns m / n rounded to the minor unit, e.g. for averages
+func (m Money) Div(n int64) Money {
+	if n == 0 {
+		return Money{currency: m.currency}
+	}
+	return Money{minor: divRound(m.minor, n), currency: m.currency}
+}
+
+// Allocate splits m into n parts that differ by at most one minor unit and add up to
+// exactly m. The leftover units go to the first parts.
+func (m Money) Allocate(n int) []Money {
+	if n <= 0 {
+		return nil
+	}
+	parts := make([]Money, n)
+	share, rest := m.minor/int64(n), m.minor%int64(n)
+	for i := range parts {
+		parts[i] = Money{minor: share, currency: m.currency}
+		if int64(i) < rest {
+			parts[i].minor++
+		} else if int64(i) < -rest {
+			parts[i].minor--
+		}
+	}
+	return parts
+}
+
+func divRound(a, b int64) int64 {
+	if b < 0 {
+		a, b = -a, -b
+	}
+	q, r := a/b, a%b
+	if 2*r >= b {
+		q++
+	} else if 2*r <= -b {
+		q--
+	}
+	return q
+}
+
+// Convert returns m in another currency, where rate is the number of units of the
+// other currency one unit of m's currency buys. The result is rounded half away from
+// zero to the minor unit.
+func (m Money) Convert(to string, rate *big.Rat) Money {
+	// minor units of to = minor * rate * 10^decimals(to) / 10^decimals(from)
+	v := new(big.Rat).SetInt64(m.minor)
+	v.Mul(v, rate)
+	v.Mul(v, new(big.Rat).SetFrac64(pow10(Decimals(to)), pow10(Decimals(m.currency))))
+
+	num, den := v.Num(), v.Denom()
+	q, r := new(big.Int).QuoRem(num, den, new(big.Int))
+	if r.Sign() != 0 && new(big.Int).Mul(new(big.Int).Abs(r), big.NewInt(2)).Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(int64(num.Sign())))
+	}
+	return Money{minor: q.Int64(), currency: to}
+}
+
+// ParseRate reads a positive decimal exchange rate such as "1.0956" exactly
+func ParseRate(s string) (*big.Rat, error) {
+	s = strings.TrimSpace(s)
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole+frac == "" || !isDigits(whole) || !isDigits(frac) {
+		return nil, ErrInvalidAmount
+	}
+	rate, ok := new(big.Rat).SetString(s)
+	if !ok || rate.Sign() <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	return rate, nil
+}
+
+// Fits reports whether m can be stored in a DECIMAL(precision, scale) column
+func (m Money) Fits(precision, scale int) bool {
+	d := Decimals(m.currency)
+	if d > scale {
+		return false
+	}
+	abs := m.minor
+	if abs < 0 {
+		abs = -abs
+	}
+	// the whole units must fit in the column's integer digits
+	return abs/pow10(d) < pow10(precision-scale)
+}
+
+func pow10(n int) int64 {
+	p := int64(1)
+	for i := 0; i < n; i++ {
+		p *= 10
+	}
+	return p
+}
+
+// String formats m as a plain decimal with the currency's minor units, e.g. "1500.00"
+func (m Money) String() string {
+	d := Decimals(m.currency)
+	abs := m.minor
+	sign := ""
+	if abs < 0 {
+		sign, abs = "-", -abs
+	}
+	if d == 0 {
+		return fmt.Sprintf("%s%d", sign, abs)
+	}
+	unit := pow10(d)
+	return fmt.Sprintf("%s%d.%0*d", sign, abs/unit, d, abs%unit)
+}
+
+// Format formats m for display with thousands separators, e.g. "1,500.00"
+func (m Money) Format() string {
+	s := m.String()
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	whole, frac, hasFrac := strings.Cut(s, ".")
+
+	var b strings.Builder
+	b.WriteString(sign)
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	if hasFrac {
+		b.WriteByte('.')
+		b.WriteString(frac)
+	}
+	return b.String()
+}
+
+// MarshalJSON writes the amount as a JSON number with exactly the currency's decimals.
+// The currency is serialised separately by the types that hold the amount.
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// Value stores the amount in a DECIMAL column
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
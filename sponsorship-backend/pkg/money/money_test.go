@@ -0,0 +1,316 @@
+package money
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		s        string
+		currency string
+		want     int64
+		err      error
+	}{
+		{"19.99", "USD", 1999, nil},
+		{"0.1", "USD", 10, nil},
+		{"-5", "USD", -500, nil},
+		{"+5", "USD", 500, nil},
+		{" 12.50 ", "EUR", 1250, nil},
+		{".5", "USD", 50, nil},
+		{"5.", "USD", 500, nil},
+		{"007.00", "USD", 700, nil},
+		{"19.990", "USD", 1999, nil},
+		{"1500", "JPY", 1500, nil},
+		{"1500.00", "JPY", 1500, nil},
+		{"1500.5", "JPY", 0, ErrTooPrecise},
+		{"19.999", "USD", 0, ErrTooPrecise},
+		{"", "USD", 0, ErrInvalidAmount},
+		{"-", "USD", 0, ErrInvalidAmount},
+		{".", "USD", 0, ErrInvalidAmount},
+		{"1,000", "USD", 0, ErrInvalidAmount},
+		{"1e5", "USD", 0, ErrInvalidAmount},
+		{"--1", "USD", 0, ErrInvalidAmount},
+		{"12.3.4", "USD", 0, ErrInvalidAmount},
+		{"10", "XXX", 0, ErrUnknownCurrency},
+		{"92233720368547758.07", "USD", 0, ErrOverflow},
+		{"99999999999999999999", "JPY", 0, ErrOverflow},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.s, tt.currency)
+		if err != tt.err {
+			t.Errorf("Parse(%q, %s) error = %v, want %v", tt.s, tt.currency, err, tt.err)
+			continue
+		}
+		if err == nil && (got.Minor() != tt.want || got.Currency() != tt.currency) {
+			t.Errorf("Parse(%q, %s) = %d %s, want %d %s", tt.s, tt.currency, got.Minor(), got.Currency(),
+				tt.want, tt.currency)
+		}
+	}
+}
+
+func TestParseStringRoundTrip(t *testing.T) {
+	tests := []struct {
+		s        string
+		currency string
+	}{
+		{"0.00", "USD"},
+		{"19.99", "USD"},
+		{"-0.05", "USD"},
+		{"99999999.99", "EUR"},
+		{"1500", "JPY"},
+		{"-42", "KRW"},
+	}
+
+	for _, tt := range tests {
+		m, err := Parse(tt.s, tt.currency)
+		if err != nil {
+			t.Fatalf("Parse(%q, %s): %v", tt.s, tt.currency, err)
+		}
+		if got := m.String(); got != tt.s {
+			t.Errorf("Parse(%q, %s).String() = %q", tt.s, tt.currency, got)
+		}
+		if again, _ := Parse(m.String(), tt.currency); again != m {
+			t.Errorf("Parse(%q) did not round-trip: %v != %v", m.String(), again, m)
+		}
+	}
+}
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{New(0, "USD"), "0.00"},
+		{New(99999, "USD"), "999.99"},
+		{New(150000, "USD"), "1,500.00"},
+		{New(-123456789, "USD"), "-1,234,567.89"},
+		{New(1500000, "JPY"), "1,500,000"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.m.Format(); got != tt.want {
+			t.Errorf("%d %s Format() = %q, want %q", tt.m.Minor(), tt.m.Currency(), got, tt.want)
+		}
+	}
+}
+
+func TestFits(t *testing.T) {
+	tests := []struct {
+		s        string
+		currency string
+		want     bool
+	}{
+		// DECIMAL(10,2) holds eight whole digits
+		{"99999999.99", "USD", true},
+		{"-99999999.99", "USD", true},
+		{"100000000.00", "USD", false},
+		{"-100000000.00", "USD", false},
+		{"99999999", "JPY", true},
+		{"100000000", "JPY", false},
+		{"0", "USD", true},
+	}
+
+	for _, tt := range tests {
+		m := MustParse(tt.s, tt.currency)
+		if got := m.Fits(10, 2); got != tt.want {
+			t.Errorf("%s %s Fits(10, 2) = %v, want %v", tt.s, tt.currency, got, tt.want)
+		}
+	}
+
+	// A currency with more decimals than the column cannot be stored exactly
+	if New(1, "USD").Fits(10, 0) {
+		t.Error("USD fits a DECIMAL(10,0) column")
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		m    Money
+		n    int
+		want []int64
+	}{
+		{New(1000, "USD"), 3, []int64{334, 333, 333}},
+		{New(-1000, "USD"), 3, []int64{-334, -333, -333}},
+		{New(1001, "USD"), 4, []int64{251, 250, 250, 250}},
+		{New(-2, "USD"), 3, []int64{-1, -1, 0}},
+		{New(900, "USD"), 3, []int64{300, 300, 300}},
+		{New(0, "USD"), 2, []int64{0, 0}},
+		{New(10000, "JPY"), 3, []int64{3334, 3333, 3333}},
+		{New(100, "USD"), 0, nil},
+	}
+
+	for _, tt := range tests {
+		parts := tt.m.Allocate(tt.n)
+		if len(parts) != len(tt.want) {
+			t.Errorf("%d.Allocate(%d) returned %d parts, want %d", tt.m.Minor(), tt.n, len(parts), len(tt.want))
+			continue
+		}
+		sum := Zero(tt.m.Currency())
+		for i, p := range parts {
+			if p.Minor() != tt.want[i] || p.Currency() != tt.m.Currency() {
+				t.Errorf("%d.Allocate(%d)[%d] = %d %s, want %d", tt.m.Minor(), tt.n, i, p.Minor(), p.Currency(),
+					tt.want[i])
+			}
+			sum = sum.Add(p)
+		}
+		if tt.n > 0 && sum != tt.m {
+			t.Errorf("%d.Allocate(%d) adds up to %d", tt.m.Minor(), tt.n, sum.Minor())
+		}
+	}
+}
+
+func TestDivRound(t *testing.T) {
+	tests := []struct {
+		a, b int64
+		want int64
+	}{
+		{5, 2, 3},
+		{-5, 2, -3},
+		{5, -2, -3},
+		{-5, -2, 3},
+		{7, 2, 4},
+		{4, 3, 1},
+		{-4, 3, -1},
+		{6, 4, 2},
+		{-6, 4, -2},
+		{0, 7, 0},
+		{10, 5, 2},
+	}
+
+	for _, tt := range tests {
+		if got := divRound(tt.a, tt.b); got != tt.want {
+			t.Errorf("divRound(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPercentAndMul(t *testing.T) {
+	tests := []struct {
+		name string
+		got  Money
+		want int64
+	}{
+		{"7.25% of 100.00", New(10000, "USD").Percent(7.25), 725},
+		{"15% of 0.10", New(10, "USD").Percent(15), 2},
+		{"15% of -0.10", New(-10, "USD").Percent(15), -2},
+		{"20% of 999 JPY", New(999, "JPY").Percent(20), 200},
+		{"1.5 x 3.33", New(333, "USD").Mul(1.5), 500},
+		{"0.1 x 0.05", New(5, "USD").Mul(0.1), 1},
+		{"10.00 / 4", New(1000, "USD").Div(4), 250},
+		{"0.05 / 2", New(5, "USD").Div(2), 3},
+		{"10.00 / 0", New(1000, "USD").Div(0), 0},
+	}
+
+	for _, tt := range tests {
+		if tt.got.Minor() != tt.want {
+			t.Errorf("%s = %d, want %d", tt.name, tt.got.Minor(), tt.want)
+		}
+	}
+}
+
+func TestConvert(t *testing.T) {
+	tests := []struct {
+		m    Money
+		to   string
+		rate string
+		want int64
+	}{
+		{New(10000, "USD"), "EUR", "0.9", 9000},
+		{New(100, "USD"), "JPY", "149.505", 150},
+		{New(-100, "USD"), "JPY", "149.505", -150},
+		{New(100, "USD"), "JPY", "149.49", 149},
+		{New(1000, "JPY"), "USD", "0.0067", 670},
+		{New(1, "USD"), "EUR", "0.5", 1},
+		{New(-1, "USD"), "EUR", "0.5", -1},
+		{New(1, "USD"), "EUR", "0.49", 0},
+		{New(12345, "EUR"), "EUR", "1", 12345},
+	}
+
+	for _, tt := range tests {
+		rate, err := ParseRate(tt.rate)
+		if err != nil {
+			t.Fatalf("ParseRate(%q): %v", tt.rate, err)
+		}
+		got := tt.m.Convert(tt.to, rate)
+		if got.Minor() != tt.want || got.Currency() != tt.to {
+			t.Errorf("%s %s at %s = %d %s, want %d %s", tt.m, tt.m.Currency(), tt.rate, got.Minor(), got.Currency(),
+				tt.want, tt.to)
+		}
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	for _, s := range []string{"1", "1.0956", "0.0067", " 149.5 "} {
+		if _, err := ParseRate(s); err != nil {
+			t.Errorf("ParseRate(%q) = %v", s, err)
+		}
+	}
+	for _, s := range []string{"", "0", "0.000", "-1.2", "1/3", "1e3", "abc"} {
+		if _, err := ParseRate(s); err == nil {
+			t.Errorf("ParseRate(%q) accepted an invalid rate", s)
+		}
+	}
+
+	rate, _ := ParseRate("1.0956")
+	if rate.Cmp(big.NewRat(10956, 10000)) != 0 {
+		t.Errorf("ParseRate(1.0956) = %s", rate)
+	}
+}
+
+func TestMustMatch(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Money
+		want string
+	}{
+		{"same currency", New(1, "USD"), New(2, "USD"), "USD"},
+		{"zero value adopts the other currency", Money{}, New(2, "EUR"), "EUR"},
+		{"zero value on the right", New(2, "EUR"), Money{}, "EUR"},
+		{"both without a currency", Money{}, Money{}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := tt.a.Add(tt.b).Currency(); got != tt.want {
+			t.Errorf("%s: currency = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+
+	panics := []struct {
+		name string
+		a, b Money
+	}{
+		{"different currencies", New(1, "USD"), New(1, "EUR")},
+		{"non-zero amount without a currency", New(1, ""), New(1, "EUR")},
+	}
+
+	for _, tt := range panics {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%s: Add did not panic", tt.name)
+				}
+			}()
+			tt.a.Add(tt.b)
+		}()
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	tests := []struct {
+		m    Money
+		want string
+	}{
+		{New(1999, "USD"), "19.99"},
+		{New(-5, "USD"), "-0.05"},
+		{New(1500, "JPY"), "1500"},
+	}
+
+	for _, tt := range tests {
+		got, err := tt.m.MarshalJSON()
+		if err != nil || string(got) != tt.want {
+			t.Errorf("MarshalJSON() = %s, %v; want %s", got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,310 @@
+package payments
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/webhook"
+
+	"sponsorship-backend/pkg/money"
+)
+
+// FakeProvider is an in-memory Provider for tests and offline development. Nothing is
+// charged: CompleteCheckout plays the customer paying for a session and returns the
+// webhook events Stripe would send, signed with the fake's webhook secret.
+type FakeProvider struct {
+	mu            sync.Mutex
+	webhookSecret string
+	seq           int
+	sessions      map[string]*FakeSession
+	refunded      map[string]money.Money // by payment intent
+}
+
+// FakeSession is a checkout session held by a FakeProvider
+type FakeSession struct {
+	ID             string
+	Params         CheckoutParams
+	Completed      bool
+	Expired        bool
+	CustomerID     string
+	PaymentIntent  string
+	SubscriptionID string
+}
+
+// total is what the customer pays for the session, after any discount
+func (s *FakeSession) total() money.Money {
+	if s.Params.Discount == nil {
+		return s.Params.Amount
+	}
+	return s.Params.Amount.Sub(s.Params.Discount.AmountOff)
+}
+
+// SignedEvent is a webhook request body and its Stripe-Signature header
+type SignedEvent struct {
+	Payload   []byte
+	Signature string
+}
+
+func NewFakeProvider(webhookSecret string) *FakeProvider {
+	return &FakeProvider{
+		webhookSecret: webhookSecret,
+		sessions:      make(map[string]*FakeSession),
+		refunded:      make(map[string]money.Money),
+	}
+}
+
+// nextID returns a new ID with a Stripe-like prefix. The caller must hold f.mu.
+func (f *FakeProvider) nextID(prefix string) string {
+	f.seq++
+	return fmt.Sprintf("%s_fake_%d", prefix, f.seq)
+}
+
+func (f *FakeProvider) CreateCheckoutSession(params *CheckoutParams) (*CheckoutSession, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sess := &FakeSession{ID: f.nextID("cs"), Params: *params}
+	f.sessions[sess.ID] = sess
+	return &CheckoutSession{
+		ID:        sess.ID,
+		URL:       "https://checkout.fake.invalid/pay/" + sess.ID,
+		ExpiresAt: time.Now().Add(24 * time.Hour),
+	}, nil
+}
+
+func (f *FakeProvider) CreatePortalSession(customerID, returnURL string) (string, error) {
+	return "https://billing.fake.invalid/portal/" + customerID, nil
+}
+
+// Refund succeeds at once for payments of completed sessions, up to the amount paid
+func (f *FakeProvider) Refund(params *RefundParams) (*Refund, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sess := f.paidSession(params.PaymentIntentID)
+	if sess == nil {
+		return nil, fmt.Errorf("unknown payment intent %q", params.PaymentIntentID)
+	}
+	paid := sess.total()
+	if params.Amount.Currency() != paid.Currency() || !params.Amount.IsPositive() {
+		return nil, fmt.Errorf("invalid refund amount %s for a payment in %s", params.Amount, paid.Currency())
+	}
+	refunded, ok := f.refunded[params.PaymentIntentID]
+	if !ok {
+		refunded = money.Zero(paid.Currency())
+	}
+	total := refunded.Add(params.Amount)
+	if total.Cmp(paid) > 0 {
+		return nil, fmt.Errorf("refund of %s exceeds what is left of payment intent %q", params.Amount,
+			params.PaymentIntentID)
+	}
+
+	f.refunded[params.PaymentIntentID] = total
+	return &Refund{ID: f.nextID("re"), Status: string(stripe.RefundStatusSucceeded)}, nil
+}
+
+// ChargeRefunded returns the charge.refunded event Stripe sends after a refund, with the
+// total refunded so far on the payment intent
+func (f *FakeProvider) ChargeRefunded(paymentIntentID string) (SignedEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sess := f.paidSession(paymentIntentID)
+	refunded, ok := f.refunded[paymentIntentID]
+	if sess == nil || !ok {
+		return SignedEvent{}, fmt.Errorf("payment intent %q has no refunds", paymentIntentID)
+	}
+	return f.signedEvent(stripe.EventTypeChargeRefunded, map[string]interface{}{
+		"id":              f.nextID("ch"),
+		"object":          "charge",
+		"amount":          sess.total().Minor(),
+		"amount_refunded": refunded.Minor(),
+		"currency":        strings.ToLower(refunded.Currency()),
+		"customer":        sess.CustomerID,
+		"payment_intent":  paymentIntentID,
+		"refunded":        refunded.Cmp(sess.total()) == 0,
+	}, time.Now())
+}
+
+// paidSession returns the completed session paid with a payment intent. The caller must
+// hold f.mu.
+func (f *FakeProvider) paidSession(paymentIntentID string) *FakeSession {
+	for _, sess := range f.sessions {
+		if sess.Completed && sess.PaymentIntent == paymentIntentID {
+			return sess
+		}
+	}
+	return nil
+}
+
+func (f *FakeProvider) ConstructEvent(payload []byte, signature string) (stripe.Event, error) {
+	return webhook.ConstructEventWithOptions(payload, signature, f.webhookSecret,
+		webhook.ConstructEventOptions{IgnoreAPIVersionMismatch: true})
+}
+
+// Session returns a copy of a session created by the fake
+func (f *FakeProvider) Session(id string) (FakeSession, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sess, ok := f.sessions[id]
+	if !ok {
+		return FakeSession{}, false
+	}
+	return *sess, true
+}
+
+// CompleteCheckout marks a session paid and returns, in order, the events that report it:
+// checkout.session.completed, and for a subscription also customer.subscription.created
+// and the invoice.paid of its first period
+func (f *FakeProvider) CompleteCheckout(sessionID string) ([]SignedEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sess, ok := f.sessions[sessionID]
+	if !ok {
+		return nil, fmt.Errorf("unknown checkout session %q", sessionID)
+	}
+	if sess.Completed || sess.Expired {
+		return nil, fmt.Errorf("checkout session %q is no longer open", sessionID)
+	}
+	sess.Completed = true
+	sess.CustomerID = f.nextID("cus")
+	sess.PaymentIntent = f.nextID("pi")
+
+	now := time.Now()
+	p := sess.Params
+	currency := strings.ToLower(p.Amount.Currency())
+	total, discount := sess.total(), int64(0)
+	if p.Discount != nil {
+		discount = p.Discount.AmountOff.Minor()
+	}
+	checkout := map[string]interface{}{
+		"id":                  sess.ID,
+		"object":              "checkout.session",
+		"mode":                stripe.CheckoutSessionModePayment,
+		"status":              stripe.CheckoutSessionStatusComplete,
+		"payment_status":      stripe.CheckoutSessionPaymentStatusPaid,
+		"amount_total":        total.Minor(),
+		"total_details":       map[string]interface{}{"amount_discount": discount},
+		"currency":            currency,
+		"client_reference_id": p.ClientReferenceID,
+		"customer_email":      p.CustomerEmail,
+		"metadata":            p.Metadata,
+		"customer":            sess.CustomerID,
+		"payment_intent":      sess.PaymentIntent,
+	}
+	if p.BillingInterval == "" {
+		event, err := f.signedEvent(stripe.EventTypeCheckoutSessionCompleted, checkout, now)
+		if err != nil {
+			return nil, err
+		}
+		return []SignedEvent{event}, nil
+	}
+
+	sess.SubscriptionID = f.nextID("sub")
+	checkout["mode"] = stripe.CheckoutSessionModeSubscription
+	checkout["payment_intent"] = nil
+	checkout["subscription"] = sess.SubscriptionID
+
+	periodEnd := now.AddDate(0, 1, 0)
+	if p.BillingInterval == "year" {
+		periodEnd = now.AddDate(1, 0, 0)
+	}
+	subscription := map[string]interface{}{
+		"id":                   sess.SubscriptionID,
+		"object":               "subscription",
+		"status":               stripe.SubscriptionStatusActive,
+		"customer":             sess.CustomerID,
+		"metadata":             p.Metadata,
+		"current_period_start": now.Unix(),
+		"current_period_end":   periodEnd.Unix(),
+		"cancel_at_period_end": false,
+	}
+	invoice := map[string]interface{}{
+		"id":                   f.nextID("in"),
+		"object":               "invoice",
+		"status":               stripe.InvoiceStatusPaid,
+		"amount_paid":          total.Minor(),
+		"currency":             currency,
+		"customer":             sess.CustomerID,
+		"payment_intent":       sess.PaymentIntent,
+		"subscription":         sess.SubscriptionID,
+		"subscription_details": map[string]interface{}{"metadata": p.Metadata},
+		"status_transitions":   map[string]interface{}{"paid_at": now.Unix()},
+	}
+
+	var events []SignedEvent
+	for _, e := range []struct {
+		eventType stripe.EventType
+		object    map[string]interface{}
+	}{
+		{stripe.EventTypeCheckoutSessionCompleted, checkout},
+		{stripe.EventTypeCustomerSubscriptionCreated, subscription},
+		{stripe.EventTypeInvoicePaid, invoice},
+	} {
+		event, err := f.signedEvent(e.eventType, e.object, now)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// ExpireCheckout expires an open session, as Stripe does after 24 hours, and returns the
+// checkout.session.expired event
+func (f *FakeProvider) ExpireCheckout(sessionID string) (SignedEvent, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sess, ok := f.sessions[sessionID]
+	if !ok || sess.Completed || sess.Expired {
+		return SignedEvent{}, fmt.Errorf("checkout session %q is not open", sessionID)
+	}
+	sess.Expired = true
+
+	mode := stripe.CheckoutSessionModePayment
+	if sess.Params.BillingInterval != "" {
+		mode = stripe.CheckoutSessionModeSubscription
+	}
+	return f.signedEvent(stripe.EventTypeCheckoutSessionExpired, map[string]interface{}{
+		"id":                  sess.ID,
+		"object":              "checkout.session",
+		"mode":                mode,
+		"status":              stripe.CheckoutSessionStatusExpired,
+		"payment_status":      stripe.CheckoutSessionPaymentStatusUnpaid,
+		"amount_total":        sess.total().Minor(),
+		"currency":            strings.ToLower(sess.Params.Amount.Currency()),
+		"client_reference_id": sess.Params.ClientReferenceID,
+		"metadata":            sess.Params.Metadata,
+	}, time.Now())
+}
+
+// signedEvent wraps an object in an event and signs it. The caller must hold f.mu.
+func (f *FakeProvider) signedEvent(eventType stripe.EventType, object map[string]interface{},
+	now time.Time) (SignedEvent, error) {
+	payload, err := json.Marshal(map[string]interface{}{
+		"id":          f.nextID("evt"),
+		"object":      "event",
+		"api_version": stripe.APIVersion,
+		"created":     now.Unix(),
+		"livemode":    false,
+		"type":        eventType,
+		"data":        map[string]interface{}{"object": object},
+	})
+	if err != nil {
+		return SignedEvent{}, fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	signed := webhook.GenerateTestSignedPayload(&webhook.UnsignedPayload{
+		Payload:   payload,
+		Secret:    f.webhookSecret,
+		Timestamp: now,
+	})
+	return SignedEvent{Payload: signed.Payload, Signature: signed.Header}, nil
+}
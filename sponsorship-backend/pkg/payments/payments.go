@@ -0,0 +1,76 @@
+// Package payments takes payments through a payment provider. Webhook events are in
+// Stripe's format whichever provider sent them.
+package payments
+
+import (
+	"errors"
+	"time"
+
+	"github.com/stripe/stripe-go/v76"
+
+	"sponsorship-backend/pkg/money"
+)
+
+// ErrNotConfigured is returned when the provider is missing the keys it needs
+var ErrNotConfigured = errors.New("payments: provider is not configured")
+
+// CheckoutParams describes a hosted checkout for one product
+type CheckoutParams struct {
+	ProductName string
+	Amount      money.Money
+	// BillingInterval is month or year to sell a subscription, or empty for a one-off payment
+	BillingInterval string
+	// ClientReferenceID is the user who pays, if they have an account
+	ClientReferenceID string
+	// CustomerEmail prefills the payer's email
+	CustomerEmail string
+	// Metadata is attached to the session, and to the subscription if one is started
+	Metadata map[string]string
+	// Discount is taken off the amount, or nil to charge it in full
+	Discount   *Discount
+	SuccessURL string
+	CancelURL  string
+}
+
+// Discount is a coupon applied to a checkout, as the amount it takes off the price
+type Discount struct {
+	// Code is the coupon code the customer sees on the checkout page
+	Code      string
+	AmountOff money.Money
+	// Duration is once to discount only the first payment of a subscription, or forever
+	Duration string
+}
+
+// CheckoutSession is a checkout the customer completes at URL until it expires
+type CheckoutSession struct {
+	ID        string
+	URL       string
+	ExpiresAt time.Time
+}
+
+// RefundParams describes a refund of a payment, in whole or in part
+type RefundParams struct {
+	PaymentIntentID string
+	Amount          money.Money
+	// Reason is duplicate, fraudulent or requested_by_customer, or empty
+	Reason string
+}
+
+// Refund is a refund as created by the provider. Status is pending, succeeded, failed,
+// canceled or requires_action.
+type Refund struct {
+	ID     string
+	Status string
+}
+
+// Provider creates checkouts and verifies the webhook events that report their outcome
+type Provider interface {
+	CreateCheckoutSession(params *CheckoutParams) (*CheckoutSession, error)
+	// CreatePortalSession returns the URL of a page where the customer manages their
+	// subscription and payment methods, which sends them back to returnURL
+	CreatePortalSession(customerID, returnURL string) (string, error)
+	Refund(params *RefundParams) (*Refund, error)
+	// ConstructEvent verifies the signature header sent with a webhook payload and
+	// parses the event
+	ConstructEvent(payload []byte, signature string) (stripe.Event, error)
+}
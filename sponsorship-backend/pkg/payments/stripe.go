@@ -0,0 +1,136 @@
+package payments
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/stripe/stripe-go/v76"
+	"github.com/stripe/stripe-go/v76/client"
+	"github.com/stripe/stripe-go/v76/webhook"
+)
+
+// StripeProvider takes payments with Stripe Checkout. It has its own API client, so
+// several providers with different keys can coexist.
+type StripeProvider struct {
+	api           *client.API
+	secretKey     string
+	webhookSecret string
+}
+
+func NewStripeProvider(secretKey, webhookSecret string) *StripeProvider {
+	api := &client.API{}
+	api.Init(secretKey, nil)
+	return &StripeProvider{api: api, secretKey: secretKey, webhookSecret: webhookSecret}
+}
+
+func (p *StripeProvider) CreateCheckoutSession(params *CheckoutParams) (*CheckoutSession, error) {
+	if p.secretKey == "" {
+		return nil, ErrNotConfigured
+	}
+
+	sessionParams := &stripe.CheckoutSessionParams{
+		PaymentMethodTypes: stripe.StringSlice([]string{"card"}),
+		LineItems: []*stripe.CheckoutSessionLineItemParams{
+			{
+				PriceData: &stripe.CheckoutSessionLineItemPriceDataParams{
+					Currency: stripe.String(strings.ToLower(params.Amount.Currency())),
+					ProductData: &stripe.CheckoutSessionLineItemPriceDataProductDataParams{
+						Name: stripe.String(params.ProductName),
+					},
+					UnitAmount: stripe.Int64(params.Amount.Minor()),
+				},
+				Quantity: stripe.Int64(1),
+			},
+		},
+		Mode:       stripe.String(string(stripe.CheckoutSessionModePayment)),
+		SuccessURL: stripe.String(params.SuccessURL),
+		CancelURL:  stripe.String(params.CancelURL),
+		Metadata:   params.Metadata,
+	}
+	if params.ClientReferenceID != "" {
+		sessionParams.ClientReferenceID = stripe.String(params.ClientReferenceID)
+	}
+	if params.CustomerEmail != "" {
+		sessionParams.CustomerEmail = stripe.String(params.CustomerEmail)
+	}
+
+	// The subscription carries the metadata too, since its own webhook events do not
+	// include the session
+	if params.BillingInterval != "" {
+		sessionParams.Mode = stripe.String(string(stripe.CheckoutSessionModeSubscription))
+		sessionParams.LineItems[0].PriceData.Recurring = &stripe.CheckoutSessionLineItemPriceDataRecurringParams{
+			Interval: stripe.String(params.BillingInterval),
+		}
+		sessionParams.SubscriptionData = &stripe.CheckoutSessionSubscriptionDataParams{
+			Metadata: params.Metadata,
+		}
+	}
+
+	// Coupons are defined and counted by us; Stripe gets a one-use coupon for the amount
+	// taken off, so the total charged is exactly what we computed
+	if params.Discount != nil {
+		coupon, err := p.api.Coupons.New(&stripe.CouponParams{
+			Name:           stripe.String(params.Discount.Code),
+			AmountOff:      stripe.Int64(params.Discount.AmountOff.Minor()),
+			Currency:       stripe.String(strings.ToLower(params.Discount.AmountOff.Currency())),
+			Duration:       stripe.String(params.Discount.Duration),
+			MaxRedemptions: stripe.Int64(1),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create stripe coupon: %w", err)
+		}
+		sessionParams.Discounts = []*stripe.CheckoutSessionDiscountParams{{Coupon: stripe.String(coupon.ID)}}
+	}
+
+	sess, err := p.api.CheckoutSessions.New(sessionParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stripe checkout session: %w", err)
+	}
+	return &CheckoutSession{ID: sess.ID, URL: sess.URL, ExpiresAt: time.Unix(sess.ExpiresAt, 0)}, nil
+}
+
+func (p *StripeProvider) CreatePortalSession(customerID, returnURL string) (string, error) {
+	if p.secretKey == "" {
+		return "", ErrNotConfigured
+	}
+
+	sess, err := p.api.BillingPortalSessions.New(&stripe.BillingPortalSessionParams{
+		Customer:  stripe.String(customerID),
+		ReturnURL: stripe.String(returnURL),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create stripe billing portal session: %w", err)
+	}
+	return sess.URL, nil
+}
+
+func (p *StripeProvider) Refund(params *RefundParams) (*Refund, error) {
+	if p.secretKey == "" {
+		return nil, ErrNotConfigured
+	}
+
+	refundParams := &stripe.RefundParams{
+		PaymentIntent: stripe.String(params.PaymentIntentID),
+		Amount:        stripe.Int64(params.Amount.Minor()),
+	}
+	if params.Reason != "" {
+		refundParams.Reason = stripe.String(params.Reason)
+	}
+
+	refund, err := p.api.Refunds.New(refundParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create stripe refund: %w", err)
+	}
+	return &Refund{ID: refund.ID, Status: string(refund.Status)}, nil
+}
+
+// ConstructEvent accepts events of any API version: we only read fields that are stable
+// across versions, whatever version the webhook endpoint is pinned to
+func (p *StripeProvider) ConstructEvent(payload []byte, signature string) (stripe.Event, error) {
+	if p.webhookSecret == "" {
+		return stripe.Event{}, ErrNotConfigured
+	}
+	return webhook.ConstructEventWithOptions(payload, signature, p.webhookSecret,
+		webhook.ConstructEventOptions{IgnoreAPIVersionMismatch: true})
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStorageRoundTrip(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := "creator/deal/attachment"
+	if err := store.Put(key, strings.NewReader("hello"), 5, "text/plain"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	body, err := store.Get(key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, _ := io.ReadAll(body)
+	body.Close()
+	if string(got) != "hello" {
+		t.Errorf("Get returned %q, want %q", got, "hello")
+	}
+
+	if err := store.Delete(key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Get(key); err != ErrNotExist {
+		t.Errorf("Get after Delete returned %v, want ErrNotExist", err)
+	}
+	if err := store.Delete(key); err != nil {
+		t.Errorf("Delete of a missing object returned %v, want nil", err)
+	}
+}
+
+func TestLocalStorageRejectsKeysOutsideRoot(t *testing.T) {
+	parent := t.TempDir()
+	root := filepath.Join(parent, "root")
+	store, err := NewLocalStorage(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys := []string{
+		"",
+		"../escape",
+		"a/../../escape",
+		"a/../b",
+		"/absolute",
+		"a//b",
+		"./a",
+		"a/",
+		`a\..\..\escape`,
+	}
+	for _, key := range keys {
+		if err := store.Put(key, strings.NewReader("x"), 1, ""); err == nil {
+			t.Errorf("Put(%q) succeeded, want an invalid key error", key)
+		}
+		if _, err := store.Get(key); err == nil || err == ErrNotExist {
+			t.Errorf("Get(%q) returned %v, want an invalid key error", key, err)
+		}
+		if err := store.Delete(key); err == nil {
+			t.Errorf("Delete(%q) succeeded, want an invalid key error", key)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(parent, "escape")); !os.IsNotExist(err) {
+		t.Errorf("a file was written outside the storage root")
+	}
+}
+
+func TestLocalStoragePutChecksSize(t *testing.T) {
+	store, err := NewLocalStorage(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Put("short", strings.NewReader("abc"), 10, ""); err == nil {
+		t.Fatal("Put of a short body succeeded, want an error")
+	}
+	if _, err := store.Get("short"); err != ErrNotExist {
+		t.Errorf("Get after a failed Put returned %v, want ErrNotExist", err)
+	}
+}
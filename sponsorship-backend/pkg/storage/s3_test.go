@@ -0,0 +1,227 @@
+package storage
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeS3 is a MinIO-style stand-in for one bucket. It checks every request's SigV4
+// signature the way S3 does and keeps objects in memory.
+type fakeS3 struct {
+	bucket    string
+	region    string
+	accessKey string
+	secretKey string
+
+	mu           sync.Mutex
+	objects      map[string][]byte
+	contentTypes map[string]string
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	f := &fakeS3{
+		bucket:       "attachments",
+		region:       "us-east-1",
+		accessKey:    "AKIDEXAMPLE",
+		secretKey:    "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+		objects:      map[string][]byte{},
+		contentTypes: map[string]string{},
+	}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	if err := f.verify(r, body); err != nil {
+		w.WriteHeader(http.StatusForbidden)
+		fmt.Fprintf(w, "<Error><Code>SignatureDoesNotMatch</Code><Message>%s</Message></Error>", err)
+		return
+	}
+
+	prefix := "/" + f.bucket + "/"
+	if !strings.HasPrefix(r.URL.Path, prefix) {
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprint(w, "<Error><Code>NoSuchBucket</Code></Error>")
+		return
+	}
+	key := strings.TrimPrefix(r.URL.Path, prefix)
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.Method {
+	case http.MethodPut:
+		f.objects[key] = body
+		f.contentTypes[key] = r.Header.Get("Content-Type")
+		w.WriteHeader(http.StatusOK)
+	case http.MethodGet:
+		object, ok := f.objects[key]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprint(w, "<Error><Code>NoSuchKey</Code></Error>")
+			return
+		}
+		w.Header().Set("Content-Type", f.contentTypes[key])
+		w.Write(object)
+	case http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+// verify recomputes the request's SigV4 signature from what arrived on the wire
+func (f *fakeS3) verify(r *http.Request, body []byte) error {
+	auth := r.Header.Get("Authorization")
+	fields, ok := strings.CutPrefix(auth, "AWS4-HMAC-SHA256 ")
+	if !ok {
+		return fmt.Errorf("unsupported authorization %q", auth)
+	}
+	params := map[string]string{}
+	for _, field := range strings.Split(fields, ", ") {
+		name, value, _ := strings.Cut(field, "=")
+		params[name] = value
+	}
+
+	amzDate := r.Header.Get("X-Amz-Date")
+	signedAt, err := time.Parse("20060102T150405Z", amzDate)
+	if err != nil || time.Since(signedAt).Abs() > 15*time.Minute {
+		return fmt.Errorf("bad X-Amz-Date %q", amzDate)
+	}
+	scope := amzDate[:8] + "/" + f.region + "/s3/aws4_request"
+	if params["Credential"] != f.accessKey+"/"+scope {
+		return fmt.Errorf("bad credential %q", params["Credential"])
+	}
+
+	payloadHash := r.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash != "UNSIGNED-PAYLOAD" {
+		sum := sha256.Sum256(body)
+		if payloadHash != hex.EncodeToString(sum[:]) {
+			return fmt.Errorf("payload hash does not match body")
+		}
+	}
+
+	var canonicalHeaders strings.Builder
+	for _, name := range strings.Split(params["SignedHeaders"], ";") {
+		value := r.Header.Get(name)
+		if name == "host" {
+			value = r.Host
+		}
+		canonicalHeaders.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	path, query, _ := strings.Cut(r.RequestURI, "?")
+	canonicalRequest := strings.Join([]string{
+		r.Method, path, query, canonicalHeaders.String(), params["SignedHeaders"], payloadHash,
+	}, "\n")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := []byte("AWS4" + f.secretKey)
+	for _, part := range []string{amzDate[:8], f.region, "s3", "aws4_request"} {
+		key = hmacSum(key, part)
+	}
+	want := hex.EncodeToString(hmacSum(key, stringToSign))
+	if !hmac.Equal([]byte(params["Signature"]), []byte(want)) {
+		return fmt.Errorf("signature does not match")
+	}
+	return nil
+}
+
+func hmacSum(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func TestS3StorageRoundTrip(t *testing.T) {
+	f, srv := newFakeS3(t)
+	store, err := NewS3Storage(srv.URL, f.region, f.bucket, f.accessKey, f.secretKey, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Spaces and brackets must be encoded the same way when signing and sending
+	key := "creator/deal/brief (final) v2.pdf"
+	content := "%PDF-1.4 test"
+	if err := store.Put(key, strings.NewReader(content), int64(len(content)), "application/pdf"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	if got := string(f.objects[key]); got != content {
+		t.Errorf("stored %q, want %q", got, content)
+	}
+	if got := f.contentTypes[key]; got != "application/pdf" {
+		t.Errorf("stored content type %q, want application/pdf", got)
+	}
+
+	body, err := store.Get(key)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	got, _ := io.ReadAll(body)
+	body.Close()
+	if string(got) != content {
+		t.Errorf("Get returned %q, want %q", got, content)
+	}
+
+	if err := store.Delete(key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, ok := f.objects[key]; ok {
+		t.Error("object still stored after Delete")
+	}
+}
+
+func TestS3StorageGetMissingObject(t *testing.T) {
+	f, srv := newFakeS3(t)
+	store, err := NewS3Storage(srv.URL, f.region, f.bucket, f.accessKey, f.secretKey, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := store.Get("creator/deal/missing"); err != ErrNotExist {
+		t.Errorf("Get of a missing object returned %v, want ErrNotExist", err)
+	}
+}
+
+func TestS3StorageWrongSecretIsRejected(t *testing.T) {
+	f, srv := newFakeS3(t)
+	store, err := NewS3Storage(srv.URL, f.region, f.bucket, f.accessKey, "not-the-secret", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = store.Put("creator/deal/file", strings.NewReader("x"), 1, "text/plain")
+	if err == nil || !strings.Contains(err.Error(), "status 403") {
+		t.Fatalf("Put with the wrong secret returned %v, want a 403 error", err)
+	}
+	if _, err := store.Get("creator/deal/file"); err == nil || err == ErrNotExist {
+		t.Errorf("Get with the wrong secret returned %v, want a 403 error", err)
+	}
+	if len(f.objects) != 0 {
+		t.Error("an object was stored despite the bad signature")
+	}
+}
+
+func TestNewS3StorageValidatesConfig(t *testing.T) {
+	if _, err := NewS3Storage("not a url", "us-east-1", "bucket", "", "", true); err == nil {
+		t.Error("NewS3Storage accepted an invalid endpoint")
+	}
+	if _, err := NewS3Storage("http://localhost:9000", "us-east-1", "", "", "", true); err == nil {
+		t.Error("NewS3Storage accepted an empty bucket")
+	}
+}
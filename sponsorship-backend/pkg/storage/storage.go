@@ -0,0 +1,16 @@
+package storage
+
+import (
+	"errors"
+	"io"
+)
+
+// ErrNotExist is returned when no object is stored under a key
+var ErrNotExist = errors.New("storage: object does not exist")
+
+// Storage keeps uploaded files as opaque objects addressed by a slash-separated key
+type Storage interface {
+	Put(key string, body io.Reader, size int64, contentType string) error
+	Get(key string) (io.ReadCloser, error)
+	Delete(key string) error
+}